@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "taheri24.ir/graph1/docs"
 	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/scheduler"
 	"taheri24.ir/graph1/internal/server"
 	"taheri24.ir/graph1/pkg/config"
 	"taheri24.ir/graph1/pkg/utils"
@@ -34,8 +42,35 @@ func main() {
 		return
 	}
 
-	slog.Info("Server starting on port ", "port", cfg.Server.Port)
-	if err := rootRouter.Run(":" + cfg.Server.Port); err != nil {
-		slog.Error("Failed to start server: %v", "err", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background jobs
+	if cfg.Reminders.Enabled {
+		reminders := scheduler.NewReminderScheduler(db, cfg.Reminders.Interval)
+		go reminders.Run(ctx)
+		slog.Info("Reminder scheduler started", "interval", cfg.Reminders.Interval.String())
+	}
+	if cfg.Trash.PurgeEnabled {
+		retention := scheduler.NewRetentionJob(db, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+		go retention.Run(ctx)
+		slog.Info("Trash retention job started", "retention", cfg.Trash.Retention.String(), "interval", cfg.Trash.PurgeInterval.String())
+	}
+
+	srv := &http.Server{Addr: ":" + cfg.Server.Port, Handler: rootRouter}
+	go func() {
+		slog.Info("Server starting on port ", "port", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Failed to start server", "err", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Server shutdown failed", "err", err)
 	}
 }
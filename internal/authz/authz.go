@@ -0,0 +1,166 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Action is an operation whose permission depends on the role of the caller. Reading tasks and alerts is
+// left to the scopes of the credential.
+type Action string
+
+const (
+	// CreateTask creates a task, which then belongs to its creator
+	CreateTask Action = "create tasks"
+	// EditTask changes a task, its blockers or its labels
+	EditTask Action = "edit this task"
+	// DeleteTask moves a task to the trash or restores it
+	DeleteTask Action = "delete this task"
+	// PurgeTask deletes a task permanently
+	PurgeTask Action = "purge tasks"
+	// ManageAlerts fires and resets alerts
+	ManageAlerts Action = "fire or reset alerts"
+	// ManageUsers creates, changes and deletes the users tasks are assigned to
+	ManageUsers Action = "manage users"
+	// ManageAPIKeys mints, lists and revokes the API keys of the tenant
+	ManageAPIKeys Action = "manage API keys"
+	// CreateComment comments on a task, which then belongs to its author
+	CreateComment Action = "comment on tasks"
+	// EditComment changes the body of a comment
+	EditComment Action = "edit this comment"
+	// DeleteComment deletes a comment
+	DeleteComment Action = "delete this comment"
+	// ManageLabels creates and renames labels
+	ManageLabels Action = "create or rename labels"
+	// DeleteLabel deletes a label, taking it off every task
+	DeleteLabel Action = "delete labels"
+)
+
+// ErrForbidden is matched by every error of Authorize
+var ErrForbidden = errors.New("forbidden")
+
+// Error explains which permission the caller of a request lacks
+type Error struct {
+	Action Action
+	Role   string
+	// Reason tells the caller what it would need
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return e.Reason
+}
+
+// Is makes errors.Is(err, ErrForbidden) hold for every *Error
+func (e *Error) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+// Authorize returns nil when the caller of the request behind ctx may perform action on task, and an *Error
+// explaining why not otherwise. Admins may do everything. Members may create tasks, edit the tasks they
+// created or are assigned to, delete the tasks they created, comment and create or rename labels. Viewers
+// may change nothing. Editing and deleting comments is decided by AuthorizeComment.
+//
+// task is only needed when NeedsTask says so; a nil task then fails the check.
+func Authorize(ctx context.Context, action Action, task *models.Task) error {
+	role := middleware.GetRoleFromContext(ctx)
+	switch {
+	case role == middleware.RoleAdmin:
+		return nil
+	case action == ManageAlerts || action == ManageUsers || action == ManageAPIKeys || action == PurgeTask ||
+		action == DeleteLabel:
+		return deny(action, role, fmt.Sprintf("only admins can %s; this caller is a %s", action, role))
+	case role != middleware.RoleMember:
+		return deny(action, role, fmt.Sprintf("the %s role cannot %s; that needs the member role", role, action))
+	case action == CreateTask || action == CreateComment || action == ManageLabels:
+		return nil
+	case action == EditComment || action == DeleteComment:
+		return AuthorizeComment(ctx, action, nil)
+	}
+
+	subject, _ := middleware.GetSubjectFromContext(ctx)
+	if action == EditTask {
+		if task != nil && (task.CreatedBy == subject || task.Assignee == subject) {
+			return nil
+		}
+		return deny(action, role, "members can only edit tasks they created or are assigned to; "+
+			"editing this task needs the admin role")
+	}
+	if task != nil && task.CreatedBy == subject {
+		return nil
+	}
+	return deny(action, role, "members can only delete tasks they created; deleting this task needs the admin role")
+}
+
+// AuthorizeComment returns nil when the caller of the request behind ctx may perform action on comment, and
+// an *Error explaining why not otherwise. Members may only edit and delete the comments they wrote; a nil
+// comment fails the check for them.
+func AuthorizeComment(ctx context.Context, action Action, comment *models.Comment) error {
+	role := middleware.GetRoleFromContext(ctx)
+	if role != middleware.RoleMember || action == CreateComment {
+		return Authorize(ctx, action, nil)
+	}
+
+	subject, _ := middleware.GetSubjectFromContext(ctx)
+	if comment != nil && comment.Author == subject {
+		return nil
+	}
+	if action == EditComment {
+		return deny(action, role, "members can only edit comments they wrote; editing this comment needs the admin role")
+	}
+	return deny(action, role, "members can only delete comments they wrote; deleting this comment needs the admin role")
+}
+
+// AuthorizeAll authorizes action on every task, returning the first refusal
+func AuthorizeAll(ctx context.Context, action Action, tasks []models.Task) error {
+	for i := range tasks {
+		if err := Authorize(ctx, action, &tasks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NeedsTask reports whether Authorize decides action for the caller of the request behind ctx from the task
+// acted on, so that callers only load the task when it matters
+func NeedsTask(ctx context.Context, action Action) bool {
+	return middleware.GetRoleFromContext(ctx) == middleware.RoleMember && (action == EditTask || action == DeleteTask)
+}
+
+// Check authorizes action on task for the caller of the request, aborting the request with 403 and the
+// explanation of Authorize when it is refused
+func Check(c *gin.Context, action Action, task *models.Task) bool {
+	return CheckErr(c, Authorize(c.Request.Context(), action, task))
+}
+
+// CheckComment authorizes action on comment for the caller of the request, aborting the request with 403 and
+// the explanation of AuthorizeComment when it is refused
+func CheckComment(c *gin.Context, action Action, comment *models.Comment) bool {
+	return CheckErr(c, AuthorizeComment(c.Request.Context(), action, comment))
+}
+
+// CheckErr aborts the request with 403 when err, returned by Authorize or AuthorizeAll, refuses it, reporting
+// whether the request may go on
+func CheckErr(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	ctx := c.Request.Context()
+	subject, _ := middleware.GetSubjectFromContext(ctx)
+	middleware.GetLoggerFromContext(ctx).Info("Request lacks a permission",
+		"role", middleware.GetRoleFromContext(ctx), "subject", subject, "reason", err.Error())
+	c.AbortWithStatusJSON(http.StatusForbidden, dto.NewErrorResponse("Forbidden", err.Error()))
+	return false
+}
+
+func deny(action Action, role, reason string) error {
+	return &Error{Action: action, Role: role, Reason: reason}
+}
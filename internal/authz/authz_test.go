@@ -0,0 +1,154 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func callerContext(subject, role string) context.Context {
+	ctx := middleware.ContextWithSubject(context.TODO(), subject)
+	return middleware.ContextWithRole(ctx, role)
+}
+
+func TestAuthorize(t *testing.T) {
+	created := &models.Task{Title: "Mine", CreatedBy: "alice"}
+	assigned := &models.Task{Title: "Assigned", CreatedBy: "bob", Assignee: "alice"}
+	others := &models.Task{Title: "Theirs", CreatedBy: "bob"}
+
+	tests := []struct {
+		name    string
+		role    string
+		action  Action
+		task    *models.Task
+		allowed bool
+	}{
+		{"admin edits others' task", middleware.RoleAdmin, EditTask, others, true},
+		{"admin deletes others' task", middleware.RoleAdmin, DeleteTask, others, true},
+		{"admin purges", middleware.RoleAdmin, PurgeTask, nil, true},
+		{"admin fires alerts", middleware.RoleAdmin, ManageAlerts, nil, true},
+		{"admin manages users", middleware.RoleAdmin, ManageUsers, nil, true},
+		{"admin manages API keys", middleware.RoleAdmin, ManageAPIKeys, nil, true},
+		{"admin deletes labels", middleware.RoleAdmin, DeleteLabel, nil, true},
+		{"member creates", middleware.RoleMember, CreateTask, nil, true},
+		{"member edits own task", middleware.RoleMember, EditTask, created, true},
+		{"member edits assigned task", middleware.RoleMember, EditTask, assigned, true},
+		{"member edits others' task", middleware.RoleMember, EditTask, others, false},
+		{"member deletes own task", middleware.RoleMember, DeleteTask, created, true},
+		{"member deletes assigned task", middleware.RoleMember, DeleteTask, assigned, false},
+		{"member deletes unknown task", middleware.RoleMember, DeleteTask, nil, false},
+		{"member purges own task", middleware.RoleMember, PurgeTask, created, false},
+		{"member fires alerts", middleware.RoleMember, ManageAlerts, nil, false},
+		{"member manages users", middleware.RoleMember, ManageUsers, nil, false},
+		{"member manages API keys", middleware.RoleMember, ManageAPIKeys, nil, false},
+		{"viewer manages API keys", middleware.RoleViewer, ManageAPIKeys, nil, false},
+		{"member comments", middleware.RoleMember, CreateComment, nil, true},
+		{"member manages labels", middleware.RoleMember, ManageLabels, nil, true},
+		{"member deletes labels", middleware.RoleMember, DeleteLabel, nil, false},
+		{"viewer comments", middleware.RoleViewer, CreateComment, nil, false},
+		{"viewer manages labels", middleware.RoleViewer, ManageLabels, nil, false},
+		{"viewer creates", middleware.RoleViewer, CreateTask, nil, false},
+		{"viewer edits own task", middleware.RoleViewer, EditTask, created, false},
+		// Callers whose role is unknown are viewers
+		{"unknown role creates", "root", CreateTask, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(callerContext("alice", tt.role), tt.action, tt.task)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrForbidden)
+			var authzErr *Error
+			if assert.ErrorAs(t, err, &authzErr) {
+				assert.Equal(t, tt.action, authzErr.Action)
+				assert.NotEmpty(t, authzErr.Reason)
+			}
+		})
+	}
+
+	// Without authentication nothing may be changed
+	assert.ErrorIs(t, Authorize(context.TODO(), ManageAlerts, nil), ErrForbidden)
+	assert.ErrorIs(t, Authorize(context.TODO(), CreateTask, nil), ErrForbidden)
+	assert.NoError(t, AuthorizeAll(callerContext("alice", middleware.RoleMember), DeleteTask, []models.Task{*created}))
+	assert.ErrorIs(t, AuthorizeAll(callerContext("alice", middleware.RoleMember), DeleteTask,
+		[]models.Task{*created, *others}), ErrForbidden)
+}
+
+func TestAuthorizeComment(t *testing.T) {
+	own := &models.Comment{Author: "alice", Body: "Mine"}
+	others := &models.Comment{Author: "bob", Body: "Theirs"}
+
+	tests := []struct {
+		name    string
+		role    string
+		action  Action
+		comment *models.Comment
+		allowed bool
+	}{
+		{"admin edits others' comment", middleware.RoleAdmin, EditComment, others, true},
+		{"admin deletes others' comment", middleware.RoleAdmin, DeleteComment, others, true},
+		{"member comments", middleware.RoleMember, CreateComment, nil, true},
+		{"member edits own comment", middleware.RoleMember, EditComment, own, true},
+		{"member deletes own comment", middleware.RoleMember, DeleteComment, own, true},
+		{"member edits others' comment", middleware.RoleMember, EditComment, others, false},
+		{"member deletes others' comment", middleware.RoleMember, DeleteComment, others, false},
+		{"member deletes unknown comment", middleware.RoleMember, DeleteComment, nil, false},
+		{"viewer comments", middleware.RoleViewer, CreateComment, nil, false},
+		{"viewer edits own comment", middleware.RoleViewer, EditComment, own, false},
+		{"viewer deletes own comment", middleware.RoleViewer, DeleteComment, own, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeComment(callerContext("alice", tt.role), tt.action, tt.comment)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrForbidden)
+			var authzErr *Error
+			if assert.ErrorAs(t, err, &authzErr) {
+				assert.Equal(t, tt.action, authzErr.Action)
+				assert.NotEmpty(t, authzErr.Reason)
+			}
+		})
+	}
+
+	// Comments cannot be authorized without the comment
+	assert.ErrorIs(t, Authorize(callerContext("alice", middleware.RoleMember), EditComment, nil), ErrForbidden)
+	assert.NoError(t, AuthorizeComment(callerContext("bob", middleware.RoleAdmin), DeleteComment, others))
+	assert.ErrorIs(t, AuthorizeComment(context.TODO(), DeleteComment, others), ErrForbidden)
+}
+
+func TestNeedsTask(t *testing.T) {
+	member := callerContext("alice", middleware.RoleMember)
+	assert.True(t, NeedsTask(member, EditTask))
+	assert.True(t, NeedsTask(member, DeleteTask))
+	assert.False(t, NeedsTask(member, CreateTask))
+	assert.False(t, NeedsTask(callerContext("alice", middleware.RoleAdmin), EditTask))
+	assert.False(t, NeedsTask(callerContext("alice", middleware.RoleViewer), EditTask))
+	assert.False(t, NeedsTask(context.TODO(), DeleteTask))
+}
+
+func TestCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/tasks", nil).WithContext(callerContext("alice", middleware.RoleViewer))
+
+	assert.False(t, Check(c, CreateTask, nil))
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"Forbidden","message":"the viewer role cannot create tasks; that needs the member role"}`,
+		w.Body.String())
+}
@@ -35,3 +35,9 @@ func (r *RedisCacheImpl[T]) Set(id string, item T) error {
 func (r *RedisCacheImpl[T]) Invalidate(id string) error {
 	return r.redisCache.client.Del(context.Background(), fmt.Sprintf("%s:%s", r.sectionName, id)).Err()
 }
+
+// Section implements CacheInterface.Section. The keys of the section are prefixed with its name, below the
+// section of r.
+func (r *RedisCacheImpl[T]) Section(name string) CacheInterface[T] {
+	return NewRedisCacheImpl[T](r.sectionName+":"+name, r.redisCache)
+}
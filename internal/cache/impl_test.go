@@ -131,6 +131,35 @@ func TestRedisCacheImplInvalidate(t *testing.T) {
 	assert.Nil(t, retrievedTask)
 }
 
+func TestRedisCacheImplSection(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	redisCache, err := NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer redisCache.Close()
+
+	cache := NewRedisCacheImpl[models.Task]("tasks", redisCache)
+	acme, globex := cache.Section("acme"), cache.Section("globex")
+
+	taskID := uuid.New()
+	require.NoError(t, acme.Set(taskID.String(), models.Task{ID: taskID, Title: "Acme's"}))
+	// The section is part of the key
+	assert.True(t, mr.Exists("tasks:acme:"+taskID.String()))
+	assert.False(t, mr.Exists("tasks:"+taskID.String()))
+
+	retrievedTask, err := globex.Get(taskID.String())
+	assert.NoError(t, err)
+	assert.Nil(t, retrievedTask)
+	retrievedTask, err = cache.Get(taskID.String())
+	assert.NoError(t, err)
+	assert.Nil(t, retrievedTask)
+
+	require.NoError(t, acme.Invalidate(taskID.String()))
+	assert.False(t, mr.Exists("tasks:acme:"+taskID.String()))
+}
+
 func TestRedisCacheImplConcurrentAccess(t *testing.T) {
 	// Start mini Redis
 	mr, err := miniredis.Run()
@@ -5,4 +5,7 @@ type CacheInterface[T any] interface {
 	Get(id string) (*T, error)
 	Set(id string, item T) error
 	Invalidate(id string) error
+	// Section returns a view of the cache whose items are kept apart from those of the cache and of its
+	// other sections, such as the items of one tenant
+	Section(name string) CacheInterface[T]
 }
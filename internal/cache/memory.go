@@ -5,10 +5,12 @@ import (
 	"sync"
 )
 
-// InMemoryCacheImpl is an in-memory cache implementation using map[string]any as store
+// InMemoryCacheImpl is an in-memory cache implementation using map[string]any as store. Its sections share
+// the store, keeping their items under the prefix of their name.
 type InMemoryCacheImpl[T any] struct {
-	store map[string]any
-	mu    sync.RWMutex
+	store  map[string]any
+	mu     *sync.RWMutex
+	prefix string
 }
 
 var _ CacheInterface[any] = (*InMemoryCacheImpl[any])(nil)
@@ -17,6 +19,7 @@ var _ CacheInterface[any] = (*InMemoryCacheImpl[any])(nil)
 func NewInMemoryCacheImpl[T any]() *InMemoryCacheImpl[T] {
 	return &InMemoryCacheImpl[T]{
 		store: make(map[string]any),
+		mu:    &sync.RWMutex{},
 	}
 }
 
@@ -25,7 +28,7 @@ func (m *InMemoryCacheImpl[T]) Get(id string) (*T, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if item, exists := m.store[id]; exists {
+	if item, exists := m.store[m.prefix+id]; exists {
 		if typedItem, ok := item.(T); ok {
 			return &typedItem, nil
 		}
@@ -41,7 +44,7 @@ func (m *InMemoryCacheImpl[T]) Set(id string, item T) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.store[id] = item
+	m.store[m.prefix+id] = item
 	return nil
 }
 
@@ -50,6 +53,11 @@ func (m *InMemoryCacheImpl[T]) Invalidate(id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	delete(m.store, id)
+	delete(m.store, m.prefix+id)
 	return nil
 }
+
+// Section implements CacheInterface.Section - returns a view of the store keeping its items under name
+func (m *InMemoryCacheImpl[T]) Section(name string) CacheInterface[T] {
+	return &InMemoryCacheImpl[T]{store: m.store, mu: m.mu, prefix: m.prefix + name + ":"}
+}
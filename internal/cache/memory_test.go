@@ -243,6 +243,34 @@ func TestInMemoryCacheImplInvalidate(t *testing.T) {
 	})
 }
 
+func TestInMemoryCacheImplSection(t *testing.T) {
+	cache := NewInMemoryCacheImpl[models.Task]()
+	acme, globex := cache.Section("acme"), cache.Section("globex")
+
+	id := uuid.New().String()
+	assert.NoError(t, acme.Set(id, models.Task{Title: "Acme's"}))
+
+	// The same key holds different items in different sections
+	got, err := globex.Get(id)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	got, err = cache.Get(id)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	got, err = cache.Section("acme").Get(id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "Acme's", got.Title)
+	}
+
+	assert.NoError(t, globex.Invalidate(id))
+	got, _ = acme.Get(id)
+	assert.NotNil(t, got)
+	assert.NoError(t, acme.Invalidate(id))
+	got, _ = acme.Get(id)
+	assert.Nil(t, got)
+}
+
 func TestInMemoryCacheImplGenericTypes(t *testing.T) {
 	t.Run("test with string type", func(t *testing.T) {
 		stringCache := NewInMemoryCacheImpl[string]()
@@ -24,3 +24,8 @@ func (n *NoOpCacheImpl[T]) Set(id string, item T) error {
 func (n *NoOpCacheImpl[T]) Invalidate(id string) error {
 	return nil
 }
+
+// Section implements CacheInterface.Section - returns the cache itself, which holds nothing to keep apart
+func (n *NoOpCacheImpl[T]) Section(name string) CacheInterface[T] {
+	return n
+}
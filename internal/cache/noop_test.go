@@ -79,6 +79,16 @@ func TestNoOpCacheImplInvalidate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestNoOpCacheImplSection(t *testing.T) {
+	cache := NewNoOpCacheImpl[models.Task]()
+	section := cache.Section("acme")
+
+	assert.NoError(t, section.Set("id", models.Task{Title: "Test"}))
+	got, err := section.Get("id")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
 func TestNoOpCacheImplGenericTypes(t *testing.T) {
 	// Test with different types to ensure generics work
 
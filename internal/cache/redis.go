@@ -52,6 +52,16 @@ func (r *RedisCache) Set(key string, data any, expiration time.Duration) error {
 	return r.client.Set(r.ctx, key, data, expiration).Err()
 }
 
+// SetNX stores data in Redis cache unless the key already exists, and reports whether it was stored
+func (r *RedisCache) SetNX(key string, data any, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(r.ctx, key, data, expiration).Result()
+}
+
+// Delete removes a key from Redis cache
+func (r *RedisCache) Delete(key string) error {
+	return r.client.Del(r.ctx, key).Err()
+}
+
 // Get gets data from Redis cache
 func Get[T any](r *RedisCache, format string, args ...any) (*T, error) {
 	key := fmt.Sprintf(format, args...)
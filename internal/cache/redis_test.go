@@ -2,6 +2,7 @@ package cache
 
 import (
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
@@ -73,3 +74,31 @@ func TestRedisCache_SetT_JSONMarshalError(t *testing.T) {
 	err = Set(cache, invalidValue, "test_key")
 	assert.Error(t, err)
 }
+
+func TestRedisCache_SetNXAndDelete(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	cache, err := NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	stored, err := cache.SetNX("key", "first", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, time.Minute, mr.TTL("key"))
+
+	// The key is taken, so the second value is not stored
+	stored, err = cache.SetNX("key", "second", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, stored)
+	value, _ := mr.Get("key")
+	assert.Equal(t, "first", value)
+
+	require.NoError(t, cache.Delete("key"))
+	assert.False(t, mr.Exists("key"))
+	stored, err = cache.SetNX("key", "second", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, stored)
+}
@@ -0,0 +1,59 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeysIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	deploy := models.APIKey{Name: "deploy", Prefix: "tk_deploy0", Hash: "hash-deploy", Scopes: "tasks:read tasks:write", CreatedAt: base}
+	monitor := models.APIKey{Name: "monitor", Prefix: "tk_monitor", Hash: "hash-monitor", Scopes: "alerts:fire", CreatedAt: base.Add(time.Hour)}
+	require.NoError(t, db.CreateAPIKey(context.TODO(), &deploy))
+	require.NoError(t, db.CreateAPIKey(context.TODO(), &monitor))
+
+	// Hashes are unique
+	assert.Error(t, db.CreateAPIKey(context.TODO(), &models.APIKey{Name: "copy", Prefix: "tk_copy", Hash: "hash-deploy"}))
+
+	found, err := db.GetAPIKeyByHash(context.TODO(), "hash-deploy")
+	require.NoError(t, err)
+	assert.Equal(t, deploy.ID, found.ID)
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, found.ScopeList())
+	_, err = db.GetAPIKeyByHash(context.TODO(), "hash-unknown")
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+
+	used := base.Add(2 * time.Hour)
+	require.NoError(t, db.TouchAPIKey(context.TODO(), deploy.ID, used))
+
+	revoked, err := db.RevokeAPIKey(context.TODO(), deploy.ID, base.Add(3*time.Hour))
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	assert.False(t, revoked.Active(base.Add(4*time.Hour)))
+	// Revoking again keeps the first revocation
+	again, err := db.RevokeAPIKey(context.TODO(), deploy.ID, base.Add(5*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, again.RevokedAt.Equal(base.Add(3*time.Hour)))
+	_, err = db.RevokeAPIKey(context.TODO(), uuid.New(), base)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+
+	// Revoked keys are still listed, newest first
+	keys, err := db.ListAPIKeys(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "monitor", keys[0].Name)
+	assert.Nil(t, keys[0].RevokedAt)
+	assert.Equal(t, "deploy", keys[1].Name)
+	require.NotNil(t, keys[1].LastUsedAt)
+	assert.True(t, keys[1].LastUsedAt.Equal(used))
+	assert.NotNil(t, keys[1].RevokedAt)
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CommentRepository defines the interface for task comment database operations.
+// Every method reports gorm.ErrRecordNotFound when the task does not exist.
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	GetComment(ctx context.Context, taskID, id uuid.UUID) (*models.Comment, error)
+	ListComments(ctx context.Context, taskID uuid.UUID, page, limit int) ([]models.Comment, int64, error)
+	UpdateComment(ctx context.Context, comment *models.Comment) error
+	DeleteComment(ctx context.Context, taskID, id uuid.UUID) error
+}
+
+// Ensure Database implements CommentRepository
+var _ CommentRepository = (*Database)(nil)
+
+// CreateComment adds a comment to the thread of comment.TaskID
+func (d *Database) CreateComment(ctx context.Context, comment *models.Comment) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureTaskExists(tx, comment.TaskID); err != nil {
+			return err
+		}
+		return tx.Create(comment).Error
+	})
+}
+
+// GetComment retrieves a comment of a task by ID
+func (d *Database) GetComment(ctx context.Context, taskID, id uuid.UUID) (*models.Comment, error) {
+	var comment models.Comment
+	db := d.DB.WithContext(ctx)
+	if err := ensureTaskExists(db, taskID); err != nil {
+		return nil, err
+	}
+	err := db.First(&comment, "task_id = ? AND id = ?", taskID, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListComments retrieves a page of a task's comments, oldest first
+func (d *Database) ListComments(ctx context.Context, taskID uuid.UUID, page, limit int) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+	var total int64
+
+	db := d.DB.WithContext(ctx)
+	if err := ensureTaskExists(db, taskID); err != nil {
+		return nil, 0, err
+	}
+
+	query := db.Model(&models.Comment{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at, id").Offset(offset).Limit(limit).Find(&comments).Error
+	return comments, total, err
+}
+
+// UpdateComment saves an edited comment
+func (d *Database) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	return d.DB.WithContext(ctx).Save(comment).Error
+}
+
+// DeleteComment soft-deletes a comment of a task
+func (d *Database) DeleteComment(ctx context.Context, taskID, id uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureTaskExists(tx, taskID); err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Comment{}, "task_id = ? AND id = ?", taskID, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ensureTaskExists returns gorm.ErrRecordNotFound unless a live task with the given ID exists in the tenant
+// of the context of tx
+func ensureTaskExists(tx *gorm.DB, id uuid.UUID) error {
+	return tx.Scopes(inTenant).Select("id").First(&models.Task{}, "id = ?", id).Error
+}
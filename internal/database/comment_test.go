@@ -0,0 +1,68 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentsIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build")
+	design, build := tasks[0], tasks[1]
+
+	comments := make([]models.Comment, 3)
+	for i, body := range []string{"First", "Second", "Third"} {
+		comments[i] = models.Comment{TaskID: design.ID, Author: "alice", Body: body}
+		require.NoError(t, db.CreateComment(context.TODO(), &comments[i]))
+	}
+	require.NoError(t, db.CreateComment(context.TODO(), &models.Comment{TaskID: build.ID, Author: "bob", Body: "Elsewhere"}))
+
+	err := db.CreateComment(context.TODO(), &models.Comment{TaskID: uuid.New(), Author: "alice", Body: "Lost"})
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+
+	page, total, err := db.ListComments(context.TODO(), design.ID, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, page, 1)
+	assert.Equal(t, "Third", page[0].Body)
+
+	_, _, err = db.ListComments(context.TODO(), uuid.New(), 1, 10)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+
+	// Comments are scoped to their task
+	_, err = db.GetComment(context.TODO(), build.ID, comments[0].ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	assert.True(t, utils.ErrIsRecordNotFound(db.DeleteComment(context.TODO(), build.ID, comments[0].ID)))
+
+	comments[0].Body = "Edited"
+	require.NoError(t, db.UpdateComment(context.TODO(), &comments[0]))
+	found, err := db.GetComment(context.TODO(), design.ID, comments[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Edited", found.Body)
+
+	require.NoError(t, db.DeleteComment(context.TODO(), design.ID, comments[1].ID))
+	_, total, err = db.ListComments(context.TODO(), design.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+}
+
+func TestDeleteTaskHidesComments(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design")
+	comment := models.Comment{TaskID: tasks[0].ID, Author: "alice", Body: "Note"}
+	require.NoError(t, db.CreateComment(context.TODO(), &comment))
+
+	require.NoError(t, db.Delete(context.TODO(), tasks[0].ID, database.AnyVersion))
+
+	_, err := db.GetComment(context.TODO(), tasks[0].ID, comment.ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	var live int64
+	require.NoError(t, db.DB.Model(&models.Comment{}).Where("task_id = ?", tasks[0].ID).Count(&live).Error)
+	assert.Zero(t, live)
+}
@@ -2,16 +2,23 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
+	"taheri24.ir/graph1/internal/middleware"
 	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
 	"taheri24.ir/graph1/pkg/config"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -19,36 +26,211 @@ import (
 type TaskRepository interface {
 	Create(ctx context.Context, task *models.Task) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Task, error)
-	GetAll(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error)
+	GetAll(ctx context.Context, opts TaskListOptions) ([]models.Task, int64, error)
+	Search(ctx context.Context, query string, opts TaskListOptions) ([]TaskSearchResult, int64, error)
+	GetUnfinished(ctx context.Context) ([]models.Task, error)
+	GetFiltered(ctx context.Context, status, assignee string) ([]models.Task, error)
+	ClaimDueTasks(ctx context.Context, now time.Time) ([]models.Task, error)
 	Update(ctx context.Context, task *models.Task) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	AddDependency(ctx context.Context, taskID, blockerID uuid.UUID) error
+	RemoveDependency(ctx context.Context, taskID, blockerID uuid.UUID) error
+	GetBlockers(ctx context.Context, taskID uuid.UUID) ([]models.Task, error)
+	GetDependents(ctx context.Context, taskID uuid.UUID) ([]models.Task, error)
+	GetBlockersForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error)
+	GetDependentsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error)
+	GetDependencies(ctx context.Context, taskIDs []uuid.UUID) ([]models.TaskDependency, error)
+	AddLabel(ctx context.Context, taskID, labelID uuid.UUID) error
+	RemoveLabel(ctx context.Context, taskID, labelID uuid.UUID) error
+	GetLabelsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error)
+	GetSubtree(ctx context.Context, id uuid.UUID) ([]models.Task, error)
+	DeleteTree(ctx context.Context, id uuid.UUID, version int64) ([]uuid.UUID, error)
+	GetHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskEvent, error)
+	GetTrashed(ctx context.Context, page, limit int) ([]models.Task, int64, error)
+	GetByIDWithTrashed(ctx context.Context, id uuid.UUID) (*models.Task, error)
+	Restore(ctx context.Context, id uuid.UUID) ([]models.Task, error)
+	Purge(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error)
+	Transaction(ctx context.Context, fn func(repo TaskRepository) error) error
+}
+
+// ErrVersionConflict is returned when a task was modified since the version being updated was read
+var ErrVersionConflict = errors.New("task has been modified by someone else")
+
+// AnyVersion makes Delete, DeleteTree and Purge act on a task whatever its version. Versions start at 1.
+const AnyVersion int64 = 0
+
+// TaskListOptions holds the pagination, filtering and sorting parameters for GetAll
+type TaskListOptions struct {
+	Page     int
+	Limit    int
+	Status   string
+	Assignee string
+	// Sort is one of created_at, updated_at, due_at, priority, title or status; empty sorts by created_at
+	Sort string
+	// Order is "asc" or "desc"; empty sorts ascending
+	Order string
+	// DueFrom and DueBefore restrict results to tasks due in [DueFrom, DueBefore); either may be nil
+	DueFrom   *time.Time
+	DueBefore *time.Time
+	// Unfinished excludes completed tasks
+	Unfinished bool
+	// Labels restricts results to tasks carrying these label names, combined according to LabelMode
+	Labels []string
+	// LabelMode is "all" to require every label or "any" (the default) to require at least one
+	LabelMode string
+	// After switches to keyset pagination: only the tasks that come after this position in created_at, id
+	// order, in the direction of Order, are listed and Page is ignored. It requires the created_at sort.
+	After *TaskCursor
+	// SkipTotal skips counting the matching tasks, in which case the total returned is 0
+	SkipTotal bool
+	// Filter restricts results to tasks matching a parsed filter expression, see ParseTaskFilter
+	Filter FilterExpr
+}
+
+// TaskCursor is the position of a task in created_at, id order, from which keyset pagination resumes
+type TaskCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// priorityRank maps priorities to their urgency so they sort low < medium < high < urgent
+const priorityRank = "CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 ELSE 0 END"
+
+// orderClause builds a deterministic ORDER BY for the requested sort. Ties are broken by
+// creation time and ID, and tasks without a due date always come last when sorting by due_at.
+func (opts TaskListOptions) orderClause() string {
+	direction := "ASC"
+	if strings.EqualFold(opts.Order, "desc") {
+		direction = "DESC"
+	}
+
+	switch opts.Sort {
+	case "priority":
+		return priorityRank + " " + direction + ", created_at, id"
+	case "due_at":
+		return "due_at IS NULL, due_at " + direction + ", created_at, id"
+	case "updated_at", "title", "status":
+		return opts.Sort + " " + direction + ", created_at, id"
+	default:
+		return "created_at " + direction + ", id " + direction
+	}
 }
 
 type Database struct {
 	DB *gorm.DB
+	// fts5 records whether SQLite supports FTS5 and the tasks_fts table was created, found once by
+	// NewDatabase rather than on every search
+	fts5 bool
 }
 
 // Ensure Database implements TaskRepository
 var _ TaskRepository = (*Database)(nil)
 
-// Create creates a new task
+// Create creates a new task in the tenant of ctx and records it in the task history. Unless CreatedBy is
+// set, the task is recorded as created by the actor of ctx. An assignee has to be a user of the tenant,
+// given by ID or username, or ErrUnknownAssignee is returned.
 func (d *Database) Create(ctx context.Context, task *models.Task) error {
-	return d.DB.WithContext(ctx).Create(task).Error
+	if task.CreatedBy == "" {
+		task.CreatedBy = middleware.GetActorFromContext(ctx)
+	}
+	task.TenantID = middleware.GetTenantFromContext(ctx)
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if task.ParentID != nil {
+			if err := checkParent(tx, task.ID, *task.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := resolveAssignee(tx, task); err != nil {
+			return err
+		}
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, task.ID, models.TaskEventCreated, models.DiffTasks(models.Task{}, *task))
+	})
 }
 
-// GetByID retrieves a task by ID
+// GetByID retrieves a task by ID. Like every query on tasks, it only finds the tasks of the tenant of ctx.
 func (d *Database) GetByID(ctx context.Context, id uuid.UUID) (task *models.Task, err error) {
-	err = d.DB.WithContext(ctx).First(&task, "id = ?", id).Error
+	err = d.DB.WithContext(ctx).Scopes(inTenant).First(&task, "id = ?", id).Error
 	return task, err
 }
 
-// GetAll retrieves tasks with pagination and filtering
-func (d *Database) GetAll(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error) {
+// GetAll retrieves tasks with pagination, filtering and sorting
+func (d *Database) GetAll(ctx context.Context, opts TaskListOptions) ([]models.Task, int64, error) {
 	var tasks []models.Task
 	var total int64
 
-	offset := (page - 1) * limit
-	query := d.DB.WithContext(ctx).Model(&models.Task{})
+	query := d.filterTasks(d.DB.WithContext(ctx).Model(&models.Task{}).Scopes(inTenant), opts)
+
+	if !opts.SkipTotal {
+		err := query.Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	err := opts.page(query.Order(opts.orderClause())).Find(&tasks).Error
+	return tasks, total, err
+}
+
+// page restricts an ordered query to the page of opts: the tasks following opts.After when it is set,
+// and otherwise the opts.Page-th run of opts.Limit tasks
+func (opts TaskListOptions) page(query *gorm.DB) *gorm.DB {
+	if opts.After == nil {
+		return query.Offset((opts.Page - 1) * opts.Limit).Limit(opts.Limit)
+	}
+	comparison := ">"
+	if strings.EqualFold(opts.Order, "desc") {
+		comparison = "<"
+	}
+	return query.
+		Where("(tasks.created_at "+comparison+" ? OR (tasks.created_at = ? AND tasks.id "+comparison+" ?))",
+			opts.After.CreatedAt, opts.After.CreatedAt, opts.After.ID).
+		Limit(opts.Limit)
+}
+
+// filterTasks restricts a query on tasks to those matching the filters of opts
+func (d *Database) filterTasks(query *gorm.DB, opts TaskListOptions) *gorm.DB {
+	if opts.Status != "" {
+		query = query.Where("status = ?", opts.Status)
+	}
+	if opts.Assignee != "" {
+		query = query.Where("assignee = ?", opts.Assignee)
+	}
+	if opts.DueFrom != nil {
+		query = query.Where("due_at >= ?", *opts.DueFrom)
+	}
+	if opts.DueBefore != nil {
+		query = query.Where("due_at < ?", *opts.DueBefore)
+	}
+	if opts.Unfinished {
+		query = query.Where("status <> ?", types.StatusCompleted)
+	}
+	if len(opts.Labels) > 0 {
+		query = query.Where("tasks.id IN (?)", d.labelledTaskIDs(opts.Labels, opts.LabelMode))
+	}
+	if opts.Filter != nil {
+		condition, args := opts.Filter.sql()
+		query = query.Where("("+condition+")", args...)
+	}
+	return query
+}
+
+// GetUnfinished retrieves every task that is not completed, oldest first
+func (d *Database) GetUnfinished(ctx context.Context) ([]models.Task, error) {
+	var tasks []models.Task
+	err := d.DB.WithContext(ctx).Scopes(inTenant).
+		Where("status <> ?", types.StatusCompleted).
+		Order("created_at, id").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetFiltered retrieves every task matching the optional status and assignee filters, oldest first
+func (d *Database) GetFiltered(ctx context.Context, status, assignee string) ([]models.Task, error) {
+	var tasks []models.Task
+	query := d.DB.WithContext(ctx).Model(&models.Task{}).Scopes(inTenant)
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -57,23 +239,154 @@ func (d *Database) GetAll(ctx context.Context, page, limit int, status, assignee
 		query = query.Where("assignee = ?", assignee)
 	}
 
-	err := query.Count(&total).Error
+	err := query.Order("created_at, id").Find(&tasks).Error
+	return tasks, err
+}
+
+// ClaimDueTasks marks the unfinished tasks due by now that no reminder was sent for as reminded, and returns them
+// soonest first. The tasks are claimed with one conditional update, so when several instances scan at once
+// every task is returned to only one of them.
+func (d *Database) ClaimDueTasks(ctx context.Context, now time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := d.DB.WithContext(ctx).Model(&tasks).Clauses(clause.Returning{}).Scopes(inTenant).
+		Where("due_at <= ? AND reminded_at IS NULL", now).
+		Where("status <> ?", types.StatusCompleted).
+		UpdateColumn("reminded_at", now).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-
-	err = query.Offset(offset).Limit(limit).Find(&tasks).Error
-	return tasks, total, err
+	slices.SortFunc(tasks, func(a, b models.Task) int {
+		if c := a.DueAt.Compare(*b.DueAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	return tasks, nil
 }
 
-// Update updates an existing task
+// Update updates an existing task, rejecting a parent that would make the task its own ancestor and, as
+// Create does, an assignee that is not a user. A task is reassigned by changing either its Assignee or its
+// AssigneeID.
+// It is a compare-and-swap on task.Version: when the stored task has moved on to another version
+// ErrVersionConflict is returned, otherwise the version is incremented. Updates that change
+// nothing are not written. The changed fields are recorded in the task history.
 func (d *Database) Update(ctx context.Context, task *models.Task) error {
-	return d.DB.WithContext(ctx).Save(task).Error
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if task.ParentID != nil {
+			if err := checkParent(tx, task.ID, *task.ParentID); err != nil {
+				return err
+			}
+		}
+		var before models.Task
+		if err := tx.Scopes(inTenant).First(&before, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+		if before.Version != task.Version {
+			return ErrVersionConflict
+		}
+		keepChangedAssignee(before, task)
+		if err := resolveAssignee(tx, task); err != nil {
+			return err
+		}
+		changes := models.DiffTasks(before, *task)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		// A new due time deserves a reminder of its own
+		task.RemindedAt = before.RemindedAt
+		if _, ok := changes["due_at"]; ok {
+			task.RemindedAt = nil
+		}
+		task.Version++
+		result := tx.Model(&models.Task{}).Scopes(inTenant).
+			Where("id = ? AND version = ?", task.ID, before.Version).
+			Select("*").Omit("id", "tenant_id", "created_at", "created_by", "deleted_at", clause.Associations).
+			Updates(task)
+		if result.Error == nil && result.RowsAffected == 0 {
+			result.Error = ErrVersionConflict
+		}
+		if result.Error != nil {
+			task.Version = before.Version
+			return result.Error
+		}
+		return recordEvent(ctx, tx, task.ID, models.TaskEventUpdated, changes)
+	})
+}
+
+// touchTasks moves tasks to a new version without changing their fields, for changes such as
+// relabelling that alter how a task is presented
+func touchTasks(tx *gorm.DB, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Unscoped().Model(&models.Task{}).Scopes(inTenant).Where("id IN ?", ids).Updates(map[string]any{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}).Error
 }
 
-// Delete deletes a task by ID
-func (d *Database) Delete(ctx context.Context, id uuid.UUID) error {
-	return d.DB.WithContext(ctx).Delete(&models.Task{}, "id = ?", id).Error
+// Delete soft-deletes a task together with its comments and records the deletion. Tasks with subtasks are refused
+// with ErrTaskHasChildren; use DeleteTree to remove them. A task that is not live in the tenant of ctx is
+// gorm.ErrRecordNotFound. Unless version is AnyVersion the task is only deleted at that version, and
+// ErrVersionConflict is returned when it has moved on.
+func (d *Database) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		children, err := countChildren(tx, id)
+		if err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrTaskHasChildren
+		}
+		// The task goes first so that its comments are never deleted before it; Restore relies on this
+		result := tx.Scopes(inTenant, atVersion(version)).Delete(&models.Task{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return missingOrMoved(tx, id, version)
+		}
+		if err := tx.Delete(&models.Comment{}, "task_id = ?", id).Error; err != nil {
+			return err
+		}
+		return recordEvent(ctx, tx, id, models.TaskEventDeleted, nil)
+	})
+}
+
+// atVersion restricts a query on tasks to the given version, unless it is AnyVersion
+func atVersion(version int64) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if version == AnyVersion {
+			return tx
+		}
+		return tx.Where("version = ?", version)
+	}
+}
+
+// missingOrMoved explains why the task id of tx's scope was not found at version: ErrVersionConflict when it
+// exists at another version, gorm.ErrRecordNotFound otherwise
+func missingOrMoved(tx *gorm.DB, id uuid.UUID, version int64) error {
+	if version == AnyVersion {
+		return gorm.ErrRecordNotFound
+	}
+	var count int64
+	if err := tx.Model(&models.Task{}).Scopes(inTenant).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVersionConflict
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// Transaction runs fn with a repository whose operations all take part in one transaction, which is
+// committed when fn returns nil and rolled back otherwise. Each operation of the repository that fails
+// is rolled back on its own, so fn may carry on after a failed operation and still commit the others.
+func (d *Database) Transaction(ctx context.Context, fn func(repo TaskRepository) error) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&Database{DB: tx, fts5: d.fts5})
+	})
 }
 
 func NewDatabase(cfg *config.Config) (*Database, error) {
@@ -95,6 +408,7 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 	result := &Database{DB: db}
 	if cfg.Database.Type == "sqlite" {
 		Migrate(db)
+		result.fts5 = hasFTS5(db)
 	}
 
 	return result, nil
@@ -102,9 +416,35 @@ func NewDatabase(cfg *config.Config) (*Database, error) {
 
 // Migrate handles auto-migration of database schema
 func Migrate(db *gorm.DB) error {
-	if err := db.AutoMigrate(&models.Task{}); err != nil {
+	if err := db.SetupJoinTable(&models.Task{}, "Blockers", &models.TaskDependency{}); err != nil {
+		return fmt.Errorf("failed to setup task dependencies: %w", err)
+	}
+	if err := db.SetupJoinTable(&models.Task{}, "Labels", &models.TaskLabel{}); err != nil {
+		return fmt.Errorf("failed to setup task labels: %w", err)
+	}
+	hadReminders := !db.Migrator().HasTable(&models.Task{}) || db.Migrator().HasColumn(&models.Task{}, "reminded_at")
+	if err := db.AutoMigrate(&models.Task{}, &models.TaskDependency{}, &models.Label{}, &models.TaskLabel{}, &models.Comment{}, &models.TaskEvent{}, &models.APIKey{}, &models.User{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
+	// Tasks that were overdue before reminders were recorded were reminded about already, or were due
+	// before the scheduler started and never will be
+	if !hadReminders {
+		if err := db.Model(&models.Task{}).Where("due_at <= ?", time.Now()).UpdateColumn("reminded_at", gorm.Expr("due_at")).Error; err != nil {
+			return fmt.Errorf("failed to record past reminders: %w", err)
+		}
+	}
+	// Label names used to be unique across tenants
+	if db.Migrator().HasIndex(&models.Label{}, "idx_labels_name") {
+		if err := db.Migrator().DropIndex(&models.Label{}, "idx_labels_name"); err != nil {
+			return fmt.Errorf("failed to drop the global label name index: %w", err)
+		}
+	}
+	if err := MigrateAssignees(db); err != nil {
+		return fmt.Errorf("failed to convert task assignees into users: %w", err)
+	}
+	if err := migrateSearch(db); err != nil {
+		return fmt.Errorf("failed to set up task search: %w", err)
+	}
 
 	slog.Info("Database connection established and migrations completed")
 	return nil
@@ -3,14 +3,17 @@ package database_test
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
 	"taheri24.ir/graph1/internal/models"
 	"taheri24.ir/graph1/internal/types"
 	"taheri24.ir/graph1/pkg/config"
+	"taheri24.ir/graph1/pkg/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
@@ -41,6 +44,7 @@ func TestCreateTaskIntegration(t *testing.T) {
 	err = database.Migrate(db.DB)
 	require.NoError(t, err)
 
+	createTestUsers(t, db, "test@example.com")
 	task := &models.Task{
 		Title:       "Integration Test Task",
 		Description: "Testing create operation",
@@ -60,6 +64,7 @@ func TestCreateTaskIntegration(t *testing.T) {
 	assert.Equal(t, task.Description, found.Description)
 	assert.Equal(t, task.Status, found.Status)
 	assert.Equal(t, task.Assignee, found.Assignee)
+	assert.Equal(t, middleware.AnonymousActor, found.CreatedBy)
 	assert.False(t, found.CreatedAt.IsZero())
 	assert.False(t, found.UpdatedAt.IsZero())
 }
@@ -74,14 +79,16 @@ func TestUpdateTaskIntegration(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a task first
+	createTestUsers(t, db, "original@test.com", "updated@test.com")
 	originalTask := &models.Task{
 		Title:       "Original Title",
 		Description: "Original Description",
 		Status:      types.StatusPending,
 		Assignee:    "original@test.com",
 	}
-	err = db.Create(context.TODO(), originalTask)
+	err = db.Create(middleware.ContextWithActor(context.TODO(), "alice"), originalTask)
 	require.NoError(t, err)
+	assert.Equal(t, "alice", originalTask.CreatedBy)
 
 	originalUpdatedAt := originalTask.UpdatedAt
 
@@ -92,6 +99,8 @@ func TestUpdateTaskIntegration(t *testing.T) {
 	originalTask.Title = "Updated Title"
 	originalTask.Status = types.StatusCompleted
 	originalTask.Assignee = "updated@test.com"
+	// Who created a task never changes
+	originalTask.CreatedBy = "mallory"
 
 	err = db.Update(context.TODO(), originalTask)
 	assert.NoError(t, err)
@@ -104,9 +113,97 @@ func TestUpdateTaskIntegration(t *testing.T) {
 	assert.Equal(t, types.StatusCompleted, found.Status)
 	assert.Equal(t, "updated@test.com", found.Assignee)
 	assert.Equal(t, "Original Description", found.Description) // Should remain unchanged
+	assert.Equal(t, "alice", found.CreatedBy)
 	assert.True(t, found.UpdatedAt.After(originalUpdatedAt))
 }
 
+func TestUpdateVersionConflictIntegration(t *testing.T) {
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	task := &models.Task{Title: "Original", Status: types.StatusPending}
+	require.NoError(t, db.Create(context.TODO(), task))
+	assert.Equal(t, int64(1), task.Version)
+
+	// Two writers read the same version
+	first, err := db.GetByID(context.TODO(), task.ID)
+	require.NoError(t, err)
+	second, err := db.GetByID(context.TODO(), task.ID)
+	require.NoError(t, err)
+
+	first.Title = "First"
+	require.NoError(t, db.Update(context.TODO(), first))
+	assert.Equal(t, int64(2), first.Version)
+
+	// The second writer's version is stale
+	second.Title = "Second"
+	err = db.Update(context.TODO(), second)
+	assert.ErrorIs(t, err, database.ErrVersionConflict)
+	assert.Equal(t, int64(1), second.Version)
+
+	// Saving without changes keeps the version
+	require.NoError(t, db.Update(context.TODO(), first))
+	assert.Equal(t, int64(2), first.Version)
+
+	found, err := db.GetByID(context.TODO(), task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First", found.Title)
+	assert.Equal(t, int64(2), found.Version)
+}
+
+func TestTransactionIntegration(t *testing.T) {
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	existing := &models.Task{Title: "Existing", Status: types.StatusPending}
+	require.NoError(t, db.Create(context.TODO(), existing))
+
+	// A failed operation is rolled back on its own and the others are committed
+	var committed models.Task
+	err = db.Transaction(context.TODO(), func(repo database.TaskRepository) error {
+		duplicate := &models.Task{ID: existing.ID, Title: "Duplicate", Status: types.StatusPending}
+		assert.Error(t, repo.Create(context.TODO(), duplicate))
+
+		committed = models.Task{Title: "Committed", Status: types.StatusPending}
+		return repo.Create(context.TODO(), &committed)
+	})
+	require.NoError(t, err)
+	found, err := db.GetByID(context.TODO(), committed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Committed", found.Title)
+	found, err = db.GetByID(context.TODO(), existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Existing", found.Title)
+
+	// Returning an error rolls back every operation
+	rollback := errors.New("rollback")
+	var discarded models.Task
+	err = db.Transaction(context.TODO(), func(repo database.TaskRepository) error {
+		discarded = models.Task{Title: "Discarded", Status: types.StatusPending}
+		require.NoError(t, repo.Create(context.TODO(), &discarded))
+		existing.Title = "Renamed"
+		require.NoError(t, repo.Update(context.TODO(), existing))
+		require.NoError(t, repo.Delete(context.TODO(), committed.ID, database.AnyVersion))
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback)
+
+	_, err = db.GetByID(context.TODO(), discarded.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	found, err = db.GetByID(context.TODO(), existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Existing", found.Title)
+	_, err = db.GetByID(context.TODO(), committed.ID)
+	assert.NoError(t, err)
+	history, err := db.GetHistory(context.TODO(), discarded.ID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
 func TestDeleteTaskIntegration(t *testing.T) {
 	cfg := config.NewTestConfig()
 	db, err := database.NewDatabase(cfg)
@@ -117,6 +214,7 @@ func TestDeleteTaskIntegration(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create a task first
+	createTestUsers(t, db, "delete@test.com")
 	task := &models.Task{
 		Title:       "Task to Delete",
 		Description: "Will be deleted",
@@ -132,17 +230,55 @@ func TestDeleteTaskIntegration(t *testing.T) {
 	assert.Equal(t, int64(1), count)
 
 	// Delete the task
-	err = db.Delete(context.TODO(), task.ID)
+	err = db.Delete(context.TODO(), task.ID, database.AnyVersion)
 	assert.NoError(t, err)
 
 	// Verify it was deleted
 	db.DB.Model(&models.Task{}).Count(&count)
 	assert.Equal(t, int64(0), count)
 
-	// Try to delete non-existent task (should not error due to GORM behavior)
+	// Deleting a task that does not exist, or no longer does, is not found
 	nonExistentID := uuid.New()
-	err = db.Delete(context.TODO(), nonExistentID)
-	assert.NoError(t, err) // GORM doesn't return error for deleting non-existent records
+	err = db.Delete(context.TODO(), nonExistentID, database.AnyVersion)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	err = db.Delete(context.TODO(), task.ID, database.AnyVersion)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	_, err = db.DeleteTree(context.TODO(), nonExistentID, database.AnyVersion)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestDeleteTaskAtVersion(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Epic", "Story", "Loose")
+	epic, story, loose := tasks[0], tasks[1], tasks[2]
+	story.ParentID = &epic.ID
+	require.NoError(t, db.Update(context.TODO(), &story))
+
+	// A task that moved on since its version was read is left alone
+	stale := loose.Version
+	loose.Title = "Renamed"
+	require.NoError(t, db.Update(context.TODO(), &loose))
+	assert.ErrorIs(t, db.Delete(context.TODO(), loose.ID, stale), database.ErrVersionConflict)
+	_, err := db.GetByID(context.TODO(), loose.ID)
+	require.NoError(t, err)
+	require.NoError(t, db.Delete(context.TODO(), loose.ID, loose.Version))
+	assert.True(t, utils.ErrIsRecordNotFound(db.Delete(context.TODO(), loose.ID, loose.Version)))
+
+	_, err = db.DeleteTree(context.TODO(), epic.ID, epic.Version+1)
+	assert.ErrorIs(t, err, database.ErrVersionConflict)
+	_, err = db.GetByID(context.TODO(), story.ID)
+	require.NoError(t, err)
+	deleted, err := db.DeleteTree(context.TODO(), epic.ID, epic.Version)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{epic.ID, story.ID}, deleted)
+
+	// Tasks in the trash are purged at their version too
+	_, err = db.Purge(context.TODO(), loose.ID, false, stale)
+	assert.ErrorIs(t, err, database.ErrVersionConflict)
+	purged, err := db.Purge(context.TODO(), loose.ID, false, loose.Version)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{loose.ID}, purged)
+	_, err = db.Purge(context.TODO(), loose.ID, false, loose.Version)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
 }
 
 func TestGetAllTasksIntegration(t *testing.T) {
@@ -158,6 +294,7 @@ func TestGetAllTasksIntegration(t *testing.T) {
 	db.DB.Exec("DELETE FROM tasks")
 
 	// Create multiple tasks
+	createTestUsers(t, db, "user1@test.com", "user2@test.com", "user3@test.com")
 	tasks := []models.Task{
 		{
 			Title:       "Task 1",
@@ -191,24 +328,24 @@ func TestGetAllTasksIntegration(t *testing.T) {
 	}
 
 	// Test GetAll without filters
-	foundTasks, total, err := db.GetAll(context.TODO(), 1, 10, "", "")
+	foundTasks, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(4), total)
 	assert.Len(t, foundTasks, 4)
 
 	// Test pagination
-	foundTasks, total, err = db.GetAll(context.TODO(), 1, 2, "", "")
+	foundTasks, total, err = db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 2})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(4), total)
 	assert.Len(t, foundTasks, 2)
 
-	foundTasks, total, err = db.GetAll(context.TODO(), 2, 2, "", "")
+	foundTasks, total, err = db.GetAll(context.TODO(), database.TaskListOptions{Page: 2, Limit: 2})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(4), total)
 	assert.Len(t, foundTasks, 2)
 
 	// Test filtering by status
-	foundTasks, total, err = db.GetAll(context.TODO(), 1, 10, "pending", "")
+	foundTasks, total, err = db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Status: "pending"})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), total)
 	assert.Len(t, foundTasks, 2)
@@ -217,7 +354,7 @@ func TestGetAllTasksIntegration(t *testing.T) {
 	}
 
 	// Test filtering by assignee
-	foundTasks, total, err = db.GetAll(context.TODO(), 1, 10, "", "user1@test.com")
+	foundTasks, total, err = db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Assignee: "user1@test.com"})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), total)
 	assert.Len(t, foundTasks, 2)
@@ -226,13 +363,230 @@ func TestGetAllTasksIntegration(t *testing.T) {
 	}
 
 	// Test combined filtering
-	foundTasks, total, err = db.GetAll(context.TODO(), 1, 10, "completed", "user1@test.com")
+	foundTasks, total, err = db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Status: "completed", Assignee: "user1@test.com"})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), total)
 	assert.Len(t, foundTasks, 1)
 	assert.Equal(t, "Task 3", foundTasks[0].Title)
 }
 
+func TestGetAllSortingIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	defer db.Close()
+
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	due := func(days int) *time.Time {
+		at := base.AddDate(0, 0, days)
+		return &at
+	}
+	tasks := []models.Task{
+		{Title: "Charlie", Priority: types.PriorityLow, DueAt: due(3)},
+		{Title: "Alpha", Priority: types.PriorityUrgent},
+		{Title: "Bravo", Priority: types.PriorityMedium, DueAt: due(1)},
+		{Title: "Delta", Priority: types.PriorityHigh, DueAt: due(2)},
+	}
+	for i := range tasks {
+		tasks[i].Status = types.StatusPending
+		tasks[i].CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+
+	titles := func(opts database.TaskListOptions) []string {
+		opts.Page, opts.Limit = 1, 10
+		found, _, err := db.GetAll(context.TODO(), opts)
+		require.NoError(t, err)
+		result := make([]string, len(found))
+		for i, task := range found {
+			result[i] = task.Title
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"Charlie", "Alpha", "Bravo", "Delta"}, titles(database.TaskListOptions{}))
+	assert.Equal(t, []string{"Delta", "Bravo", "Alpha", "Charlie"}, titles(database.TaskListOptions{Sort: "created_at", Order: "desc"}))
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie", "Delta"}, titles(database.TaskListOptions{Sort: "title"}))
+	assert.Equal(t, []string{"Alpha", "Delta", "Bravo", "Charlie"}, titles(database.TaskListOptions{Sort: "priority", Order: "desc"}))
+	// Tasks without a due date come last in either direction
+	assert.Equal(t, []string{"Bravo", "Delta", "Charlie", "Alpha"}, titles(database.TaskListOptions{Sort: "due_at"}))
+	assert.Equal(t, []string{"Charlie", "Delta", "Bravo", "Alpha"}, titles(database.TaskListOptions{Sort: "due_at", Order: "desc"}))
+}
+
+func TestGetAllKeysetIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	defer db.Close()
+
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	tasks := make([]models.Task, 5)
+	for i := range tasks {
+		tasks[i] = models.Task{Title: fmt.Sprintf("Task %d", i), Status: types.StatusPending, CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+	}
+	// Tasks created at the same time are ordered by ID
+	tasks[2].CreatedAt = tasks[1].CreatedAt
+	for i := range tasks {
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+	if tasks[2].ID.String() < tasks[1].ID.String() {
+		tasks[1], tasks[2] = tasks[2], tasks[1]
+	}
+
+	// pages lists every page of opts, creating a task at insertAt, on a page already read, after the first page
+	pages := func(opts database.TaskListOptions, insertAt time.Time) [][]uuid.UUID {
+		opts.Limit, opts.SkipTotal = 2, true
+		var result [][]uuid.UUID
+		for {
+			found, total, err := db.GetAll(context.TODO(), opts)
+			require.NoError(t, err)
+			assert.Zero(t, total)
+			if len(found) == 0 {
+				return result
+			}
+			ids := make([]uuid.UUID, len(found))
+			for i, task := range found {
+				ids[i] = task.ID
+			}
+			result = append(result, ids)
+			last := found[len(found)-1]
+			opts.After = &database.TaskCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+
+			if len(result) == 1 {
+				// Tasks created while paging do not shift the pages
+				inserted := models.Task{Title: "Inserted", Status: types.StatusPending, CreatedAt: insertAt}
+				require.NoError(t, db.Create(context.TODO(), &inserted))
+				defer db.Purge(context.TODO(), inserted.ID, false, database.AnyVersion)
+			}
+		}
+	}
+
+	assert.Equal(t, [][]uuid.UUID{{tasks[0].ID, tasks[1].ID}, {tasks[2].ID, tasks[3].ID}, {tasks[4].ID}},
+		pages(database.TaskListOptions{Sort: "created_at"}, base.Add(-time.Hour)))
+	assert.Equal(t, [][]uuid.UUID{{tasks[4].ID, tasks[3].ID}, {tasks[2].ID, tasks[1].ID}, {tasks[0].ID}},
+		pages(database.TaskListOptions{Sort: "created_at", Order: "desc", Status: "pending"}, base.Add(time.Hour*10)))
+
+	// Counting stays available alongside a cursor
+	_, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Limit: 2,
+		After: &database.TaskCursor{CreatedAt: tasks[3].CreatedAt, ID: tasks[3].ID}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+}
+
+func TestDueFiltersIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	due := func(d time.Duration) *time.Time {
+		at := now.Add(d)
+		return &at
+	}
+	tasks := []models.Task{
+		{Title: "Overdue", Status: types.StatusPending, DueAt: due(-2 * time.Hour)},
+		{Title: "Done", Status: types.StatusCompleted, DueAt: due(-time.Hour)},
+		{Title: "Later today", Status: types.StatusInProgress, DueAt: due(3 * time.Hour)},
+		{Title: "Next week", Status: types.StatusPending, DueAt: due(8 * 24 * time.Hour)},
+		{Title: "No due date", Status: types.StatusPending},
+	}
+	for i := range tasks {
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+
+	titles := func(found []models.Task) []string {
+		result := make([]string, len(found))
+		for i, task := range found {
+			result[i] = task.Title
+		}
+		return result
+	}
+
+	found, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, DueBefore: &now, Unfinished: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Overdue"}, titles(found))
+
+	from, before := now.Add(-12*time.Hour), now.Add(12*time.Hour)
+	found, _, err = db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, DueFrom: &from, DueBefore: &before})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Overdue", "Done", "Later today"}, titles(found))
+}
+
+func TestClaimDueTasksIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	due := func(d time.Duration) *time.Time {
+		at := now.Add(d)
+		return &at
+	}
+	tasks := []models.Task{
+		{Title: "Overdue", Status: types.StatusPending, DueAt: due(-2 * time.Hour)},
+		{Title: "Done", Status: types.StatusCompleted, DueAt: due(-time.Hour)},
+		{Title: "Due now", Status: types.StatusInProgress, DueAt: due(0)},
+		{Title: "Later today", Status: types.StatusPending, DueAt: due(3 * time.Hour)},
+		{Title: "No due date", Status: types.StatusPending},
+	}
+	for i := range tasks {
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+	titles := func(found []models.Task) []string {
+		result := make([]string, len(found))
+		for i, task := range found {
+			result[i] = task.Title
+		}
+		return result
+	}
+
+	// Unfinished tasks due by now are claimed once, soonest first, without moving them to a new version
+	claimed, err := db.ClaimDueTasks(context.TODO(), now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Overdue", "Due now"}, titles(claimed))
+	claimed, err = db.ClaimDueTasks(context.TODO(), now)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+	stored, err := db.GetByID(context.TODO(), tasks[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, tasks[0].Version, stored.Version)
+
+	// Moving the due time makes the task due for a reminder again
+	stored.DueAt = due(time.Hour)
+	require.NoError(t, db.Update(context.TODO(), stored))
+	stored.Title = "Overdue, renamed"
+	require.NoError(t, db.Update(context.TODO(), stored))
+	claimed, err = db.ClaimDueTasks(context.TODO(), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Overdue, renamed"}, titles(claimed))
+	stored, err = db.GetByID(context.TODO(), tasks[0].ID)
+	require.NoError(t, err)
+	stored.Title = "Overdue"
+	require.NoError(t, db.Update(context.TODO(), stored))
+	claimed, err = db.ClaimDueTasks(context.TODO(), now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+func TestMigrateRecordsPastRemindersIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	defer db.Close()
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	overdue := models.Task{Title: "Overdue", Status: types.StatusPending, DueAt: &past}
+	upcoming := models.Task{Title: "Upcoming", Status: types.StatusPending, DueAt: &future}
+	require.NoError(t, db.Create(context.TODO(), &overdue))
+	require.NoError(t, db.Create(context.TODO(), &upcoming))
+
+	// Tasks overdue before reminders were recorded are not reminded about again after the upgrade
+	require.NoError(t, db.DB.Migrator().DropColumn(&models.Task{}, "reminded_at"))
+	require.NoError(t, database.Migrate(db.DB))
+	claimed, err := db.ClaimDueTasks(context.TODO(), future)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, upcoming.ID, claimed[0].ID)
+}
+
 func TestHealthCheckIntegration(t *testing.T) {
 	cfg := config.NewTestConfig()
 	db, err := database.NewDatabase(cfg)
@@ -356,7 +710,7 @@ func (suite *DatabaseTestSuite) TestGetAll() {
 		recover() // Just recover from panic, test passes if we get here
 	}()
 
-	suite.db.GetAll(context.TODO(), page, limit, status, assignee)
+	suite.db.GetAll(context.TODO(), database.TaskListOptions{Page: page, Limit: limit, Status: status, Assignee: assignee})
 }
 
 func (suite *DatabaseTestSuite) TestUpdate() {
@@ -384,7 +738,7 @@ func (suite *DatabaseTestSuite) TestDelete() {
 		recover() // Just recover from panic, test passes if we get here
 	}()
 
-	suite.db.Delete(context.TODO(), taskID)
+	suite.db.Delete(context.TODO(), taskID, database.AnyVersion)
 }
 
 func (suite *DatabaseTestSuite) TestHealth() {
@@ -582,7 +936,7 @@ func TestDatabaseGetAllWithNilDB(t *testing.T) {
 		}
 	}()
 
-	tasks, total, err := db.GetAll(context.TODO(), 1, 10, "pending", "user@example.com")
+	tasks, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Status: "pending", Assignee: "user@example.com"})
 	// If we get here without panic, that's also fine
 	if err != nil {
 		assert.Error(t, err)
@@ -617,7 +971,7 @@ func TestDatabaseGetAllSuccess(t *testing.T) {
 	mock.ExpectQuery(`SELECT \* FROM "tasks"`).
 		WillReturnRows(rows)
 
-	tasks, total, err := db.GetAll(context.TODO(), 1, 10, "pending", "user@example.com")
+	tasks, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Status: "pending", Assignee: "user@example.com"})
 	assert.NoError(t, err)
 	assert.Len(t, tasks, 2)
 	assert.Equal(t, int64(2), total)
@@ -642,7 +996,7 @@ func TestDatabaseGetAllCountError(t *testing.T) {
 	mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"`).
 		WillReturnError(fmt.Errorf("count failed"))
 
-	tasks, total, err := db.GetAll(context.TODO(), 1, 10, "pending", "user@example.com")
+	tasks, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Status: "pending", Assignee: "user@example.com"})
 	assert.Error(t, err)
 	assert.Nil(t, tasks)
 	assert.Equal(t, int64(0), total)
@@ -671,7 +1025,7 @@ func TestDatabaseGetAllSelectError(t *testing.T) {
 	mock.ExpectQuery(`SELECT \* FROM "tasks"`).
 		WillReturnError(fmt.Errorf("select failed"))
 
-	tasks, total, err := db.GetAll(context.TODO(), 1, 10, "pending", "user@example.com")
+	tasks, total, err := db.GetAll(context.TODO(), database.TaskListOptions{Page: 1, Limit: 10, Status: "pending", Assignee: "user@example.com"})
 	assert.Error(t, err)
 	assert.Nil(t, tasks)
 	assert.Equal(t, int64(2), total) // Count should have succeeded
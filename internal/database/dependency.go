@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"taheri24.ir/graph1/internal/graph"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CycleError is returned when a new dependency would make the task graph cyclic
+type CycleError struct {
+	// Cycle lists the task IDs forming the cycle, starting and ending at the same task
+	Cycle []uuid.UUID
+}
+
+func (e *CycleError) Error() string {
+	ids := make([]string, len(e.Cycle))
+	for i, id := range e.Cycle {
+		ids[i] = id.String()
+	}
+	return fmt.Sprintf("dependency would create a cycle: %s", strings.Join(ids, " -> "))
+}
+
+// AddDependency records that blockerID must be finished before taskID.
+// Adding an edge that already exists is a no-op.
+func (d *Database) AddDependency(ctx context.Context, taskID, blockerID uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock both tasks, in a fixed order so that concurrent inserts cannot deadlock on them
+		endpoints := []uuid.UUID{taskID, blockerID}
+		if strings.Compare(blockerID.String(), taskID.String()) < 0 {
+			endpoints[0], endpoints[1] = blockerID, taskID
+		}
+		for _, id := range endpoints {
+			if err := lockTask(tx, id); err != nil {
+				return err
+			}
+		}
+
+		edges, err := reachableDependencies(tx, taskID)
+		if err != nil {
+			return err
+		}
+		g := dependencyGraph(edges)
+		if cycle := g.CycleWith(blockerID, taskID); cycle != nil {
+			return &CycleError{Cycle: cycle}
+		}
+
+		dependency := models.TaskDependency{TaskID: taskID, BlockerID: blockerID}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dependency).Error
+	})
+}
+
+// lockTask locks the row of the live task id of the tenant of tx for the rest of the transaction
+func lockTask(tx *gorm.DB, id uuid.UUID) error {
+	return tx.Scopes(inTenant).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").First(&models.Task{}, "id = ?", id).Error
+}
+
+// reachableDependencies returns the edges of the tenant of tx reachable from the task from, following edges
+// from blockers to the tasks they block, and locks the tasks they lead to. Two inserts that could only form
+// a cycle together then lock a task in common, so the later one waits and sees the edge of the earlier one.
+// Tasks in the trash are walked too, since restoring them brings their edges back.
+func reachableDependencies(tx *gorm.DB, from uuid.UUID) ([]models.TaskDependency, error) {
+	var edges []models.TaskDependency
+	seen := map[uuid.UUID]bool{from: true}
+	frontier := []uuid.UUID{from}
+	for len(frontier) > 0 {
+		var batch []models.TaskDependency
+		err := tx.Unscoped().Model(&models.Task{}).Scopes(inTenant).
+			Select("task_dependencies.*").
+			Joins("JOIN task_dependencies ON task_dependencies.task_id = tasks.id").
+			Where("task_dependencies.blocker_id IN ?", frontier).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: clause.CurrentTable}}).
+			Scan(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = nil
+		for _, edge := range batch {
+			if !seen[edge.TaskID] {
+				seen[edge.TaskID] = true
+				frontier = append(frontier, edge.TaskID)
+			}
+		}
+		edges = append(edges, batch...)
+	}
+	return edges, nil
+}
+
+// RemoveDependency deletes the edge between blockerID and taskID
+func (d *Database) RemoveDependency(ctx context.Context, taskID, blockerID uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureTaskExists(tx, taskID); err != nil {
+			return err
+		}
+		result := tx.Delete(&models.TaskDependency{}, "task_id = ? AND blocker_id = ?", taskID, blockerID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetBlockers returns the tasks that must be finished before taskID
+func (d *Database) GetBlockers(ctx context.Context, taskID uuid.UUID) ([]models.Task, error) {
+	var tasks []models.Task
+	err := d.DB.WithContext(ctx).Scopes(inTenant).
+		Joins("JOIN task_dependencies ON task_dependencies.blocker_id = tasks.id").
+		Where("task_dependencies.task_id = ?", taskID).
+		Order("tasks.created_at").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetDependents returns the tasks waiting for taskID to be finished
+func (d *Database) GetDependents(ctx context.Context, taskID uuid.UUID) ([]models.Task, error) {
+	var tasks []models.Task
+	err := d.DB.WithContext(ctx).Scopes(inTenant).
+		Joins("JOIN task_dependencies ON task_dependencies.task_id = tasks.id").
+		Where("task_dependencies.blocker_id = ?", taskID).
+		Order("tasks.created_at").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetBlockersForTasks returns the tasks that must be finished before each task in taskIDs, ordered by
+// creation. Tasks without blockers are absent from the map.
+func (d *Database) GetBlockersForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error) {
+	return d.tasksByDependency(ctx, taskIDs, "task_dependencies.blocker_id", "task_dependencies.task_id")
+}
+
+// GetDependentsForTasks returns the tasks waiting for each task in taskIDs to be finished, ordered by
+// creation. Tasks without dependents are absent from the map.
+func (d *Database) GetDependentsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error) {
+	return d.tasksByDependency(ctx, taskIDs, "task_dependencies.task_id", "task_dependencies.blocker_id")
+}
+
+// tasksByDependency loads in one query the tasks joined to the dependency edges on column, grouped by the
+// other end of the edge, key, for the tasks in taskIDs
+func (d *Database) tasksByDependency(ctx context.Context, taskIDs []uuid.UUID, column, key string) (map[uuid.UUID][]models.Task, error) {
+	result := make(map[uuid.UUID][]models.Task)
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		models.Task
+		EdgeTaskID uuid.UUID
+	}
+	err := d.DB.WithContext(ctx).
+		Model(&models.Task{}).Scopes(inTenant).
+		Select("tasks.*, "+key+" AS edge_task_id").
+		Joins("JOIN task_dependencies ON "+column+" = tasks.id").
+		Where(key+" IN ?", taskIDs).
+		Order("tasks.created_at").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.EdgeTaskID] = append(result[row.EdgeTaskID], row.Task)
+	}
+	return result, nil
+}
+
+// GetDependencies returns the edges whose task and blocker are both in taskIDs. The IDs are bound once, in a
+// subquery on the tasks of the tenant, and edges to blockers outside of them are dropped here rather than by
+// binding the list a second time, which would halve how many tasks fit in the parameters of one query.
+func (d *Database) GetDependencies(ctx context.Context, taskIDs []uuid.UUID) ([]models.TaskDependency, error) {
+	edges := []models.TaskDependency{}
+	if len(taskIDs) == 0 {
+		return edges, nil
+	}
+	db := d.DB.WithContext(ctx)
+	selected := db.Model(&models.Task{}).Scopes(inTenant).Select("id").Where("id IN ?", taskIDs)
+	var found []models.TaskDependency
+	if err := db.Where("task_id IN (?)", selected).Order("created_at").Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	inSet := make(map[uuid.UUID]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		inSet[id] = true
+	}
+	for _, edge := range found {
+		if inSet[edge.BlockerID] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges, nil
+}
+
+// dependencyGraph builds a graph where every edge points from a blocker to the task it blocks
+func dependencyGraph(edges []models.TaskDependency) *graph.Graph[uuid.UUID] {
+	g := graph.New[uuid.UUID]()
+	for _, edge := range edges {
+		g.AddEdge(edge.BlockerID, edge.TaskID)
+	}
+	return g
+}
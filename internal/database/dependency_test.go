@@ -0,0 +1,214 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/config"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDependencyTestDB(t *testing.T, titles ...string) (*database.Database, []models.Task) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	tasks := make([]models.Task, len(titles))
+	for i, title := range titles {
+		tasks[i] = models.Task{Title: title, Status: types.StatusPending}
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+	return db, tasks
+}
+
+func TestAddDependencyIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship")
+	design, build, ship := tasks[0], tasks[1], tasks[2]
+
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, build.ID))
+	// Adding the same edge twice is a no-op
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, build.ID))
+
+	blockers, err := db.GetBlockers(context.TODO(), ship.ID)
+	require.NoError(t, err)
+	require.Len(t, blockers, 1)
+	assert.Equal(t, build.ID, blockers[0].ID)
+
+	dependents, err := db.GetDependents(context.TODO(), design.ID)
+	require.NoError(t, err)
+	require.Len(t, dependents, 1)
+	assert.Equal(t, build.ID, dependents[0].ID)
+}
+
+func TestDependenciesForTasks(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship", "Docs")
+	design, build, ship, docs := tasks[0], tasks[1], tasks[2], tasks[3]
+
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, build.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, docs.ID))
+
+	ids := []uuid.UUID{design.ID, build.ID, ship.ID}
+	blockers, err := db.GetBlockersForTasks(context.TODO(), ids)
+	require.NoError(t, err)
+	assert.NotContains(t, blockers, design.ID)
+	require.Len(t, blockers[build.ID], 1)
+	assert.Equal(t, design.ID, blockers[build.ID][0].ID)
+	require.Len(t, blockers[ship.ID], 2)
+	assert.Equal(t, []uuid.UUID{build.ID, docs.ID}, []uuid.UUID{blockers[ship.ID][0].ID, blockers[ship.ID][1].ID})
+
+	dependents, err := db.GetDependentsForTasks(context.TODO(), ids)
+	require.NoError(t, err)
+	assert.NotContains(t, dependents, ship.ID)
+	require.Len(t, dependents[design.ID], 1)
+	assert.Equal(t, build.ID, dependents[design.ID][0].ID)
+
+	// Tasks in the trash are left out, like in GetBlockers
+	require.NoError(t, db.Delete(context.TODO(), docs.ID, database.AnyVersion))
+	blockers, err = db.GetBlockersForTasks(context.TODO(), ids)
+	require.NoError(t, err)
+	require.Len(t, blockers[ship.ID], 1)
+	assert.Equal(t, build.ID, blockers[ship.ID][0].ID)
+
+	empty, err := db.GetBlockersForTasks(context.TODO(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestAddDependencyRejectsCycles(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship")
+	design, build, ship := tasks[0], tasks[1], tasks[2]
+
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, build.ID))
+
+	err := db.AddDependency(context.TODO(), design.ID, ship.ID)
+	var cycleErr *database.CycleError
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Equal(t, []uuid.UUID{ship.ID, design.ID, build.ID, ship.ID}, cycleErr.Cycle)
+
+	err = db.AddDependency(context.TODO(), design.ID, design.ID)
+	require.True(t, errors.As(err, &cycleErr))
+
+	blockers, err := db.GetBlockers(context.TODO(), design.ID)
+	require.NoError(t, err)
+	assert.Empty(t, blockers)
+}
+
+func TestAddDependencyWalksTenantEdgesOnly(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build")
+	design, build := tasks[0], tasks[1]
+	globex := middleware.ContextWithTenant(context.TODO(), "globex")
+	stray := models.Task{Title: "Stray", Status: types.StatusPending}
+	require.NoError(t, db.Create(globex, &stray))
+
+	// Edges never cross tenants; should one exist, it must neither be walked nor leak into a cycle
+	require.NoError(t, db.DB.Create(&[]models.TaskDependency{
+		{TaskID: stray.ID, BlockerID: build.ID},
+		{TaskID: design.ID, BlockerID: stray.ID},
+	}).Error)
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+}
+
+func TestAddDependencyRejectsCyclesThroughTrash(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship")
+	design, build, ship := tasks[0], tasks[1], tasks[2]
+
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, build.ID))
+	require.NoError(t, db.Delete(context.TODO(), build.ID, database.AnyVersion))
+
+	// Restoring the build task would close the cycle
+	var cycleErr *database.CycleError
+	assert.ErrorAs(t, db.AddDependency(context.TODO(), design.ID, ship.ID), &cycleErr)
+}
+
+func TestAddDependencyUnknownTask(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design")
+
+	err := db.AddDependency(context.TODO(), tasks[0].ID, uuid.New())
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestRemoveDependencyIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build")
+	design, build := tasks[0], tasks[1]
+
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(t, db.RemoveDependency(context.TODO(), build.ID, design.ID))
+
+	blockers, err := db.GetBlockers(context.TODO(), build.ID)
+	require.NoError(t, err)
+	assert.Empty(t, blockers)
+
+	err = db.RemoveDependency(context.TODO(), build.ID, design.ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestGetUnfinishedAndDependenciesIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship")
+	design, build, ship := tasks[0], tasks[1], tasks[2]
+
+	require.NoError(t, db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), ship.ID, build.ID))
+
+	design.Status = types.StatusCompleted
+	require.NoError(t, db.Update(context.TODO(), &design))
+
+	unfinished, err := db.GetUnfinished(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, unfinished, 2)
+	assert.Equal(t, build.ID, unfinished[0].ID)
+	assert.Equal(t, ship.ID, unfinished[1].ID)
+
+	edges, err := db.GetDependencies(context.TODO(), []uuid.UUID{build.ID, ship.ID})
+	require.NoError(t, err)
+	require.Len(t, edges, 1)
+	assert.Equal(t, models.TaskDependency{TaskID: ship.ID, BlockerID: build.ID}, models.TaskDependency{TaskID: edges[0].TaskID, BlockerID: edges[0].BlockerID})
+
+	// Edges to blockers outside of the list are left out, and so are the tasks of other tenants
+	edges, err = db.GetDependencies(context.TODO(), []uuid.UUID{ship.ID})
+	require.NoError(t, err)
+	assert.Empty(t, edges)
+	edges, err = db.GetDependencies(middleware.ContextWithTenant(context.TODO(), "globex"), []uuid.UUID{build.ID, ship.ID})
+	require.NoError(t, err)
+	assert.Empty(t, edges)
+
+	edges, err = db.GetDependencies(context.TODO(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, edges)
+}
+
+func TestGetFilteredIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship")
+	createTestUsers(t, db, "bob")
+	tasks[1].Assignee = "bob"
+	require.NoError(t, db.Update(context.TODO(), &tasks[1]))
+	tasks[2].Assignee = "bob"
+	tasks[2].Status = types.StatusCompleted
+	require.NoError(t, db.Update(context.TODO(), &tasks[2]))
+
+	all, err := db.GetFiltered(context.TODO(), "", "")
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	bobs, err := db.GetFiltered(context.TODO(), "", "bob")
+	require.NoError(t, err)
+	require.Len(t, bobs, 2)
+	assert.Equal(t, tasks[1].ID, bobs[0].ID)
+
+	pending, err := db.GetFiltered(context.TODO(), string(types.StatusPending), "bob")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, tasks[1].ID, pending[0].ID)
+}
@@ -0,0 +1,545 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"taheri24.ir/graph1/internal/types"
+
+	"github.com/google/uuid"
+)
+
+// maxFilterLength and maxFilterDepth bound the size and the nesting of the filters that are parsed
+const (
+	maxFilterLength = 2000
+	maxFilterDepth  = 16
+)
+
+// FilterError reports a filter that cannot be parsed, pointing at the offending token
+type FilterError struct {
+	Message string
+	// Position is the 1-based character position of the token in the filter
+	Position int
+	// Token is the text of the token, empty at the end of the filter
+	Token string
+}
+
+func (e *FilterError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+	}
+	return fmt.Sprintf("%s at position %d (%q)", e.Message, e.Position, e.Token)
+}
+
+// FilterExpr is a parsed task filter. It is built by ParseTaskFilter and compiles to a parameterized
+// condition on tasks.
+type FilterExpr interface {
+	// sql renders the expression as a condition with placeholders for its values
+	sql() (string, []any)
+}
+
+// FilterAnd matches tasks matching all of its terms
+type FilterAnd struct {
+	Terms []FilterExpr
+}
+
+// FilterOr matches tasks matching any of its terms
+type FilterOr struct {
+	Terms []FilterExpr
+}
+
+// FilterNot matches tasks not matching its expression
+type FilterNot struct {
+	Expr FilterExpr
+}
+
+// FilterComparison compares a field of tasks with values
+type FilterComparison struct {
+	Field string
+	// Op is one of =, !=, <, <=, >, >=, in, not in, contains, is null or is not null
+	Op string
+	// Values holds one value for the binary operators, the list for in and not in and none for is null.
+	// They are converted to the type of the field: string, float64, int64, time.Time or uuid.UUID.
+	Values []any
+}
+
+// filterFieldKind is the type of a field that can be filtered on, deciding its operators and values
+type filterFieldKind int
+
+const (
+	filterText filterFieldKind = iota
+	filterNumber
+	filterInteger
+	filterTime
+	filterPriority
+	filterUUID
+)
+
+type filterField struct {
+	column   string
+	kind     filterFieldKind
+	nullable bool
+}
+
+// taskFilterFields are the fields filters may refer to; filters never reach any other column
+var taskFilterFields = map[string]filterField{
+	"id":          {column: "tasks.id", kind: filterUUID},
+	"title":       {column: "tasks.title", kind: filterText},
+	"description": {column: "tasks.description", kind: filterText},
+	"status":      {column: "tasks.status", kind: filterText},
+	"assignee":    {column: "tasks.assignee", kind: filterText},
+	"assignee_id": {column: "tasks.assignee_id", kind: filterUUID, nullable: true},
+	"priority":    {column: "tasks.priority", kind: filterPriority},
+	"estimate":    {column: "tasks.estimate", kind: filterNumber},
+	"version":     {column: "tasks.version", kind: filterInteger},
+	"due_at":      {column: "tasks.due_at", kind: filterTime, nullable: true},
+	"parent_id":   {column: "tasks.parent_id", kind: filterUUID, nullable: true},
+	"created_at":  {column: "tasks.created_at", kind: filterTime},
+	"updated_at":  {column: "tasks.updated_at", kind: filterTime},
+}
+
+// filterFieldNames lists taskFilterFields for error messages
+const filterFieldNames = "id, title, description, status, assignee, assignee_id, priority, estimate, version, due_at, parent_id, created_at, updated_at"
+
+// operators returns the comparison operators that apply to the field
+func (f filterField) operators() []string {
+	var ops []string
+	switch f.kind {
+	case filterText:
+		ops = []string{"=", "!=", "in", "not in", "contains"}
+	case filterUUID:
+		ops = []string{"=", "!=", "in", "not in"}
+	case filterTime:
+		ops = []string{"=", "!=", "<", "<=", ">", ">="}
+	default:
+		ops = []string{"=", "!=", "<", "<=", ">", ">=", "in", "not in"}
+	}
+	if f.nullable {
+		ops = append(ops, "is null", "is not null")
+	}
+	return ops
+}
+
+// value converts the text of a value to the type of the field
+func (f filterField) value(text string) (any, error) {
+	switch f.kind {
+	case filterNumber:
+		return strconv.ParseFloat(text, 64)
+	case filterInteger:
+		return strconv.ParseInt(text, 10, 64)
+	case filterTime:
+		if at, err := time.Parse(time.RFC3339, text); err == nil {
+			return at, nil
+		}
+		return time.Parse(time.DateOnly, text)
+	case filterPriority:
+		if !priorityValues[types.TaskPriority(text)] {
+			return nil, fmt.Errorf("priority must be one of: low, medium, high, urgent")
+		}
+		return text, nil
+	case filterUUID:
+		return uuid.Parse(text)
+	default:
+		return text, nil
+	}
+}
+
+// expected describes the values of the field for error messages
+func (f filterField) expected() string {
+	switch f.kind {
+	case filterNumber:
+		return "a number"
+	case filterInteger:
+		return "a whole number"
+	case filterTime:
+		return "a date such as 2026-01-01 or a time such as 2026-01-01T09:00:00Z"
+	case filterPriority:
+		return "one of low, medium, high, urgent"
+	case filterUUID:
+		return "a UUID"
+	default:
+		return "a value"
+	}
+}
+
+var priorityValues = map[types.TaskPriority]bool{
+	types.PriorityLow: true, types.PriorityMedium: true, types.PriorityHigh: true, types.PriorityUrgent: true,
+}
+
+// priorityRanks are the ranks priorityRank gives to each priority, to compare priorities by urgency
+var priorityRanks = map[string]int{"low": 1, "medium": 2, "high": 3, "urgent": 4}
+
+type filterTokenKind int
+
+const (
+	filterEOF filterTokenKind = iota
+	// filterWord is a field name, keyword or unquoted value: a run of letters, digits and _ . : + -
+	filterWord
+	filterString
+	filterOperator
+	filterPunct
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	value string
+	// text is the token as written, quotes included
+	text string
+	pos  int
+}
+
+// keyword reports whether the token is the given keyword, which is matched case-insensitively
+func (t filterToken) keyword(word string) bool {
+	return t.kind == filterWord && strings.EqualFold(t.value, word)
+}
+
+func isFilterWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_.:+-", c) >= 0
+}
+
+// lexFilter splits a filter into tokens, ending with a filterEOF token
+func lexFilter(src string) ([]filterToken, error) {
+	var tokens []filterToken
+	position := func(offset int) int { return utf8.RuneCountInString(src[:offset]) + 1 }
+
+	for i := 0; ; {
+		for i < len(src) && strings.IndexByte(" \t\r\n", src[i]) >= 0 {
+			i++
+		}
+		if i >= len(src) {
+			return append(tokens, filterToken{kind: filterEOF, pos: position(i)}), nil
+		}
+
+		start := i
+		c := src[i]
+		switch {
+		case c == '(' || c == ')' || c == ',':
+			i++
+			tokens = append(tokens, filterToken{kind: filterPunct, value: src[start:i], text: src[start:i], pos: position(start)})
+		case c == '=' || c == '<' || c == '>' || c == '!':
+			i++
+			if i < len(src) && src[i] == '=' {
+				i++
+			}
+			op := src[start:i]
+			if op == "!" {
+				return nil, &FilterError{Message: `unknown operator "!"; use != or not`, Position: position(start), Token: op}
+			}
+			if op == "==" {
+				op = "="
+			}
+			tokens = append(tokens, filterToken{kind: filterOperator, value: op, text: src[start:i], pos: position(start)})
+		case c == '"' || c == '\'':
+			var value strings.Builder
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				value.WriteByte(src[i])
+			}
+			if i >= len(src) {
+				return nil, &FilterError{Message: "unterminated string", Position: position(start), Token: src[start:]}
+			}
+			i++
+			tokens = append(tokens, filterToken{kind: filterString, value: value.String(), text: src[start:i], pos: position(start)})
+		case isFilterWordChar(c):
+			for i < len(src) && isFilterWordChar(src[i]) {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: filterWord, value: src[start:i], text: src[start:i], pos: position(start)})
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, &FilterError{Message: "unexpected character", Position: position(start), Token: string(r)}
+		}
+	}
+}
+
+// filterParser parses the grammar
+//
+//	expr       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" expr ")" | comparison
+//	comparison = field ( op value | [ "not" ] "in" "(" value { "," value } ")" | "contains" value | "is" [ "not" ] "null" )
+//	op         = "=" | "!=" | "<" | "<=" | ">" | ">="
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// ParseTaskFilter parses a filter on tasks such as
+//
+//	status in (pending, in_progress) and assignee != "bot" and created_at > 2026-01-01
+//
+// Comparisons name a task field, an operator and a value; they are combined with and, or and not, which
+// are case-insensitive, and grouped with parentheses. Values are quoted when they contain anything but
+// letters, digits and _ . : + -. Dates are taken as midnight UTC. A *FilterError is returned for filters
+// that cannot be parsed or that use unknown fields, operators not supported by a field, or invalid values.
+func ParseTaskFilter(src string) (FilterExpr, error) {
+	if len(src) > maxFilterLength {
+		return nil, &FilterError{Message: fmt.Sprintf("filter is longer than %d characters", maxFilterLength), Position: maxFilterLength + 1}
+	}
+	tokens, err := lexFilter(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	if p.peek().kind == filterEOF {
+		return nil, p.errorf(p.peek(), "filter is empty")
+	}
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != filterEOF {
+		return nil, p.errorf(tok, "expected and, or or the end of the filter")
+	}
+	return expr, nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != filterEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...any) *FilterError {
+	return &FilterError{Message: fmt.Sprintf(format, args...), Position: tok.pos, Token: tok.text}
+}
+
+func (p *filterParser) expectPunct(value string) error {
+	if tok := p.next(); tok.kind != filterPunct || tok.value != value {
+		return p.errorf(tok, "expected %q", value)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr(depth int) (FilterExpr, error) {
+	term, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterExpr{term}
+	for p.peek().keyword("or") {
+		p.next()
+		term, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return term, nil
+	}
+	return &FilterOr{Terms: terms}, nil
+}
+
+func (p *filterParser) parseAnd(depth int) (FilterExpr, error) {
+	term, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterExpr{term}
+	for p.peek().keyword("and") {
+		p.next()
+		term, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return term, nil
+	}
+	return &FilterAnd{Terms: terms}, nil
+}
+
+func (p *filterParser) parseUnary(depth int) (FilterExpr, error) {
+	tok := p.peek()
+	if depth > maxFilterDepth {
+		return nil, p.errorf(tok, "filter is nested more than %d levels deep", maxFilterDepth)
+	}
+	switch {
+	case tok.keyword("not"):
+		p.next()
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &FilterNot{Expr: expr}, nil
+	case tok.kind == filterPunct && tok.value == "(":
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *filterParser) parseComparison() (FilterExpr, error) {
+	fieldTok := p.next()
+	if fieldTok.kind != filterWord {
+		return nil, p.errorf(fieldTok, "expected a field")
+	}
+	name := strings.ToLower(fieldTok.value)
+	field, ok := taskFilterFields[name]
+	if !ok {
+		return nil, p.errorf(fieldTok, "unknown field %q; fields are: %s", fieldTok.value, filterFieldNames)
+	}
+
+	opTok := p.next()
+	op, err := p.parseOperator(opTok)
+	if err != nil {
+		return nil, err
+	}
+	supported := field.operators()
+	if !slices.Contains(supported, op) {
+		return nil, p.errorf(opTok, "operator %q cannot be used with %s; use one of: %s", op, name, strings.Join(supported, ", "))
+	}
+
+	comparison := &FilterComparison{Field: name, Op: op}
+	switch op {
+	case "is null", "is not null":
+		return comparison, nil
+	case "in", "not in":
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		for {
+			value, err := p.parseValue(field, name)
+			if err != nil {
+				return nil, err
+			}
+			comparison.Values = append(comparison.Values, value)
+			if p.peek().kind == filterPunct && p.peek().value == "," {
+				p.next()
+				continue
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+			return comparison, nil
+		}
+	default:
+		value, err := p.parseValue(field, name)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Values = []any{value}
+		return comparison, nil
+	}
+}
+
+// parseOperator reads the operator starting with tok, which may span several keywords
+func (p *filterParser) parseOperator(tok filterToken) (string, error) {
+	switch {
+	case tok.kind == filterOperator:
+		return tok.value, nil
+	case tok.keyword("in"), tok.keyword("contains"):
+		return strings.ToLower(tok.value), nil
+	case tok.keyword("not"):
+		if !p.peek().keyword("in") {
+			return "", p.errorf(p.peek(), `expected "in" after "not"`)
+		}
+		p.next()
+		return "not in", nil
+	case tok.keyword("is"):
+		op := "is null"
+		if p.peek().keyword("not") {
+			p.next()
+			op = "is not null"
+		}
+		if !p.next().keyword("null") {
+			return "", p.errorf(tok, `expected "is null" or "is not null"`)
+		}
+		return op, nil
+	case tok.kind == filterEOF:
+		return "", p.errorf(tok, "expected an operator")
+	default:
+		return "", p.errorf(tok, "unknown operator %q", tok.text)
+	}
+}
+
+// parseValue reads a value and converts it to the type of field
+func (p *filterParser) parseValue(field filterField, name string) (any, error) {
+	tok := p.next()
+	if tok.kind != filterWord && tok.kind != filterString {
+		return nil, p.errorf(tok, "expected a value for %s", name)
+	}
+	if tok.kind == filterWord && tok.keyword("null") {
+		return nil, p.errorf(tok, `compare with null using "is null" or "is not null"`)
+	}
+	value, err := field.value(tok.value)
+	if err != nil {
+		return nil, p.errorf(tok, "invalid value for %s: expected %s", name, field.expected())
+	}
+	return value, nil
+}
+
+func (e *FilterAnd) sql() (string, []any) {
+	return joinFilterTerms(e.Terms, " AND ")
+}
+
+func (e *FilterOr) sql() (string, []any) {
+	return joinFilterTerms(e.Terms, " OR ")
+}
+
+func joinFilterTerms(terms []FilterExpr, separator string) (string, []any) {
+	conditions := make([]string, len(terms))
+	var args []any
+	for i, term := range terms {
+		condition, termArgs := term.sql()
+		conditions[i] = "(" + condition + ")"
+		args = append(args, termArgs...)
+	}
+	return strings.Join(conditions, separator), args
+}
+
+func (e *FilterNot) sql() (string, []any) {
+	condition, args := e.Expr.sql()
+	return "NOT (" + condition + ")", args
+}
+
+func (e *FilterComparison) sql() (string, []any) {
+	field := taskFilterFields[e.Field]
+	column := field.column
+	values := e.Values
+
+	// Priorities are compared by urgency rather than alphabetically
+	if field.kind == filterPriority && e.Op != "=" && e.Op != "!=" && e.Op != "in" && e.Op != "not in" {
+		column = priorityRank
+		values = []any{priorityRanks[values[0].(string)]}
+	}
+
+	switch e.Op {
+	case "is null":
+		return column + " IS NULL", nil
+	case "is not null":
+		return column + " IS NOT NULL", nil
+	case "in":
+		return column + " IN ?", []any{values}
+	case "not in":
+		return column + " NOT IN ?", []any{values}
+	case "contains":
+		return "lower(" + column + `) LIKE ? ESCAPE '\'`, []any{"%" + escapeLike(strings.ToLower(values[0].(string))) + "%"}
+	case "!=":
+		return column + " <> ?", values
+	default:
+		return column + " " + e.Op + " ?", values
+	}
+}
@@ -0,0 +1,122 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTaskFilter(t *testing.T) {
+	expr, err := database.ParseTaskFilter(`status in (pending,in_progress) and assignee != "bot" AND created_at > 2026-01-01`)
+	require.NoError(t, err)
+	assert.Equal(t, &database.FilterAnd{Terms: []database.FilterExpr{
+		&database.FilterComparison{Field: "status", Op: "in", Values: []any{"pending", "in_progress"}},
+		&database.FilterComparison{Field: "assignee", Op: "!=", Values: []any{"bot"}},
+		&database.FilterComparison{Field: "created_at", Op: ">", Values: []any{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}}, expr)
+
+	// and binds tighter than or, and parentheses group
+	expr, err = database.ParseTaskFilter(`not estimate >= 2.5 or (version = 3 or due_at is not null) and title contains 'it\'s'`)
+	require.NoError(t, err)
+	assert.Equal(t, &database.FilterOr{Terms: []database.FilterExpr{
+		&database.FilterNot{Expr: &database.FilterComparison{Field: "estimate", Op: ">=", Values: []any{2.5}}},
+		&database.FilterAnd{Terms: []database.FilterExpr{
+			&database.FilterOr{Terms: []database.FilterExpr{
+				&database.FilterComparison{Field: "version", Op: "=", Values: []any{int64(3)}},
+				&database.FilterComparison{Field: "due_at", Op: "is not null"},
+			}},
+			&database.FilterComparison{Field: "title", Op: "contains", Values: []any{"it's"}},
+		}},
+	}}, expr)
+}
+
+func TestParseTaskFilterErrors(t *testing.T) {
+	tests := []struct {
+		filter   string
+		position int
+		token    string
+		message  string
+	}{
+		{"", 1, "", "filter is empty"},
+		{`colour = "red"`, 1, "colour", `unknown field "colour"`},
+		{`status = pending and owner = me`, 22, "owner", `unknown field "owner"`},
+		{`status ~ pending`, 8, "~", "unexpected character"},
+		{`status like "p%"`, 8, "like", `unknown operator "like"`},
+		{`created_at contains 2026`, 12, "contains", `operator "contains" cannot be used with created_at`},
+		{`assignee is null`, 10, "is", `operator "is null" cannot be used with assignee`},
+		{`created_at > yesterday`, 14, "yesterday", "invalid value for created_at: expected a date"},
+		{`priority >= critical`, 13, "critical", "invalid value for priority"},
+		{`status in (pending, )`, 21, ")", "expected a value for status"},
+		{`status in pending`, 11, "pending", `expected "("`},
+		{`(status = pending`, 18, "", `expected ")"`},
+		{`status = pending assignee = bob`, 18, "assignee", "expected and, or or the end of the filter"},
+		{`status = "pending`, 10, `"pending`, "unterminated string"},
+		{`due_at = null`, 10, "null", `compare with null using "is null"`},
+		{`título = "x"`, 2, "í", "unexpected character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := database.ParseTaskFilter(tt.filter)
+			var filterErr *database.FilterError
+			require.ErrorAs(t, err, &filterErr)
+			assert.Equal(t, tt.position, filterErr.Position)
+			assert.Equal(t, tt.token, filterErr.Token)
+			assert.Contains(t, filterErr.Message, tt.message)
+		})
+	}
+}
+
+func TestTaskFilterIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	users := createTestUsers(t, db, "alice", "bot", "bob")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	due := base.AddDate(0, 1, 0)
+	tasks := []models.Task{
+		{Title: "Write docs", Status: types.StatusPending, Assignee: "alice", Priority: types.PriorityLow, Estimate: 1, CreatedAt: base.AddDate(0, 0, -1)},
+		{Title: "Fix login", Status: types.StatusInProgress, Assignee: "bot", Priority: types.PriorityUrgent, Estimate: 3, CreatedAt: base.AddDate(0, 0, 1), DueAt: &due},
+		{Title: "Ship release", Status: types.StatusInProgress, Assignee: "bob", Priority: types.PriorityHigh, Estimate: 5, CreatedAt: base.AddDate(0, 0, 2)},
+		{Title: "Plan sprint", Status: types.StatusCompleted, Assignee: "alice", Priority: types.PriorityMedium, Estimate: 2, CreatedAt: base.AddDate(0, 0, 3)},
+	}
+	for i := range tasks {
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+
+	titles := func(filter string, opts database.TaskListOptions) []string {
+		expr, err := database.ParseTaskFilter(filter)
+		require.NoError(t, err)
+		opts.Page, opts.Limit, opts.Filter = 1, 10, expr
+		found, total, err := db.GetAll(context.TODO(), opts)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(found)), total)
+		result := []string{}
+		for _, task := range found {
+			result = append(result, task.Title)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"Ship release"},
+		titles(`status in (pending,in_progress) and assignee != "bot" and created_at > 2026-01-01`, database.TaskListOptions{}))
+	// Priorities compare by urgency
+	assert.Equal(t, []string{"Fix login", "Ship release"}, titles(`priority >= high`, database.TaskListOptions{}))
+	assert.Equal(t, []string{"Write docs", "Plan sprint"}, titles(`priority not in (high, urgent)`, database.TaskListOptions{}))
+	assert.Equal(t, []string{"Fix login", "Plan sprint"}, titles(`title CONTAINS "IN" and not title contains docs`, database.TaskListOptions{}))
+	assert.Equal(t, []string{"Fix login"}, titles(`due_at is not null`, database.TaskListOptions{}))
+	assert.Equal(t, []string{"Write docs", "Ship release", "Plan sprint"}, titles(`due_at is null`, database.TaskListOptions{}))
+	assert.Equal(t, []string{"Write docs", "Plan sprint"}, titles(`estimate < 2.5 or (assignee = alice and version = 1)`, database.TaskListOptions{}))
+	assert.Equal(t, []string{"Fix login", "Ship release"}, titles(`assignee_id in (`+users[1].ID.String()+`, `+users[2].ID.String()+`)`, database.TaskListOptions{}))
+	// Filters combine with the other options
+	assert.Equal(t, []string{"Write docs"}, titles(`assignee = alice`, database.TaskListOptions{Unfinished: true}))
+
+	// Values are bound as parameters, never spliced into the query
+	assert.Equal(t, []string{}, titles(`title = "x' OR '1'='1" or title contains "%"`, database.TaskListOptions{}))
+	assert.Len(t, titles(`title != "'; DROP TABLE tasks; --"`, database.TaskListOptions{}), 4)
+}
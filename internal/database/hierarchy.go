@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrParentNotFound is returned when a task names a parent that does not exist
+	ErrParentNotFound = errors.New("parent task not found")
+	// ErrParentCycle is returned when a task would become its own ancestor
+	ErrParentCycle = errors.New("task cannot be its own ancestor")
+	// ErrTaskHasChildren is returned when deleting a task that still has subtasks without cascading
+	ErrTaskHasChildren = errors.New("task has subtasks")
+)
+
+// GetSubtree returns the task with the given ID followed by all of its descendants,
+// level by level and oldest first within a level
+func (d *Database) GetSubtree(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+	db := d.DB.WithContext(ctx)
+
+	var root models.Task
+	if err := db.Scopes(inTenant).First(&root, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{root}
+	level := []uuid.UUID{root.ID}
+	for len(level) > 0 {
+		var children []models.Task
+		if err := db.Scopes(inTenant).Where("parent_id IN ?", level).Order("created_at, id").Find(&children).Error; err != nil {
+			return nil, err
+		}
+		level = level[:0]
+		for _, child := range children {
+			level = append(level, child.ID)
+		}
+		tasks = append(tasks, children...)
+	}
+	return tasks, nil
+}
+
+// DeleteTree soft-deletes a task, all of its descendants and their comments, records each
+// deletion in the task history, and returns the IDs of the deleted tasks. Unless version is AnyVersion
+// the task is only deleted at that version, as in Delete.
+func (d *Database) DeleteTree(ctx context.Context, id uuid.UUID, version int64) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// As in Delete, the tasks go before their comments, starting with the one whose version is checked
+		result := tx.Scopes(inTenant, atVersion(version)).Delete(&models.Task{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return missingOrMoved(tx, id, version)
+		}
+
+		ids = []uuid.UUID{id}
+		level := []uuid.UUID{id}
+		for len(level) > 0 {
+			var children []uuid.UUID
+			if err := tx.Model(&models.Task{}).Scopes(inTenant).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+			level = children
+		}
+
+		if len(ids) > 1 {
+			if err := tx.Scopes(inTenant).Delete(&models.Task{}, "id IN ?", ids[1:]).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&models.Comment{}, "task_id IN ?", ids).Error; err != nil {
+			return err
+		}
+		for _, taskID := range ids {
+			if err := recordEvent(ctx, tx, taskID, models.TaskEventDeleted, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// checkParent returns ErrParentNotFound when parentID does not exist and ErrParentCycle
+// when taskID is parentID or one of its ancestors
+func checkParent(tx *gorm.DB, taskID, parentID uuid.UUID) error {
+	visited := map[uuid.UUID]bool{}
+	for current := &parentID; current != nil; {
+		if *current == taskID {
+			return ErrParentCycle
+		}
+		if visited[*current] {
+			// The existing hierarchy already loops; stop rather than walking it forever
+			return ErrParentCycle
+		}
+		visited[*current] = true
+
+		var ancestor models.Task
+		err := tx.Scopes(inTenant).Select("id", "parent_id").First(&ancestor, "id = ?", *current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if *current == parentID {
+				return ErrParentNotFound
+			}
+			// A deleted ancestor ends the chain
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		current = ancestor.ParentID
+	}
+	return nil
+}
+
+// countChildren returns how many live subtasks the task with the given ID has
+func countChildren(tx *gorm.DB, id uuid.UUID) (int64, error) {
+	var count int64
+	err := tx.Model(&models.Task{}).Scopes(inTenant).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
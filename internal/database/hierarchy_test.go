@@ -0,0 +1,88 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHierarchyTestDB creates an epic with two stories, the first of which has a subtask
+func newHierarchyTestDB(t *testing.T) (*database.Database, models.Task, models.Task, models.Task, models.Task) {
+	db, tasks := newDependencyTestDB(t, "Epic")
+	epic := tasks[0]
+
+	story := models.Task{Title: "Story", Status: types.StatusPending, ParentID: &epic.ID}
+	require.NoError(t, db.Create(context.TODO(), &story))
+	other := models.Task{Title: "Other story", Status: types.StatusCompleted, ParentID: &epic.ID}
+	require.NoError(t, db.Create(context.TODO(), &other))
+	subtask := models.Task{Title: "Subtask", Status: types.StatusPending, ParentID: &story.ID}
+	require.NoError(t, db.Create(context.TODO(), &subtask))
+	return db, epic, story, other, subtask
+}
+
+func TestGetSubtreeIntegration(t *testing.T) {
+	db, epic, story, other, subtask := newHierarchyTestDB(t)
+
+	tree, err := db.GetSubtree(context.TODO(), epic.ID)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(tree))
+	for i, task := range tree {
+		ids[i] = task.ID
+	}
+	assert.Equal(t, []uuid.UUID{epic.ID, story.ID, other.ID, subtask.ID}, ids)
+
+	tree, err = db.GetSubtree(context.TODO(), subtask.ID)
+	require.NoError(t, err)
+	assert.Len(t, tree, 1)
+
+	_, err = db.GetSubtree(context.TODO(), uuid.New())
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestParentValidationIntegration(t *testing.T) {
+	db, epic, story, _, subtask := newHierarchyTestDB(t)
+
+	missing := uuid.New()
+	err := db.Create(context.TODO(), &models.Task{Title: "Orphan", ParentID: &missing})
+	assert.ErrorIs(t, err, database.ErrParentNotFound)
+
+	// A task cannot become its own parent or the child of one of its descendants
+	epic.ParentID = &epic.ID
+	assert.ErrorIs(t, db.Update(context.TODO(), &epic), database.ErrParentCycle)
+	epic.ParentID = &subtask.ID
+	assert.ErrorIs(t, db.Update(context.TODO(), &epic), database.ErrParentCycle)
+
+	// Moving a subtree elsewhere is fine
+	subtask.ParentID = &epic.ID
+	require.NoError(t, db.Update(context.TODO(), &subtask))
+	story.ParentID = &subtask.ID
+	require.NoError(t, db.Update(context.TODO(), &story))
+}
+
+func TestDeleteWithSubtasksIntegration(t *testing.T) {
+	db, epic, story, other, subtask := newHierarchyTestDB(t)
+	comment := models.Comment{TaskID: subtask.ID, Author: "alice", Body: "Note"}
+	require.NoError(t, db.CreateComment(context.TODO(), &comment))
+
+	assert.ErrorIs(t, db.Delete(context.TODO(), epic.ID, database.AnyVersion), database.ErrTaskHasChildren)
+	require.NoError(t, db.Delete(context.TODO(), other.ID, database.AnyVersion))
+
+	deleted, err := db.DeleteTree(context.TODO(), epic.ID, database.AnyVersion)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{epic.ID, story.ID, subtask.ID}, deleted)
+
+	for _, id := range deleted {
+		_, err := db.GetByID(context.TODO(), id)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+	}
+	_, err = db.GetComment(context.TODO(), subtask.ID, comment.ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"context"
+
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetHistory returns the recorded changes of a task of the tenant of ctx, oldest first. The history of a
+// deleted task remains available.
+func (d *Database) GetHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	var events []models.TaskEvent
+	err := d.DB.WithContext(ctx).Scopes(inTenant).
+		Where("task_id = ?", taskID).
+		Order("created_at, id").
+		Find(&events).Error
+	return events, err
+}
+
+// recordEvent writes a history record for a task of the tenant of ctx, attributed to the actor and request
+// found in ctx
+func recordEvent(ctx context.Context, tx *gorm.DB, taskID uuid.UUID, action string, changes models.TaskChanges) error {
+	if changes == nil {
+		changes = models.TaskChanges{}
+	}
+	return tx.Create(&models.TaskEvent{
+		TaskID:       taskID,
+		TenantID:     middleware.GetTenantFromContext(ctx),
+		Action:       action,
+		Actor:        middleware.GetActorFromContext(ctx),
+		ClaimedActor: middleware.GetClaimedActorFromContext(ctx),
+		RequestID:    middleware.GetRequestIDFromContext(ctx),
+		Changes:      changes,
+	}).Error
+}
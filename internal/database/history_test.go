@@ -0,0 +1,76 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHistoryIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	createTestUsers(t, db, "bob")
+
+	ctx := middleware.ContextWithActor(context.TODO(), "alice")
+	task := models.Task{Title: "Write docs", Status: types.StatusPending, Priority: types.PriorityMedium}
+	require.NoError(t, db.Create(ctx, &task))
+
+	task.Status = types.StatusInProgress
+	task.Assignee = "bob"
+	require.NoError(t, db.Update(context.TODO(), &task))
+
+	// Saving without changes records nothing
+	require.NoError(t, db.Update(context.TODO(), &task))
+	require.NoError(t, db.Delete(middleware.ContextWithClaimedActor(ctx, "carol"), task.ID, database.AnyVersion))
+
+	events, err := db.GetHistory(context.TODO(), task.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	created := events[0]
+	assert.Equal(t, models.TaskEventCreated, created.Action)
+	assert.Equal(t, "alice", created.Actor)
+	assert.Empty(t, created.ClaimedActor)
+	assert.Equal(t, models.FieldChange{Before: "", After: "Write docs"}, created.Changes["title"])
+	assert.NotContains(t, created.Changes, "due_at")
+
+	updated := events[1]
+	assert.Equal(t, models.TaskEventUpdated, updated.Action)
+	assert.Equal(t, middleware.AnonymousActor, updated.Actor)
+	assert.Equal(t, models.TaskChanges{
+		"status":   {Before: "pending", After: "in_progress"},
+		"assignee": {Before: "", After: "bob"},
+	}, updated.Changes)
+
+	deleted := events[2]
+	assert.Equal(t, models.TaskEventDeleted, deleted.Action)
+	assert.Empty(t, deleted.Changes)
+	// A claimed actor is recorded next to the actor, never in its place
+	assert.Equal(t, "alice", deleted.Actor)
+	assert.Equal(t, "carol", deleted.ClaimedActor)
+
+	events, err = db.GetHistory(context.TODO(), uuid.New())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestDeleteTreeHistoryIntegration(t *testing.T) {
+	db, epic, story, other, subtask := newHierarchyTestDB(t)
+
+	_, err := db.DeleteTree(context.TODO(), epic.ID, database.AnyVersion)
+	require.NoError(t, err)
+
+	for _, task := range []models.Task{epic, story, other, subtask} {
+		events, err := db.GetHistory(context.TODO(), task.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 2, task.Title)
+		assert.Equal(t, models.TaskEventDeleted, events[1].Action)
+	}
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLabelExists is returned when a label name is already taken by another label
+var ErrLabelExists = errors.New("label already exists")
+
+// LabelRepository defines the interface for label database operations
+type LabelRepository interface {
+	CreateLabel(ctx context.Context, label *models.Label) error
+	GetLabel(ctx context.Context, id uuid.UUID) (*models.Label, error)
+	ListLabels(ctx context.Context) ([]models.Label, error)
+	UpdateLabel(ctx context.Context, label *models.Label) error
+	DeleteLabel(ctx context.Context, id uuid.UUID) error
+	GetLabelledTaskIDs(ctx context.Context, labelID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// Ensure Database implements LabelRepository
+var _ LabelRepository = (*Database)(nil)
+
+// CreateLabel creates a new label in the tenant of ctx with a name unique within the tenant
+func (d *Database) CreateLabel(ctx context.Context, label *models.Label) error {
+	label.TenantID = middleware.GetTenantFromContext(ctx)
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureLabelNameFree(tx, label.Name, uuid.Nil); err != nil {
+			return err
+		}
+		return tx.Create(label).Error
+	})
+}
+
+// GetLabel retrieves a label of the tenant of ctx by ID
+func (d *Database) GetLabel(ctx context.Context, id uuid.UUID) (*models.Label, error) {
+	var label models.Label
+	if err := d.DB.WithContext(ctx).Scopes(inTenant).First(&label, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+// ListLabels retrieves every label of the tenant of ctx ordered by name
+func (d *Database) ListLabels(ctx context.Context) ([]models.Label, error) {
+	var labels []models.Label
+	err := d.DB.WithContext(ctx).Scopes(inTenant).Order("name").Find(&labels).Error
+	return labels, err
+}
+
+// UpdateLabel saves a label, keeping its name unique
+func (d *Database) UpdateLabel(ctx context.Context, label *models.Label) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(inTenant).Select("id").First(&models.Label{}, "id = ?", label.ID).Error; err != nil {
+			return err
+		}
+		if err := ensureLabelNameFree(tx, label.Name, label.ID); err != nil {
+			return err
+		}
+		label.TenantID = middleware.GetTenantFromContext(ctx)
+		if err := tx.Save(label).Error; err != nil {
+			return err
+		}
+		// The label is part of every task carrying it
+		var taskIDs []uuid.UUID
+		if err := tasksWithLabel(tx, label.ID).Pluck("task_id", &taskIDs).Error; err != nil {
+			return err
+		}
+		return touchTasks(tx, taskIDs)
+	})
+}
+
+// DeleteLabel deletes a label and detaches it from every task
+func (d *Database) DeleteLabel(ctx context.Context, id uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(inTenant).Select("id").First(&models.Label{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		var taskIDs []uuid.UUID
+		if err := tasksWithLabel(tx, id).Pluck("task_id", &taskIDs).Error; err != nil {
+			return err
+		}
+		if err := touchTasks(tx, taskIDs); err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.TaskLabel{}, "label_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Label{}, "id = ?", id).Error
+	})
+}
+
+// AddLabel attaches labelID to taskID. Attaching a label twice is a no-op.
+func (d *Database) AddLabel(ctx context.Context, taskID, labelID uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureTaskExists(tx, taskID); err != nil {
+			return err
+		}
+		if err := tx.Scopes(inTenant).Select("id").First(&models.Label{}, "id = ?", labelID).Error; err != nil {
+			return err
+		}
+
+		taskLabel := models.TaskLabel{TaskID: taskID, LabelID: labelID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&taskLabel)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return touchTasks(tx, []uuid.UUID{taskID})
+	})
+}
+
+// RemoveLabel detaches labelID from taskID
+func (d *Database) RemoveLabel(ctx context.Context, taskID, labelID uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureTaskExists(tx, taskID); err != nil {
+			return err
+		}
+		result := tx.Delete(&models.TaskLabel{}, "task_id = ? AND label_id = ?", taskID, labelID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return touchTasks(tx, []uuid.UUID{taskID})
+	})
+}
+
+// GetLabelledTaskIDs returns the IDs of the tasks of the tenant of ctx, live or in the trash, that carry
+// labelID
+func (d *Database) GetLabelledTaskIDs(ctx context.Context, labelID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := d.DB.WithContext(ctx).Unscoped().Model(&models.Task{}).Scopes(inTenant).
+		Where("id IN (?)", tasksWithLabel(d.DB, labelID)).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// tasksWithLabel builds a query selecting the IDs of the tasks that carry labelID
+func tasksWithLabel(tx *gorm.DB, labelID uuid.UUID) *gorm.DB {
+	return tx.Session(&gorm.Session{NewDB: true}).Model(&models.TaskLabel{}).Select("task_id").Where("label_id = ?", labelID)
+}
+
+// GetLabelsForTasks returns the labels of each task in taskIDs, ordered by name.
+// Tasks without labels are absent from the map.
+func (d *Database) GetLabelsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+	result := make(map[uuid.UUID][]models.Label)
+	if len(taskIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		models.Label
+		TaskID uuid.UUID
+	}
+	err := d.DB.WithContext(ctx).
+		Model(&models.Label{}).Scopes(inTenant).
+		Select("labels.*, task_labels.task_id").
+		Joins("JOIN task_labels ON task_labels.label_id = labels.id").
+		Where("task_labels.task_id IN ?", taskIDs).
+		Order("labels.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.TaskID] = append(result[row.TaskID], row.Label)
+	}
+	return result, nil
+}
+
+// labelledTaskIDs builds a subquery selecting the tasks that carry any (or, for mode "all",
+// every) one of the named labels
+func (d *Database) labelledTaskIDs(names []string, mode string) *gorm.DB {
+	query := d.DB.Model(&models.TaskLabel{}).
+		Select("task_labels.task_id").
+		Joins("JOIN labels ON labels.id = task_labels.label_id").
+		Where("labels.name IN ?", names)
+
+	if mode == "all" {
+		distinct := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			distinct[name] = struct{}{}
+		}
+		query = query.Group("task_labels.task_id").Having("COUNT(DISTINCT labels.id) = ?", len(distinct))
+	}
+	return query
+}
+
+// ensureLabelNameFree returns ErrLabelExists when a label of the tenant of the context of tx other than
+// exceptID already uses name
+func ensureLabelNameFree(tx *gorm.DB, name string, exceptID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.Label{}).Scopes(inTenant).Where("name = ? AND id <> ?", name, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLabelExists
+	}
+	return nil
+}
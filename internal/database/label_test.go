@@ -0,0 +1,139 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestLabels(t *testing.T, db *database.Database, names ...string) []models.Label {
+	labels := make([]models.Label, len(names))
+	for i, name := range names {
+		labels[i] = models.Label{Name: name}
+		require.NoError(t, db.CreateLabel(context.TODO(), &labels[i]))
+	}
+	return labels
+}
+
+func TestLabelCRUDIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	labels := createTestLabels(t, db, "bug", "backend")
+
+	// Names are unique, on create and on rename
+	err := db.CreateLabel(context.TODO(), &models.Label{Name: "bug"})
+	assert.ErrorIs(t, err, database.ErrLabelExists)
+	labels[1].Name = "bug"
+	assert.ErrorIs(t, db.UpdateLabel(context.TODO(), &labels[1]), database.ErrLabelExists)
+
+	labels[1].Name = "api"
+	labels[1].Color = "#00ff00"
+	require.NoError(t, db.UpdateLabel(context.TODO(), &labels[1]))
+	found, err := db.GetLabel(context.TODO(), labels[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "api", found.Name)
+	assert.Equal(t, "#00ff00", found.Color)
+
+	all, err := db.ListLabels(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "api", all[0].Name)
+	assert.Equal(t, "bug", all[1].Name)
+
+	require.NoError(t, db.DeleteLabel(context.TODO(), labels[0].ID))
+	_, err = db.GetLabel(context.TODO(), labels[0].ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	assert.True(t, utils.ErrIsRecordNotFound(db.DeleteLabel(context.TODO(), labels[0].ID)))
+}
+
+func TestTaskLabelsIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Login crash", "Add endpoint", "Write docs")
+	labels := createTestLabels(t, db, "bug", "backend", "docs")
+	bug, backend, docs := labels[0], labels[1], labels[2]
+
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[0].ID, bug.ID))
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[0].ID, backend.ID))
+	// Adding the same label twice is a no-op
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[0].ID, backend.ID))
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[1].ID, backend.ID))
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[2].ID, docs.ID))
+
+	assert.True(t, utils.ErrIsRecordNotFound(db.AddLabel(context.TODO(), uuid.New(), bug.ID)))
+	assert.True(t, utils.ErrIsRecordNotFound(db.AddLabel(context.TODO(), tasks[0].ID, uuid.New())))
+
+	byTask, err := db.GetLabelsForTasks(context.TODO(), []uuid.UUID{tasks[0].ID, tasks[1].ID})
+	require.NoError(t, err)
+	require.Len(t, byTask[tasks[0].ID], 2)
+	assert.Equal(t, "backend", byTask[tasks[0].ID][0].Name)
+	assert.Equal(t, "bug", byTask[tasks[0].ID][1].Name)
+	require.Len(t, byTask[tasks[1].ID], 1)
+	assert.Equal(t, backend.ID, byTask[tasks[1].ID][0].ID)
+	assert.NotContains(t, byTask, tasks[2].ID)
+
+	titles := func(opts database.TaskListOptions) []string {
+		opts.Page, opts.Limit = 1, 10
+		found, total, err := db.GetAll(context.TODO(), opts)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(found)), total)
+		result := make([]string, len(found))
+		for i, task := range found {
+			result[i] = task.Title
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"Login crash", "Add endpoint"}, titles(database.TaskListOptions{Labels: []string{"bug", "backend"}, LabelMode: "any"}))
+	assert.Equal(t, []string{"Login crash"}, titles(database.TaskListOptions{Labels: []string{"bug", "backend"}, LabelMode: "all"}))
+	assert.Equal(t, []string{"Login crash"}, titles(database.TaskListOptions{Labels: []string{"bug", "bug"}, LabelMode: "all"}))
+	assert.Empty(t, titles(database.TaskListOptions{Labels: []string{"bug", "docs"}, LabelMode: "all"}))
+	assert.Empty(t, titles(database.TaskListOptions{Labels: []string{"unknown"}, LabelMode: "any"}))
+
+	require.NoError(t, db.RemoveLabel(context.TODO(), tasks[0].ID, bug.ID))
+	assert.True(t, utils.ErrIsRecordNotFound(db.RemoveLabel(context.TODO(), tasks[0].ID, bug.ID)))
+
+	// Deleting a label detaches it from its tasks
+	require.NoError(t, db.DeleteLabel(context.TODO(), backend.ID))
+	byTask, err = db.GetLabelsForTasks(context.TODO(), []uuid.UUID{tasks[0].ID, tasks[1].ID})
+	require.NoError(t, err)
+	assert.Empty(t, byTask)
+}
+
+func TestLabelChangesBumpTaskVersionIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Login crash", "Write docs")
+	labels := createTestLabels(t, db, "bug", "docs")
+	bug := labels[0]
+
+	version := func(id uuid.UUID) int64 {
+		task, err := db.GetByID(context.TODO(), id)
+		require.NoError(t, err)
+		return task.Version
+	}
+
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[0].ID, bug.ID))
+	assert.Equal(t, int64(2), version(tasks[0].ID))
+	// Attaching a label the task already has changes nothing
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[0].ID, bug.ID))
+	assert.Equal(t, int64(2), version(tasks[0].ID))
+
+	ids, err := db.GetLabelledTaskIDs(context.TODO(), bug.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{tasks[0].ID}, ids)
+
+	bug.Name = "defect"
+	require.NoError(t, db.UpdateLabel(context.TODO(), &bug))
+	assert.Equal(t, int64(3), version(tasks[0].ID))
+	assert.Equal(t, int64(1), version(tasks[1].ID))
+
+	require.NoError(t, db.DeleteLabel(context.TODO(), bug.ID))
+	assert.Equal(t, int64(4), version(tasks[0].ID))
+
+	require.NoError(t, db.AddLabel(context.TODO(), tasks[1].ID, labels[1].ID))
+	require.NoError(t, db.RemoveLabel(context.TODO(), tasks[1].ID, labels[1].ID))
+	assert.Equal(t, int64(3), version(tasks[1].ID))
+}
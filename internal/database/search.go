@@ -0,0 +1,304 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SnippetStart and SnippetEnd surround the words of a search snippet that match the query
+const (
+	SnippetStart = "<mark>"
+	SnippetEnd   = "</mark>"
+)
+
+// maxSearchTerms bounds the number of words of a query that are searched for
+const maxSearchTerms = 10
+
+// snippetWords is the number of words of a description kept around its first match
+const snippetWords = 24
+
+// TaskSearchResult is a task matching a full-text search
+type TaskSearchResult struct {
+	Task models.Task
+	// Rank is the relevance of the task to the query; higher ranks match better
+	Rank float64
+	// TitleSnippet is the title and DescriptionSnippet an excerpt of the description around its matches,
+	// with the matching words between SnippetStart and SnippetEnd
+	TitleSnippet       string
+	DescriptionSnippet string
+}
+
+// searchRow is a row of a search before its task is loaded
+type searchRow struct {
+	ID                 uuid.UUID
+	Rank               float64
+	TitleSnippet       string
+	DescriptionSnippet string
+}
+
+// Headline options of ts_headline for the title, which is highlighted whole, and for the description
+const (
+	titleHeadlineOptions       = "StartSel=" + SnippetStart + ", StopSel=" + SnippetEnd + ", HighlightAll=true"
+	descriptionHeadlineOptions = "StartSel=" + SnippetStart + ", StopSel=" + SnippetEnd + ", MaxWords=24, MinWords=8"
+)
+
+// Search retrieves the tasks whose title or description matches the words of query, most relevant first
+// unless opts asks for another sort, narrowed by the filters of opts and paged by opts.Page; opts.After is
+// not supported. Title matches rank above description matches. PostgreSQL searches a weighted tsvector
+// column and SQLite an FTS5 table, both stemming English words; when SQLite is built without FTS5 the words
+// are matched as substrings instead.
+func (d *Database) Search(ctx context.Context, query string, opts TaskListOptions) ([]TaskSearchResult, int64, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []TaskSearchResult{}, 0, nil
+	}
+	db := d.DB.WithContext(ctx)
+
+	var matches *gorm.DB
+	snippets := "search.title_snippet, search.description_snippet"
+	var snippetArgs []any
+	highlight := false
+	switch {
+	case db.Dialector.Name() == "postgres":
+		words := strings.Join(terms, " ")
+		matches = db.Raw(`SELECT id AS task_id, ts_rank(search_vector, plainto_tsquery('english', ?)) AS search_rank
+			FROM tasks WHERE search_vector @@ plainto_tsquery('english', ?)`, words, words)
+		snippets = "ts_headline('english', tasks.title, plainto_tsquery('english', ?), ?) AS title_snippet, " +
+			"ts_headline('english', tasks.description, plainto_tsquery('english', ?), ?) AS description_snippet"
+		snippetArgs = []any{words, titleHeadlineOptions, words, descriptionHeadlineOptions}
+	case d.fts5:
+		matches = db.Raw(`SELECT task_id, -bm25(tasks_fts, 0, 2.0, 1.0) AS search_rank,
+			highlight(tasks_fts, 1, ?, ?) AS title_snippet,
+			snippet(tasks_fts, 2, ?, ?, '…', ?) AS description_snippet
+			FROM tasks_fts WHERE tasks_fts MATCH ?`,
+			SnippetStart, SnippetEnd, SnippetStart, SnippetEnd, snippetWords, ftsQuery(terms))
+	default:
+		matches = likeMatches(db, terms)
+		highlight = true
+	}
+
+	search := d.filterTasks(db.Model(&models.Task{}).Scopes(inTenant).Joins("JOIN (?) AS search ON search.task_id = tasks.id", matches), opts)
+
+	var total int64
+	if !opts.SkipTotal {
+		if err := search.Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	order := "search.search_rank DESC, created_at, id"
+	if opts.Sort != "" {
+		order = opts.orderClause()
+	}
+	var rows []searchRow
+	err := search.Select("tasks.id, search.search_rank AS rank, "+snippets, snippetArgs...).
+		Order(order).Offset((opts.Page - 1) * opts.Limit).Limit(opts.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(rows) == 0 {
+		return []TaskSearchResult{}, total, nil
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var tasks []models.Task
+	if err := db.Scopes(inTenant).Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	results := make([]TaskSearchResult, 0, len(rows))
+	for _, row := range rows {
+		task, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		result := TaskSearchResult{Task: task, Rank: row.Rank, TitleSnippet: row.TitleSnippet, DescriptionSnippet: row.DescriptionSnippet}
+		if highlight {
+			result.TitleSnippet = highlightTerms(task.Title, terms)
+			result.DescriptionSnippet = highlightTerms(excerpt(task.Description, terms), terms)
+		}
+		results = append(results, result)
+	}
+	return results, total, nil
+}
+
+// searchTerms splits a query into its distinct lower-cased words, ignoring punctuation
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if seen[word] || len(terms) == maxSearchTerms {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// ftsQuery builds an FTS5 query matching rows that contain every term. Terms are quoted so that words
+// such as AND or NEAR are not read as operators.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// likeMatches finds the tasks containing every term in their title or description, for databases without
+// full-text search. Each term found in the title adds 2 to the rank and each found in the description 1;
+// the snippets are highlighted once the tasks are loaded.
+func likeMatches(db *gorm.DB, terms []string) *gorm.DB {
+	ranks := make([]string, len(terms))
+	conditions := make([]string, len(terms))
+	var rankArgs, conditionArgs []any
+	for i, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		ranks[i] = `CASE WHEN lower(title) LIKE ? ESCAPE '\' THEN 2 ELSE 0 END + ` +
+			`CASE WHEN lower(description) LIKE ? ESCAPE '\' THEN 1 ELSE 0 END`
+		conditions[i] = `(lower(title) LIKE ? ESCAPE '\' OR lower(description) LIKE ? ESCAPE '\')`
+		rankArgs = append(rankArgs, pattern, pattern)
+		conditionArgs = append(conditionArgs, pattern, pattern)
+	}
+	return db.Raw("SELECT id AS task_id, "+strings.Join(ranks, " + ")+" AS search_rank, "+
+		"title AS title_snippet, description AS description_snippet FROM tasks WHERE "+
+		strings.Join(conditions, " AND "), append(rankArgs, conditionArgs...)...)
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// termsPattern matches any of terms, case-insensitively, preferring the longest
+func termsPattern(terms []string) *regexp.Regexp {
+	sorted := append([]string(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for i, term := range sorted {
+		sorted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(sorted, "|"))
+}
+
+// highlightTerms surrounds every occurrence of terms in text with SnippetStart and SnippetEnd
+func highlightTerms(text string, terms []string) string {
+	return termsPattern(terms).ReplaceAllStringFunc(text, func(match string) string {
+		return SnippetStart + match + SnippetEnd
+	})
+}
+
+// excerpt cuts text down to snippetWords words around the first occurrence of terms, marking the cuts with
+// an ellipsis
+func excerpt(text string, terms []string) string {
+	words := strings.Fields(text)
+	if len(words) <= snippetWords {
+		return text
+	}
+	pattern := termsPattern(terms)
+	first := 0
+	for i, word := range words {
+		if pattern.MatchString(word) {
+			first = i
+			break
+		}
+	}
+	start := max(0, min(first-snippetWords/4, len(words)-snippetWords))
+	end := start + snippetWords
+
+	result := strings.Join(words[start:end], " ")
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(words) {
+		result += "…"
+	}
+	return result
+}
+
+// hasFTS5 reports whether the tasks_fts table was created, that is whether SQLite supports FTS5
+func hasFTS5(db *gorm.DB) bool {
+	var count int64
+	db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts'").Scan(&count)
+	return count > 0
+}
+
+// migrateSearch sets up full-text search of tasks: a weighted tsvector column with a GIN index on
+// PostgreSQL, and on SQLite an FTS5 table that triggers keep in step with the tasks
+func migrateSearch(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return migratePostgresSearch(db)
+	case "sqlite":
+		return migrateSQLiteSearch(db)
+	default:
+		return nil
+	}
+}
+
+func migratePostgresSearch(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED`).Error; err != nil {
+			return err
+		}
+		return tx.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector)").Error
+	})
+}
+
+// sqliteSearchTriggers copy every change to the title or description of a task into tasks_fts
+var sqliteSearchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+		INSERT INTO tasks_fts (task_id, title, description) VALUES (new.id, new.title, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF title, description ON tasks BEGIN
+		UPDATE tasks_fts SET title = new.title, description = new.description WHERE task_id = new.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+		DELETE FROM tasks_fts WHERE task_id = old.id;
+	END`,
+}
+
+func migrateSQLiteSearch(db *gorm.DB) error {
+	if hasFTS5(db) {
+		return nil
+	}
+	err := db.Exec("CREATE VIRTUAL TABLE tasks_fts USING fts5(task_id UNINDEXED, title, description, tokenize = 'porter unicode61')").Error
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			slog.Warn("SQLite was built without FTS5; task search falls back to substring matching, build with -tags sqlite_fts5 to enable it")
+			return nil
+		}
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, trigger := range sqliteSearchTriggers {
+			if err := tx.Exec(trigger).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("INSERT INTO tasks_fts (task_id, title, description) SELECT id, title, description FROM tasks").Error
+	})
+}
@@ -0,0 +1,135 @@
+package database_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSearchTestDB creates a database holding tasks with the given titles and descriptions
+func newSearchTestDB(t *testing.T, tasks ...models.Task) (*database.Database, []models.Task) {
+	db, _ := newDependencyTestDB(t)
+	for i := range tasks {
+		if tasks[i].Status == "" {
+			tasks[i].Status = types.StatusPending
+		}
+		require.NoError(t, db.Create(context.TODO(), &tasks[i]))
+	}
+	return db, tasks
+}
+
+func searchTitles(results []database.TaskSearchResult) []string {
+	titles := make([]string, len(results))
+	for i, result := range results {
+		titles[i] = result.Task.Title
+	}
+	return titles
+}
+
+func TestSearchIntegration(t *testing.T) {
+	db, tasks := newSearchTestDB(t,
+		models.Task{Title: "Write docs", Description: "Document the login flow for new users"},
+		models.Task{Title: "Fix login crash", Description: "The app crashes when the password is empty"},
+		models.Task{Title: "Refactor payments", Description: "Split the payment service"},
+	)
+	opts := database.TaskListOptions{Page: 1, Limit: 10}
+
+	// Title matches rank above description matches
+	results, total, err := db.Search(context.TODO(), "LOGIN", opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"Fix login crash", "Write docs"}, searchTitles(results))
+	assert.Greater(t, results[0].Rank, results[1].Rank)
+	assert.Equal(t, "Fix <mark>login</mark> crash", results[0].TitleSnippet)
+	assert.Equal(t, "Write docs", results[1].TitleSnippet)
+	assert.Equal(t, "Document the <mark>login</mark> flow for new users", results[1].DescriptionSnippet)
+
+	// Every word must match, in the title or the description
+	results, _, err = db.Search(context.TODO(), "login, docs!", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write docs"}, searchTitles(results))
+
+	results, total, err = db.Search(context.TODO(), "login checkout", opts)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Zero(t, total)
+
+	// Queries without words find nothing
+	results, _, err = db.Search(context.TODO(), " ?! ", opts)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Filters and sorting apply to the matches
+	tasks[0].Status = types.StatusCompleted
+	require.NoError(t, db.Update(context.TODO(), &tasks[0]))
+	results, _, err = db.Search(context.TODO(), "login", database.TaskListOptions{Page: 1, Limit: 10, Unfinished: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Fix login crash"}, searchTitles(results))
+
+	results, _, err = db.Search(context.TODO(), "login", database.TaskListOptions{Page: 1, Limit: 10, Sort: "title", Order: "desc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write docs", "Fix login crash"}, searchTitles(results))
+
+	results, total, err = db.Search(context.TODO(), "login", database.TaskListOptions{Page: 2, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"Write docs"}, searchTitles(results))
+}
+
+func TestSearchFollowsChangesIntegration(t *testing.T) {
+	db, tasks := newSearchTestDB(t,
+		models.Task{Title: "Refactor payments", Description: "Split the billing service"},
+		models.Task{Title: "Payments dashboard"},
+	)
+	opts := database.TaskListOptions{Page: 1, Limit: 10}
+
+	// Migrating again keeps a single search entry per task
+	require.NoError(t, database.Migrate(db.DB))
+
+	tasks[0].Title = "Refactor checkout"
+	require.NoError(t, db.Update(context.TODO(), &tasks[0]))
+	results, _, err := db.Search(context.TODO(), "checkout", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Refactor checkout"}, searchTitles(results))
+	results, _, err = db.Search(context.TODO(), "payments", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Payments dashboard"}, searchTitles(results))
+
+	// Deleted tasks are not found
+	require.NoError(t, db.Delete(context.TODO(), tasks[1].ID, database.AnyVersion))
+	results, _, err = db.Search(context.TODO(), "payments", opts)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = db.Purge(context.TODO(), tasks[1].ID, false, database.AnyVersion)
+	require.NoError(t, err)
+	results, _, err = db.Search(context.TODO(), "dashboard", opts)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearchSnippetIntegration(t *testing.T) {
+	words := strings.Fields(strings.Repeat("lorem ipsum dolor sit amet ", 10))
+	words[25] = "deadline"
+	description := strings.Join(words, " ")
+	db, _ := newSearchTestDB(t, models.Task{Title: "Long read", Description: description})
+
+	results, _, err := db.Search(context.TODO(), "deadline", database.TaskListOptions{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	// Long descriptions are cut down to the words around the match
+	snippet := results[0].DescriptionSnippet
+	assert.Contains(t, snippet, "<mark>deadline</mark>")
+	assert.True(t, strings.HasPrefix(snippet, "…"), snippet)
+	assert.True(t, strings.HasSuffix(snippet, "…"), snippet)
+	assert.Less(t, len(snippet), len(description))
+	assert.Equal(t, "Long read", results[0].TitleSnippet)
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"taheri24.ir/graph1/internal/middleware"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inTenant is a scope restricting a query on tasks, labels, users, task events or API keys to the rows of the
+// tenant of the query's context, see middleware.GetTenantFromContext. Contexts made by
+// middleware.ContextWithAllTenants see every tenant.
+func inTenant(db *gorm.DB) *gorm.DB {
+	ctx := db.Statement.Context
+	if middleware.SpansAllTenants(ctx) {
+		return db
+	}
+	return db.Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"},
+		Value:  middleware.GetTenantFromContext(ctx),
+	})
+}
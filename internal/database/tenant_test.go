@@ -0,0 +1,172 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/config"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolationIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	acme := middleware.ContextWithTenant(context.TODO(), "acme")
+	globex := middleware.ContextWithTenant(context.TODO(), "globex")
+
+	require.NoError(t, db.CreateUser(acme, &models.User{Username: "alice"}))
+	task := models.Task{Title: "Acme launch plan", Status: types.StatusPending, Assignee: "alice"}
+	require.NoError(t, db.Create(acme, &task))
+	assert.Equal(t, "acme", task.TenantID)
+	child := models.Task{Title: "Acme press release", Status: types.StatusPending, ParentID: &task.ID}
+	require.NoError(t, db.Create(acme, &child))
+
+	t.Run("reads", func(t *testing.T) {
+		_, err := db.GetByID(globex, task.ID)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+		_, err = db.GetByIDWithTrashed(globex, task.ID)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+
+		tasks, total, err := db.GetAll(globex, database.TaskListOptions{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+		assert.Zero(t, total)
+		tasks, err = db.GetFiltered(globex, "", "alice")
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+
+		results, _, err := db.Search(globex, "launch", database.TaskListOptions{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		_, err = db.GetSubtree(globex, task.ID)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+		events, err := db.GetHistory(globex, task.ID)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+		_, _, err = db.ListComments(globex, task.ID, 1, 10)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+
+		// The owner still sees everything
+		found, err := db.GetByID(acme, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, task.Title, found.Title)
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		hijacked := task
+		hijacked.Title = "Hijacked"
+		err := db.Update(globex, &hijacked)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+
+		// Tasks of another tenant can be neither parents nor blockers
+		stray := models.Task{Title: "Globex task", Status: types.StatusPending, ParentID: &task.ID}
+		assert.Error(t, db.Create(globex, &stray))
+		stray.ParentID = nil
+		require.NoError(t, db.Create(globex, &stray))
+		assert.True(t, utils.ErrIsRecordNotFound(db.AddDependency(globex, stray.ID, task.ID)))
+		assert.True(t, utils.ErrIsRecordNotFound(db.AddDependency(globex, task.ID, stray.ID)))
+		assert.True(t, utils.ErrIsRecordNotFound(
+			db.CreateComment(globex, &models.Comment{TaskID: task.ID, Author: "mallory", Body: "Hi"})))
+
+		found, err := db.GetByID(acme, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Acme launch plan", found.Title)
+		assert.Equal(t, task.Version, found.Version)
+	})
+
+	t.Run("deletes", func(t *testing.T) {
+		// A task of another tenant cannot be deleted, just like a task that does not exist
+		assert.True(t, utils.ErrIsRecordNotFound(db.Delete(globex, child.ID, database.AnyVersion)))
+		_, err := db.DeleteTree(globex, task.ID, database.AnyVersion)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+		_, err = db.Purge(globex, task.ID, true, database.AnyVersion)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+
+		tree, err := db.GetSubtree(acme, task.ID)
+		require.NoError(t, err)
+		assert.Len(t, tree, 2)
+
+		// Nor can trashed tasks be restored or purged from another tenant
+		require.NoError(t, db.Delete(acme, child.ID, database.AnyVersion))
+		_, err = db.Restore(globex, child.ID)
+		assert.True(t, utils.ErrIsRecordNotFound(err))
+		trashed, _, err := db.GetTrashed(globex, 1, 10)
+		require.NoError(t, err)
+		assert.Empty(t, trashed)
+		purged, err := db.PurgeTrashedBefore(globex, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+
+		_, err = db.GetByIDWithTrashed(acme, child.ID)
+		require.NoError(t, err)
+	})
+}
+
+func TestTenantLabelsIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	acme := middleware.ContextWithTenant(context.TODO(), "acme")
+	globex := middleware.ContextWithTenant(context.TODO(), "globex")
+
+	// Label names are unique within a tenant only
+	urgent := models.Label{Name: "urgent", Color: "#ff0000"}
+	require.NoError(t, db.CreateLabel(acme, &urgent))
+	require.NoError(t, db.CreateLabel(globex, &models.Label{Name: "urgent", Color: "#00ff00"}))
+	assert.ErrorIs(t, db.CreateLabel(acme, &models.Label{Name: "urgent"}), database.ErrLabelExists)
+
+	labels, err := db.ListLabels(globex)
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, "#00ff00", labels[0].Color)
+	_, err = db.GetLabel(globex, urgent.ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	assert.True(t, utils.ErrIsRecordNotFound(db.DeleteLabel(globex, urgent.ID)))
+
+	task := models.Task{Title: "Globex task", Status: types.StatusPending}
+	require.NoError(t, db.Create(globex, &task))
+	assert.True(t, utils.ErrIsRecordNotFound(db.AddLabel(globex, task.ID, urgent.ID)))
+
+	_, err = db.GetLabel(acme, urgent.ID)
+	require.NoError(t, err)
+}
+
+func TestPurgeTrashedBeforeAllTenantsIntegration(t *testing.T) {
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	var ids []uuid.UUID
+	for _, tenant := range []string{"acme", "globex"} {
+		ctx := middleware.ContextWithTenant(context.TODO(), tenant)
+		task := models.Task{Title: "Old " + tenant, Status: types.StatusPending}
+		require.NoError(t, db.Create(ctx, &task))
+		require.NoError(t, db.Delete(ctx, task.ID, database.AnyVersion))
+		ids = append(ids, task.ID)
+	}
+
+	purged, err := db.PurgeTrashedBefore(middleware.ContextWithAllTenants(context.TODO()), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids, purged)
+
+	// Each tenant sees the purge of its own task only
+	events, err := db.GetHistory(middleware.ContextWithTenant(context.TODO(), "acme"), ids[0])
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, models.TaskEventPurged, events[len(events)-1].Action)
+	events, err = db.GetHistory(middleware.ContextWithTenant(context.TODO(), "globex"), ids[0])
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrParentDeleted is returned when restoring a task whose parent is still in the trash
+var ErrParentDeleted = errors.New("parent task is in the trash")
+
+// GetTrashed retrieves soft-deleted tasks with pagination, most recently deleted first
+func (d *Database) GetTrashed(ctx context.Context, page, limit int) ([]models.Task, int64, error) {
+	var tasks []models.Task
+	var total int64
+
+	query := d.DB.WithContext(ctx).Unscoped().Model(&models.Task{}).Scopes(inTenant).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("deleted_at DESC, id").Offset((page - 1) * limit).Limit(limit).Find(&tasks).Error
+	return tasks, total, err
+}
+
+// GetByIDWithTrashed retrieves a task by ID, whether it is live or in the trash
+func (d *Database) GetByIDWithTrashed(ctx context.Context, id uuid.UUID) (task *models.Task, err error) {
+	err = d.DB.WithContext(ctx).Unscoped().Scopes(inTenant).First(&task, "id = ?", id).Error
+	return task, err
+}
+
+// Restore brings a soft-deleted task back together with the subtasks and comments that were
+// deleted with it, records the restores in the task history, and returns the restored tasks,
+// the requested one first. Subtasks and comments deleted before the task stay in the trash.
+// A task whose parent is still in the trash is refused with ErrParentDeleted; one whose parent
+// has been purged becomes a top-level task.
+func (d *Database) Restore(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+	var restored []models.Task
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		if err := tx.Unscoped().Scopes(inTenant).Where("deleted_at IS NOT NULL").First(&task, "id = ?", id).Error; err != nil {
+			return err
+		}
+		deletedAt := task.DeletedAt.Time
+
+		if task.ParentID != nil {
+			var parent models.Task
+			err := tx.Unscoped().Scopes(inTenant).Select("id", "deleted_at").First(&parent, "id = ?", *task.ParentID).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Unscoped().Model(&task).Update("parent_id", nil).Error; err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			case parent.DeletedAt.Valid:
+				return ErrParentDeleted
+			}
+		}
+
+		ids := []uuid.UUID{id}
+		level := []uuid.UUID{id}
+		for len(level) > 0 {
+			var children []uuid.UUID
+			err := tx.Unscoped().Model(&models.Task{}).Scopes(inTenant).
+				Where("parent_id IN ? AND deleted_at >= ?", level, deletedAt).
+				Pluck("id", &children).Error
+			if err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+			level = children
+		}
+
+		// A restore is a change like any other, so clients holding the version from before the delete must
+		// fetch the task again before updating it
+		err := tx.Unscoped().Model(&models.Task{}).Scopes(inTenant).Where("id IN ?", ids).Updates(map[string]any{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Model(&models.Comment{}).
+			Where("task_id IN ? AND deleted_at >= ?", ids, deletedAt).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		for _, taskID := range ids {
+			if err := recordEvent(ctx, tx, taskID, models.TaskEventRestored, nil); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Scopes(inTenant).Where("id IN ?", ids).Find(&restored).Error; err != nil {
+			return err
+		}
+		slices.SortFunc(restored, func(a, b models.Task) int {
+			return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// Purge permanently deletes a task, whether live or in the trash, together with its comments,
+// labels and dependencies, and returns the IDs of the purged tasks. Subtasks already in the trash
+// are purged with it; live subtasks are purged too when cascade is set and otherwise refuse the
+// purge with ErrTaskHasChildren. The history of purged tasks is kept. Unless version is AnyVersion the
+// task is only purged at that version, and ErrVersionConflict is returned when it has moved on.
+func (d *Database) Purge(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The task is locked so that it cannot move to another version before it is gone
+		var task models.Task
+		err := tx.Unscoped().Scopes(inTenant, atVersion(version)).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&task, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return missingOrMoved(tx.Unscoped(), id, version)
+		}
+		if err != nil {
+			return err
+		}
+
+		ids = []uuid.UUID{id}
+		level := []uuid.UUID{id}
+		for len(level) > 0 {
+			var children []models.Task
+			err := tx.Unscoped().Scopes(inTenant).Select("id", "deleted_at").Where("parent_id IN ?", level).Find(&children).Error
+			if err != nil {
+				return err
+			}
+			level = level[:0]
+			for _, child := range children {
+				if !cascade && !child.DeletedAt.Valid {
+					return ErrTaskHasChildren
+				}
+				level = append(level, child.ID)
+			}
+			ids = append(ids, level...)
+		}
+
+		return purgeTasks(ctx, tx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// PurgeTrashedBefore permanently deletes every task that was moved to the trash before cutoff,
+// as Purge does, and returns the IDs of the purged tasks. The retention job calls it for every tenant.
+func (d *Database) PurgeTrashedBefore(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var expired []models.Task
+		err := tx.Unscoped().Scopes(inTenant).Select("id", "tenant_id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("tenant_id, id").
+			Find(&expired).Error
+		if err != nil {
+			return err
+		}
+		// Each tenant's tasks are purged in the context of that tenant, which records their purges
+		byTenant := map[string][]uuid.UUID{}
+		for _, task := range expired {
+			ids = append(ids, task.ID)
+			byTenant[task.TenantID] = append(byTenant[task.TenantID], task.ID)
+		}
+		for tenant, tenantIDs := range byTenant {
+			if err := purgeTasks(middleware.ContextWithTenant(ctx, tenant), tx, tenantIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// purgeTasks hard-deletes the given tasks and everything attached to them, and records the purges
+func purgeTasks(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) error {
+	if err := tx.Unscoped().Delete(&models.Comment{}, "task_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&models.TaskLabel{}, "task_id IN ?", ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&models.TaskDependency{}, "task_id IN ? OR blocker_id IN ?", ids, ids).Error; err != nil {
+		return err
+	}
+	if err := tx.Unscoped().Scopes(inTenant).Delete(&models.Task{}, "id IN ?", ids).Error; err != nil {
+		return err
+	}
+	for _, taskID := range ids {
+		if err := recordEvent(ctx, tx, taskID, models.TaskEventPurged, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
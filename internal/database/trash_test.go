@@ -0,0 +1,171 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTrashedIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Design", "Build", "Ship")
+	require.NoError(t, db.Delete(context.TODO(), tasks[0].ID, database.AnyVersion))
+	require.NoError(t, db.Delete(context.TODO(), tasks[2].ID, database.AnyVersion))
+
+	trashed, total, err := db.GetTrashed(context.TODO(), 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, trashed, 2)
+	assert.Equal(t, tasks[2].ID, trashed[0].ID)
+	assert.True(t, trashed[0].DeletedAt.Valid)
+
+	trashed, _, err = db.GetTrashed(context.TODO(), 2, 1)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, tasks[0].ID, trashed[0].ID)
+
+	// Trashed and live tasks can be looked up by ID alike
+	for _, task := range tasks {
+		found, err := db.GetByIDWithTrashed(context.TODO(), task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, task.ID, found.ID)
+	}
+	_, err = db.GetByIDWithTrashed(context.TODO(), uuid.New())
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestRestoreIntegration(t *testing.T) {
+	db, epic, story, other, subtask := newHierarchyTestDB(t)
+	kept := models.Comment{TaskID: story.ID, Author: "alice", Body: "Keep me"}
+	require.NoError(t, db.CreateComment(context.TODO(), &kept))
+	removed := models.Comment{TaskID: story.ID, Author: "alice", Body: "Removed on purpose"}
+	require.NoError(t, db.CreateComment(context.TODO(), &removed))
+	require.NoError(t, db.DeleteComment(context.TODO(), story.ID, removed.ID))
+
+	// The other story was deleted on its own before the epic went with the rest of its tree
+	require.NoError(t, db.Delete(context.TODO(), other.ID, database.AnyVersion))
+	_, err := db.DeleteTree(context.TODO(), epic.ID, database.AnyVersion)
+	require.NoError(t, err)
+
+	_, err = db.Restore(context.TODO(), story.ID)
+	assert.ErrorIs(t, err, database.ErrParentDeleted)
+
+	trashed := make(map[uuid.UUID]models.Task)
+	for _, id := range []uuid.UUID{epic.ID, story.ID, subtask.ID} {
+		task, err := db.GetByIDWithTrashed(context.TODO(), id)
+		require.NoError(t, err)
+		trashed[id] = *task
+	}
+
+	restored, err := db.Restore(context.TODO(), epic.ID)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, len(restored))
+	for i, task := range restored {
+		ids[i] = task.ID
+		assert.False(t, task.DeletedAt.Valid)
+		// Restoring moves the version on, so an If-Match taken before the delete no longer applies
+		assert.Equal(t, trashed[task.ID].Version+1, task.Version)
+		assert.False(t, task.UpdatedAt.Before(trashed[task.ID].UpdatedAt))
+	}
+	assert.Equal(t, []uuid.UUID{epic.ID, story.ID, subtask.ID}, ids)
+
+	_, err = db.GetByID(context.TODO(), other.ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+
+	comments, total, err := db.ListComments(context.TODO(), story.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, kept.ID, comments[0].ID)
+
+	events, err := db.GetHistory(context.TODO(), subtask.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskEventRestored, events[len(events)-1].Action)
+
+	_, err = db.Restore(context.TODO(), epic.ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestRestoreDetachesMissingParentIntegration(t *testing.T) {
+	db, _, story, _, subtask := newHierarchyTestDB(t)
+	require.NoError(t, db.Delete(context.TODO(), subtask.ID, database.AnyVersion))
+	// Purge keeps a task's trashed subtasks with it, so remove the parent behind its back
+	require.NoError(t, db.DB.Unscoped().Delete(&models.Task{}, "id = ?", story.ID).Error)
+
+	trashed, err := db.GetByIDWithTrashed(context.TODO(), subtask.ID)
+	require.NoError(t, err)
+
+	restored, err := db.Restore(context.TODO(), subtask.ID)
+	require.NoError(t, err)
+	require.Len(t, restored, 1)
+	assert.Nil(t, restored[0].ParentID)
+	assert.Equal(t, trashed.Version+1, restored[0].Version)
+}
+
+func TestPurgeIntegration(t *testing.T) {
+	db, epic, story, other, subtask := newHierarchyTestDB(t)
+	label := models.Label{Name: "bug"}
+	require.NoError(t, db.CreateLabel(context.TODO(), &label))
+	require.NoError(t, db.AddLabel(context.TODO(), subtask.ID, label.ID))
+	require.NoError(t, db.AddDependency(context.TODO(), other.ID, subtask.ID))
+	require.NoError(t, db.CreateComment(context.TODO(), &models.Comment{TaskID: subtask.ID, Author: "alice", Body: "Gone"}))
+
+	_, err := db.Purge(context.TODO(), story.ID, false, database.AnyVersion)
+	assert.ErrorIs(t, err, database.ErrTaskHasChildren)
+
+	// A subtask already in the trash does not hold up the purge
+	require.NoError(t, db.Delete(context.TODO(), subtask.ID, database.AnyVersion))
+	purged, err := db.Purge(context.TODO(), story.ID, false, database.AnyVersion)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{story.ID, subtask.ID}, purged)
+
+	var count int64
+	require.NoError(t, db.DB.Unscoped().Model(&models.Task{}).Where("id IN ?", purged).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.DB.Unscoped().Model(&models.Comment{}).Where("task_id = ?", subtask.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.DB.Model(&models.TaskLabel{}).Where("task_id = ?", subtask.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	blockers, err := db.GetBlockers(context.TODO(), other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, blockers)
+
+	// The history outlives the task
+	events, err := db.GetHistory(context.TODO(), subtask.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskEventPurged, events[len(events)-1].Action)
+
+	purged, err = db.Purge(context.TODO(), epic.ID, true, database.AnyVersion)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{epic.ID, other.ID}, purged)
+
+	_, err = db.Purge(context.TODO(), epic.ID, true, database.AnyVersion)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+}
+
+func TestPurgeTrashedBeforeIntegration(t *testing.T) {
+	db, tasks := newDependencyTestDB(t, "Old", "Recent", "Live")
+	require.NoError(t, db.Delete(context.TODO(), tasks[0].ID, database.AnyVersion))
+	require.NoError(t, db.DB.Unscoped().Model(&models.Task{}).Where("id = ?", tasks[0].ID).
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+	require.NoError(t, db.Delete(context.TODO(), tasks[1].ID, database.AnyVersion))
+
+	purged, err := db.PurgeTrashedBefore(context.TODO(), time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{tasks[0].ID}, purged)
+
+	trashed, _, err := db.GetTrashed(context.TODO(), 1, 10)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, tasks[1].ID, trashed[0].ID)
+
+	purged, err = db.PurgeTrashedBefore(context.TODO(), time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+}
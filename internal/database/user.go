@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUserExists is returned when a username is already taken by another user
+	ErrUserExists = errors.New("user already exists")
+	// ErrUnknownAssignee is returned when a task is assigned to someone who is not a user of its tenant
+	ErrUnknownAssignee = errors.New("unknown assignee")
+)
+
+// UserRepository defines the interface for user database operations
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetAssignedTaskIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// Ensure Database implements UserRepository
+var _ UserRepository = (*Database)(nil)
+
+// CreateUser creates a new user in the tenant of ctx with a username unique within the tenant
+func (d *Database) CreateUser(ctx context.Context, user *models.User) error {
+	user.TenantID = middleware.GetTenantFromContext(ctx)
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureUsernameFree(tx, user.Username, uuid.Nil); err != nil {
+			return err
+		}
+		return usernameTaken(tx, tx.Create(user).Error)
+	})
+}
+
+// GetUser retrieves a user of the tenant of ctx by ID
+func (d *Database) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := d.DB.WithContext(ctx).Scopes(inTenant).First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers retrieves every user of the tenant of ctx ordered by username
+func (d *Database) ListUsers(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := d.DB.WithContext(ctx).Scopes(inTenant).Order("username").Find(&users).Error
+	return users, err
+}
+
+// UpdateUser saves a user, keeping its username unique. Renaming a user renames the assignee of every task
+// assigned to it, recording the change in the history of each task.
+func (d *Database) UpdateUser(ctx context.Context, user *models.User) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before models.User
+		if err := tx.Scopes(inTenant).Select("id", "username").First(&before, "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		if err := ensureUsernameFree(tx, user.Username, user.ID); err != nil {
+			return err
+		}
+		user.TenantID = middleware.GetTenantFromContext(ctx)
+		if err := tx.Save(user).Error; err != nil {
+			return usernameTaken(tx, err)
+		}
+		if before.Username == user.Username {
+			return nil
+		}
+		var taskIDs []uuid.UUID
+		if err := tx.Unscoped().Model(&models.Task{}).Scopes(inTenant).Where("assignee_id = ?", user.ID).Pluck("id", &taskIDs).Error; err != nil {
+			return err
+		}
+		if len(taskIDs) == 0 {
+			return nil
+		}
+		err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", taskIDs).Updates(map[string]any{
+			"assignee":   user.Username,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		renamed := models.TaskChanges{"assignee": {Before: before.Username, After: user.Username}}
+		for _, taskID := range taskIDs {
+			if err := recordEvent(ctx, tx, taskID, models.TaskEventUpdated, renamed); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteUser deletes a user and unassigns every task assigned to it, recording the change in the history of
+// each task
+func (d *Database) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Scopes(inTenant).Select("id", "username").First(&user, "id = ?", id).Error; err != nil {
+			return err
+		}
+		var taskIDs []uuid.UUID
+		if err := tx.Unscoped().Model(&models.Task{}).Scopes(inTenant).Where("assignee_id = ?", id).Pluck("id", &taskIDs).Error; err != nil {
+			return err
+		}
+		if len(taskIDs) > 0 {
+			err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", taskIDs).Updates(map[string]any{
+				"assignee":    "",
+				"assignee_id": nil,
+				"version":     gorm.Expr("version + 1"),
+				"updated_at":  time.Now(),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		unassigned := models.TaskChanges{"assignee": {Before: user.Username, After: ""}}
+		for _, taskID := range taskIDs {
+			if err := recordEvent(ctx, tx, taskID, models.TaskEventUpdated, unassigned); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.User{}, "id = ?", id).Error
+	})
+}
+
+// GetAssignedTaskIDs returns the IDs of the tasks of the tenant of ctx, live or in the trash, assigned to
+// userID
+func (d *Database) GetAssignedTaskIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := d.DB.WithContext(ctx).Unscoped().Model(&models.Task{}).Scopes(inTenant).
+		Where("assignee_id = ?", userID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ensureUsernameFree returns ErrUserExists when a user of the tenant of the context of tx other than exceptID
+// already uses username
+func ensureUsernameFree(tx *gorm.DB, username string, exceptID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.User{}).Scopes(inTenant).Where("username = ? AND id <> ?", username, exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUserExists
+	}
+	return nil
+}
+
+// usernameTaken returns ErrUserExists for err violating idx_users_tenant_username, which happens when another
+// request takes the username between the check of ensureUsernameFree and the write, and err otherwise
+func usernameTaken(tx *gorm.DB, err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := tx.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return ErrUserExists
+	}
+	return err
+}
+
+// resolveAssignee finds the user of the tenant a task is assigned to, by AssigneeID or else by the username
+// in Assignee, and sets both fields from it. It returns ErrUnknownAssignee when there is no such user, or
+// when the two fields name different users.
+func resolveAssignee(tx *gorm.DB, task *models.Task) error {
+	if task.AssigneeID != nil && *task.AssigneeID == uuid.Nil {
+		task.AssigneeID = nil
+	}
+	if task.AssigneeID == nil && task.Assignee == "" {
+		return nil
+	}
+
+	var user models.User
+	query := tx.Scopes(inTenant).Select("id", "username")
+	var err error
+	if task.AssigneeID != nil {
+		err = query.First(&user, "id = ?", *task.AssigneeID).Error
+	} else {
+		err = query.First(&user, "username = ?", task.Assignee).Error
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) && task.AssigneeID != nil:
+		return fmt.Errorf("%w: no user has the ID %s", ErrUnknownAssignee, task.AssigneeID)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("%w: no user is named %q", ErrUnknownAssignee, task.Assignee)
+	case err != nil:
+		return err
+	}
+	if task.Assignee != "" && task.Assignee != user.Username {
+		return fmt.Errorf("%w: the user with the ID %s is named %q, not %q", ErrUnknownAssignee, user.ID,
+			user.Username, task.Assignee)
+	}
+
+	task.AssigneeID, task.Assignee = &user.ID, user.Username
+	return nil
+}
+
+// keepChangedAssignee drops whichever of the assignee fields of task still holds its value in before when the
+// other one changed, so that callers reassign a task by setting either field alone
+func keepChangedAssignee(before models.Task, task *models.Task) {
+	sameID := task.AssigneeID != nil && before.AssigneeID != nil && *task.AssigneeID == *before.AssigneeID
+	switch {
+	case sameID && task.Assignee != before.Assignee:
+		task.AssigneeID = nil
+	case !sameID && task.AssigneeID != nil && task.Assignee == before.Assignee:
+		task.Assignee = ""
+	}
+}
+
+// MigrateAssignees turns every distinct assignee name of the tasks written before tasks referred to users
+// into a user of the tenant of those tasks, and assigns the tasks to it. Names that are already usernames
+// are assigned to the existing user. Running it again does nothing.
+func MigrateAssignees(db *gorm.DB) error {
+	var names []struct {
+		TenantID string
+		Assignee string
+	}
+	err := db.Unscoped().Model(&models.Task{}).
+		Distinct("tenant_id", "assignee").
+		Where("assignee <> '' AND assignee_id IS NULL").
+		Order("tenant_id, assignee").
+		Scan(&names).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			user := models.User{TenantID: name.TenantID, Username: name.Assignee}
+			if err := tx.Where("tenant_id = ? AND username = ?", name.TenantID, name.Assignee).FirstOrCreate(&user).Error; err != nil {
+				return fmt.Errorf("failed to create user %q: %w", name.Assignee, err)
+			}
+			// The assignee of these tasks does not change, so neither do their versions
+			err := tx.Unscoped().Model(&models.Task{}).
+				Where("tenant_id = ? AND assignee = ? AND assignee_id IS NULL", name.TenantID, name.Assignee).
+				UpdateColumn("assignee_id", user.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(names) > 0 {
+			slog.Info("Converted task assignees into users", "count", len(names))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,190 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createTestUsers(t *testing.T, db *database.Database, usernames ...string) []models.User {
+	users := make([]models.User, len(usernames))
+	for i, username := range usernames {
+		users[i] = models.User{Username: username}
+		require.NoError(t, db.CreateUser(context.TODO(), &users[i]))
+	}
+	return users
+}
+
+func TestUserCRUDIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	users := createTestUsers(t, db, "bob", "alice")
+
+	// Usernames are unique, on create and on rename
+	err := db.CreateUser(context.TODO(), &models.User{Username: "bob"})
+	assert.ErrorIs(t, err, database.ErrUserExists)
+	users[1].Username = "bob"
+	assert.ErrorIs(t, db.UpdateUser(context.TODO(), &users[1]), database.ErrUserExists)
+
+	users[1].Username = "alice"
+	users[1].Name = "Alice Liddell"
+	users[1].Email = "alice@example.com"
+	require.NoError(t, db.UpdateUser(context.TODO(), &users[1]))
+	found, err := db.GetUser(context.TODO(), users[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Liddell", found.Name)
+	assert.Equal(t, "alice@example.com", found.Email)
+
+	all, err := db.ListUsers(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "alice", all[0].Username)
+	assert.Equal(t, "bob", all[1].Username)
+
+	require.NoError(t, db.DeleteUser(context.TODO(), users[0].ID))
+	_, err = db.GetUser(context.TODO(), users[0].ID)
+	assert.True(t, utils.ErrIsRecordNotFound(err))
+	assert.True(t, utils.ErrIsRecordNotFound(db.DeleteUser(context.TODO(), users[0].ID)))
+	assert.True(t, utils.ErrIsRecordNotFound(db.UpdateUser(context.TODO(), &users[0])))
+}
+
+func TestUsernameRaceIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	users := createTestUsers(t, db, "alice")
+
+	// Another request takes the username right after it was checked; the unique index still reports a clash
+	race := "bob"
+	takeUsername := func(tx *gorm.DB) {
+		if tx.Statement.Table == "users" && race != "" {
+			taken := race
+			race = ""
+			require.NoError(t, tx.Session(&gorm.Session{NewDB: true}).Create(&models.User{Username: taken, TenantID: middleware.DefaultTenant}).Error)
+		}
+	}
+	require.NoError(t, db.DB.Callback().Create().Before("gorm:create").Register("test:race", takeUsername))
+	require.NoError(t, db.DB.Callback().Update().Before("gorm:update").Register("test:race", takeUsername))
+
+	assert.ErrorIs(t, db.CreateUser(context.TODO(), &models.User{Username: "bob"}), database.ErrUserExists)
+
+	race = "carol"
+	users[0].Username = "carol"
+	assert.ErrorIs(t, db.UpdateUser(context.TODO(), &users[0]), database.ErrUserExists)
+}
+
+func TestTaskAssigneeIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	users := createTestUsers(t, db, "alice", "bob")
+	alice, bob := users[0], users[1]
+
+	// Tasks are assigned by username or by ID, and get both
+	byName := models.Task{Title: "By name", Status: types.StatusPending, Assignee: "alice"}
+	require.NoError(t, db.Create(context.TODO(), &byName))
+	require.NotNil(t, byName.AssigneeID)
+	assert.Equal(t, alice.ID, *byName.AssigneeID)
+	byID := models.Task{Title: "By ID", Status: types.StatusPending, AssigneeID: &bob.ID}
+	require.NoError(t, db.Create(context.TODO(), &byID))
+	assert.Equal(t, "bob", byID.Assignee)
+
+	// Nobody else can be assigned
+	err := db.Create(context.TODO(), &models.Task{Title: "Stray", Status: types.StatusPending, Assignee: "mallory"})
+	assert.ErrorIs(t, err, database.ErrUnknownAssignee)
+	unknown := uuid.New()
+	err = db.Create(context.TODO(), &models.Task{Title: "Stray", Status: types.StatusPending, AssigneeID: &unknown})
+	assert.ErrorIs(t, err, database.ErrUnknownAssignee)
+	err = db.Create(context.TODO(), &models.Task{Title: "Stray", Status: types.StatusPending, Assignee: "alice", AssigneeID: &bob.ID})
+	assert.ErrorIs(t, err, database.ErrUnknownAssignee)
+
+	// Updates reassign tasks by whichever field changed
+	byName.Assignee = "bob"
+	require.NoError(t, db.Update(context.TODO(), &byName))
+	assert.Equal(t, bob.ID, *byName.AssigneeID)
+	byName.AssigneeID = &alice.ID
+	require.NoError(t, db.Update(context.TODO(), &byName))
+	assert.Equal(t, "alice", byName.Assignee)
+
+	// Nor can users of another tenant
+	globex := middleware.ContextWithTenant(context.TODO(), "globex")
+	err = db.Create(globex, &models.Task{Title: "Stray", Status: types.StatusPending, AssigneeID: &alice.ID})
+	assert.ErrorIs(t, err, database.ErrUnknownAssignee)
+
+	// Renaming a user renames the assignee of its tasks, and says so in their history
+	alice.Username = "alicia"
+	require.NoError(t, db.UpdateUser(context.TODO(), &alice))
+	found, err := db.GetByID(context.TODO(), byName.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alicia", found.Assignee)
+	assert.Equal(t, byName.Version+1, found.Version)
+	events, err := db.GetHistory(context.TODO(), byName.ID)
+	require.NoError(t, err)
+	last := events[len(events)-1]
+	assert.Equal(t, models.TaskEventUpdated, last.Action)
+	assert.Equal(t, models.FieldChange{Before: "alice", After: "alicia"}, last.Changes["assignee"])
+	events, err = db.GetHistory(context.TODO(), byID.ID)
+	require.NoError(t, err)
+	assert.Len(t, events, 1) // The tasks of other users are left alone
+
+	ids, err := db.GetAssignedTaskIDs(context.TODO(), bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{byID.ID}, ids)
+
+	// Deleting a user unassigns its tasks, and says so in their history
+	require.NoError(t, db.DeleteUser(context.TODO(), bob.ID))
+	found, err = db.GetByID(context.TODO(), byID.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Assignee)
+	assert.Nil(t, found.AssigneeID)
+	events, err = db.GetHistory(context.TODO(), byID.ID)
+	require.NoError(t, err)
+	last = events[len(events)-1]
+	assert.Equal(t, models.TaskEventUpdated, last.Action)
+	assert.Equal(t, models.FieldChange{Before: "bob", After: ""}, last.Changes["assignee"])
+}
+
+func TestMigrateAssigneesIntegration(t *testing.T) {
+	db, _ := newDependencyTestDB(t)
+	createTestUsers(t, db, "alice")
+
+	// Tasks written before tasks referred to users only have an assignee name
+	for _, task := range []models.Task{
+		{Title: "One", Status: types.StatusPending, Assignee: "alice"},
+		{Title: "Two", Status: types.StatusPending, Assignee: "bob"},
+		{Title: "Three", Status: types.StatusPending, Assignee: "bob"},
+		{TenantID: "acme", Title: "Four", Status: types.StatusPending, Assignee: "bob"},
+		{Title: "Five", Status: types.StatusPending},
+	} {
+		require.NoError(t, db.DB.Omit("AssigneeID").Create(&task).Error)
+	}
+
+	require.NoError(t, database.MigrateAssignees(db.DB))
+	require.NoError(t, database.MigrateAssignees(db.DB))
+
+	users, err := db.ListUsers(context.TODO())
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Username)
+	assert.Equal(t, "bob", users[1].Username)
+	acmeUsers, err := db.ListUsers(middleware.ContextWithTenant(context.TODO(), "acme"))
+	require.NoError(t, err)
+	require.Len(t, acmeUsers, 1)
+	assert.NotEqual(t, users[1].ID, acmeUsers[0].ID)
+
+	ids, err := db.GetAssignedTaskIDs(context.TODO(), users[1].ID)
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+	ids, err = db.GetAssignedTaskIDs(context.TODO(), users[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	var unassigned int64
+	require.NoError(t, db.DB.Model(&models.Task{}).Where("assignee = '' AND assignee_id IS NULL").Count(&unassigned).Error)
+	assert.Equal(t, int64(1), unassigned)
+}
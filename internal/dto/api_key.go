@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAPIKeyRequest represents the request body for minting an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// Role is the role of the callers using the key: viewer, member or admin. It defaults to the role of the
+	// caller minting the key, which is also the highest role it can grant.
+	Role string `json:"role"`
+	// ExpiresAt optionally limits how long the key can be used; keys without it never expire
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key, without its secret
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Role       string     `json:"role"`
+	Tenant     string     `json:"tenant"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKeyResponse represents a newly minted API key. Key is the secret, which is never shown again.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// APIKeyListResponse represents the response body for listing API keys
+type APIKeyListResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
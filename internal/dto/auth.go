@@ -0,0 +1,26 @@
+package dto
+
+// TokenRequest represents the credentials of a service account asking for a token. The credentials may
+// also be sent with HTTP Basic authentication.
+type TokenRequest struct {
+	// GrantType is optional; when given it must be "client_credentials"
+	GrantType    string `json:"grant_type" form:"grant_type"`
+	ClientID     string `json:"client_id" form:"client_id"`
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+	// Scope optionally narrows the token to some scopes, separated by spaces; tokens get every scope otherwise
+	Scope string `json:"scope" form:"scope"`
+}
+
+// TokenResponse represents a token issued to a service account
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn is the number of seconds the token is valid for
+	ExpiresIn int64 `json:"expires_in"`
+	// Scope lists the scopes granted to the token, separated by spaces
+	Scope string `json:"scope"`
+	// Role is the role of the service account: viewer, member or admin
+	Role string `json:"role"`
+	// Tenant is the tenant the token is bound to; tokens without one may act on any tenant
+	Tenant string `json:"tenant,omitempty"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// CreateCommentRequest represents the request body for commenting on a task. The author is the caller.
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=5000"`
+}
+
+// UpdateCommentRequest represents the request body for editing a comment
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=5000"`
+}
+
+// CommentResponse represents the response body for a comment
+type CommentResponse struct {
+	ID        uuid.UUID `json:"id"`
+	TaskID    uuid.UUID `json:"task_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// CommentListResponse represents the response body for listing the comments of a task
+type CommentListResponse struct {
+	Comments    []CommentResponse `json:"comments"`
+	Total       int64             `json:"total"`
+	Page        int               `json:"page"`
+	Limit       int               `json:"limit"`
+	HasNext     bool              `json:"has_next"`
+	HasPrevious bool              `json:"has_previous"`
+}
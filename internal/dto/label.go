@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// CreateLabelRequest represents the request body for creating a label
+type CreateLabelRequest struct {
+	Name  string `json:"name" binding:"required,min=1,max=50"`
+	Color string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// UpdateLabelRequest represents the request body for updating a label
+type UpdateLabelRequest struct {
+	Name  *string `json:"name" binding:"omitempty,min=1,max=50"`
+	Color *string `json:"color" binding:"omitempty,hexcolor"`
+}
+
+// LabelResponse represents the response body for a label
+type LabelResponse struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Color string    `json:"color,omitempty"`
+}
+
+// LabelListResponse represents the response body for listing labels
+type LabelListResponse struct {
+	Labels []LabelResponse `json:"labels"`
+}
+
+// AddTaskLabelRequest represents the request body for attaching a label to a task
+type AddTaskLabelRequest struct {
+	LabelID uuid.UUID `json:"label_id" binding:"required"`
+}
@@ -1,6 +1,9 @@
 package dto
 
 import (
+	"encoding/json"
+	"time"
+
 	"taheri24.ir/graph1/internal/types"
 
 	"github.com/google/uuid"
@@ -8,37 +11,227 @@ import (
 
 // CreateTaskRequest represents the request body for creating a task
 type CreateTaskRequest struct {
-	Title       string           `json:"title" binding:"required,min=1,max=200"`
-	Description string           `json:"description" binding:"max=1000"`
-	Status      types.TaskStatus `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
-	Assignee    string           `json:"assignee" binding:"max=100"`
+	Title       string `json:"title" binding:"required,min=1,max=200"`
+	Description string `json:"description" binding:"max=1000"`
+	// Status must be declared by the task workflow; it defaults to pending
+	Status types.TaskStatus `json:"status"`
+	// Assignee is the username and AssigneeID the ID of the user the task is assigned to; either will do
+	Assignee   string             `json:"assignee" binding:"max=100"`
+	AssigneeID *uuid.UUID         `json:"assignee_id"`
+	Estimate   float64            `json:"estimate" binding:"gte=0,lte=10000"`
+	Priority   types.TaskPriority `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	DueAt      *time.Time         `json:"due_at"`
+	ParentID   *uuid.UUID         `json:"parent_id"`
 }
 
 // UpdateTaskRequest represents the request body for updating a task
 type UpdateTaskRequest struct {
-	Title       *string           `json:"title" binding:"omitempty,min=1,max=200"`
-	Description *string           `json:"description" binding:"omitempty,max=1000"`
-	Status      *types.TaskStatus `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
-	Assignee    *string           `json:"assignee" binding:"omitempty,max=100"`
+	Title       *string `json:"title" binding:"omitempty,min=1,max=200"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	// Status must be reachable from the current status in the task workflow
+	Status *types.TaskStatus `json:"status"`
+	// Assignee reassigns the task by username, and AssigneeID by user ID; "" and the nil UUID unassign it
+	Assignee   *string             `json:"assignee" binding:"omitempty,max=100"`
+	AssigneeID *uuid.UUID          `json:"assignee_id"`
+	Estimate   *float64            `json:"estimate" binding:"omitempty,gte=0,lte=10000"`
+	Priority   *types.TaskPriority `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	DueAt      *time.Time          `json:"due_at"`
+	// ParentID moves the task under another task; the nil UUID makes it a top-level task again
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// ReplaceTaskRequest represents the request body for replacing a task, and the document that
+// PATCH requests are applied to. Optional fields that are left out are cleared or take the
+// default they get when a task is created.
+type ReplaceTaskRequest struct {
+	Title       string `json:"title" binding:"required,min=1,max=200"`
+	Description string `json:"description" binding:"max=1000"`
+	// Status must be reachable from the current status in the task workflow
+	Status types.TaskStatus `json:"status" binding:"required"`
+	// Assignee is the username and AssigneeID the ID of the user the task is assigned to; either will do
+	Assignee   string             `json:"assignee" binding:"max=100"`
+	AssigneeID *uuid.UUID         `json:"assignee_id"`
+	Estimate   float64            `json:"estimate" binding:"gte=0,lte=10000"`
+	Priority   types.TaskPriority `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	DueAt      *time.Time         `json:"due_at"`
+	// ParentID places the task under another task; null or the nil UUID makes it a top-level task
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// BulkTaskOperation represents one operation of a bulk task request
+type BulkTaskOperation struct {
+	// Op is create, update or delete
+	Op string `json:"op" binding:"required,oneof=create update delete"`
+	// ID names the task to update or delete
+	ID *uuid.UUID `json:"id"`
+	// Version makes an update or delete conditional on the current version of the task, like If-Match
+	Version *int64 `json:"version"`
+	// Task is the new task for create, as for POST /tasks, or a JSON merge patch of the fields to
+	// change for update, as for PATCH /tasks/{id}
+	Task json.RawMessage `json:"task,omitempty" swaggertype:"object"`
 }
 
 // TaskResponse represents the response body for a task
 type TaskResponse struct {
-	ID          uuid.UUID        `json:"id"`
-	Title       string           `json:"title"`
-	Description string           `json:"description"`
-	Status      types.TaskStatus `json:"status"`
-	Assignee    string           `json:"assignee"`
-	CreatedAt   string           `json:"created_at"`
-	UpdatedAt   string           `json:"updated_at"`
+	ID          uuid.UUID          `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Status      types.TaskStatus   `json:"status"`
+	Assignee    string             `json:"assignee"`
+	AssigneeID  *uuid.UUID         `json:"assignee_id,omitempty"`
+	Estimate    float64            `json:"estimate"`
+	Priority    types.TaskPriority `json:"priority"`
+	DueAt       *string            `json:"due_at"`
+	ParentID    *uuid.UUID         `json:"parent_id,omitempty"`
+	Labels      []LabelResponse    `json:"labels,omitempty"`
+	Version     int64              `json:"version"`
+	// CreatedBy is the actor who created the task, empty for tasks created before it was recorded
+	CreatedBy string `json:"created_by,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	// DeletedAt is only set for tasks in the trash
+	DeletedAt *string `json:"deleted_at,omitempty"`
+	// Match is only set for tasks found by a search
+	Match *TaskMatchResponse `json:"match,omitempty"`
+}
+
+// TaskMatchResponse describes how a task matched a search. The matching words of the title and of the
+// description excerpt are wrapped in <mark> and </mark>.
+type TaskMatchResponse struct {
+	Rank        float64 `json:"rank"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
 }
 
 // TaskListResponse represents the response body for listing tasks
 type TaskListResponse struct {
-	Tasks       []TaskResponse `json:"tasks"`
-	Total       int64          `json:"total"`
-	Page        int            `json:"page"`
-	Limit       int            `json:"limit"`
-	HasNext     bool           `json:"has_next"`
-	HasPrevious bool           `json:"has_previous"`
+	Tasks []TaskResponse `json:"tasks"`
+	// Total is left out when the listing was asked not to count the tasks
+	Total       *int64 `json:"total,omitempty"`
+	Page        int    `json:"page"`
+	Limit       int    `json:"limit"`
+	HasNext     bool   `json:"has_next"`
+	HasPrevious bool   `json:"has_previous"`
+	// NextCursor resumes the listing after the last task of this page; it is only set when there is a next
+	// page of a listing sorted by created_at
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// FilterErrorResponse represents the response body for a task filter that cannot be used
+type FilterErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	// Position is the 1-based character position of the offending token in the filter
+	Position int `json:"position"`
+	// Token is the offending token, empty when the filter ended too early
+	Token string `json:"token"`
+}
+
+// BulkTaskResult represents the outcome of one operation of a bulk task request
+type BulkTaskResult struct {
+	Index int        `json:"index"`
+	Op    string     `json:"op"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	// Status is the HTTP status the operation would have been answered with on its own
+	Status int            `json:"status"`
+	Task   *TaskResponse  `json:"task,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+// BulkTaskResponse represents the response body for a bulk task request
+type BulkTaskResponse struct {
+	Atomic    bool             `json:"atomic"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTaskResult `json:"results"`
+}
+
+// TaskTreeNode represents a task together with its subtasks
+type TaskTreeNode struct {
+	TaskResponse
+	// Completion is the percentage of tasks in this subtree, the task itself included, that are completed
+	Completion float64        `json:"completion"`
+	Children   []TaskTreeNode `json:"children"`
+}
+
+// TaskEventResponse represents a single entry in the history of a task
+type TaskEventResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id"`
+	// ClaimedActor is who the caller said it acted for in the X-Actor header; unlike Actor it is not verified
+	ClaimedActor string `json:"claimed_actor,omitempty"`
+	// Changes maps each changed field to its value before and after the change
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// FieldChange represents the value of a task field before and after a change
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// TaskHistoryResponse represents the response body for the history of a task
+type TaskHistoryResponse struct {
+	TaskID uuid.UUID           `json:"task_id"`
+	Events []TaskEventResponse `json:"events"`
+}
+
+// AddDependencyRequest represents the request body for adding a blocker to a task
+type AddDependencyRequest struct {
+	BlockerID uuid.UUID `json:"blocker_id" binding:"required"`
+}
+
+// DependencyResponse represents a single dependency edge between two tasks
+type DependencyResponse struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	BlockerID uuid.UUID `json:"blocker_id"`
+}
+
+// DependencyListResponse represents the blockers or dependents of a task
+type DependencyListResponse struct {
+	TaskID uuid.UUID      `json:"task_id"`
+	Tasks  []TaskResponse `json:"tasks"`
+}
+
+// PlanWave represents a group of tasks whose blockers are all in earlier waves
+type PlanWave struct {
+	Wave  int            `json:"wave"`
+	Tasks []TaskResponse `json:"tasks"`
+}
+
+// CriticalPathResponse represents the longest chain of dependent tasks by estimate
+type CriticalPathResponse struct {
+	TaskIDs  []uuid.UUID `json:"task_ids"`
+	Estimate float64     `json:"estimate"`
+}
+
+// PlanResponse represents the execution plan for all unfinished tasks
+type PlanResponse struct {
+	Order        []uuid.UUID           `json:"order"`
+	Waves        []PlanWave            `json:"waves"`
+	CriticalPath *CriticalPathResponse `json:"critical_path,omitempty"`
+}
+
+// GraphNode represents a task in the exported task graph
+type GraphNode struct {
+	ID       uuid.UUID        `json:"id"`
+	Title    string           `json:"title"`
+	Status   types.TaskStatus `json:"status"`
+	Assignee string           `json:"assignee,omitempty"`
+}
+
+// GraphEdge represents a relationship between two tasks in the exported task graph
+type GraphEdge struct {
+	From uuid.UUID `json:"from"`
+	To   uuid.UUID `json:"to"`
+	Type string    `json:"type"`
+}
+
+// GraphResponse represents the task graph in JSON form
+type GraphResponse struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
 }
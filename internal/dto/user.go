@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// CreateUserRequest represents the request body for creating a user
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=1,max=100"`
+	Name     string `json:"name" binding:"max=200"`
+	Email    string `json:"email" binding:"omitempty,email,max=254"`
+}
+
+// UpdateUserRequest represents the request body for updating a user
+type UpdateUserRequest struct {
+	// Username renames the user, and with it the assignee of every task assigned to it
+	Username *string `json:"username" binding:"omitempty,min=1,max=100"`
+	Name     *string `json:"name" binding:"omitempty,max=200"`
+	Email    *string `json:"email" binding:"omitempty,email,max=254"`
+}
+
+// UserResponse represents the response body for a user
+type UserResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Name      string    `json:"name,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// UserListResponse represents the response body for listing users
+type UserListResponse struct {
+	Users []UserResponse `json:"users"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"taheri24.ir/graph1/internal/types"
+)
+
+// WorkflowResponse represents the task status workflow
+type WorkflowResponse struct {
+	Statuses []types.TaskStatus `json:"statuses"`
+	// Transitions maps every status to the statuses a task may move to from it
+	Transitions map[types.TaskStatus][]types.TaskStatus `json:"transitions"`
+}
+
+// TransitionErrorResponse represents the response to a status change the workflow does not allow
+type TransitionErrorResponse struct {
+	Error   string             `json:"error"`
+	Message string             `json:"message,omitempty"`
+	From    types.TaskStatus   `json:"from"`
+	To      types.TaskStatus   `json:"to"`
+	Allowed []types.TaskStatus `json:"allowed"`
+}
@@ -0,0 +1,110 @@
+package graph
+
+// Graph is a directed graph keyed by comparable node IDs.
+// Nodes keep their insertion order so every traversal is deterministic.
+type Graph[K comparable] struct {
+	nodes []K
+	index map[K]int
+	out   map[K][]K
+	in    map[K][]K
+}
+
+// New creates an empty Graph
+func New[K comparable]() *Graph[K] {
+	return &Graph[K]{
+		index: make(map[K]int),
+		out:   make(map[K][]K),
+		in:    make(map[K][]K),
+	}
+}
+
+// AddNode adds a node to the graph, ignoring duplicates
+func (g *Graph[K]) AddNode(id K) {
+	if _, exists := g.index[id]; exists {
+		return
+	}
+	g.index[id] = len(g.nodes)
+	g.nodes = append(g.nodes, id)
+}
+
+// AddEdge adds a directed edge from -> to, adding missing nodes on the way
+func (g *Graph[K]) AddEdge(from, to K) {
+	g.AddNode(from)
+	g.AddNode(to)
+	for _, existing := range g.out[from] {
+		if existing == to {
+			return
+		}
+	}
+	g.out[from] = append(g.out[from], to)
+	g.in[to] = append(g.in[to], from)
+}
+
+// HasNode reports whether id is part of the graph
+func (g *Graph[K]) HasNode(id K) bool {
+	_, exists := g.index[id]
+	return exists
+}
+
+// Nodes returns all nodes in insertion order
+func (g *Graph[K]) Nodes() []K {
+	return append([]K(nil), g.nodes...)
+}
+
+// Successors returns the nodes reachable from id through a single edge
+func (g *Graph[K]) Successors(id K) []K {
+	return append([]K(nil), g.out[id]...)
+}
+
+// Predecessors returns the nodes that have an edge pointing at id
+func (g *Graph[K]) Predecessors(id K) []K {
+	return append([]K(nil), g.in[id]...)
+}
+
+// Path returns the shortest path from -> to (both inclusive), or nil if to is unreachable
+func (g *Graph[K]) Path(from, to K) []K {
+	if !g.HasNode(from) || !g.HasNode(to) {
+		return nil
+	}
+	if from == to {
+		return []K{from}
+	}
+
+	parent := map[K]K{}
+	visited := map[K]bool{from: true}
+	queue := []K{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range g.out[current] {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			parent[next] = current
+			if next == to {
+				path := []K{to}
+				for node := to; node != from; {
+					node = parent[node]
+					path = append([]K{node}, path...)
+				}
+				return path
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// CycleWith returns the cycle that adding the edge from -> to would close,
+// starting and ending at from, or nil if the edge keeps the graph acyclic
+func (g *Graph[K]) CycleWith(from, to K) []K {
+	if from == to {
+		return []K{from, from}
+	}
+	path := g.Path(to, from)
+	if path == nil {
+		return nil
+	}
+	return append([]K{from}, path...)
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddEdgeAddsNodesOnce(t *testing.T) {
+	g := New[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "b")
+	g.AddNode("a")
+
+	assert.Equal(t, []string{"a", "b"}, g.Nodes())
+	assert.Equal(t, []string{"b"}, g.Successors("a"))
+	assert.Equal(t, []string{"a"}, g.Predecessors("b"))
+	assert.True(t, g.HasNode("a"))
+	assert.False(t, g.HasNode("c"))
+}
+
+func TestPath(t *testing.T) {
+	g := New[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "d")
+
+	assert.Equal(t, []string{"a", "c", "d"}, g.Path("a", "d"))
+	assert.Equal(t, []string{"b"}, g.Path("b", "b"))
+	assert.Nil(t, g.Path("d", "a"))
+	assert.Nil(t, g.Path("a", "missing"))
+}
+
+func TestCycleWith(t *testing.T) {
+	g := New[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+
+	tests := []struct {
+		name     string
+		from, to string
+		expected []string
+	}{
+		{name: "closing edge", from: "c", to: "a", expected: []string{"c", "a", "b", "c"}},
+		{name: "self edge", from: "a", to: "a", expected: []string{"a", "a"}},
+		{name: "forward edge", from: "a", to: "c", expected: nil},
+		{name: "unknown nodes", from: "x", to: "y", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, g.CycleWith(tt.from, tt.to))
+		})
+	}
+}
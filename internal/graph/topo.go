@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"errors"
+)
+
+// ErrCycle is returned when an ordering is requested for a graph that contains a cycle
+var ErrCycle = errors.New("graph contains a cycle")
+
+// Waves groups the nodes into topologically ordered levels. Every node appears in
+// the first wave after all of its predecessors, so the nodes of one wave can be
+// processed in parallel. Within a wave nodes keep their insertion order.
+func (g *Graph[K]) Waves() ([][]K, error) {
+	remaining := make(map[K]int, len(g.nodes))
+	var current []K
+	for _, node := range g.nodes {
+		remaining[node] = len(g.in[node])
+		if remaining[node] == 0 {
+			current = append(current, node)
+		}
+	}
+
+	var waves [][]K
+	visited := 0
+	for len(current) > 0 {
+		waves = append(waves, current)
+		visited += len(current)
+
+		ready := map[K]bool{}
+		for _, node := range current {
+			for _, next := range g.out[node] {
+				remaining[next]--
+				if remaining[next] == 0 {
+					ready[next] = true
+				}
+			}
+		}
+
+		current = nil
+		for _, node := range g.nodes {
+			if ready[node] {
+				current = append(current, node)
+			}
+		}
+	}
+
+	if visited != len(g.nodes) {
+		return nil, ErrCycle
+	}
+	return waves, nil
+}
+
+// TopologicalOrder returns every node after all of its predecessors
+func (g *Graph[K]) TopologicalOrder() ([]K, error) {
+	waves, err := g.Waves()
+	if err != nil {
+		return nil, err
+	}
+	order := make([]K, 0, len(g.nodes))
+	for _, wave := range waves {
+		order = append(order, wave...)
+	}
+	return order, nil
+}
+
+// CriticalPath returns the chain of nodes with the largest total weight and that total.
+// Ties are broken in favour of the node inserted first.
+func (g *Graph[K]) CriticalPath(weight func(K) float64) ([]K, float64, error) {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(order) == 0 {
+		return nil, 0, nil
+	}
+
+	finish := make(map[K]float64, len(order))
+	previous := make(map[K]K, len(order))
+	hasPrevious := make(map[K]bool, len(order))
+	for _, node := range order {
+		start := 0.0
+		for _, pred := range g.in[node] {
+			if !hasPrevious[node] || finish[pred] > start {
+				start = finish[pred]
+				previous[node] = pred
+				hasPrevious[node] = true
+			}
+		}
+		finish[node] = start + weight(node)
+	}
+
+	end := g.nodes[0]
+	for _, node := range g.nodes {
+		if finish[node] > finish[end] {
+			end = node
+		}
+	}
+
+	path := []K{end}
+	for node := end; hasPrevious[node]; {
+		node = previous[node]
+		path = append([]K{node}, path...)
+	}
+	return path, finish[end], nil
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diamond() *Graph[string] {
+	g := New[string]()
+	g.AddNode("design")
+	g.AddNode("backend")
+	g.AddNode("frontend")
+	g.AddNode("release")
+	g.AddNode("docs")
+	g.AddEdge("design", "backend")
+	g.AddEdge("design", "frontend")
+	g.AddEdge("backend", "release")
+	g.AddEdge("frontend", "release")
+	return g
+}
+
+func TestWaves(t *testing.T) {
+	waves, err := diamond().Waves()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"design", "docs"},
+		{"backend", "frontend"},
+		{"release"},
+	}, waves)
+}
+
+func TestWavesEmptyGraph(t *testing.T) {
+	waves, err := New[string]().Waves()
+	assert.NoError(t, err)
+	assert.Empty(t, waves)
+}
+
+func TestWavesCycle(t *testing.T) {
+	g := New[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+
+	_, err := g.Waves()
+	assert.ErrorIs(t, err, ErrCycle)
+
+	_, _, err = g.CriticalPath(func(string) float64 { return 1 })
+	assert.ErrorIs(t, err, ErrCycle)
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	order, err := diamond().TopologicalOrder()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"design", "docs", "backend", "frontend", "release"}, order)
+}
+
+func TestCriticalPath(t *testing.T) {
+	estimates := map[string]float64{
+		"design":   2,
+		"backend":  8,
+		"frontend": 5,
+		"release":  1,
+		"docs":     10,
+	}
+
+	path, total, err := diamond().CriticalPath(func(id string) float64 { return estimates[id] })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"design", "backend", "release"}, path)
+	assert.Equal(t, 11.0, total)
+}
+
+func TestCriticalPathIsolatedNodeWins(t *testing.T) {
+	estimates := map[string]float64{"design": 1, "backend": 1, "release": 1, "docs": 20}
+
+	path, total, err := diamond().CriticalPath(func(id string) float64 { return estimates[id] })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs"}, path)
+	assert.Equal(t, 20.0, total)
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 
+	"taheri24.ir/graph1/internal/authz"
 	"taheri24.ir/graph1/internal/dto"
 	"taheri24.ir/graph1/internal/middleware"
 
@@ -95,16 +96,21 @@ type FireAlertRequest struct {
 
 // FireAlert handles POST /alerts/fire
 // @Summary Manually fire an alert
-// @Description Manually trigger an alert by setting the alert trigger metric
+// @Description Manually trigger an alert by setting the alert trigger metric. Only admins may fire alerts.
 // @Tags alerts
 // @Accept json
 // @Produce json
 // @Param alert body FireAlertRequest true "Alert to fire"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/alerts/fire [post]
 func (h *AlertHandler) FireAlert(c *gin.Context) {
+	if !authz.Check(c, authz.ManageAlerts, nil) {
+		return
+	}
+
 	var req FireAlertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dto.NewErr(err))
@@ -122,16 +128,21 @@ func (h *AlertHandler) FireAlert(c *gin.Context) {
 
 // ResetAlert handles POST /alerts/reset
 // @Summary Reset an alert
-// @Description Reset an alert by clearing the alert trigger metric
+// @Description Reset an alert by clearing the alert trigger metric. Only admins may reset alerts.
 // @Tags alerts
 // @Accept json
 // @Produce json
 // @Param alert body FireAlertRequest true "Alert to reset"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/alerts/reset [post]
 func (h *AlertHandler) ResetAlert(c *gin.Context) {
+	if !authz.Check(c, authz.ManageAlerts, nil) {
+		return
+	}
+
 	var req FireAlertRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dto.NewErr(err))
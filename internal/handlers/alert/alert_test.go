@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -234,6 +235,13 @@ func TestAlert_JSON(t *testing.T) {
 	assert.Equal(t, "firing", unmarshaled.State)
 }
 
+// adminRequest returns a POST request with body made by an admin, the only role firing and resetting alerts
+func adminRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/alerts", strings.NewReader(body))
+	ctx := middleware.ContextWithRole(middleware.ContextWithSubject(req.Context(), "ops"), middleware.RoleAdmin)
+	return req.WithContext(ctx)
+}
+
 func TestFireAlert_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -248,10 +256,7 @@ func TestFireAlert_Success(t *testing.T) {
 	jsonBody, _ := json.Marshal(reqBody)
 
 	// Set up the request
-	c.Request = &http.Request{
-		Method: "POST",
-		Body:   io.NopCloser(strings.NewReader(string(jsonBody))),
-	}
+	c.Request = adminRequest(string(jsonBody))
 
 	// Call the handler
 	handler.FireAlert(c)
@@ -274,10 +279,7 @@ func TestFireAlert_InvalidJSON(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 
 	// Set up invalid JSON request
-	c.Request = &http.Request{
-		Method: "POST",
-		Body:   io.NopCloser(strings.NewReader(`{"alert_name":`)), // Invalid JSON
-	}
+	c.Request = adminRequest(`{"alert_name":`)
 
 	// Call the handler
 	handler.FireAlert(c)
@@ -305,10 +307,7 @@ func TestResetAlert_Success(t *testing.T) {
 	jsonBody, _ := json.Marshal(reqBody)
 
 	// Set up the request
-	c.Request = &http.Request{
-		Method: "POST",
-		Body:   io.NopCloser(strings.NewReader(string(jsonBody))),
-	}
+	c.Request = adminRequest(string(jsonBody))
 
 	// Call the handler
 	handler.ResetAlert(c)
@@ -331,10 +330,7 @@ func TestResetAlert_InvalidJSON(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 
 	// Set up invalid JSON request
-	c.Request = &http.Request{
-		Method: "POST",
-		Body:   io.NopCloser(strings.NewReader(`{"alert_name":`)), // Invalid JSON
-	}
+	c.Request = adminRequest(`{"alert_name":`)
 
 	// Call the handler
 	handler.ResetAlert(c)
@@ -428,10 +424,7 @@ func TestGinBindingValidation(t *testing.T) {
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 
-			c.Request = &http.Request{
-				Method: "POST",
-				Body:   io.NopCloser(strings.NewReader(tt.requestBody)),
-			}
+			c.Request = adminRequest(tt.requestBody)
 
 			handler.FireAlert(c)
 			assert.Equal(t, tt.expectStatus, w.Code)
@@ -442,13 +435,39 @@ func TestGinBindingValidation(t *testing.T) {
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
 
-			c.Request = &http.Request{
-				Method: "POST",
-				Body:   io.NopCloser(strings.NewReader(tt.requestBody)),
-			}
+			c.Request = adminRequest(tt.requestBody)
 
 			handler.ResetAlert(c)
 			assert.Equal(t, tt.expectStatus, w.Code)
 		})
 	}
 }
+
+func TestAlerts_AdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &AlertHandler{}
+	for _, role := range middleware.AllRoles {
+		for name, handle := range map[string]gin.HandlerFunc{"fire": handler.FireAlert, "reset": handler.ResetAlert} {
+			t.Run(role+" "+name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest("POST", "/alerts/"+name, strings.NewReader(`{"alert_name":"TestAlert"}`))
+				ctx := middleware.ContextWithSubject(c.Request.Context(), "ops")
+				c.Request = c.Request.WithContext(middleware.ContextWithRole(ctx, role))
+
+				handle(c)
+
+				if role == middleware.RoleAdmin {
+					assert.Equal(t, http.StatusOK, w.Code)
+					return
+				}
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				var response dto.ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "Forbidden", response.Error)
+				assert.Contains(t, response.Message, "only admins can fire or reset alerts")
+			})
+		}
+	}
+}
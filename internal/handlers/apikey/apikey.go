@@ -0,0 +1,272 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// keyBytes is the number of random bytes in an API key
+const keyBytes = 32
+
+// prefixLength is the number of characters of a key kept in clear to recognize it
+const prefixLength = len(middleware.APIKeyPrefix) + 8
+
+// touchInterval is how stale the last use of a key may get before it is recorded again, so that busy keys
+// do not cost a write on every request
+const touchInterval = time.Minute
+
+// errInvalidKey is returned for API keys that are unknown, revoked or expired alike
+var errInvalidKey = errors.New("API key is invalid, revoked or expired")
+
+// APIKeyHandler handles the administration of API keys
+type APIKeyHandler struct {
+	repo database.APIKeyRepository
+	now  func() time.Time
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(repo database.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo, now: time.Now}
+}
+
+// ListAPIKeys handles GET /api-keys
+// @Summary List API keys
+// @Description Retrieve every API key of the tenant of the request, revoked ones included, newest first. Secrets are never returned. Only admins may list API keys.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} dto.APIKeyListResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	if !authz.Check(c, authz.ManageAPIKeys, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	keys, err := h.repo.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to fetch API keys", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch API keys"))
+		return
+	}
+
+	responses := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = keyToResponse(key)
+	}
+	c.JSON(http.StatusOK, dto.APIKeyListResponse{Keys: responses})
+}
+
+// CreateAPIKey handles POST /api-keys
+// @Summary Mint an API key
+// @Description Create an API key granted the given scopes and role. The key is part of this response only; store it, it cannot be retrieved again. Only admins may mint API keys. Callers can only grant scopes they hold themselves and roles up to their own, which is the default; the keys:admin scope needs the admin role. The key is bound to the tenant of the request.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body dto.CreateAPIKeyRequest true "API key name and scopes"
+// @Success 201 {object} dto.CreatedAPIKeyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	if !authz.Check(c, authz.ManageAPIKeys, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for creating API key", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid API key name", "name must be non-empty"))
+		return
+	}
+	scopes, err := middleware.ParseScopes(strings.Join(req.Scopes, " "))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid scope", err.Error()))
+		return
+	}
+	for _, scope := range scopes {
+		if !middleware.HasScope(c.Request.Context(), scope) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("Forbidden",
+				"cannot grant the "+scope+" scope, which this credential was not granted"))
+			return
+		}
+	}
+	callerRole := middleware.GetRoleFromContext(c.Request.Context())
+	role := callerRole
+	if req.Role != "" {
+		if role, err = middleware.ParseRole(req.Role); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid role", err.Error()))
+			return
+		}
+		if !middleware.RoleIncludes(callerRole, role) {
+			c.JSON(http.StatusForbidden, dto.NewErrorResponse("Forbidden",
+				"cannot grant the "+role+" role with the "+callerRole+" role"))
+			return
+		}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(middleware.RoleScopes(role), scope) {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid scope",
+				"the "+scope+" scope cannot be granted with the "+role+" role"))
+			return
+		}
+	}
+	now := h.now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid expiry", "expires_at must be in the future"))
+		return
+	}
+
+	secret, err := newKey()
+	if err != nil {
+		logger.Error("Failed to generate API key", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to create API key"))
+		return
+	}
+	key := models.APIKey{
+		ID:        uuid.New(),
+		Name:      name,
+		Prefix:    secret[:prefixLength],
+		Hash:      hashKey(secret),
+		Scopes:    strings.Join(scopes, " "),
+		Role:      role,
+		CreatedBy: middleware.GetActorFromContext(c.Request.Context()),
+		CreatedAt: now,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := h.repo.CreateAPIKey(c.Request.Context(), &key); err != nil {
+		logger.Error("Failed to create API key", "name", name, "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to create API key"))
+		return
+	}
+
+	logger.Info("API key created", "id", key.ID.String(), "prefix", key.Prefix, "scopes", key.Scopes, "role", key.Role)
+	c.Header("Cache-Control", "no-store")
+	// The secret must not be kept around for replay either
+	middleware.SkipIdempotencyStore(c)
+	c.JSON(http.StatusCreated, dto.CreatedAPIKeyResponse{APIKeyResponse: keyToResponse(key), Key: secret})
+}
+
+// RevokeAPIKey handles DELETE /api-keys/{id}
+// @Summary Revoke an API key
+// @Description Revoke an API key so that it can no longer be used. The key stays listed with its revocation time. Only admins may revoke API keys.
+// @Tags api-keys
+// @Param id path string true "API key ID (UUID)"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	if !authz.Check(c, authz.ManageAPIKeys, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger.Error("Invalid API key ID provided", "idStr", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid API key ID"))
+		return
+	}
+
+	key, err := h.repo.RevokeAPIKey(c.Request.Context(), id, h.now())
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("API key not found for revocation", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("API key not found"))
+		} else {
+			logger.Error("Failed to revoke API key", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to revoke API key"))
+		}
+		return
+	}
+
+	logger.Info("API key revoked", "id", id.String(), "prefix", key.Prefix)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// VerifyAPIKey finds the active API key matching key and returns its caller, named after the key's prefix,
+// with the scopes, role and tenant of the key. It implements middleware.APIKeyVerifier.
+func (h *APIKeyHandler) VerifyAPIKey(ctx context.Context, key string) (*middleware.Principal, error) {
+	found, err := h.repo.GetAPIKeyByHash(ctx, hashKey(key))
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			return nil, errInvalidKey
+		}
+		return nil, err
+	}
+	now := h.now()
+	if !found.Active(now) {
+		return nil, errInvalidKey
+	}
+
+	if found.LastUsedAt == nil || now.Sub(*found.LastUsedAt) >= touchInterval {
+		if err := h.repo.TouchAPIKey(ctx, found.ID, now); err != nil {
+			// The key is valid all the same
+			middleware.GetLoggerFromContext(ctx).Error("Failed to record API key use", "id", found.ID.String(), "error", err)
+		}
+	}
+	return &middleware.Principal{Subject: "apikey:" + found.Prefix, Scopes: found.ScopeList(), Role: found.Role,
+		Tenant: found.TenantID}, nil
+}
+
+// newKey generates a random API key starting with middleware.APIKeyPrefix
+func newKey() (string, error) {
+	random := make([]byte, keyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return middleware.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// hashKey returns the hex SHA-256 digest under which key is stored. Keys are random enough that a fast hash
+// does not make them guessable.
+func hashKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+func keyToResponse(key models.APIKey) dto.APIKeyResponse {
+	return dto.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		Role:       key.Role,
+		Tenant:     key.TenantID,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
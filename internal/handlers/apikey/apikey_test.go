@@ -0,0 +1,184 @@
+package apikey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter serves the API key endpoints of a handler backed by a fresh database. Requests are made by
+// an authenticated admin holding scopes, or by an unauthenticated caller when scopes is nil.
+func newTestRouter(t *testing.T, scopes []string) (*APIKeyHandler, *gin.Engine) {
+	return newRoleTestRouter(t, middleware.RoleAdmin, scopes)
+}
+
+// newRoleTestRouter is newTestRouter for a caller with role
+func newRoleTestRouter(t *testing.T, role string, scopes []string) (*APIKeyHandler, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	handler := NewAPIKeyHandler(db)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if scopes != nil {
+			ctx := middleware.ContextWithSubject(c.Request.Context(), "admin-bot")
+			ctx = middleware.ContextWithActor(ctx, "admin-bot")
+			ctx = middleware.ContextWithRole(ctx, role)
+			c.Request = c.Request.WithContext(middleware.ContextWithScopes(ctx, scopes))
+		}
+	})
+	router.GET("/api-keys", handler.ListAPIKeys)
+	router.POST("/api-keys", handler.CreateAPIKey)
+	router.DELETE("/api-keys/:id", handler.RevokeAPIKey)
+	return handler, router
+}
+
+func request(router *gin.Engine, method, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func createKey(t *testing.T, router *gin.Engine, req dto.CreateAPIKeyRequest) dto.CreatedAPIKeyResponse {
+	t.Helper()
+	w := request(router, http.MethodPost, "/api-keys", req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response dto.CreatedAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestCreateAndVerifyAPIKey(t *testing.T) {
+	handler, router := newTestRouter(t, middleware.AllScopes)
+
+	created := createKey(t, router, dto.CreateAPIKeyRequest{Name: " ci ", Scopes: []string{"tasks:write", "tasks:read"}})
+	assert.Equal(t, "ci", created.Name)
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, created.Scopes)
+	// Keys get the role of the caller minting them unless given a lower one
+	assert.Equal(t, middleware.RoleAdmin, created.Role)
+	assert.Equal(t, "admin-bot", created.CreatedBy)
+	assert.True(t, strings.HasPrefix(created.Key, middleware.APIKeyPrefix))
+	assert.Equal(t, created.Key[:prefixLength], created.Prefix)
+
+	principal, err := handler.VerifyAPIKey(context.TODO(), created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "apikey:"+created.Prefix, principal.Subject)
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, principal.Scopes)
+	assert.Equal(t, middleware.RoleAdmin, principal.Role)
+
+	_, err = handler.VerifyAPIKey(context.TODO(), created.Key+"x")
+	assert.ErrorIs(t, err, errInvalidKey)
+
+	// The secret is never shown again, and its use is recorded
+	w := request(router, http.MethodGet, "/api-keys", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Key)
+	var list dto.APIKeyListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Keys, 1)
+	assert.Equal(t, created.ID, list.Keys[0].ID)
+	assert.NotNil(t, list.Keys[0].LastUsedAt)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	handler, router := newTestRouter(t, middleware.AllScopes)
+	created := createKey(t, router, dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}})
+
+	w := request(router, http.MethodDelete, "/api-keys/"+created.ID.String(), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	_, err := handler.VerifyAPIKey(context.TODO(), created.Key)
+	assert.ErrorIs(t, err, errInvalidKey)
+
+	// Revoking is idempotent and the key stays listed
+	assert.Equal(t, http.StatusNoContent, request(router, http.MethodDelete, "/api-keys/"+created.ID.String(), nil).Code)
+	var list dto.APIKeyListResponse
+	require.NoError(t, json.Unmarshal(request(router, http.MethodGet, "/api-keys", nil).Body.Bytes(), &list))
+	require.Len(t, list.Keys, 1)
+	assert.NotNil(t, list.Keys[0].RevokedAt)
+
+	assert.Equal(t, http.StatusNotFound, request(router, http.MethodDelete, "/api-keys/"+uuid.NewString(), nil).Code)
+	assert.Equal(t, http.StatusBadRequest, request(router, http.MethodDelete, "/api-keys/not-a-uuid", nil).Code)
+}
+
+func TestExpiredAPIKey(t *testing.T) {
+	handler, router := newTestRouter(t, middleware.AllScopes)
+	expiresAt := time.Now().Add(time.Hour)
+	created := createKey(t, router, dto.CreateAPIKeyRequest{Name: "temp", Scopes: []string{"alerts:fire"}, ExpiresAt: &expiresAt})
+
+	_, err := handler.VerifyAPIKey(context.TODO(), created.Key)
+	require.NoError(t, err)
+
+	handler.now = func() time.Time { return expiresAt.Add(time.Second) }
+	_, err = handler.VerifyAPIKey(context.TODO(), created.Key)
+	assert.ErrorIs(t, err, errInvalidKey)
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	_, router := newTestRouter(t, []string{middleware.ScopeKeysAdmin, middleware.ScopeTasksRead})
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		body   any
+		status int
+	}{
+		{"missing scopes", map[string]any{"name": "ci"}, http.StatusBadRequest},
+		{"blank name", dto.CreateAPIKeyRequest{Name: "  ", Scopes: []string{"tasks:read"}}, http.StatusBadRequest},
+		{"unknown scope", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:delete"}}, http.StatusBadRequest},
+		{"expired", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}, ExpiresAt: &past}, http.StatusBadRequest},
+		// Callers cannot grant more than they hold
+		{"scope not held", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:write"}}, http.StatusForbidden},
+		{"scope held", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}}, http.StatusCreated},
+		{"unknown role", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}, Role: "root"}, http.StatusBadRequest},
+		{"role below own", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}, Role: "viewer"}, http.StatusCreated},
+		// Only admin keys may administer keys
+		{"keys:admin for a member", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"keys:admin"}, Role: "member"}, http.StatusBadRequest},
+		{"keys:admin for an admin", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"keys:admin"}}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(router, http.MethodPost, "/api-keys", tt.body)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestOnlyAdminsManageAPIKeys(t *testing.T) {
+	for _, role := range []string{middleware.RoleMember, middleware.RoleViewer, ""} {
+		t.Run(role, func(t *testing.T) {
+			// Even holding the keys:admin scope is not enough, and callers that were not authenticated hold
+			// nothing
+			scopes := middleware.AllScopes
+			if role == "" {
+				scopes = nil
+			}
+			_, router := newRoleTestRouter(t, role, scopes)
+
+			w := request(router, http.MethodPost, "/api-keys", dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"tasks:read"}})
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Equal(t, http.StatusForbidden, request(router, http.MethodGet, "/api-keys", nil).Code)
+			assert.Equal(t, http.StatusForbidden, request(router, http.MethodDelete, "/api-keys/"+uuid.NewString(), nil).Code)
+		})
+	}
+}
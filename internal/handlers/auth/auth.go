@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/pkg/config"
+	"taheri24.ir/graph1/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultTokenTTL is how long issued tokens are valid when no lifetime is configured
+const DefaultTokenTTL = time.Hour
+
+// TokenSigner issues tokens for a subject
+type TokenSigner interface {
+	Issue(claims jwt.Claims, ttl time.Duration) (string, *jwt.Claims, error)
+}
+
+// DefaultRole is the role of the service accounts that were not given one
+const DefaultRole = middleware.RoleMember
+
+// AuthHandler issues tokens to service accounts, which are trusted with every scope
+type AuthHandler struct {
+	signer TokenSigner
+	// accounts maps client IDs to the SHA-256 digests of their secrets, so that comparing them takes the
+	// same time whatever their length
+	accounts map[string][32]byte
+	roles    map[string]string
+	tenants  map[string]string
+	ttl      time.Duration
+}
+
+// NewAuthHandler creates a new AuthHandler issuing tokens valid for cfg.TokenTTL to the service accounts of
+// cfg, with their roles and bound to their tenants. It fails on unknown roles, on invalid tenants and on
+// either given to accounts that do not exist.
+func NewAuthHandler(signer TokenSigner, cfg config.AuthConfig) (*AuthHandler, error) {
+	ttl := cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	digests := make(map[string][32]byte, len(cfg.ServiceAccounts))
+	for id, secret := range cfg.ServiceAccounts {
+		digests[id] = sha256.Sum256([]byte(secret))
+	}
+	roles, err := parseAccountSettings(cfg.ServiceAccounts, cfg.ServiceAccountRoles, "role", middleware.ParseRole)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := parseAccountSettings(cfg.ServiceAccounts, cfg.ServiceAccountTenants, "tenant", middleware.ParseTenant)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthHandler{signer: signer, accounts: digests, roles: roles, tenants: tenants, ttl: ttl}, nil
+}
+
+// parseAccountSettings parses the setting named name given to each service account in settings
+func parseAccountSettings(accounts, settings map[string]string, name string, parse func(string) (string, error)) (map[string]string, error) {
+	parsed := make(map[string]string, len(settings))
+	for id, setting := range settings {
+		if _, ok := accounts[id]; !ok {
+			return nil, fmt.Errorf("%s given to unknown service account %q", name, id)
+		}
+		var err error
+		if parsed[id], err = parse(setting); err != nil {
+			return nil, fmt.Errorf("service account %q: %w", id, err)
+		}
+	}
+	return parsed, nil
+}
+
+// NewKey builds the key signing and verifying tokens from the auth configuration: the HMAC secret for HS256,
+// or the RSA keys read from their PEM files for RS256
+func NewKey(cfg config.AuthConfig) (*jwt.Key, error) {
+	switch strings.ToUpper(cfg.Algorithm) {
+	case jwt.HS256:
+		return jwt.NewHMACKey([]byte(cfg.Secret), cfg.Issuer)
+	case jwt.RS256:
+		private, public, err := readRSAKeys(cfg.PrivateKeyFile, cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return jwt.NewRSAKey(private, public, cfg.Issuer)
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q; use HS256 or RS256", cfg.Algorithm)
+	}
+}
+
+func readRSAKeys(privateFile, publicFile string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	var private *rsa.PrivateKey
+	var public *rsa.PublicKey
+	if privateFile != "" {
+		data, err := os.ReadFile(privateFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if private, err = jwt.ParseRSAPrivateKeyPEM(data); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", privateFile, err)
+		}
+	}
+	if publicFile != "" {
+		data, err := os.ReadFile(publicFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if public, err = jwt.ParseRSAPublicKeyPEM(data); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", publicFile, err)
+		}
+	}
+	return private, public, nil
+}
+
+// IssueToken handles POST /auth/token
+// @Summary Issue an access token
+// @Description Exchange the credentials of a service account, sent in the body or with HTTP Basic authentication, for a signed bearer token carrying every scope its role may hold (keys:admin is for admins only) or the requested ones, the role of the account and the tenant it is bound to, if any
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body dto.TokenRequest true "Service account credentials"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/auth/token [post]
+func (h *AuthHandler) IssueToken(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.TokenRequest
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+		req.GrantType, req.Scope = c.Query("grant_type"), c.Query("scope")
+	} else if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+	if req.GrantType != "" && req.GrantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Unsupported grant type",
+			"only the client_credentials grant is supported"))
+		return
+	}
+	if req.ClientID == "" || req.ClientSecret == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid request", "client_id and client_secret are required"))
+		return
+	}
+
+	if !h.authenticate(req.ClientID, req.ClientSecret) {
+		logger.Info("Rejected service account credentials", "client_id", req.ClientID)
+		c.Header("WWW-Authenticate", `Basic realm="api"`)
+		// Unknown clients and wrong secrets get the same answer
+		c.JSON(http.StatusUnauthorized, dto.NewErrorResponse("Unauthorized", "client_id or client_secret is wrong"))
+		return
+	}
+
+	role, ok := h.roles[req.ClientID]
+	if !ok {
+		role = DefaultRole
+	}
+	scopes := middleware.RoleScopes(role)
+	if req.Scope != "" {
+		requested, err := middleware.ParseScopes(req.Scope)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid scope", err.Error()))
+			return
+		}
+		for _, scope := range requested {
+			if !slices.Contains(scopes, scope) {
+				c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid scope",
+					"the "+scope+" scope cannot be granted with the "+role+" role"))
+				return
+			}
+		}
+		scopes = requested
+	}
+	scope := strings.Join(scopes, " ")
+
+	// Accounts not bound to a tenant act on the default tenant only, never on one the caller picks
+	tenant := h.tenants[req.ClientID]
+	if tenant == "" {
+		tenant = middleware.DefaultTenant
+	}
+
+	token, _, err := h.signer.Issue(jwt.Claims{Subject: req.ClientID, Scope: scope, Role: role, Tenant: tenant}, h.ttl)
+	if err != nil {
+		logger.Error("Failed to issue token", "client_id", req.ClientID, "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to issue token"))
+		return
+	}
+
+	logger.Info("Token issued", "client_id", req.ClientID, "scope", scope, "role", role, "tenant", tenant)
+	c.Header("Cache-Control", "no-store")
+	// The token must not be kept around for replay either
+	middleware.SkipIdempotencyStore(c)
+	c.JSON(http.StatusOK, dto.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(h.ttl / time.Second),
+		Scope:       scope,
+		Role:        role,
+		Tenant:      tenant,
+	})
+}
+
+// authenticate reports whether secret is the secret of the service account id, in a time that does not
+// depend on how much of it is right
+func (h *AuthHandler) authenticate(id, secret string) bool {
+	expected, ok := h.accounts[id]
+	given := sha256.Sum256([]byte(secret))
+	return subtle.ConstantTimeCompare(given[:], expected[:]) == 1 && ok
+}
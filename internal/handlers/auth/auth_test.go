@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/pkg/config"
+	"taheri24.ir/graph1/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) *jwt.Key {
+	t.Helper()
+	key, err := jwt.NewHMACKey([]byte("0123456789abcdef0123456789abcdef"), "graph1")
+	require.NoError(t, err)
+	return key
+}
+
+func serveToken(handler *AuthHandler, req *http.Request) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.POST("/auth/token", handler.IssueToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIssueToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key := newTestKey(t)
+	handler, err := NewAuthHandler(key, config.AuthConfig{
+		ServiceAccounts:       map[string]string{"ci-bot": "s3cret", "ops": "pass"},
+		ServiceAccountRoles:   map[string]string{"ops": "Admin"},
+		ServiceAccountTenants: map[string]string{"ops": "Acme"},
+		TokenTTL:              15 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	jsonRequest := func(body string) *http.Request {
+		req := httptest.NewRequest("POST", "/auth/token", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	formRequest := httptest.NewRequest("POST", "/auth/token",
+		strings.NewReader("grant_type=client_credentials&client_id=ci-bot&client_secret=s3cret"))
+	formRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	basicRequest := httptest.NewRequest("POST", "/auth/token", nil)
+	basicRequest.SetBasicAuth("ci-bot", "s3cret")
+
+	for name, req := range map[string]*http.Request{
+		"json":  jsonRequest(`{"client_id": "ci-bot", "client_secret": "s3cret"}`),
+		"form":  formRequest,
+		"basic": basicRequest,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serveToken(handler, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+			var response dto.TokenResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "Bearer", response.TokenType)
+			assert.Equal(t, int64(900), response.ExpiresIn)
+
+			claims, err := key.Verify(response.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, "ci-bot", claims.Subject)
+			assert.Equal(t, "graph1", claims.Issuer)
+			// Service accounts get every scope of their role unless they ask for fewer
+			assert.Equal(t, strings.Join(middleware.RoleScopes(middleware.RoleMember), " "), claims.Scope)
+			assert.NotContains(t, claims.Scope, middleware.ScopeKeysAdmin)
+			assert.Equal(t, claims.Scope, response.Scope)
+			// Accounts without a role are members
+			assert.Equal(t, middleware.RoleMember, claims.Role)
+			assert.Equal(t, claims.Role, response.Role)
+			// Accounts without a tenant are bound to the default one
+			assert.Equal(t, middleware.DefaultTenant, claims.Tenant)
+			assert.Equal(t, middleware.DefaultTenant, response.Tenant)
+		})
+	}
+
+	w := serveToken(handler, jsonRequest(`{"client_id": "ci-bot", "client_secret": "s3cret", "scope": "tasks:write tasks:read"}`))
+	require.Equal(t, http.StatusOK, w.Code)
+	var narrowed dto.TokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &narrowed))
+	assert.Equal(t, "tasks:read tasks:write", narrowed.Scope)
+	claims, err := key.Verify(narrowed.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "tasks:read tasks:write", claims.Scope)
+
+	w = serveToken(handler, jsonRequest(`{"client_id": "ops", "client_secret": "pass"}`))
+	require.Equal(t, http.StatusOK, w.Code)
+	var admin dto.TokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &admin))
+	claims, err = key.Verify(admin.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, middleware.RoleAdmin, claims.Role)
+	assert.Equal(t, strings.Join(middleware.AllScopes, " "), claims.Scope)
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.Equal(t, "acme", admin.Tenant)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"wrong secret", `{"client_id": "ci-bot", "client_secret": "guess"}`, http.StatusUnauthorized},
+		{"unknown client", `{"client_id": "intruder", "client_secret": "s3cret"}`, http.StatusUnauthorized},
+		{"missing secret", `{"client_id": "ci-bot"}`, http.StatusBadRequest},
+		{"other grant", `{"grant_type": "password", "client_id": "ci-bot", "client_secret": "s3cret"}`, http.StatusBadRequest},
+		{"invalid json", `{"client_id":`, http.StatusBadRequest},
+		{"unknown scope", `{"client_id": "ci-bot", "client_secret": "s3cret", "scope": "root"}`, http.StatusBadRequest},
+		{"scope above role", `{"client_id": "ci-bot", "client_secret": "s3cret", "scope": "tasks:read keys:admin"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveToken(handler, jsonRequest(tt.body))
+			assert.Equal(t, tt.status, w.Code)
+			assert.NotContains(t, w.Body.String(), "access_token")
+		})
+	}
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Issue(jwt.Claims, time.Duration) (string, *jwt.Claims, error) {
+	return "", nil, errors.New("no key")
+}
+
+func TestIssueTokenSignerFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler, err := NewAuthHandler(failingSigner{}, config.AuthConfig{ServiceAccounts: map[string]string{"ci-bot": "s3cret"}})
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/auth/token", nil)
+	req.SetBasicAuth("ci-bot", "s3cret")
+
+	w := serveToken(handler, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestNewAuthHandlerRoles(t *testing.T) {
+	accounts := map[string]string{"ci-bot": "s3cret"}
+
+	_, err := NewAuthHandler(failingSigner{}, config.AuthConfig{ServiceAccounts: accounts,
+		ServiceAccountRoles: map[string]string{"ci-bot": "root"}})
+	assert.ErrorContains(t, err, "unknown role")
+	_, err = NewAuthHandler(failingSigner{}, config.AuthConfig{ServiceAccounts: accounts,
+		ServiceAccountRoles: map[string]string{"intruder": "admin"}})
+	assert.ErrorContains(t, err, "role given to unknown service account")
+}
+
+func TestNewAuthHandlerTenants(t *testing.T) {
+	accounts := map[string]string{"ci-bot": "s3cret"}
+
+	_, err := NewAuthHandler(failingSigner{}, config.AuthConfig{ServiceAccounts: accounts,
+		ServiceAccountTenants: map[string]string{"ci-bot": "acme_corp"}})
+	assert.ErrorContains(t, err, "invalid tenant")
+	_, err = NewAuthHandler(failingSigner{}, config.AuthConfig{ServiceAccounts: accounts,
+		ServiceAccountTenants: map[string]string{"intruder": "acme"}})
+	assert.ErrorContains(t, err, "tenant given to unknown service account")
+}
+
+func TestNewKey(t *testing.T) {
+	// HS256 needs a long enough secret
+	key, err := NewKey(config.AuthConfig{Algorithm: "HS256", Secret: strings.Repeat("s", 32)})
+	require.NoError(t, err)
+	assert.Equal(t, jwt.HS256, key.Algorithm())
+	_, err = NewKey(config.AuthConfig{Algorithm: "HS256", Secret: "short"})
+	assert.Error(t, err)
+
+	// RS256 reads its keys from PEM files
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	privateFile := filepath.Join(dir, "private.pem")
+	publicFile := filepath.Join(dir, "public.pem")
+	pkix, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(privateFile,
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}), 0o600))
+	require.NoError(t, os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}), 0o600))
+
+	signer, err := NewKey(config.AuthConfig{Algorithm: "RS256", PrivateKeyFile: privateFile})
+	require.NoError(t, err)
+	assert.True(t, signer.CanSign())
+	verifier, err := NewKey(config.AuthConfig{Algorithm: "rs256", PublicKeyFile: publicFile})
+	require.NoError(t, err)
+	assert.False(t, verifier.CanSign())
+
+	token, _, err := signer.Issue(jwt.Claims{Subject: "ci-bot"}, time.Hour)
+	require.NoError(t, err)
+	_, err = verifier.Verify(token)
+	assert.NoError(t, err)
+
+	_, err = NewKey(config.AuthConfig{Algorithm: "RS256"})
+	assert.Error(t, err)
+	_, err = NewKey(config.AuthConfig{Algorithm: "RS256", PrivateKeyFile: filepath.Join(dir, "missing.pem")})
+	assert.Error(t, err)
+	_, err = NewKey(config.AuthConfig{Algorithm: "none"})
+	assert.Error(t, err)
+}
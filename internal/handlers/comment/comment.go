@@ -0,0 +1,281 @@
+package comment
+
+import (
+	"net/http"
+	"strconv"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CommentHandler handles the comment threads of tasks
+type CommentHandler struct {
+	repo database.CommentRepository
+}
+
+// NewCommentHandler creates a new CommentHandler
+func NewCommentHandler(repo database.CommentRepository) *CommentHandler {
+	return &CommentHandler{repo: repo}
+}
+
+// ListComments handles GET /tasks/{id}/comments
+// @Summary List the comments of a task
+// @Description Retrieve a paginated list of a task's comments, oldest first
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param page query int false "Page number (default: 1)" minimum(1)
+// @Param limit query int false "Items per page (default: 10, max: 100)" minimum(1) maximum(100)
+// @Success 200 {object} dto.CommentListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/comments [get]
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	taskID, ok := parseIDParam(c, "id", "Invalid task ID")
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	comments, total, err := h.repo.ListComments(c.Request.Context(), taskID, page, limit)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task not found for comments", "id", taskID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		} else {
+			logger.Error("Failed to fetch comments", "id", taskID.String(), "page", page, "limit", limit, "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch comments"))
+		}
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	responses := make([]dto.CommentResponse, len(comments))
+	for i, comment := range comments {
+		responses[i] = commentToResponse(comment)
+	}
+
+	c.JSON(http.StatusOK, dto.CommentListResponse{
+		Comments:    responses,
+		Total:       total,
+		Page:        page,
+		Limit:       limit,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	})
+}
+
+// CreateComment handles POST /tasks/{id}/comments
+// @Summary Comment on a task
+// @Description Comment on a task as the caller, who is recorded as the author
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param comment body dto.CreateCommentRequest true "Comment"
+// @Success 201 {object} dto.CommentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/comments [post]
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	if !authz.CheckComment(c, authz.CreateComment, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	taskID, ok := parseIDParam(c, "id", "Invalid task ID")
+	if !ok {
+		return
+	}
+
+	var req dto.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for creating comment", "id", taskID.String(), "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+
+	// The author is whoever makes the request, never what the body claims
+	author := middleware.GetActorFromContext(c.Request.Context())
+	comment := models.Comment{ID: uuid.New(), TaskID: taskID, Author: author, Body: req.Body}
+	if err := h.repo.CreateComment(c.Request.Context(), &comment); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task not found for comment", "id", taskID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		} else {
+			logger.Error("Failed to create comment", "id", taskID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to create comment"))
+		}
+		return
+	}
+
+	logger.Info("Comment created successfully", "id", taskID.String(), "comment_id", comment.ID.String(), "author", comment.Author)
+	c.JSON(http.StatusCreated, commentToResponse(comment))
+}
+
+// GetComment handles GET /tasks/{id}/comments/{comment_id}
+// @Summary Get a comment of a task
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param comment_id path string true "Comment ID (UUID)"
+// @Success 200 {object} dto.CommentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/comments/{comment_id} [get]
+func (h *CommentHandler) GetComment(c *gin.Context) {
+	comment, ok := h.findComment(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, commentToResponse(*comment))
+}
+
+// UpdateComment handles PUT /tasks/{id}/comments/{comment_id}
+// @Summary Edit a comment
+// @Description Replace the body of a comment. Members can only edit the comments they wrote.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param comment_id path string true "Comment ID (UUID)"
+// @Param comment body dto.UpdateCommentRequest true "New comment body"
+// @Success 200 {object} dto.CommentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/comments/{comment_id} [put]
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for updating comment", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+
+	comment, ok := h.findComment(c)
+	if !ok || !authz.CheckComment(c, authz.EditComment, comment) {
+		return
+	}
+	comment.Body = req.Body
+
+	if err := h.repo.UpdateComment(c.Request.Context(), comment); err != nil {
+		logger.Error("Failed to update comment", "comment_id", comment.ID.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to update comment"))
+		return
+	}
+
+	logger.Info("Comment updated successfully", "id", comment.TaskID.String(), "comment_id", comment.ID.String())
+	c.JSON(http.StatusOK, commentToResponse(*comment))
+}
+
+// DeleteComment handles DELETE /tasks/{id}/comments/{comment_id}
+// @Summary Delete a comment
+// @Description Delete a comment. Members can only delete the comments they wrote.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param comment_id path string true "Comment ID (UUID)"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/comments/{comment_id} [delete]
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	comment, ok := h.findComment(c)
+	if !ok || !authz.CheckComment(c, authz.DeleteComment, comment) {
+		return
+	}
+	taskID, commentID := comment.TaskID, comment.ID
+
+	if err := h.repo.DeleteComment(c.Request.Context(), taskID, commentID); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Comment not found for deletion", "id", taskID.String(), "comment_id", commentID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Comment not found"))
+		} else {
+			logger.Error("Failed to delete comment", "id", taskID.String(), "comment_id", commentID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to delete comment"))
+		}
+		return
+	}
+
+	logger.Info("Comment deleted successfully", "id", taskID.String(), "comment_id", commentID.String())
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// findComment loads the comment named by the path parameters, writing an error response when it cannot
+func (h *CommentHandler) findComment(c *gin.Context) (*models.Comment, bool) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	taskID, ok := parseIDParam(c, "id", "Invalid task ID")
+	if !ok {
+		return nil, false
+	}
+	commentID, ok := parseIDParam(c, "comment_id", "Invalid comment ID")
+	if !ok {
+		return nil, false
+	}
+
+	comment, err := h.repo.GetComment(c.Request.Context(), taskID, commentID)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Comment not found", "id", taskID.String(), "comment_id", commentID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Comment not found"))
+		} else {
+			logger.Error("Failed to get comment", "id", taskID.String(), "comment_id", commentID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get comment"))
+		}
+		return nil, false
+	}
+	return comment, true
+}
+
+// parseIDParam parses the named path parameter as a UUID, writing a 400 response with message when it is invalid
+func parseIDParam(c *gin.Context, name, message string) (uuid.UUID, bool) {
+	idStr := c.Param(name)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Invalid ID provided", "param", name, "idStr", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse(message))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func commentToResponse(comment models.Comment) dto.CommentResponse {
+	return dto.CommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: comment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
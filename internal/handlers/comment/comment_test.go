@@ -0,0 +1,227 @@
+package comment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommentHandlerTestSuite struct {
+	suite.Suite
+	db     *database.Database
+	router *gin.Engine
+	task   models.Task
+}
+
+func (suite *CommentHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(suite.T(), err)
+	suite.db = db
+
+	suite.task = models.Task{Title: "Design", Status: types.StatusPending}
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &suite.task))
+
+	handler := NewCommentHandler(suite.db)
+	suite.router = gin.New()
+	suite.router.GET("/tasks/:id/comments", handler.ListComments)
+	suite.router.POST("/tasks/:id/comments", handler.CreateComment)
+	suite.router.GET("/tasks/:id/comments/:comment_id", handler.GetComment)
+	suite.router.PUT("/tasks/:id/comments/:comment_id", handler.UpdateComment)
+	suite.router.DELETE("/tasks/:id/comments/:comment_id", handler.DeleteComment)
+}
+
+func (suite *CommentHandlerTestSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestCommentHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(CommentHandlerTestSuite))
+}
+
+// callerContext returns a context authenticated as subject with role, as AuthMiddleware makes it
+func callerContext(subject, role string) context.Context {
+	ctx := middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), subject), role)
+	return middleware.ContextWithActor(ctx, subject)
+}
+
+// request makes a request as an admin
+func (suite *CommentHandlerTestSuite) request(method, path string, body any) *httptest.ResponseRecorder {
+	return suite.requestWithContext(callerContext("admin", middleware.RoleAdmin), method, path, body)
+}
+
+func (suite *CommentHandlerTestSuite) requestWithContext(ctx context.Context, method, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *CommentHandlerTestSuite) commentsPath() string {
+	return "/tasks/" + suite.task.ID.String() + "/comments"
+}
+
+func (suite *CommentHandlerTestSuite) createComment(body string) dto.CommentResponse {
+	alice := callerContext("alice", middleware.RoleMember)
+	w := suite.requestWithContext(alice, http.MethodPost, suite.commentsPath(), dto.CreateCommentRequest{Body: body})
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var response dto.CommentResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func (suite *CommentHandlerTestSuite) TestCreateComment() {
+	created := suite.createComment("Looks good")
+	assert.NotEqual(suite.T(), uuid.Nil, created.ID)
+	assert.Equal(suite.T(), suite.task.ID, created.TaskID)
+	assert.Equal(suite.T(), "alice", created.Author)
+	assert.Equal(suite.T(), "Looks good", created.Body)
+	assert.NotEmpty(suite.T(), created.CreatedAt)
+
+	// The author is the caller, whatever the body says
+	bob := callerContext("bob", middleware.RoleMember)
+	w := suite.requestWithContext(bob, http.MethodPost, suite.commentsPath(), map[string]any{"author": "alice", "body": "Forged"})
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+	var forged dto.CommentResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &forged))
+	assert.Equal(suite.T(), "bob", forged.Author)
+
+	// Callers that were not authenticated may only read
+	w = suite.requestWithContext(context.TODO(), http.MethodPost, suite.commentsPath(), dto.CreateCommentRequest{Body: "Anonymous"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *CommentHandlerTestSuite) TestCreateComment_Invalid() {
+	w := suite.request(http.MethodPost, suite.commentsPath(), map[string]any{"author": "alice"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request(http.MethodPost, "/tasks/not-a-uuid/comments", dto.CreateCommentRequest{Body: "Hi"})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request(http.MethodPost, "/tasks/"+uuid.New().String()+"/comments", dto.CreateCommentRequest{Body: "Hi"})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CommentHandlerTestSuite) TestListComments_Pagination() {
+	for _, body := range []string{"First", "Second", "Third"} {
+		suite.createComment(body)
+	}
+
+	w := suite.request(http.MethodGet, suite.commentsPath()+"?page=1&limit=2", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.CommentListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(3), response.Total)
+	assert.Equal(suite.T(), 1, response.Page)
+	assert.Equal(suite.T(), 2, response.Limit)
+	assert.True(suite.T(), response.HasNext)
+	assert.False(suite.T(), response.HasPrevious)
+	require.Len(suite.T(), response.Comments, 2)
+	assert.Equal(suite.T(), "First", response.Comments[0].Body)
+	assert.Equal(suite.T(), "Second", response.Comments[1].Body)
+}
+
+func (suite *CommentHandlerTestSuite) TestListComments_TaskNotFound() {
+	w := suite.request(http.MethodGet, "/tasks/"+uuid.New().String()+"/comments", nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CommentHandlerTestSuite) TestGetAndUpdateComment() {
+	created := suite.createComment("Draft")
+	path := suite.commentsPath() + "/" + created.ID.String()
+
+	w := suite.request(http.MethodPut, path, dto.UpdateCommentRequest{Body: "Final"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request(http.MethodGet, path, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response dto.CommentResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Final", response.Body)
+
+	w = suite.request(http.MethodPut, path, dto.UpdateCommentRequest{})
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w = suite.request(http.MethodGet, suite.commentsPath()+"/"+uuid.New().String(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodGet, suite.commentsPath()+"/not-a-uuid", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *CommentHandlerTestSuite) TestDeleteComment() {
+	created := suite.createComment("Obsolete")
+	path := suite.commentsPath() + "/" + created.ID.String()
+
+	w := suite.request(http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	w = suite.request(http.MethodGet, path, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CommentHandlerTestSuite) TestDeletedTaskHidesComments() {
+	created := suite.createComment("Note")
+	require.NoError(suite.T(), suite.db.Delete(context.TODO(), suite.task.ID, database.AnyVersion))
+
+	w := suite.request(http.MethodGet, suite.commentsPath(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodGet, suite.commentsPath()+"/"+created.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *CommentHandlerTestSuite) TestCommentRoles() {
+	created := suite.createComment("Mine")
+	path := suite.commentsPath() + "/" + created.ID.String()
+	viewer, bob, alice := callerContext("alice", middleware.RoleViewer), callerContext("bob", middleware.RoleMember),
+		callerContext("alice", middleware.RoleMember)
+
+	// Viewers cannot write comments, not even their own
+	w := suite.requestWithContext(viewer, http.MethodPost, suite.commentsPath(), dto.CreateCommentRequest{Body: "Hi"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(viewer, http.MethodPut, path, dto.UpdateCommentRequest{Body: "Edited"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(viewer, http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	// Members can only change the comments they wrote
+	w = suite.requestWithContext(bob, http.MethodPut, path, dto.UpdateCommentRequest{Body: "Edited"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(bob, http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(alice, http.MethodPut, path, dto.UpdateCommentRequest{Body: "Edited"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.requestWithContext(callerContext("carol", middleware.RoleAdmin), http.MethodPut, path, dto.UpdateCommentRequest{Body: "Moderated"})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.requestWithContext(alice, http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	// Everyone may read comments
+	w = suite.requestWithContext(viewer, http.MethodGet, suite.commentsPath(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
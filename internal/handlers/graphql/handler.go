@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/workflow"
+	gql "taheri24.ir/graph1/pkg/graphql"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxQueryDepth and MaxQueryComplexity bound how deeply operations may nest fields and how many fields they
+// may select, counting the fields below a list once per item, since blockers, dependents and the tasks of
+// assignees nest without end. A page of 100 tasks may select about 20 fields of each.
+const (
+	MaxQueryDepth      = 8
+	MaxQueryComplexity = 2500
+)
+
+// GraphQLHandler serves the GraphQL endpoint over the task repository and cache
+type GraphQLHandler struct {
+	repo     database.TaskRepository
+	cache    cache.CacheInterface[models.Task]
+	workflow *workflow.Workflow
+	schema   *gql.Schema
+}
+
+// NewGraphQLHandler creates a new GraphQLHandler enforcing the given status workflow
+func NewGraphQLHandler(repo database.TaskRepository, cache cache.CacheInterface[models.Task], wf *workflow.Workflow) *GraphQLHandler {
+	h := &GraphQLHandler{repo: repo, cache: cache, workflow: wf}
+	h.schema = h.buildSchema()
+	h.schema.MaxDepth, h.schema.MaxComplexity = MaxQueryDepth, MaxQueryComplexity
+	return h
+}
+
+// Query handles GET and POST /graphql
+// @Summary Execute a GraphQL operation
+// @Description Run a GraphQL query or mutation over tasks. GET accepts query, operationName and variables as query parameters and only runs queries. Queries need the tasks:read scope and mutations the tasks:write scope; operations nesting fields more than 8 deep or selecting more than 2500 fields, counting the fields below a list once per item it may return, are rejected.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body graphql.Request true "GraphQL request"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} graphql.Response
+// @Failure 403 {object} graphql.Response
+// @Failure 405 {object} graphql.Response
+// @Router /api/v1/graphql [post]
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req gql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, errorResponse("Variables are invalid JSON."))
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid GraphQL request body", "error", err)
+		c.JSON(http.StatusBadRequest, errorResponse("Request body is not a valid GraphQL request."))
+		return
+	}
+
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, errorResponse("Must provide query string."))
+		return
+	}
+
+	// Documents that do not parse are answered by Execute, as queries
+	opType := "query"
+	if doc, err := gql.Parse(req.Query); err == nil {
+		if op, err := doc.Operation(req.OperationName); err == nil {
+			opType = op.Type
+		}
+	}
+	if c.Request.Method == http.MethodGet && opType != "query" {
+		c.JSON(http.StatusMethodNotAllowed, errorResponse("Can only perform a "+opType+" operation from a POST request."))
+		return
+	}
+	// Queries need the tasks:read scope and mutations the tasks:write scope, whichever method carries them
+	scope := middleware.ScopeTasksRead
+	if opType == "mutation" {
+		scope = middleware.ScopeTasksWrite
+	}
+	if !middleware.HasScope(c.Request.Context(), scope) {
+		logger.Info("Request lacks a scope", "scope", scope, "operation", req.OperationName)
+		c.JSON(http.StatusForbidden, errorResponse("This credential was not granted the "+scope+" scope."))
+		return
+	}
+
+	// Relations of the tasks are loaded in batches over the whole operation
+	ctx := contextWithLoader(c.Request.Context(), newTaskLoader(h.repo))
+	response := h.schema.Execute(ctx, req)
+	if len(response.Errors) > 0 {
+		logger.Info("GraphQL operation completed with errors", "operation", req.OperationName, "errors", len(response.Errors))
+	} else {
+		logger.Info("GraphQL operation executed successfully", "operation", req.OperationName)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func errorResponse(message string) gql.Response {
+	return gql.Response{Errors: []*gql.Error{{Message: message}}}
+}
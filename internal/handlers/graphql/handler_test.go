@@ -0,0 +1,488 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
+	"taheri24.ir/graph1/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type GraphQLHandlerTestSuite struct {
+	suite.Suite
+	db     *database.Database
+	cache  cache.CacheInterface[models.Task]
+	router *gin.Engine
+}
+
+func (suite *GraphQLHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(suite.T(), err)
+	suite.db = db
+	taskCache := cache.NewInMemoryCacheImpl[models.Task]()
+	// The requests of the suite act on the default tenant
+	suite.cache = taskCache.Section(middleware.DefaultTenant)
+
+	handler := NewGraphQLHandler(suite.db, taskCache, workflow.Default())
+	suite.router = gin.New()
+	suite.router.GET("/graphql", handler.Query)
+	suite.router.POST("/graphql", handler.Query)
+}
+
+func (suite *GraphQLHandlerTestSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestGraphQLHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(GraphQLHandlerTestSuite))
+}
+
+type graphQLResult struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+		Path    []any  `json:"path"`
+	} `json:"errors"`
+}
+
+// post runs query as an admin
+func (suite *GraphQLHandlerTestSuite) post(query string, variables map[string]any) (*httptest.ResponseRecorder, graphQLResult) {
+	admin := middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), "admin"), middleware.RoleAdmin)
+	return suite.postWithContext(middleware.ContextWithScopes(admin, middleware.AllScopes), query, variables)
+}
+
+func (suite *GraphQLHandlerTestSuite) postWithContext(ctx context.Context, query string, variables map[string]any) (*httptest.ResponseRecorder, graphQLResult) {
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	return suite.serve(req)
+}
+
+func (suite *GraphQLHandlerTestSuite) serve(req *http.Request) (*httptest.ResponseRecorder, graphQLResult) {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	var result graphQLResult
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &result))
+	return w, result
+}
+
+func (suite *GraphQLHandlerTestSuite) createTask(title, assignee string, status types.TaskStatus) models.Task {
+	if assignee != "" {
+		err := suite.db.CreateUser(context.TODO(), &models.User{Username: assignee})
+		if !errors.Is(err, database.ErrUserExists) {
+			require.NoError(suite.T(), err)
+		}
+	}
+	task := models.Task{Title: title, Assignee: assignee, Status: status}
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &task))
+	return task
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTaskWithRelations() {
+	design := suite.createTask("Design", "alice", types.StatusCompleted)
+	build := suite.createTask("Build", "bob", types.StatusPending)
+	ship := suite.createTask("Ship", "bob", types.StatusPending)
+	require.NoError(suite.T(), suite.db.AddDependency(context.TODO(), build.ID, design.ID))
+	require.NoError(suite.T(), suite.db.AddDependency(context.TODO(), ship.ID, build.ID))
+
+	w, result := suite.post(`query ($id: ID!) {
+		task(id: $id) {
+			title
+			blockers { title assignee { name } }
+			dependents { title }
+			assignee { name tasks(status: "pending") { total tasks { title } } }
+		}
+	}`, map[string]any{"id": build.ID.String()})
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{
+		"title":      "Build",
+		"blockers":   []any{map[string]any{"title": "Design", "assignee": map[string]any{"name": "alice"}}},
+		"dependents": []any{map[string]any{"title": "Ship"}},
+		"assignee": map[string]any{
+			"name": "bob",
+			"tasks": map[string]any{
+				"total": float64(2),
+				"tasks": []any{map[string]any{"title": "Build"}, map[string]any{"title": "Ship"}},
+			},
+		},
+	}, result.Data["task"])
+
+	// The task is cached after the first lookup
+	cached, err := suite.cache.Get(build.ID.String())
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Build", cached.Title)
+}
+
+// countingRepository counts the lookups of the relations of tasks
+type countingRepository struct {
+	database.TaskRepository
+	blockers, dependents, labels int
+}
+
+func (r *countingRepository) GetBlockersForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error) {
+	r.blockers++
+	return r.TaskRepository.GetBlockersForTasks(ctx, taskIDs)
+}
+
+func (r *countingRepository) GetDependentsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error) {
+	r.dependents++
+	return r.TaskRepository.GetDependentsForTasks(ctx, taskIDs)
+}
+
+func (r *countingRepository) GetLabelsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+	r.labels++
+	return r.TaskRepository.GetLabelsForTasks(ctx, taskIDs)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestRelationsBatched() {
+	var tasks []models.Task
+	for _, title := range []string{"A", "B", "C", "D", "E"} {
+		tasks = append(tasks, suite.createTask(title, "", types.StatusPending))
+	}
+	// Every task is blocked by the one before it
+	for i := 1; i < len(tasks); i++ {
+		require.NoError(suite.T(), suite.db.AddDependency(context.TODO(), tasks[i].ID, tasks[i-1].ID))
+	}
+	label := models.Label{Name: "bug"}
+	require.NoError(suite.T(), suite.db.CreateLabel(context.TODO(), &label))
+	require.NoError(suite.T(), suite.db.AddLabel(context.TODO(), tasks[2].ID, label.ID))
+
+	repo := &countingRepository{TaskRepository: suite.db}
+	handler := NewGraphQLHandler(repo, cache.NewInMemoryCacheImpl[models.Task](), workflow.Default())
+	suite.router = gin.New()
+	suite.router.POST("/graphql", handler.Query)
+
+	_, result := suite.post(`{ tasks(page: 2, limit: 2) { tasks { title labels { name } dependents { title }
+		blockers { title labels { name } blockers { title } } } } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	list := result.Data["tasks"].(map[string]any)["tasks"].([]any)
+	require.Len(suite.T(), list, 2)
+	assert.Equal(suite.T(), map[string]any{
+		"title":      "D",
+		"labels":     []any{},
+		"dependents": []any{map[string]any{"title": "E"}},
+		"blockers": []any{map[string]any{
+			"title":    "C",
+			"labels":   []any{map[string]any{"name": "bug"}},
+			"blockers": []any{map[string]any{"title": "B"}},
+		}},
+	}, list[1])
+
+	// One lookup per relation and level, however many tasks were listed; the blockers of C and D are
+	// loaded together, and then those of B, the only one of them that was not listed
+	assert.Equal(suite.T(), 2, repo.blockers)
+	assert.Equal(suite.T(), 1, repo.dependents)
+	assert.Equal(suite.T(), 2, repo.labels)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTaskNotFound() {
+	_, result := suite.post(`{ task(id: "`+uuid.New().String()+`") { id } }`, nil)
+	assert.Empty(suite.T(), result.Errors)
+	assert.Nil(suite.T(), result.Data["task"])
+
+	_, result = suite.post(`{ task(id: "nope") { id } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Equal(suite.T(), "Invalid task ID", result.Errors[0].Message)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTasksPagination() {
+	for _, title := range []string{"A", "B", "C"} {
+		suite.createTask(title, "", types.StatusPending)
+	}
+
+	_, result := suite.post(`{ tasks(page: 2, limit: 2) { total page limit hasNext hasPrevious tasks { title } } }`, nil)
+	assert.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{
+		"total": float64(3), "page": float64(2), "limit": float64(2),
+		"hasNext": false, "hasPrevious": true,
+		"tasks": []any{map[string]any{"title": "C"}},
+	}, result.Data["tasks"])
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTasksLabels() {
+	crash := suite.createTask("Crash", "", types.StatusPending)
+	endpoint := suite.createTask("Endpoint", "", types.StatusPending)
+	suite.createTask("Docs", "", types.StatusPending)
+	bug := models.Label{Name: "bug", Color: "#ff0000"}
+	backend := models.Label{Name: "backend"}
+	require.NoError(suite.T(), suite.db.CreateLabel(context.TODO(), &bug))
+	require.NoError(suite.T(), suite.db.CreateLabel(context.TODO(), &backend))
+	require.NoError(suite.T(), suite.db.AddLabel(context.TODO(), crash.ID, bug.ID))
+	require.NoError(suite.T(), suite.db.AddLabel(context.TODO(), crash.ID, backend.ID))
+	require.NoError(suite.T(), suite.db.AddLabel(context.TODO(), endpoint.ID, backend.ID))
+
+	_, result := suite.post(`{ tasks(labels: "bug,backend") { total tasks { title labels { name color } } } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{
+		"total": float64(2),
+		"tasks": []any{
+			map[string]any{"title": "Crash", "labels": []any{
+				map[string]any{"name": "backend", "color": ""},
+				map[string]any{"name": "bug", "color": "#ff0000"},
+			}},
+			map[string]any{"title": "Endpoint", "labels": []any{map[string]any{"name": "backend", "color": ""}}},
+		},
+	}, result.Data["tasks"])
+
+	_, result = suite.post(`{ tasks(labels: "bug,backend", labelMode: "all") { tasks { title } } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{"tasks": []any{map[string]any{"title": "Crash"}}}, result.Data["tasks"])
+
+	_, result = suite.post(`{ tasks(labels: "bug", labelMode: "some") { total } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Equal(suite.T(), "labelMode must be one of: all, any", result.Errors[0].Message)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestMutations() {
+	_, result := suite.post(`mutation ($input: CreateTaskInput!) { createTask(input: $input) { id title status estimate priority dueAt } }`,
+		map[string]any{"input": map[string]any{"title": "New", "estimate": 3, "priority": "high", "dueAt": "2030-01-02T15:00:00Z"}})
+	require.Empty(suite.T(), result.Errors)
+	created := result.Data["createTask"].(map[string]any)
+	assert.Equal(suite.T(), "New", created["title"])
+	assert.Equal(suite.T(), "pending", created["status"])
+	assert.Equal(suite.T(), float64(3), created["estimate"])
+	assert.Equal(suite.T(), "high", created["priority"])
+	assert.Equal(suite.T(), "2030-01-02T15:00:00Z", created["dueAt"])
+	id := created["id"].(string)
+
+	require.NoError(suite.T(), suite.cache.Set(id, models.Task{Title: "stale"}))
+	_, result = suite.post(`mutation { updateTask(id: "`+id+`", input: {status: "in_progress"}) { title status } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{"title": "New", "status": "in_progress"}, result.Data["updateTask"])
+	cached, _ := suite.cache.Get(id)
+	assert.Nil(suite.T(), cached)
+
+	_, result = suite.post(`mutation { deleteTask(id: "`+id+`") }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), true, result.Data["deleteTask"])
+
+	_, result = suite.post(`mutation { updateTask(id: "`+id+`", input: {title: "Gone"}) { id } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Equal(suite.T(), "Task not found", result.Errors[0].Message)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestMutationsAssignees() {
+	carol := models.User{Username: "carol"}
+	require.NoError(suite.T(), suite.db.CreateUser(context.TODO(), &carol))
+
+	_, result := suite.post(`mutation ($input: CreateTaskInput!) { createTask(input: $input) { id assignee { id name } } }`,
+		map[string]any{"input": map[string]any{"title": "New", "assigneeId": carol.ID.String()}})
+	require.Empty(suite.T(), result.Errors)
+	created := result.Data["createTask"].(map[string]any)
+	assert.Equal(suite.T(), map[string]any{"id": carol.ID.String(), "name": "carol"}, created["assignee"])
+
+	_, result = suite.post(`mutation { createTask(input: {title: "Stray", assignee: "mallory"}) { id } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "unknown assignee")
+	_, result = suite.post(`mutation { updateTask(id: "`+created["id"].(string)+`", input: {assignee: "mallory"}) { id } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "unknown assignee")
+
+	_, result = suite.post(`mutation { updateTask(id: "`+created["id"].(string)+`", input: {assignee: ""}) { assignee { name } } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{"assignee": nil}, result.Data["updateTask"])
+}
+
+func (suite *GraphQLHandlerTestSuite) TestMutationsAuthorized() {
+	mine := models.Task{Title: "Mine", Status: types.StatusPending}
+	require.NoError(suite.T(), suite.db.Create(middleware.ContextWithActor(context.TODO(), "alice"), &mine))
+	theirs := suite.createTask("Theirs", "bob", types.StatusPending)
+
+	postAs := func(role, query string) graphQLResult {
+		body, _ := json.Marshal(map[string]any{"query": query})
+		ctx := middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), "alice"), role)
+		ctx = middleware.ContextWithScopes(ctx, middleware.AllScopes)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/graphql", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		_, result := suite.serve(req)
+		return result
+	}
+
+	result := postAs(middleware.RoleViewer, `mutation { createTask(input: {title: "Nope"}) { id } }`)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "the viewer role cannot create tasks")
+
+	result = postAs(middleware.RoleMember, `mutation { updateTask(id: "`+mine.ID.String()+`", input: {title: "Renamed"}) { title } }`)
+	require.Empty(suite.T(), result.Errors)
+	result = postAs(middleware.RoleMember, `mutation { updateTask(id: "`+theirs.ID.String()+`", input: {title: "Renamed"}) { title } }`)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "members can only edit tasks they created or are assigned to")
+
+	result = postAs(middleware.RoleMember, `mutation { deleteTask(id: "`+theirs.ID.String()+`") }`)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "members can only delete tasks they created")
+	result = postAs(middleware.RoleMember, `mutation { deleteTask(id: "`+mine.ID.String()+`") }`)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), true, result.Data["deleteTask"])
+
+	result = postAs(middleware.RoleAdmin, `mutation { deleteTask(id: "`+theirs.ID.String()+`") }`)
+	require.Empty(suite.T(), result.Errors)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestOperationScopes() {
+	task := suite.createTask("Design", "", types.StatusPending)
+
+	postWith := func(scopes []string, query string) (*httptest.ResponseRecorder, graphQLResult) {
+		body, _ := json.Marshal(map[string]any{"query": query})
+		ctx := middleware.ContextWithScopes(middleware.ContextWithSubject(context.TODO(), "ci"), scopes)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/graphql", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		return suite.serve(req)
+	}
+	query := `{ task(id: "` + task.ID.String() + `") { title } }`
+	mutation := `mutation { createTask(input: {title: "Build"}) { id } }`
+
+	// A read-only credential may POST queries but not mutations
+	w, result := postWith([]string{middleware.ScopeTasksRead}, query)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Empty(suite.T(), result.Errors)
+	w, result = postWith([]string{middleware.ScopeTasksRead}, mutation)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "tasks:write")
+
+	// A write-only credential may run mutations but not queries
+	w, _ = postWith([]string{middleware.ScopeTasksWrite}, mutation)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w, result = postWith([]string{middleware.ScopeTasksWrite}, query)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	assert.Contains(suite.T(), result.Errors[0].Message, "tasks:read")
+
+	// Callers that were not authenticated may only query
+	w, result = suite.postWithContext(context.TODO(), query, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	require.Empty(suite.T(), result.Errors)
+	w, _ = suite.postWithContext(context.TODO(), mutation, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestQueryLimits() {
+	task := suite.createTask("Design", "alice", types.StatusPending)
+
+	// Assignees and their tasks nest without end
+	nested := "title"
+	for range MaxQueryDepth / 2 {
+		nested = "assignee { tasks(limit: 1) { tasks { " + nested + " } } }"
+	}
+	w, result := suite.post(`{ task(id: "`+task.ID.String()+`") { `+nested+` } }`, nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Nil(suite.T(), result.Data)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "more than the maximum of 8")
+
+	_, result = suite.post(`{ task(id: "`+task.ID.String()+`") { assignee { tasks { tasks { title } } } } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+
+	// Fields below a list count once per item it may return
+	_, result = suite.post(`{ tasks(limit: 100) { tasks { title blockers { title } } } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	_, result = suite.post(`{ tasks(limit: 100) { tasks { title blockers { title blockers { title } } } } }`, nil)
+	assert.Nil(suite.T(), result.Data)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Equal(suite.T(), "Operation selects more than 2500 fields.", result.Errors[0].Message)
+	_, result = suite.post(`query ($limit: Int) { tasks(limit: $limit) { tasks { title blockers { title blockers { title } } } } }`,
+		map[string]any{"limit": 10})
+	require.Empty(suite.T(), result.Errors)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestSubtasks() {
+	epic := suite.createTask("Epic", "", types.StatusPending)
+
+	_, result := suite.post(`mutation ($input: CreateTaskInput!) { createTask(input: $input) { id parentId } }`,
+		map[string]any{"input": map[string]any{"title": "Story", "parentId": epic.ID.String()}})
+	require.Empty(suite.T(), result.Errors)
+	story := result.Data["createTask"].(map[string]any)
+	assert.Equal(suite.T(), epic.ID.String(), story["parentId"])
+
+	_, result = suite.post(`mutation { updateTask(id: "`+epic.ID.String()+`", input: {parentId: "`+story["id"].(string)+`"}) { id } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Equal(suite.T(), "task cannot be its own ancestor", result.Errors[0].Message)
+
+	_, result = suite.post(`mutation { deleteTask(id: "`+epic.ID.String()+`") }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "Task has subtasks")
+
+	_, result = suite.post(`mutation { deleteTask(id: "`+epic.ID.String()+`", cascade: true) }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	_, result = suite.post(`{ task(id: "`+story["id"].(string)+`") { id } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Nil(suite.T(), result.Data["task"])
+}
+
+func (suite *GraphQLHandlerTestSuite) TestStatusWorkflow() {
+	done := suite.createTask("Done", "", types.StatusCompleted)
+
+	_, result := suite.post(`mutation { updateTask(id: "`+done.ID.String()+`", input: {status: "pending"}) { status } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Equal(suite.T(), "cannot change status from completed to pending; allowed: in_progress", result.Errors[0].Message)
+
+	_, result = suite.post(`mutation { updateTask(id: "`+done.ID.String()+`", input: {status: "in_progress"}) { status } }`, nil)
+	require.Empty(suite.T(), result.Errors)
+	assert.Equal(suite.T(), map[string]any{"status": "in_progress"}, result.Data["updateTask"])
+}
+
+func (suite *GraphQLHandlerTestSuite) TestCreateTaskValidation() {
+	_, result := suite.post(`mutation { createTask(input: {title: "", status: "bogus"}) { id } }`, nil)
+	require.Len(suite.T(), result.Errors, 1)
+	assert.Contains(suite.T(), result.Errors[0].Message, "title is required")
+	assert.Equal(suite.T(), []any{"createTask"}, result.Errors[0].Path)
+	assert.Nil(suite.T(), result.Data["createTask"])
+}
+
+func (suite *GraphQLHandlerTestSuite) TestGetRequests() {
+	suite.createTask("Listed", "", types.StatusPending)
+
+	params := url.Values{"query": {`query ($limit: Int) { tasks(limit: $limit) { total } }`}, "variables": {`{"limit": 5}`}}
+	req, _ := http.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+	w, result := suite.serve(req)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), map[string]any{"total": float64(1)}, result.Data["tasks"])
+
+	params = url.Values{"query": {`mutation { deleteTask(id: "x") }`}}
+	req, _ = http.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+	w, _ = suite.serve(req)
+	assert.Equal(suite.T(), http.StatusMethodNotAllowed, w.Code)
+
+	params = url.Values{"query": {`{ tasks { total } }`}, "variables": {`{`}}
+	req, _ = http.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+	w, _ = suite.serve(req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *GraphQLHandlerTestSuite) TestBadRequests() {
+	w, result := suite.post("", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Equal(suite.T(), "Must provide query string.", result.Errors[0].Message)
+
+	req, _ := http.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString("not json"))
+	req.Header.Set("Content-Type", "application/json")
+	w, _ = suite.serve(req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+	w, result = suite.post("{ tasks {", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Nil(suite.T(), result.Data)
+	assert.Len(suite.T(), result.Errors, 1)
+}
@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"context"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// loaderKey is the context key of the taskLoader of an operation
+type loaderKey struct{}
+
+// taskLoader batches the blockers, dependents and labels of the tasks of one operation. Every task a resolver
+// returns is tracked, and the first lookup of a relation loads it for all the tracked tasks in one query, so
+// that selecting blockers on a page of tasks, and their blockers in turn, takes one query per level instead of
+// one per task. It lives as long as the request and is not safe for concurrent use, like the executor.
+type taskLoader struct {
+	tracked    []uuid.UUID
+	seen       map[uuid.UUID]bool
+	blockers   relation[models.Task]
+	dependents relation[models.Task]
+	labels     relation[models.Label]
+}
+
+// relation holds the items of a relation loaded so far, keyed by task
+type relation[T any] struct {
+	load   func(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]T, error)
+	loaded map[uuid.UUID][]T
+}
+
+func newTaskLoader(repo database.TaskRepository) *taskLoader {
+	return &taskLoader{
+		seen:       map[uuid.UUID]bool{},
+		blockers:   relation[models.Task]{load: repo.GetBlockersForTasks, loaded: map[uuid.UUID][]models.Task{}},
+		dependents: relation[models.Task]{load: repo.GetDependentsForTasks, loaded: map[uuid.UUID][]models.Task{}},
+		labels:     relation[models.Label]{load: repo.GetLabelsForTasks, loaded: map[uuid.UUID][]models.Label{}},
+	}
+}
+
+// contextWithLoader returns a copy of ctx carrying loader
+func contextWithLoader(ctx context.Context, loader *taskLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+// loader returns the taskLoader of the operation behind ctx, or a new one when Query did not set one
+func (h *GraphQLHandler) loader(ctx context.Context) *taskLoader {
+	if loader, ok := ctx.Value(loaderKey{}).(*taskLoader); ok {
+		return loader
+	}
+	return newTaskLoader(h.repo)
+}
+
+// track remembers tasks, so that their relations are loaded together with those of the others
+func (l *taskLoader) track(tasks ...models.Task) {
+	for _, task := range tasks {
+		if !l.seen[task.ID] {
+			l.seen[task.ID] = true
+			l.tracked = append(l.tracked, task.ID)
+		}
+	}
+}
+
+// Blockers returns the tasks that must be finished before the task id
+func (l *taskLoader) Blockers(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+	return l.loadTasks(ctx, &l.blockers, id)
+}
+
+// Dependents returns the tasks waiting for the task id
+func (l *taskLoader) Dependents(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+	return l.loadTasks(ctx, &l.dependents, id)
+}
+
+// Labels returns the labels of the task id
+func (l *taskLoader) Labels(ctx context.Context, id uuid.UUID) ([]models.Label, error) {
+	l.track(models.Task{ID: id})
+	return get(ctx, &l.labels, l.tracked, id)
+}
+
+// loadTasks returns the tasks related to id through r and tracks every task the lookup brought in, so that
+// the next level of the operation is batched as well
+func (l *taskLoader) loadTasks(ctx context.Context, r *relation[models.Task], id uuid.UUID) ([]models.Task, error) {
+	l.track(models.Task{ID: id})
+	before := len(r.loaded)
+	tasks, err := get(ctx, r, l.tracked, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.loaded) != before {
+		for _, related := range r.loaded {
+			l.track(related...)
+		}
+	}
+	return tasks, nil
+}
+
+// get returns the items of the task id, loading them for every task in tracked that r has not loaded yet
+func get[T any](ctx context.Context, r *relation[T], tracked []uuid.UUID, id uuid.UUID) ([]T, error) {
+	if items, ok := r.loaded[id]; ok {
+		return items, nil
+	}
+
+	var missing []uuid.UUID
+	for _, taskID := range tracked {
+		if _, ok := r.loaded[taskID]; !ok {
+			missing = append(missing, taskID)
+		}
+	}
+	found, err := r.load(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, taskID := range missing {
+		items := found[taskID]
+		if items == nil {
+			items = []T{}
+		}
+		r.loaded[taskID] = items
+	}
+	return r.loaded[id], nil
+}
@@ -0,0 +1,544 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/handlers/task"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	gql "taheri24.ir/graph1/pkg/graphql"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// errTaskNotFound is reported when a mutation targets a task that does not exist
+var errTaskNotFound = errors.New("Task not found")
+
+// relationListSize is the number of blockers, dependents or labels a task is assumed to have when the cost
+// of an operation is measured, since those lists are not paged
+const relationListSize = 5
+
+// buildSchema wires the task types to resolvers backed by the handler's repository and cache.
+//
+//	type Query {
+//	  task(id: ID!): Task
+//	  tasks(status: String, assignee: String, due: String, labels: String, labelMode: String = "any",
+//	        page: Int = 1, limit: Int = 10, sort: String, order: String): TaskConnection
+//	}
+//	type Mutation {
+//	  createTask(input: CreateTaskInput!): Task
+//	  updateTask(id: ID!, input: UpdateTaskInput!): Task
+//	  deleteTask(id: ID!, cascade: Boolean = false): Boolean
+//	}
+//	type Task {
+//	  id, title, description, status, estimate, priority, dueAt, parentId, createdAt, updatedAt
+//	  assignee: Assignee
+//	  blockers: [Task]
+//	  dependents: [Task]
+//	  labels: [Label]
+//	}
+//	type Label { id, name, color }
+//	type Assignee { id: ID, name: String, tasks(status: String, page: Int, limit: Int, sort: String, order: String): TaskConnection }
+//	type TaskConnection { tasks: [Task], total, page, limit, hasNext, hasPrevious }
+func (h *GraphQLHandler) buildSchema() *gql.Schema {
+	taskType := &gql.Object{Name: "Task"}
+	connectionType := &gql.Object{Name: "TaskConnection"}
+	assigneeType := &gql.Object{Name: "Assignee"}
+	labelType := &gql.Object{Name: "Label", Fields: gql.Fields{
+		"id":    {Resolve: labelField(func(l models.Label) any { return l.ID.String() })},
+		"name":  {Resolve: labelField(func(l models.Label) any { return l.Name })},
+		"color": {Resolve: labelField(func(l models.Label) any { return l.Color })},
+	}}
+
+	listArgs := map[string]*gql.ArgumentConfig{
+		"status": {},
+		"page":   {Default: int64(1)},
+		"limit":  {Default: int64(10)},
+		"sort":   {},
+		"order":  {},
+	}
+
+	taskType.Fields = gql.Fields{
+		"id":          {Resolve: taskField(func(t models.Task) any { return t.ID.String() })},
+		"title":       {Resolve: taskField(func(t models.Task) any { return t.Title })},
+		"description": {Resolve: taskField(func(t models.Task) any { return t.Description })},
+		"status":      {Resolve: taskField(func(t models.Task) any { return string(t.Status) })},
+		"estimate":    {Resolve: taskField(func(t models.Task) any { return t.Estimate })},
+		"priority":    {Resolve: taskField(func(t models.Task) any { return string(t.Priority) })},
+		"version":     {Resolve: taskField(func(t models.Task) any { return t.Version })},
+		"createdAt":   {Resolve: taskField(func(t models.Task) any { return t.CreatedAt.Format(time.RFC3339) })},
+		"updatedAt":   {Resolve: taskField(func(t models.Task) any { return t.UpdatedAt.Format(time.RFC3339) })},
+		"dueAt": {
+			Resolve: taskField(func(t models.Task) any {
+				if t.DueAt == nil {
+					return nil
+				}
+				return t.DueAt.Format(time.RFC3339)
+			}),
+		},
+		"parentId": {
+			Resolve: taskField(func(t models.Task) any {
+				if t.ParentID == nil {
+					return nil
+				}
+				return t.ParentID.String()
+			}),
+		},
+		"assignee": {
+			Type: assigneeType,
+			Resolve: taskField(func(t models.Task) any {
+				if t.Assignee == "" {
+					return nil
+				}
+				user := models.User{Username: t.Assignee}
+				if t.AssigneeID != nil {
+					user.ID = *t.AssigneeID
+				}
+				return user
+			}),
+		},
+		"blockers": {
+			Type:     taskType,
+			ListSize: relationListSize,
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				tasks, err := h.loader(p.Context).Blockers(p.Context, p.Source.(models.Task).ID)
+				if err != nil {
+					return nil, h.internalError(p, "Failed to fetch task blockers", err)
+				}
+				return tasks, nil
+			},
+		},
+		"dependents": {
+			Type:     taskType,
+			ListSize: relationListSize,
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				tasks, err := h.loader(p.Context).Dependents(p.Context, p.Source.(models.Task).ID)
+				if err != nil {
+					return nil, h.internalError(p, "Failed to fetch task dependents", err)
+				}
+				return tasks, nil
+			},
+		},
+		"labels": {
+			Type:     labelType,
+			ListSize: relationListSize,
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				labels, err := h.loader(p.Context).Labels(p.Context, p.Source.(models.Task).ID)
+				if err != nil {
+					return nil, h.internalError(p, "Failed to fetch task labels", err)
+				}
+				return labels, nil
+			},
+		},
+	}
+
+	assigneeType.Fields = gql.Fields{
+		"id": {
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				if id := p.Source.(models.User).ID; id != uuid.Nil {
+					return id.String(), nil
+				}
+				return nil, nil
+			},
+		},
+		"name": {Resolve: func(p gql.ResolveParams) (any, error) { return p.Source.(models.User).Username, nil }},
+		"tasks": {
+			Type:    connectionType,
+			Args:    listArgs,
+			SizeArg: "limit",
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				return h.resolveTaskList(p, p.Source.(models.User).Username, "", "", "any")
+			},
+		},
+	}
+
+	connectionType.Fields = gql.Fields{
+		"tasks":       {Type: taskType},
+		"total":       {},
+		"page":        {},
+		"limit":       {},
+		"hasNext":     {},
+		"hasPrevious": {},
+	}
+
+	query := &gql.Object{Name: "Query", Fields: gql.Fields{
+		"task": {
+			Type:    taskType,
+			Args:    map[string]*gql.ArgumentConfig{"id": {NonNull: true}},
+			Resolve: h.resolveTask,
+		},
+		"tasks": {
+			Type:    connectionType,
+			SizeArg: "limit",
+			Args: map[string]*gql.ArgumentConfig{
+				"status":    listArgs["status"],
+				"assignee":  {},
+				"due":       {},
+				"labels":    {},
+				"labelMode": {Default: "any"},
+				"page":      listArgs["page"],
+				"limit":     listArgs["limit"],
+				"sort":      listArgs["sort"],
+				"order":     listArgs["order"],
+			},
+			Resolve: func(p gql.ResolveParams) (any, error) {
+				assignee, err := p.Args.String("assignee")
+				if err != nil {
+					return nil, err
+				}
+				due, err := p.Args.String("due")
+				if err != nil {
+					return nil, err
+				}
+				labels, err := p.Args.String("labels")
+				if err != nil {
+					return nil, err
+				}
+				labelMode, err := p.Args.String("labelMode")
+				if err != nil {
+					return nil, err
+				}
+				return h.resolveTaskList(p, assignee, due, labels, labelMode)
+			},
+		},
+	}}
+
+	mutation := &gql.Object{Name: "Mutation", Fields: gql.Fields{
+		"createTask": {
+			Type:    taskType,
+			Args:    map[string]*gql.ArgumentConfig{"input": {NonNull: true}},
+			Resolve: h.resolveCreateTask,
+		},
+		"updateTask": {
+			Type:    taskType,
+			Args:    map[string]*gql.ArgumentConfig{"id": {NonNull: true}, "input": {NonNull: true}},
+			Resolve: h.resolveUpdateTask,
+		},
+		"deleteTask": {
+			Args:    map[string]*gql.ArgumentConfig{"id": {NonNull: true}, "cascade": {Default: false}},
+			Resolve: h.resolveDeleteTask,
+		},
+	}}
+
+	return &gql.Schema{Query: query, Mutation: mutation}
+}
+
+// taskField adapts a models.Task accessor into a resolver
+func taskField(get func(models.Task) any) gql.ResolveFunc {
+	return func(p gql.ResolveParams) (any, error) {
+		return get(p.Source.(models.Task)), nil
+	}
+}
+
+// labelField adapts a models.Label accessor into a resolver
+func labelField(get func(models.Label) any) gql.ResolveFunc {
+	return func(p gql.ResolveParams) (any, error) {
+		return get(p.Source.(models.Label)), nil
+	}
+}
+
+// resolveTask mirrors GET /tasks/{id}: cache first, then the repository
+func (h *GraphQLHandler) resolveTask(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, err := middleware.TenantCache(p.Context, h.cache).Get(id.String()); err == nil && cached != nil {
+		h.loader(p.Context).track(*cached)
+		return *cached, nil
+	}
+
+	taskPtr, err := h.repo.GetByID(p.Context, id)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, h.internalError(p, "Failed to get task", err)
+	}
+
+	if err := middleware.TenantCache(p.Context, h.cache).Set(id.String(), *taskPtr); err != nil {
+		logger := middleware.GetLoggerFromContext(p.Context)
+		logger.Error("Failed to set task in cache", "id", id.String(), "error", err)
+	}
+	h.loader(p.Context).track(*taskPtr)
+	return *taskPtr, nil
+}
+
+// resolveTaskList mirrors GET /tasks pagination, filtering and sorting
+func (h *GraphQLHandler) resolveTaskList(p gql.ResolveParams, assignee, due, labels, labelMode string) (any, error) {
+	status, err := p.Args.String("status")
+	if err != nil {
+		return nil, err
+	}
+	page, err := p.Args.Int("page")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := p.Args.Int("limit")
+	if err != nil {
+		return nil, err
+	}
+	sort, err := p.Args.String("sort")
+	if err != nil {
+		return nil, err
+	}
+	order, err := p.Args.String("order")
+	if err != nil {
+		return nil, err
+	}
+	if errs := task.ValidateListSort(sort, order); len(errs) > 0 {
+		return nil, validationError(errs)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	opts := database.TaskListOptions{
+		Page:     page,
+		Limit:    limit,
+		Status:   status,
+		Assignee: assignee,
+		Sort:     sort,
+		Order:    order,
+	}
+	if due != "" && !task.ApplyDueFilter(&opts, due, time.Now()) {
+		return nil, errors.New("due must be one of: overdue, today, week")
+	}
+	if !task.ApplyLabelFilter(&opts, labels, labelMode) {
+		return nil, errors.New("labelMode must be one of: all, any")
+	}
+
+	tasks, total, err := h.repo.GetAll(p.Context, opts)
+	if err != nil {
+		return nil, h.internalError(p, "Failed to fetch tasks", err)
+	}
+	h.loader(p.Context).track(tasks...)
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return map[string]any{
+		"tasks":       tasks,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"hasNext":     page < totalPages,
+		"hasPrevious": page > 1,
+	}, nil
+}
+
+func (h *GraphQLHandler) resolveCreateTask(p gql.ResolveParams) (any, error) {
+	if err := authz.Authorize(p.Context, authz.CreateTask, nil); err != nil {
+		return nil, err
+	}
+	var req dto.CreateTaskRequest
+	if err := decodeInput(p.Args, &req); err != nil {
+		return nil, err
+	}
+	if errs := task.ValidateCreateTaskRequest(req, h.workflow); len(errs) > 0 {
+		return nil, validationError(errs)
+	}
+
+	newTask := task.BuildTask(req)
+	if err := h.repo.Create(p.Context, &newTask); err != nil {
+		if isParentError(err) || errors.Is(err, database.ErrUnknownAssignee) {
+			return nil, err
+		}
+		return nil, h.internalError(p, "Failed to create task", err)
+	}
+
+	logger := middleware.GetLoggerFromContext(p.Context)
+	logger.Info("Task created successfully", "id", newTask.ID.String(), "title", newTask.Title, "status", string(newTask.Status))
+	return newTask, nil
+}
+
+func (h *GraphQLHandler) resolveUpdateTask(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	var req dto.UpdateTaskRequest
+	if err := decodeInput(p.Args, &req); err != nil {
+		return nil, err
+	}
+	if errs := task.ValidateUpdateTaskRequest(req, h.workflow); len(errs) > 0 {
+		return nil, validationError(errs)
+	}
+
+	existing, err := h.repo.GetByID(p.Context, id)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			return nil, errTaskNotFound
+		}
+		return nil, h.internalError(p, "Failed to get task", err)
+	}
+	if err := authz.Authorize(p.Context, authz.EditTask, existing); err != nil {
+		return nil, err
+	}
+	if req.Status != nil {
+		if err := h.workflow.CheckTransition(existing.Status, *req.Status); err != nil {
+			return nil, err
+		}
+	}
+
+	task.ApplyUpdate(existing, req)
+	if err := h.repo.Update(p.Context, existing); err != nil {
+		if isParentError(err) || errors.Is(err, database.ErrUnknownAssignee) || errors.Is(err, database.ErrVersionConflict) {
+			return nil, err
+		}
+		return nil, h.internalError(p, "Failed to update task", err)
+	}
+	h.invalidate(p, id)
+
+	logger := middleware.GetLoggerFromContext(p.Context)
+	logger.Info("Task updated successfully", "id", id.String(), "title", existing.Title, "status", string(existing.Status))
+	return *existing, nil
+}
+
+func (h *GraphQLHandler) resolveDeleteTask(p gql.ResolveParams) (any, error) {
+	id, err := idArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	cascade, err := p.Args.Bool("cascade")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.authorizeDelete(p, id, cascade); err != nil {
+		return nil, err
+	}
+
+	deleted := []uuid.UUID{id}
+	if cascade {
+		deleted, err = h.repo.DeleteTree(p.Context, id, database.AnyVersion)
+	} else {
+		err = h.repo.Delete(p.Context, id, database.AnyVersion)
+	}
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			return nil, errTaskNotFound
+		}
+		if errors.Is(err, database.ErrTaskHasChildren) {
+			return nil, errors.New("Task has subtasks; delete them first or pass cascade: true")
+		}
+		return nil, h.internalError(p, "Failed to delete task", err)
+	}
+	for _, deletedID := range deleted {
+		h.invalidate(p, deletedID)
+	}
+
+	logger := middleware.GetLoggerFromContext(p.Context)
+	logger.Info("Task deleted successfully", "id", id.String(), "count", len(deleted))
+	return true, nil
+}
+
+// authorizeDelete checks that the caller may delete the task with the given id, together with all its
+// descendants when cascade is set, loading them only when the decision depends on them
+func (h *GraphQLHandler) authorizeDelete(p gql.ResolveParams, id uuid.UUID, cascade bool) error {
+	if !authz.NeedsTask(p.Context, authz.DeleteTask) {
+		return authz.Authorize(p.Context, authz.DeleteTask, nil)
+	}
+	var tasks []models.Task
+	var err error
+	if cascade {
+		tasks, err = h.repo.GetSubtree(p.Context, id)
+	} else {
+		var found *models.Task
+		if found, err = h.repo.GetByID(p.Context, id); err == nil {
+			tasks = []models.Task{*found}
+		}
+	}
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			return errTaskNotFound
+		}
+		return h.internalError(p, "Failed to get task", err)
+	}
+	return authz.AuthorizeAll(p.Context, authz.DeleteTask, tasks)
+}
+
+func (h *GraphQLHandler) invalidate(p gql.ResolveParams, id uuid.UUID) {
+	if err := middleware.TenantCache(p.Context, h.cache).Invalidate(id.String()); err != nil {
+		logger := middleware.GetLoggerFromContext(p.Context)
+		logger.Error("Failed to invalidate task cache", "id", id.String(), "error", err)
+	}
+}
+
+// isParentError reports whether err rejects the parent of a created or updated task
+func isParentError(err error) bool {
+	return errors.Is(err, database.ErrParentNotFound) || errors.Is(err, database.ErrParentCycle)
+}
+
+// internalError logs the underlying error and returns a message that is safe to show to clients
+func (h *GraphQLHandler) internalError(p gql.ResolveParams, message string, err error) error {
+	logger := middleware.GetLoggerFromContext(p.Context)
+	logger.Error(message, "error", err)
+	return errors.New(message)
+}
+
+func idArg(args gql.Args) (uuid.UUID, error) {
+	idStr, err := args.String("id")
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return uuid.Nil, errors.New("Invalid task ID")
+	}
+	return id, nil
+}
+
+// decodeInput maps the camelCase "input" argument onto a request DTO through its snake_case JSON tags
+func decodeInput(args gql.Args, target any) error {
+	input, err := args.Object("input")
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]any, len(input))
+	for key, value := range input {
+		fields[snakeCase(key)] = value
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("invalid input: %w", err)
+	}
+	return nil
+}
+
+// snakeCase converts a GraphQL field name such as dueAt into its JSON tag form due_at
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func validationError(errs []task.ValidationError) error {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
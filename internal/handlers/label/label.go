@@ -0,0 +1,304 @@
+package label
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// LabelHandler handles label-related HTTP requests
+type LabelHandler struct {
+	repo      database.LabelRepository
+	taskCache cache.CacheInterface[models.Task]
+}
+
+// NewLabelHandler creates a new LabelHandler. taskCache is the task cache, whose entries go stale
+// when a label on a cached task is renamed or deleted.
+func NewLabelHandler(repo database.LabelRepository, taskCache cache.CacheInterface[models.Task]) *LabelHandler {
+	return &LabelHandler{repo: repo, taskCache: taskCache}
+}
+
+// ListLabels handles GET /labels
+// @Summary List labels
+// @Description Retrieve every label ordered by name
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.LabelListResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/labels [get]
+func (h *LabelHandler) ListLabels(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	labels, err := h.repo.ListLabels(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to fetch labels", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch labels"))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.LabelListResponse{Labels: LabelsToResponses(labels)})
+}
+
+// CreateLabel handles POST /labels
+// @Summary Create a label
+// @Description Create a new label. Label names are unique and cannot contain commas. Viewers may not create labels.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param label body dto.CreateLabelRequest true "Label information"
+// @Success 201 {object} dto.LabelResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/labels [post]
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+	if !authz.Check(c, authz.ManageLabels, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.CreateLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for creating label", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+	name, ok := validateName(c, req.Name)
+	if !ok {
+		return
+	}
+
+	label := models.Label{ID: uuid.New(), Name: name, Color: req.Color}
+	if err := h.repo.CreateLabel(c.Request.Context(), &label); err != nil {
+		if errors.Is(err, database.ErrLabelExists) {
+			logger.Info("Label name already in use", "name", name)
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("Label already exists"))
+		} else {
+			logger.Error("Failed to create label", "name", name, "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to create label"))
+		}
+		return
+	}
+
+	logger.Info("Label created successfully", "id", label.ID.String(), "name", label.Name)
+	c.JSON(http.StatusCreated, labelToResponse(label))
+}
+
+// GetLabel handles GET /labels/{id}
+// @Summary Get a label by ID
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Label ID (UUID)"
+// @Success 200 {object} dto.LabelResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/labels/{id} [get]
+func (h *LabelHandler) GetLabel(c *gin.Context) {
+	label, ok := h.findLabel(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, labelToResponse(*label))
+}
+
+// UpdateLabel handles PUT /labels/{id}
+// @Summary Update a label
+// @Description Rename or recolor a label. Viewers may not change labels.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Label ID (UUID)"
+// @Param label body dto.UpdateLabelRequest true "Label updates"
+// @Success 200 {object} dto.LabelResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/labels/{id} [put]
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+	if !authz.Check(c, authz.ManageLabels, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.UpdateLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for updating label", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+
+	label, ok := h.findLabel(c)
+	if !ok {
+		return
+	}
+	if req.Name != nil {
+		if label.Name, ok = validateName(c, *req.Name); !ok {
+			return
+		}
+	}
+	if req.Color != nil {
+		label.Color = *req.Color
+	}
+
+	if err := h.repo.UpdateLabel(c.Request.Context(), label); err != nil {
+		if errors.Is(err, database.ErrLabelExists) {
+			logger.Info("Label name already in use", "name", label.Name)
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("Label already exists"))
+		} else {
+			logger.Error("Failed to update label", "id", label.ID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to update label"))
+		}
+		return
+	}
+
+	h.invalidateTasks(c, label.ID)
+
+	logger.Info("Label updated successfully", "id", label.ID.String(), "name", label.Name)
+	c.JSON(http.StatusOK, labelToResponse(*label))
+}
+
+// DeleteLabel handles DELETE /labels/{id}
+// @Summary Delete a label
+// @Description Delete a label and remove it from every task. Only admins may delete labels.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Label ID (UUID)"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/labels/{id} [delete]
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	if !authz.Check(c, authz.DeleteLabel, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseLabelID(c)
+	if !ok {
+		return
+	}
+
+	// The tasks have to be looked up before the label is detached from them
+	taskIDs, err := h.repo.GetLabelledTaskIDs(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("Failed to get labelled tasks", "id", id.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to delete label"))
+		return
+	}
+
+	if err := h.repo.DeleteLabel(c.Request.Context(), id); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Label not found for deletion", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Label not found"))
+		} else {
+			logger.Error("Failed to delete label", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to delete label"))
+		}
+		return
+	}
+
+	h.invalidateCachedTasks(c, taskIDs)
+
+	logger.Info("Label deleted successfully", "id", id.String())
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// invalidateTasks drops the tasks carrying labelID from the task cache
+func (h *LabelHandler) invalidateTasks(c *gin.Context, labelID uuid.UUID) {
+	taskIDs, err := h.repo.GetLabelledTaskIDs(c.Request.Context(), labelID)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Failed to get labelled tasks", "id", labelID.String(), "error", err)
+		return
+	}
+	h.invalidateCachedTasks(c, taskIDs)
+}
+
+// invalidateCachedTasks drops the given tasks from the task cache
+func (h *LabelHandler) invalidateCachedTasks(c *gin.Context, taskIDs []uuid.UUID) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	taskCache := middleware.TenantCache(c.Request.Context(), h.taskCache)
+	for _, taskID := range taskIDs {
+		if err := taskCache.Invalidate(taskID.String()); err != nil {
+			// Log error but don't fail the request
+			logger.Error("Failed to invalidate task cache", "id", taskID.String(), "error", err)
+		}
+	}
+}
+
+// findLabel loads the label named by the id path parameter, writing an error response when it cannot
+func (h *LabelHandler) findLabel(c *gin.Context) (*models.Label, bool) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseLabelID(c)
+	if !ok {
+		return nil, false
+	}
+
+	label, err := h.repo.GetLabel(c.Request.Context(), id)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Label not found", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Label not found"))
+		} else {
+			logger.Error("Failed to get label", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get label"))
+		}
+		return nil, false
+	}
+	return label, true
+}
+
+func parseLabelID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Invalid label ID provided", "idStr", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid label ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// validateName trims a label name and rejects blank names and names containing commas,
+// which separate label names in the labels filter of GET /tasks
+func validateName(c *gin.Context, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, ",") {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid label name", "name must be non-empty and cannot contain commas"))
+		return "", false
+	}
+	return name, true
+}
+
+func labelToResponse(label models.Label) dto.LabelResponse {
+	return dto.LabelResponse{ID: label.ID, Name: label.Name, Color: label.Color}
+}
+
+// LabelsToResponses converts models.Label to dto.LabelResponse
+func LabelsToResponses(labels []models.Label) []dto.LabelResponse {
+	responses := make([]dto.LabelResponse, len(labels))
+	for i, label := range labels {
+		responses[i] = labelToResponse(label)
+	}
+	return responses
+}
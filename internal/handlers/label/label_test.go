@@ -0,0 +1,242 @@
+package label
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LabelHandlerTestSuite struct {
+	suite.Suite
+	db        *database.Database
+	taskCache cache.CacheInterface[models.Task]
+	router    *gin.Engine
+}
+
+func (suite *LabelHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(suite.T(), err)
+	suite.db = db
+
+	taskCache := cache.NewInMemoryCacheImpl[models.Task]()
+	// The requests of the suite act on the default tenant
+	suite.taskCache = taskCache.Section(middleware.DefaultTenant)
+	handler := NewLabelHandler(suite.db, taskCache)
+	suite.router = gin.New()
+	suite.router.GET("/labels", handler.ListLabels)
+	suite.router.POST("/labels", handler.CreateLabel)
+	suite.router.GET("/labels/:id", handler.GetLabel)
+	suite.router.PUT("/labels/:id", handler.UpdateLabel)
+	suite.router.DELETE("/labels/:id", handler.DeleteLabel)
+}
+
+func (suite *LabelHandlerTestSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestLabelHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(LabelHandlerTestSuite))
+}
+
+// request makes a request as an admin
+func (suite *LabelHandlerTestSuite) request(method, path string, body any) *httptest.ResponseRecorder {
+	admin := middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), "admin"), middleware.RoleAdmin)
+	return suite.requestWithContext(admin, method, path, body)
+}
+
+func (suite *LabelHandlerTestSuite) requestWithContext(ctx context.Context, method, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *LabelHandlerTestSuite) createLabel(name string) dto.LabelResponse {
+	w := suite.request(http.MethodPost, "/labels", dto.CreateLabelRequest{Name: name})
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var response dto.LabelResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func (suite *LabelHandlerTestSuite) TestCreateLabel() {
+	w := suite.request(http.MethodPost, "/labels", dto.CreateLabelRequest{Name: "  bug ", Color: "#ff0000"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response dto.LabelResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEqual(suite.T(), uuid.Nil, response.ID)
+	assert.Equal(suite.T(), "bug", response.Name)
+	assert.Equal(suite.T(), "#ff0000", response.Color)
+}
+
+func (suite *LabelHandlerTestSuite) TestCreateLabelConflict() {
+	suite.createLabel("bug")
+
+	w := suite.request(http.MethodPost, "/labels", dto.CreateLabelRequest{Name: "bug"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *LabelHandlerTestSuite) TestCreateLabelInvalid() {
+	testCases := []struct {
+		name string
+		body any
+	}{
+		{"missing name", map[string]any{"color": "#ff0000"}},
+		{"blank name", dto.CreateLabelRequest{Name: "   "}},
+		{"comma in name", dto.CreateLabelRequest{Name: "bug,backend"}},
+		{"invalid color", dto.CreateLabelRequest{Name: "bug", Color: "red"}},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			w := suite.request(http.MethodPost, "/labels", tc.body)
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (suite *LabelHandlerTestSuite) TestListLabels() {
+	suite.createLabel("frontend")
+	suite.createLabel("backend")
+
+	w := suite.request(http.MethodGet, "/labels", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.LabelListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(suite.T(), response.Labels, 2)
+	assert.Equal(suite.T(), "backend", response.Labels[0].Name)
+	assert.Equal(suite.T(), "frontend", response.Labels[1].Name)
+}
+
+func (suite *LabelHandlerTestSuite) TestGetLabel() {
+	created := suite.createLabel("bug")
+
+	w := suite.request(http.MethodGet, "/labels/"+created.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.request(http.MethodGet, "/labels/"+uuid.New().String(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+	w = suite.request(http.MethodGet, "/labels/invalid-uuid", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *LabelHandlerTestSuite) TestUpdateLabel() {
+	created := suite.createLabel("bug")
+	suite.createLabel("backend")
+
+	color := "#123abc"
+	w := suite.request(http.MethodPut, "/labels/"+created.ID.String(), dto.UpdateLabelRequest{Color: &color})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.LabelResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "bug", response.Name)
+	assert.Equal(suite.T(), color, response.Color)
+
+	taken := "backend"
+	w = suite.request(http.MethodPut, "/labels/"+created.ID.String(), dto.UpdateLabelRequest{Name: &taken})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request(http.MethodPut, "/labels/"+uuid.New().String(), dto.UpdateLabelRequest{Color: &color})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *LabelHandlerTestSuite) TestDeleteLabel() {
+	created := suite.createLabel("bug")
+	task := models.Task{Title: "Crash", Status: types.StatusPending}
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &task))
+	require.NoError(suite.T(), suite.db.AddLabel(context.TODO(), task.ID, created.ID))
+
+	w := suite.request(http.MethodDelete, "/labels/"+created.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+
+	labels, err := suite.db.GetLabelsForTasks(context.TODO(), []uuid.UUID{task.ID})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), labels)
+
+	w = suite.request(http.MethodDelete, "/labels/"+created.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *LabelHandlerTestSuite) TestLabelChangesInvalidateTaskCache() {
+	created := suite.createLabel("bug")
+	labelled := models.Task{Title: "Crash", Status: types.StatusPending}
+	other := models.Task{Title: "Docs", Status: types.StatusPending}
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &labelled))
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &other))
+	require.NoError(suite.T(), suite.db.AddLabel(context.TODO(), labelled.ID, created.ID))
+
+	cached := func(id uuid.UUID) bool {
+		task, err := suite.taskCache.Get(id.String())
+		return err == nil && task != nil
+	}
+
+	require.NoError(suite.T(), suite.taskCache.Set(labelled.ID.String(), labelled))
+	require.NoError(suite.T(), suite.taskCache.Set(other.ID.String(), other))
+	name := "defect"
+	w := suite.request(http.MethodPut, "/labels/"+created.ID.String(), dto.UpdateLabelRequest{Name: &name})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.False(suite.T(), cached(labelled.ID))
+	assert.True(suite.T(), cached(other.ID))
+
+	require.NoError(suite.T(), suite.taskCache.Set(labelled.ID.String(), labelled))
+	w = suite.request(http.MethodDelete, "/labels/"+created.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.False(suite.T(), cached(labelled.ID))
+	assert.True(suite.T(), cached(other.ID))
+}
+
+func (suite *LabelHandlerTestSuite) TestLabelRoles() {
+	bug := suite.createLabel("bug")
+	path := "/labels/" + bug.ID.String()
+	caller := func(role string) context.Context {
+		return middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), "alice"), role)
+	}
+	viewer, member := caller(middleware.RoleViewer), caller(middleware.RoleMember)
+	name := "defect"
+
+	// Viewers cannot change labels
+	w := suite.requestWithContext(viewer, http.MethodPost, "/labels", dto.CreateLabelRequest{Name: "feature"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(viewer, http.MethodPut, path, dto.UpdateLabelRequest{Name: &name})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(viewer, http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(viewer, http.MethodGet, "/labels", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// Members create and rename labels, but only admins delete them
+	w = suite.requestWithContext(member, http.MethodPost, "/labels", dto.CreateLabelRequest{Name: "feature"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	w = suite.requestWithContext(member, http.MethodPut, path, dto.UpdateLabelRequest{Name: &name})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.requestWithContext(member, http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(caller(middleware.RoleAdmin), http.MethodDelete, path, nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+}
@@ -0,0 +1,204 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+// actAs makes the requests to the suite's router come from subject with role
+func (suite *TaskHandlerTestSuite) actAs(subject, role string) {
+	suite.router.Use(func(c *gin.Context) {
+		ctx := middleware.ContextWithSubject(c.Request.Context(), subject)
+		ctx = middleware.ContextWithActor(ctx, subject)
+		c.Request = c.Request.WithContext(middleware.ContextWithRole(ctx, role))
+	})
+}
+
+func (suite *TaskHandlerTestSuite) serve(method, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *TaskHandlerTestSuite) assertForbidden(w *httptest.ResponseRecorder, message string) {
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code, w.Body.String())
+	var response dto.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Forbidden", response.Error)
+	assert.Contains(suite.T(), response.Message, message)
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_ViewerCannotCreate() {
+	suite.actAs("alice", middleware.RoleViewer)
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		suite.Fail("viewers must not create tasks")
+		return nil
+	}
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+
+	w := suite.serve("POST", "/tasks", dto.CreateTaskRequest{Title: "Nope"})
+	suite.assertForbidden(w, "the viewer role cannot create tasks")
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_MemberEditsOwnAndAssignedTasks() {
+	suite.actAs("alice", middleware.RoleMember)
+	tasks := map[uuid.UUID]*models.Task{}
+	for _, task := range []models.Task{
+		{ID: uuid.New(), Title: "Created", Status: types.StatusPending, CreatedBy: "alice"},
+		{ID: uuid.New(), Title: "Assigned", Status: types.StatusPending, CreatedBy: "bob", Assignee: "alice"},
+		{ID: uuid.New(), Title: "Theirs", Status: types.StatusPending, CreatedBy: "bob"},
+	} {
+		tasks[task.ID] = &task
+	}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		task := *tasks[id]
+		return &task, nil
+	}
+	updated := 0
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, task *models.Task) error {
+		updated++
+		return nil
+	}
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+
+	for id, task := range tasks {
+		w := suite.serve("PUT", "/tasks/"+id.String(), dto.ReplaceTaskRequest{Title: "Renamed", Status: types.StatusPending})
+		if task.Title == "Theirs" {
+			suite.assertForbidden(w, "members can only edit tasks they created or are assigned to")
+		} else {
+			assert.Equal(suite.T(), http.StatusOK, w.Code, task.Title)
+		}
+	}
+	assert.Equal(suite.T(), 2, updated)
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_MemberDeletesOnlyOwnTasks() {
+	suite.actAs("alice", middleware.RoleMember)
+	own := models.Task{ID: uuid.New(), Title: "Own", CreatedBy: "alice"}
+	assigned := models.Task{ID: uuid.New(), Title: "Assigned", CreatedBy: "bob", Assignee: "alice"}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		if id == own.ID {
+			return &own, nil
+		}
+		return &assigned, nil
+	}
+	var deleted []uuid.UUID
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
+		deleted = append(deleted, id)
+		return nil
+	}
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+
+	assert.Equal(suite.T(), http.StatusNoContent, suite.serve("DELETE", "/tasks/"+own.ID.String(), nil).Code)
+	suite.assertForbidden(suite.serve("DELETE", "/tasks/"+assigned.ID.String(), nil),
+		"members can only delete tasks they created")
+	// Purging is for admins only, even for tasks of one's own
+	suite.assertForbidden(suite.serve("DELETE", "/tasks/"+own.ID.String()+"?purge=true", nil), "only admins can purge tasks")
+	assert.Equal(suite.T(), []uuid.UUID{own.ID}, deleted)
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_MemberCascadeNeedsWholeSubtree() {
+	suite.actAs("alice", middleware.RoleMember)
+	epic := models.Task{ID: uuid.New(), Title: "Epic", CreatedBy: "alice"}
+	story := models.Task{ID: uuid.New(), Title: "Story", CreatedBy: "bob", ParentID: &epic.ID}
+	suite.mockRepo.GetSubtreeFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+		return []models.Task{epic, story}, nil
+	}
+	suite.mockRepo.DeleteTreeFunc = func(ctx context.Context, id uuid.UUID, version int64) ([]uuid.UUID, error) {
+		suite.Fail("the subtree holds a task of someone else")
+		return nil, nil
+	}
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+
+	w := suite.serve("DELETE", "/tasks/"+epic.ID.String()+"?cascade=true", nil)
+	suite.assertForbidden(w, "members can only delete tasks they created")
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_MemberRestoresOwnTrashedTask() {
+	suite.actAs("alice", middleware.RoleMember)
+	own := models.Task{ID: uuid.New(), Title: "Own", CreatedBy: "alice"}
+	suite.mockRepo.GetByIDWithTrashedFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		if id == own.ID {
+			return &own, nil
+		}
+		return &models.Task{ID: id, CreatedBy: "bob"}, nil
+	}
+	suite.mockRepo.RestoreFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+		return []models.Task{own}, nil
+	}
+	suite.router.POST("/tasks/:id/restore", suite.handler.RestoreTask)
+
+	assert.Equal(suite.T(), http.StatusOK, suite.serve("POST", "/tasks/"+own.ID.String()+"/restore", nil).Code)
+	suite.assertForbidden(suite.serve("POST", "/tasks/"+uuid.NewString()+"/restore", nil),
+		"members can only delete tasks they created")
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_MemberChangesBlockersAndLabels() {
+	suite.actAs("alice", middleware.RoleMember)
+	theirs := models.Task{ID: uuid.New(), Title: "Theirs", CreatedBy: "bob"}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		if id == theirs.ID {
+			return &theirs, nil
+		}
+		return nil, gorm.ErrRecordNotFound
+	}
+	suite.router.POST("/tasks/:id/blockers", suite.handler.AddBlocker)
+	suite.router.DELETE("/tasks/:id/labels/:label_id", suite.handler.RemoveTaskLabel)
+
+	suite.assertForbidden(suite.serve("POST", "/tasks/"+theirs.ID.String()+"/blockers",
+		dto.AddDependencyRequest{BlockerID: uuid.New()}), "members can only edit tasks")
+	suite.assertForbidden(suite.serve("DELETE", "/tasks/"+theirs.ID.String()+"/labels/"+uuid.NewString(), nil),
+		"members can only edit tasks")
+	// Tasks that do not exist are not found rather than forbidden
+	assert.Equal(suite.T(), http.StatusNotFound,
+		suite.serve("POST", "/tasks/"+uuid.NewString()+"/blockers", dto.AddDependencyRequest{BlockerID: uuid.New()}).Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestAuthorize_BulkChecksEachOperation() {
+	suite.actAs("alice", middleware.RoleMember)
+	own := models.Task{ID: uuid.New(), Title: "Own", Status: types.StatusPending, CreatedBy: "alice"}
+	theirs := models.Task{ID: uuid.New(), Title: "Theirs", Status: types.StatusPending, CreatedBy: "bob"}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		if id == own.ID {
+			return &own, nil
+		}
+		return &theirs, nil
+	}
+	suite.mockRepo.TransactionFunc = func(ctx context.Context, fn func(repo database.TaskRepository) error) error {
+		return fn(suite.mockRepo)
+	}
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+
+	w := suite.serve("POST", "/tasks/bulk", []dto.BulkTaskOperation{
+		{Op: "create", Task: json.RawMessage(`{"title":"New"}`)},
+		{Op: "update", ID: &own.ID, Task: json.RawMessage(`{"title":"Renamed"}`)},
+		{Op: "update", ID: &theirs.ID, Task: json.RawMessage(`{"title":"Renamed"}`)},
+		{Op: "delete", ID: &theirs.ID},
+	})
+	require.Equal(suite.T(), http.StatusOK, w.Code, w.Body.String())
+	var response dto.BulkTaskResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	statuses := make([]int, len(response.Results))
+	for i, result := range response.Results {
+		statuses[i] = result.Status
+	}
+	assert.Equal(suite.T(), []int{http.StatusCreated, http.StatusOK, http.StatusForbidden, http.StatusForbidden}, statuses)
+}
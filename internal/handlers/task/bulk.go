@@ -0,0 +1,297 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/jsonpatch"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxBulkOperations bounds the number of operations in one bulk request
+const maxBulkOperations = 100
+
+// errBulkFailed rolls back an atomic bulk request when one of its operations fails
+var errBulkFailed = errors.New("bulk operation failed")
+
+// BulkTasks handles POST /tasks/bulk
+// @Summary Create, update and delete tasks in bulk
+// @Description Run up to 100 create, update and delete operations in one transaction. Creates take the body of POST /tasks and updates a JSON merge patch as for PATCH /tasks/{id}; a version makes an update or delete conditional like If-Match. By default every operation is applied or rejected on its own and the response lists the outcome of each. With atomic=true the operations are all applied or none are: the first failure rolls back the others and its status is returned.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param atomic query bool false "Apply all operations or none (default: false)"
+// @Param Idempotency-Key header string false "Replay the stored response when a request with this key is retried"
+// @Param operations body []dto.BulkTaskOperation true "Operations to run, in order"
+// @Success 200 {object} dto.BulkTaskResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.BulkTaskResponse
+// @Failure 404 {object} dto.BulkTaskResponse
+// @Failure 409 {object} dto.BulkTaskResponse
+// @Failure 412 {object} dto.BulkTaskResponse
+// @Failure 422 {object} dto.BulkTaskResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/bulk [post]
+func (h *TaskHandler) BulkTasks(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var operations []dto.BulkTaskOperation
+	if err := c.ShouldBindJSON(&operations); err != nil {
+		logger.Error("Invalid request body for bulk task operations", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+	if len(operations) == 0 || len(operations) > maxBulkOperations {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid bulk request",
+			fmt.Sprintf("send between 1 and %d operations", maxBulkOperations)))
+		return
+	}
+	atomic := c.Query("atomic") == "true"
+
+	results := make([]dto.BulkTaskResult, 0, len(operations))
+	failed := -1
+	err := h.repo.Transaction(c.Request.Context(), func(repo database.TaskRepository) error {
+		for i, op := range operations {
+			result := h.runBulkOperation(c.Request.Context(), repo, i, op)
+			results = append(results, result)
+			if atomic && result.Error != nil {
+				failed = i
+				return errBulkFailed
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkFailed) {
+		logger.Error("Failed to run bulk task operations", "count", len(operations), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to run bulk operations"))
+		return
+	}
+
+	response := dto.BulkTaskResponse{Atomic: atomic, Results: results}
+	if failed >= 0 {
+		response.Results = rolledBackResults(operations, results, failed)
+		response.Failed = 1
+		logger.Info("Rolled back atomic bulk task operations", "count", len(operations), "failed", failed)
+		c.JSON(results[failed].Status, response)
+		return
+	}
+
+	for _, result := range results {
+		if result.Error != nil {
+			response.Failed++
+			continue
+		}
+		response.Succeeded++
+		if result.Op != "create" {
+			// Invalidate cache
+			if err := middleware.TenantCache(c.Request.Context(), h.cache).Invalidate(result.ID.String()); err != nil {
+				// Log error but don't fail the request
+				logger.Error("Failed to invalidate task cache", "id", result.ID.String(), "error", err)
+			}
+		}
+	}
+
+	logger.Info("Bulk task operations completed", "succeeded", response.Succeeded, "failed", response.Failed)
+	c.JSON(http.StatusOK, response)
+}
+
+// rolledBackResults reports every operation of an atomic request whose operation at index failed: the
+// operations before it were rolled back and those after it were never run
+func rolledBackResults(operations []dto.BulkTaskOperation, results []dto.BulkTaskResult, failed int) []dto.BulkTaskResult {
+	cause := fmt.Sprintf("operation %d failed", failed)
+	for i := range operations {
+		switch {
+		case i < failed:
+			rolledBack := dto.NewErrorResponse("Rolled back", cause)
+			results[i] = dto.BulkTaskResult{Index: i, Op: operations[i].Op, ID: operations[i].ID,
+				Status: http.StatusFailedDependency, Error: &rolledBack}
+		case i > failed:
+			notRun := dto.NewErrorResponse("Not attempted", cause)
+			results = append(results, dto.BulkTaskResult{Index: i, Op: operations[i].Op, ID: operations[i].ID,
+				Status: http.StatusFailedDependency, Error: &notRun})
+		}
+	}
+	return results
+}
+
+// runBulkOperation runs a single operation of a bulk request against repo
+func (h *TaskHandler) runBulkOperation(ctx context.Context, repo database.TaskRepository, index int, op dto.BulkTaskOperation) dto.BulkTaskResult {
+	result := dto.BulkTaskResult{Index: index, Op: op.Op, ID: op.ID}
+	var task *models.Task
+	var status int
+	var errResp *dto.ErrorResponse
+
+	switch op.Op {
+	case "create":
+		task, status, errResp = h.bulkCreate(ctx, repo, op)
+	case "update":
+		task, status, errResp = h.bulkUpdate(ctx, repo, op)
+	default:
+		status, errResp = bulkDelete(ctx, repo, op)
+	}
+
+	result.Status = status
+	if errResp != nil {
+		logger := middleware.GetLoggerFromContext(ctx)
+		logger.Info("Bulk task operation failed", "index", index, "op", op.Op, "status", status, "error", errResp.Error)
+		result.Error = errResp
+		return result
+	}
+	if task != nil {
+		response := taskToResponse(*task)
+		result.ID = &task.ID
+		result.Task = &response
+	}
+	return result
+}
+
+// bulkCreate creates the task of a create operation
+func (h *TaskHandler) bulkCreate(ctx context.Context, repo database.TaskRepository, op dto.BulkTaskOperation) (*models.Task, int, *dto.ErrorResponse) {
+	var req dto.CreateTaskRequest
+	if len(op.Task) == 0 {
+		return nil, http.StatusBadRequest, bulkError("Invalid operation", `create needs a "task"`)
+	}
+	if err := json.Unmarshal(op.Task, &req); err != nil {
+		return nil, http.StatusBadRequest, bulkError("Invalid operation", err.Error())
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return nil, http.StatusBadRequest, bulkError("Invalid operation", err.Error())
+	}
+	if req.Status != "" && !h.workflow.IsValid(req.Status) {
+		return nil, http.StatusBadRequest, bulkError("Invalid status", statusError(h.workflow).Message)
+	}
+	if err := authz.Authorize(ctx, authz.CreateTask, nil); err != nil {
+		return nil, http.StatusForbidden, bulkError("Forbidden", err.Error())
+	}
+
+	task := BuildTask(req)
+	if err := repo.Create(ctx, &task); err != nil {
+		status, errResp := bulkRepositoryError(ctx, err)
+		return nil, status, errResp
+	}
+	return &task, http.StatusCreated, nil
+}
+
+// bulkUpdate applies the merge patch of an update operation
+func (h *TaskHandler) bulkUpdate(ctx context.Context, repo database.TaskRepository, op dto.BulkTaskOperation) (*models.Task, int, *dto.ErrorResponse) {
+	if len(op.Task) == 0 {
+		return nil, http.StatusBadRequest, bulkError("Invalid operation", `update needs a "task"`)
+	}
+	task, status, errResp := findBulkTask(ctx, repo, op)
+	if errResp != nil {
+		return nil, status, errResp
+	}
+	if err := authz.Authorize(ctx, authz.EditTask, task); err != nil {
+		return nil, http.StatusForbidden, bulkError("Forbidden", err.Error())
+	}
+
+	apply, err := decodePatch(mergePatchMediaType, op.Task)
+	if err != nil {
+		return nil, http.StatusBadRequest, bulkError("Invalid patch document", err.Error())
+	}
+	doc, err := taskDocument(taskToReplaceRequest(*task))
+	if err != nil {
+		return nil, http.StatusInternalServerError, bulkError("Failed to update task")
+	}
+	patched, err := apply(doc)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, http.StatusConflict, bulkError("Patch test failed", err.Error())
+		}
+		return nil, http.StatusUnprocessableEntity, bulkError("Patch cannot be applied", err.Error())
+	}
+	req, err := decodePatchedTask(patched)
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, bulkError("Invalid patched task", err.Error())
+	}
+
+	if !h.workflow.IsValid(req.Status) {
+		return nil, http.StatusBadRequest, bulkError("Invalid status", statusError(h.workflow).Message)
+	}
+	if err := h.workflow.CheckTransition(task.Status, req.Status); err != nil {
+		return nil, http.StatusUnprocessableEntity, bulkError("Invalid status transition", err.Error())
+	}
+
+	ApplyReplace(task, req)
+	if err := repo.Update(ctx, task); err != nil {
+		status, errResp := bulkRepositoryError(ctx, err)
+		return nil, status, errResp
+	}
+	return task, http.StatusOK, nil
+}
+
+// bulkDelete moves the task of a delete operation to the trash
+func bulkDelete(ctx context.Context, repo database.TaskRepository, op dto.BulkTaskOperation) (int, *dto.ErrorResponse) {
+	task, status, errResp := findBulkTask(ctx, repo, op)
+	if errResp != nil {
+		return status, errResp
+	}
+	if err := authz.Authorize(ctx, authz.DeleteTask, task); err != nil {
+		return http.StatusForbidden, bulkError("Forbidden", err.Error())
+	}
+	// The version that was checked must still be current when the task is deleted
+	version := database.AnyVersion
+	if op.Version != nil {
+		version = task.Version
+	}
+	if err := repo.Delete(ctx, task.ID, version); err != nil {
+		return bulkRepositoryError(ctx, err)
+	}
+	return http.StatusNoContent, nil
+}
+
+// findBulkTask loads the task named by an update or delete operation and checks its version
+func findBulkTask(ctx context.Context, repo database.TaskRepository, op dto.BulkTaskOperation) (*models.Task, int, *dto.ErrorResponse) {
+	if op.ID == nil {
+		return nil, http.StatusBadRequest, bulkError("Invalid operation", op.Op+` needs an "id"`)
+	}
+	task, err := repo.GetByID(ctx, *op.ID)
+	if err != nil {
+		status, errResp := bulkRepositoryError(ctx, err)
+		return nil, status, errResp
+	}
+	if op.Version != nil && *op.Version != task.Version {
+		return nil, http.StatusPreconditionFailed, bulkError("Precondition failed",
+			fmt.Sprintf("the task is at version %d", task.Version))
+	}
+	return task, http.StatusOK, nil
+}
+
+// bulkRepositoryError maps an error of the task repository to the status and error of a bulk result
+func bulkRepositoryError(ctx context.Context, err error) (int, *dto.ErrorResponse) {
+	switch {
+	case utils.ErrIsRecordNotFound(err):
+		return http.StatusNotFound, bulkError("Task not found")
+	case errors.Is(err, database.ErrParentNotFound):
+		return http.StatusNotFound, bulkError("Parent task not found")
+	case errors.Is(err, database.ErrParentCycle):
+		return http.StatusConflict, bulkError("Task cannot be its own ancestor")
+	case errors.Is(err, database.ErrUnknownAssignee):
+		return http.StatusUnprocessableEntity, bulkError("Unknown assignee", err.Error())
+	case errors.Is(err, database.ErrTaskHasChildren):
+		return http.StatusConflict, bulkError("Task has subtasks", "delete the subtasks first")
+	case errors.Is(err, database.ErrVersionConflict):
+		return http.StatusConflict, bulkError("Conflict", err.Error())
+	default:
+		logger := middleware.GetLoggerFromContext(ctx)
+		logger.Error("Failed to apply bulk task operation", "error", err)
+		return http.StatusInternalServerError, bulkError("Failed to apply operation")
+	}
+}
+
+// bulkError builds the error of a failed bulk operation
+func bulkError(error string, message ...string) *dto.ErrorResponse {
+	errResp := dto.NewErrorResponse(error, message...)
+	return &errResp
+}
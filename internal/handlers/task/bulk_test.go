@@ -0,0 +1,251 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+// bulkTestRepo serves the given tasks from the mock repository and records what is written
+type bulkTestRepo struct {
+	tasks       map[uuid.UUID]models.Task
+	created     []models.Task
+	updated     []models.Task
+	deleted     []uuid.UUID
+	transaction error
+}
+
+func (suite *TaskHandlerTestSuite) useBulkTestRepo(tasks ...models.Task) *bulkTestRepo {
+	repo := &bulkTestRepo{tasks: make(map[uuid.UUID]models.Task)}
+	for _, task := range tasks {
+		repo.tasks[task.ID] = task
+	}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		task, ok := repo.tasks[id]
+		if !ok {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return &task, nil
+	}
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		repo.created = append(repo.created, *task)
+		return nil
+	}
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, task *models.Task) error {
+		repo.updated = append(repo.updated, *task)
+		return nil
+	}
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
+		repo.deleted = append(repo.deleted, id)
+		return nil
+	}
+	suite.mockRepo.TransactionFunc = func(ctx context.Context, fn func(repo database.TaskRepository) error) error {
+		repo.transaction = fn(suite.mockRepo)
+		return repo.transaction
+	}
+	return repo
+}
+
+func (suite *TaskHandlerTestSuite) sendBulk(query, body string) (*httptest.ResponseRecorder, dto.BulkTaskResponse) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/bulk"+query, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w, req)
+
+	var response dto.BulkTaskResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func bulkTestTask(title string, status types.TaskStatus) models.Task {
+	return models.Task{ID: uuid.New(), Title: title, Status: status, Assignee: "alice", Priority: types.PriorityHigh, Version: 3}
+}
+
+func (suite *TaskHandlerTestSuite) TestBulkTasks_PerItemResults() {
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+	first := bulkTestTask("First", types.StatusInProgress)
+	second := bulkTestTask("Second", types.StatusPending)
+	repo := suite.useBulkTestRepo(first, second)
+	var invalidated []string
+	suite.mockCache.InvalidateFunc = func(id string) error {
+		invalidated = append(invalidated, id)
+		return nil
+	}
+
+	w, response := suite.sendBulk("", fmt.Sprintf(`[
+		{"op": "create", "task": {"title": "New task"}},
+		{"op": "update", "id": %q, "task": {"assignee": "bob", "status": "completed"}},
+		{"op": "update", "id": %q, "task": {"status": "done"}},
+		{"op": "delete", "id": %q},
+		{"op": "delete", "id": %q}
+	]`, first.ID, second.ID, second.ID, uuid.New()))
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.False(suite.T(), response.Atomic)
+	assert.Equal(suite.T(), 3, response.Succeeded)
+	assert.Equal(suite.T(), 2, response.Failed)
+	require.Len(suite.T(), response.Results, 5)
+
+	statuses := []int{http.StatusCreated, http.StatusOK, http.StatusBadRequest, http.StatusNoContent, http.StatusNotFound}
+	for i, result := range response.Results {
+		assert.Equal(suite.T(), i, result.Index)
+		assert.Equal(suite.T(), statuses[i], result.Status, "operation %d", i)
+	}
+
+	require.Len(suite.T(), repo.created, 1)
+	assert.Equal(suite.T(), repo.created[0].ID, *response.Results[0].ID)
+	assert.Equal(suite.T(), "New task", response.Results[0].Task.Title)
+	assert.Equal(suite.T(), types.StatusPending, response.Results[0].Task.Status)
+
+	// Updates are merge patches, keeping the fields they leave out
+	require.Len(suite.T(), repo.updated, 1)
+	assert.Equal(suite.T(), "bob", repo.updated[0].Assignee)
+	assert.Equal(suite.T(), types.StatusCompleted, repo.updated[0].Status)
+	assert.Equal(suite.T(), "First", repo.updated[0].Title)
+	assert.Equal(suite.T(), types.PriorityHigh, repo.updated[0].Priority)
+
+	assert.Equal(suite.T(), "Invalid status", response.Results[2].Error.Error)
+	assert.Equal(suite.T(), []uuid.UUID{second.ID}, repo.deleted)
+	assert.Equal(suite.T(), "Task not found", response.Results[4].Error.Error)
+
+	// Every updated or deleted task leaves the cache
+	assert.ElementsMatch(suite.T(), []string{first.ID.String(), second.ID.String()}, invalidated)
+}
+
+func (suite *TaskHandlerTestSuite) TestBulkTasks_Atomic() {
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+	first := bulkTestTask("First", types.StatusCompleted)
+	second := bulkTestTask("Second", types.StatusPending)
+	repo := suite.useBulkTestRepo(first, second)
+	invalidated := 0
+	suite.mockCache.InvalidateFunc = func(id string) error {
+		invalidated++
+		return nil
+	}
+
+	// Completed tasks cannot go straight back to pending
+	w, response := suite.sendBulk("?atomic=true", fmt.Sprintf(`[
+		{"op": "delete", "id": %q},
+		{"op": "update", "id": %q, "task": {"status": "pending"}},
+		{"op": "create", "task": {"title": "Never created"}}
+	]`, second.ID, first.ID))
+
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+	assert.ErrorIs(suite.T(), repo.transaction, errBulkFailed)
+	assert.True(suite.T(), response.Atomic)
+	assert.Equal(suite.T(), 0, response.Succeeded)
+	assert.Equal(suite.T(), 1, response.Failed)
+	require.Len(suite.T(), response.Results, 3)
+
+	assert.Equal(suite.T(), http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(suite.T(), "Rolled back", response.Results[0].Error.Error)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, response.Results[1].Status)
+	assert.Equal(suite.T(), "Invalid status transition", response.Results[1].Error.Error)
+	assert.Equal(suite.T(), http.StatusFailedDependency, response.Results[2].Status)
+	assert.Equal(suite.T(), "Not attempted", response.Results[2].Error.Error)
+	assert.Empty(suite.T(), repo.created)
+	assert.Zero(suite.T(), invalidated)
+
+	// Without failures an atomic request succeeds as a whole
+	repo = suite.useBulkTestRepo(first, second)
+	w, response = suite.sendBulk("?atomic=true", fmt.Sprintf(`[
+		{"op": "delete", "id": %q},
+		{"op": "update", "id": %q, "task": {"status": "in_progress"}}
+	]`, second.ID, first.ID))
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NoError(suite.T(), repo.transaction)
+	assert.Equal(suite.T(), 2, response.Succeeded)
+	assert.Equal(suite.T(), 2, invalidated)
+}
+
+func (suite *TaskHandlerTestSuite) TestBulkTasks_Version() {
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+	task := bulkTestTask("Versioned", types.StatusPending)
+	repo := suite.useBulkTestRepo(task)
+
+	_, response := suite.sendBulk("", fmt.Sprintf(`[
+		{"op": "update", "id": %q, "version": 2, "task": {"title": "Stale"}},
+		{"op": "delete", "id": %q, "version": 2},
+		{"op": "update", "id": %q, "version": 3, "task": {"title": "Current"}}
+	]`, task.ID, task.ID, task.ID))
+
+	require.Len(suite.T(), response.Results, 3)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, response.Results[0].Status)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, response.Results[1].Status)
+	assert.Equal(suite.T(), http.StatusOK, response.Results[2].Status)
+	assert.Empty(suite.T(), repo.deleted)
+	require.Len(suite.T(), repo.updated, 1)
+	assert.Equal(suite.T(), "Current", repo.updated[0].Title)
+}
+
+func (suite *TaskHandlerTestSuite) TestBulkTasks_InvalidOperations() {
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+	suite.useBulkTestRepo()
+
+	_, response := suite.sendBulk("", `[
+		{"op": "create"},
+		{"op": "create", "task": {"description": "No title"}},
+		{"op": "create", "task": {"title": "Bad status", "status": "done"}},
+		{"op": "update", "task": {"title": "No ID"}},
+		{"op": "update", "id": "`+uuid.NewString()+`"},
+		{"op": "delete"}
+	]`)
+
+	require.Len(suite.T(), response.Results, 6)
+	for _, result := range response.Results {
+		assert.Equal(suite.T(), http.StatusBadRequest, result.Status, "operation %d", result.Index)
+		assert.NotNil(suite.T(), result.Error)
+	}
+	assert.Equal(suite.T(), 6, response.Failed)
+}
+
+func (suite *TaskHandlerTestSuite) TestBulkTasks_InvalidRequest() {
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+	suite.useBulkTestRepo()
+	tooMany := "[" + strings.TrimSuffix(strings.Repeat(`{"op":"delete"},`, maxBulkOperations+1), ",") + "]"
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"not an array", `{"op": "create"}`},
+		{"empty", `[]`},
+		{"unknown operation", `[{"op": "archive"}]`},
+		{"too many operations", tooMany},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			w, _ := suite.sendBulk("", tt.body)
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestBulkTasks_TransactionError() {
+	suite.router.POST("/tasks/bulk", suite.handler.BulkTasks)
+	suite.useBulkTestRepo()
+	suite.mockRepo.TransactionFunc = func(ctx context.Context, fn func(repo database.TaskRepository) error) error {
+		fn(suite.mockRepo)
+		return assert.AnError
+	}
+
+	w, _ := suite.sendBulk("", `[{"op": "create", "task": {"title": "Lost"}}]`)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,53 @@
+package task
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// errInvalidCursor is returned for cursors that were not issued by this API
+var errInvalidCursor = errors.New("cursor is invalid; pass the next_cursor of a previous response")
+
+// listCursor is the content of the opaque cursors that resume a task listing
+type listCursor struct {
+	CreatedAt string    `json:"c"`
+	ID        uuid.UUID `json:"i"`
+	// Order is the direction of the listing the cursor was issued for
+	Order string `json:"o"`
+}
+
+// encodeCursor builds the cursor that resumes a listing in the given order after task
+func encodeCursor(task models.Task, order string) string {
+	data, _ := json.Marshal(listCursor{
+		CreatedAt: task.CreatedAt.Format(time.RFC3339Nano),
+		ID:        task.ID,
+		Order:     order,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor built by encodeCursor, returning the position it resumes from and the order
+// it was issued for
+func decodeCursor(cursor string) (*database.TaskCursor, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", errInvalidCursor
+	}
+	var decoded listCursor
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID == uuid.Nil {
+		return nil, "", errInvalidCursor
+	}
+	// The offset is kept so that the time compares equal to the stored one on every database
+	createdAt, err := time.Parse(time.RFC3339Nano, decoded.CreatedAt)
+	if err != nil {
+		return nil, "", errInvalidCursor
+	}
+	return &database.TaskCursor{CreatedAt: createdAt, ID: decoded.ID}, decoded.Order, nil
+}
@@ -0,0 +1,175 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetBlockers handles GET /tasks/{id}/blockers
+// @Summary List the blockers of a task
+// @Description Retrieve the tasks that must be finished before the given task
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Success 200 {object} dto.DependencyListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/blockers [get]
+func (h *TaskHandler) GetBlockers(c *gin.Context) {
+	h.listDependencies(c, "blockers", h.repo.GetBlockers)
+}
+
+// GetDependents handles GET /tasks/{id}/dependents
+// @Summary List the dependents of a task
+// @Description Retrieve the tasks that are waiting for the given task to be finished
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Success 200 {object} dto.DependencyListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/dependents [get]
+func (h *TaskHandler) GetDependents(c *gin.Context) {
+	h.listDependencies(c, "dependents", h.repo.GetDependents)
+}
+
+// AddBlocker handles POST /tasks/{id}/blockers
+// @Summary Add a blocker to a task
+// @Description Declare that another task must be finished before the given task. Edges that would create a cycle are rejected.
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param dependency body dto.AddDependencyRequest true "Blocking task"
+// @Success 201 {object} dto.DependencyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/blockers [post]
+func (h *TaskHandler) AddBlocker(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if !h.authorizeTask(c, authz.EditTask, id, false) {
+		return
+	}
+
+	var req dto.AddDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for adding blocker", "id", id.String(), "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+
+	err := h.repo.AddDependency(c.Request.Context(), id, req.BlockerID)
+	if err != nil {
+		var cycleErr *database.CycleError
+		switch {
+		case errors.As(err, &cycleErr):
+			logger.Info("Rejected cyclic dependency", "id", id.String(), "blocker_id", req.BlockerID.String())
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("Dependency would create a cycle", cycleErr.Error()))
+		case utils.ErrIsRecordNotFound(err):
+			logger.Info("Task not found for dependency", "id", id.String(), "blocker_id", req.BlockerID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		default:
+			logger.Error("Failed to add dependency", "id", id.String(), "blocker_id", req.BlockerID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to add dependency"))
+		}
+		return
+	}
+
+	logger.Info("Dependency added successfully", "id", id.String(), "blocker_id", req.BlockerID.String())
+	c.JSON(http.StatusCreated, dto.DependencyResponse{TaskID: id, BlockerID: req.BlockerID})
+}
+
+// RemoveBlocker handles DELETE /tasks/{id}/blockers/{blocker_id}
+// @Summary Remove a blocker from a task
+// @Description Delete the dependency between a task and one of its blockers
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param blocker_id path string true "Blocking task ID (UUID)"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/blockers/{blocker_id} [delete]
+func (h *TaskHandler) RemoveBlocker(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	blockerID, ok := parseUUIDParam(c, "blocker_id")
+	if !ok {
+		return
+	}
+	if !h.authorizeTask(c, authz.EditTask, id, false) {
+		return
+	}
+
+	if err := h.repo.RemoveDependency(c.Request.Context(), id, blockerID); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Dependency not found for removal", "id", id.String(), "blocker_id", blockerID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Dependency not found"))
+		} else {
+			logger.Error("Failed to remove dependency", "id", id.String(), "blocker_id", blockerID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to remove dependency"))
+		}
+		return
+	}
+
+	logger.Info("Dependency removed successfully", "id", id.String(), "blocker_id", blockerID.String())
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// listDependencies writes one side of a task's dependency edges using the given lookup
+func (h *TaskHandler) listDependencies(c *gin.Context, kind string, lookup func(ctx context.Context, id uuid.UUID) ([]models.Task, error)) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.GetByID(c.Request.Context(), id); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task not found", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		} else {
+			logger.Error("Failed to get task", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get task"))
+		}
+		return
+	}
+
+	tasks, err := lookup(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("Failed to fetch task "+kind, "id", id.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch task "+kind))
+		return
+	}
+
+	logger.Info("Task "+kind+" retrieved successfully", "id", id.String(), "count", len(tasks))
+	c.JSON(http.StatusOK, dto.DependencyListResponse{TaskID: id, Tasks: tasksToResponses(tasks)})
+}
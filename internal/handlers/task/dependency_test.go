@@ -0,0 +1,151 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func (suite *TaskHandlerTestSuite) TestAddBlocker_Success() {
+	taskID, blockerID := uuid.New(), uuid.New()
+	var gotTask, gotBlocker uuid.UUID
+	suite.mockRepo.AddDependencyFunc = func(ctx context.Context, t, b uuid.UUID) error {
+		gotTask, gotBlocker = t, b
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(dto.AddDependencyRequest{BlockerID: blockerID})
+	req, _ := http.NewRequest("POST", "/tasks/"+taskID.String()+"/blockers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/blockers", suite.handler.AddBlocker)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.Equal(suite.T(), taskID, gotTask)
+	assert.Equal(suite.T(), blockerID, gotBlocker)
+
+	var response dto.DependencyResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), blockerID, response.BlockerID)
+}
+
+func (suite *TaskHandlerTestSuite) TestAddBlocker_Cycle() {
+	taskID, blockerID := uuid.New(), uuid.New()
+	suite.mockRepo.AddDependencyFunc = func(ctx context.Context, t, b uuid.UUID) error {
+		return &database.CycleError{Cycle: []uuid.UUID{b, t, b}}
+	}
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(dto.AddDependencyRequest{BlockerID: blockerID})
+	req, _ := http.NewRequest("POST", "/tasks/"+taskID.String()+"/blockers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/blockers", suite.handler.AddBlocker)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	var response dto.ErrorResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Dependency would create a cycle", response.Error)
+	assert.Contains(suite.T(), response.Message, blockerID.String())
+}
+
+func (suite *TaskHandlerTestSuite) TestAddBlocker_NotFound() {
+	suite.mockRepo.AddDependencyFunc = func(ctx context.Context, t, b uuid.UUID) error {
+		return sql.ErrNoRows
+	}
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(dto.AddDependencyRequest{BlockerID: uuid.New()})
+	req, _ := http.NewRequest("POST", "/tasks/"+uuid.New().String()+"/blockers", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/blockers", suite.handler.AddBlocker)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestAddBlocker_InvalidBody() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/"+uuid.New().String()+"/blockers", bytes.NewBufferString(`{"blocker_id":"nope"}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/blockers", suite.handler.AddBlocker)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestRemoveBlocker() {
+	suite.mockRepo.RemoveDependencyFunc = func(ctx context.Context, t, b uuid.UUID) error {
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+uuid.New().String()+"/blockers/"+uuid.New().String(), nil)
+	suite.router.DELETE("/tasks/:id/blockers/:blocker_id", suite.handler.RemoveBlocker)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestRemoveBlocker_NotFound() {
+	suite.mockRepo.RemoveDependencyFunc = func(ctx context.Context, t, b uuid.UUID) error {
+		return sql.ErrNoRows
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+uuid.New().String()+"/blockers/"+uuid.New().String(), nil)
+	suite.router.DELETE("/tasks/:id/blockers/:blocker_id", suite.handler.RemoveBlocker)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetBlockers() {
+	taskID := uuid.New()
+	blocker := models.Task{ID: uuid.New(), Title: "Blocker", Status: types.StatusPending}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return &models.Task{ID: id}, nil
+	}
+	suite.mockRepo.GetBlockersFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+		return []models.Task{blocker}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+taskID.String()+"/blockers", nil)
+	suite.router.GET("/tasks/:id/blockers", suite.handler.GetBlockers)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.DependencyListResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), taskID, response.TaskID)
+	assert.Len(suite.T(), response.Tasks, 1)
+	assert.Equal(suite.T(), blocker.ID, response.Tasks[0].ID)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetDependents_TaskNotFound() {
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+uuid.New().String()+"/dependents", nil)
+	suite.router.GET("/tasks/:id/dependents", suite.handler.GetDependents)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
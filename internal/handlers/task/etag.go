@@ -0,0 +1,133 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskETag returns the strong entity tag of a task, derived from its version
+func taskETag(task models.Task) string {
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+// listETag returns the strong entity tag of a serialized task list
+func listETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// lastModified returns the Last-Modified time of tasks: the latest update among them, or the
+// zero time when there are none
+func lastModified(tasks ...models.Task) time.Time {
+	var latest time.Time
+	for _, task := range tasks {
+		if task.UpdatedAt.After(latest) {
+			latest = task.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// setValidators sets the ETag and, when it is known, the Last-Modified header of a response
+func setValidators(c *gin.Context, etag string, modified time.Time) {
+	c.Header("ETag", etag)
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified reports whether a conditional GET shows that the client already has the current
+// representation. If-None-Match takes precedence over If-Modified-Since, which is skipped when
+// modified is the zero time.
+func notModified(c *gin.Context, etag string, modified time.Time) bool {
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		return etagMatchesWeak(ifNoneMatch, etag)
+	}
+	if modified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	// Last-Modified has a resolution of one second
+	return err == nil && !modified.Truncate(time.Second).After(since)
+}
+
+// writeListResponse writes a task list with its validators, or 304 Not Modified when the client
+// already has it. Lists are only revalidated by entity tag because removing a task from a list
+// does not move its Last-Modified time.
+func writeListResponse(c *gin.Context, response dto.TaskListResponse, modified time.Time) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Failed to encode task list", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch tasks"))
+		return
+	}
+
+	etag := listETag(body)
+	setValidators(c, etag, modified)
+	if notModified(c, etag, time.Time{}) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatchesWeak reports whether an If-None-Match header value lists etag, using the weak
+// comparison: a W/ prefix on either side is ignored.
+func etagMatchesWeak(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches reports whether an If-Match header value lists etag. "*" matches any task;
+// weak tags never match because If-Match uses the strong comparison.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch writes a 412 response and returns false when the request carries an If-Match
+// header that does not match the current version of task
+func checkIfMatch(c *gin.Context, task models.Task) bool {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" || etagMatches(ifMatch, taskETag(task)) {
+		return true
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	logger.Info("Task precondition failed", "id", task.ID.String(), "if_match", ifMatch, "etag", taskETag(task))
+	c.Header("ETag", taskETag(task))
+	c.JSON(http.StatusPreconditionFailed, dto.NewErrorResponse("Precondition failed", "the task has been modified; fetch it again and retry"))
+	return false
+}
+
+// writeVersionConflict writes the response for an update that lost a race with another writer:
+// 412 when the client made the update conditional with If-Match, 409 otherwise
+func writeVersionConflict(c *gin.Context) {
+	status := http.StatusConflict
+	if c.GetHeader("If-Match") != "" {
+		status = http.StatusPreconditionFailed
+	}
+	c.JSON(status, dto.NewErrorResponse("Task was modified concurrently", "fetch it again and retry"))
+}
@@ -0,0 +1,323 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func TestEtagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"3"`, `"3"`))
+	assert.True(t, etagMatches(`"1", "3"`, `"3"`))
+	assert.True(t, etagMatches(`*`, `"3"`))
+	assert.False(t, etagMatches(`"2"`, `"3"`))
+	assert.False(t, etagMatches(`W/"3"`, `"3"`))
+	assert.False(t, etagMatches(`3`, `"3"`))
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTask_ETag() {
+	task := models.Task{ID: uuid.New(), Title: "Versioned", Status: types.StatusPending, Version: 4}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return &task, nil
+	}
+	suite.router.GET("/tasks/:id", suite.handler.GetTask)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+task.ID.String(), nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "MISS", w.Header().Get("X-Cache-Status"))
+	assert.Equal(suite.T(), `"4"`, w.Header().Get("ETag"))
+	var response dto.TaskResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(4), response.Version)
+
+	// A cached task carries the same tag
+	suite.mockCache.GetFunc = func(id string) (*models.Task, error) {
+		return &task, nil
+	}
+	w = httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "HIT", w.Header().Get("X-Cache-Status"))
+	assert.Equal(suite.T(), `"4"`, w.Header().Get("ETag"))
+}
+
+func (suite *TaskHandlerTestSuite) TestUpdateTask_IfMatch() {
+	task := models.Task{ID: uuid.New(), Title: "Versioned", Status: types.StatusPending, Version: 2}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		current := task
+		return &current, nil
+	}
+	updated := 0
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, t *models.Task) error {
+		updated++
+		t.Version++
+		return nil
+	}
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+
+	send := func(ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/tasks/"+task.ID.String(), bytes.NewBufferString(`{"title":"Renamed","status":"pending"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(`"1"`)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+	assert.Equal(suite.T(), `"2"`, w.Header().Get("ETag"))
+	assert.Equal(suite.T(), 0, updated)
+	var errResponse dto.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(suite.T(), "Precondition failed", errResponse.Error)
+
+	w = send(`"2"`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `"3"`, w.Header().Get("ETag"))
+
+	w = send("*")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	// Unconditional updates are still allowed
+	w = send("")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), 3, updated)
+}
+
+func (suite *TaskHandlerTestSuite) TestUpdateTask_VersionConflict() {
+	task := models.Task{ID: uuid.New(), Title: "Versioned", Status: types.StatusPending, Version: 2}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		current := task
+		return &current, nil
+	}
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, t *models.Task) error {
+		return database.ErrVersionConflict
+	}
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+
+	send := func(ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/tasks/"+task.ID.String(), bytes.NewBufferString(`{"title":"Renamed","status":"pending"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Another replica won the race after the handler read the task
+	assert.Equal(suite.T(), http.StatusConflict, send("").Code)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, send(`"2"`).Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestDeleteTask_IfMatch() {
+	task := models.Task{ID: uuid.New(), Title: "Versioned", Status: types.StatusPending, Version: 5}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		if id != task.ID {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return &task, nil
+	}
+	deleted := 0
+	var deletedVersion int64
+	var deleteErr error
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
+		deleted++
+		deletedVersion = version
+		return deleteErr
+	}
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+
+	send := func(id uuid.UUID, ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/tasks/"+id.String(), nil)
+		req.Header.Set("If-Match", ifMatch)
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(task.ID, `"4"`)
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+	assert.Equal(suite.T(), `"5"`, w.Header().Get("ETag"))
+	assert.Equal(suite.T(), 0, deleted)
+
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, send(uuid.New(), "*").Code)
+
+	// The task is only deleted at the version that matched
+	assert.Equal(suite.T(), http.StatusNoContent, send(task.ID, `"5"`).Code)
+	assert.Equal(suite.T(), 1, deleted)
+	assert.Equal(suite.T(), int64(5), deletedVersion)
+
+	// Another writer changed the task after it matched
+	deleteErr = database.ErrVersionConflict
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, send(task.ID, `"5"`).Code)
+
+	// Without If-Match any version is deleted
+	deleteErr = nil
+	assert.Equal(suite.T(), http.StatusNoContent, send(task.ID, "").Code)
+	assert.Equal(suite.T(), database.AnyVersion, deletedVersion)
+}
+
+func (suite *TaskHandlerTestSuite) TestPurgeTask_IfMatchTrashed() {
+	task := models.Task{ID: uuid.New(), Title: "Trashed", Status: types.StatusPending, Version: 3,
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	suite.mockRepo.GetByIDWithTrashedFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		if id != task.ID {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return &task, nil
+	}
+	var purgedVersion int64
+	suite.mockRepo.PurgeFunc = func(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error) {
+		purgedVersion = version
+		return []uuid.UUID{id}, nil
+	}
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+task.ID.String()+"?purge=true", nil)
+	req.Header.Set("If-Match", `"3"`)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(suite.T(), int64(3), purgedVersion)
+}
+
+func TestEtagMatchesWeak(t *testing.T) {
+	assert.True(t, etagMatchesWeak(`"3"`, `"3"`))
+	assert.True(t, etagMatchesWeak(`W/"3"`, `"3"`))
+	assert.True(t, etagMatchesWeak(`"1", W/"3"`, `"3"`))
+	assert.True(t, etagMatchesWeak(`*`, `"3"`))
+	assert.False(t, etagMatchesWeak(`"2"`, `"3"`))
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTask_NotModifiedFromCache() {
+	updatedAt := time.Date(2026, 3, 4, 5, 6, 7, 500, time.UTC)
+	task := models.Task{ID: uuid.New(), Title: "Versioned", Status: types.StatusPending, Version: 4, UpdatedAt: updatedAt}
+	suite.mockCache.GetFunc = func(id string) (*models.Task, error) {
+		return &task, nil
+	}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		suite.T().Fatal("a cache hit must not read the task from the database")
+		return nil, nil
+	}
+	suite.mockRepo.GetLabelsForTasksFunc = func(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+		suite.T().Fatal("a revalidated task must not load its labels")
+		return nil, nil
+	}
+	suite.router.GET("/tasks/:id", suite.handler.GetTask)
+
+	send := func(header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks/"+task.ID.String(), nil)
+		req.Header.Set(header, value)
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("If-None-Match", `"4"`)
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+	assert.Empty(suite.T(), w.Body.String())
+	assert.Equal(suite.T(), "HIT", w.Header().Get("X-Cache-Status"))
+	assert.Equal(suite.T(), `"4"`, w.Header().Get("ETag"))
+	assert.Equal(suite.T(), "Wed, 04 Mar 2026 05:06:07 GMT", w.Header().Get("Last-Modified"))
+
+	w = send("If-Modified-Since", "Wed, 04 Mar 2026 05:06:07 GMT")
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTask_Modified() {
+	updatedAt := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	task := models.Task{ID: uuid.New(), Title: "Versioned", Status: types.StatusPending, Version: 4, UpdatedAt: updatedAt}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return &task, nil
+	}
+	suite.router.GET("/tasks/:id", suite.handler.GetTask)
+
+	send := func(header, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks/"+task.ID.String(), nil)
+		req.Header.Set(header, value)
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("If-None-Match", `"3"`)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "MISS", w.Header().Get("X-Cache-Status"))
+	assert.Equal(suite.T(), `"4"`, w.Header().Get("ETag"))
+
+	w = send("If-Modified-Since", "Wed, 04 Mar 2026 05:06:06 GMT")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = send("If-None-Match", `"4"`)
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+	assert.Equal(suite.T(), "MISS", w.Header().Get("X-Cache-Status"))
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_ConditionalGet() {
+	older := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	tasks := []models.Task{
+		{ID: uuid.New(), Title: "First", Status: types.StatusPending, Version: 1, UpdatedAt: newer},
+		{ID: uuid.New(), Title: "Second", Status: types.StatusPending, Version: 1, UpdatedAt: older},
+	}
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		return tasks, int64(len(tasks)), nil
+	}
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+
+	send := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		suite.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "Wed, 04 Mar 2026 06:06:07 GMT", w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(suite.T(), etag)
+	var response dto.TaskListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(suite.T(), response.Tasks, 2)
+
+	w = send(etag)
+	assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+	assert.Empty(suite.T(), w.Body.String())
+
+	// Any change to the listed tasks changes the tag
+	tasks[1].Title = "Renamed"
+	w = send(etag)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotEqual(suite.T(), etag, w.Header().Get("ETag"))
+}
@@ -0,0 +1,194 @@
+package task
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// edgeBlocks is the relationship type of a dependency edge, drawn from blocker to blocked task
+const edgeBlocks = "blocks"
+
+// statusColors are the fill colours used for task nodes in DOT and Mermaid output
+var statusColors = map[types.TaskStatus]string{
+	types.StatusPending:    "#fff4c2",
+	types.StatusInProgress: "#cfe2ff",
+	types.StatusCompleted:  "#d1e7dd",
+}
+
+// defaultStatusColor is the fill colour of nodes whose status has no colour of its own, such as those added by a workflow
+const defaultStatusColor = "#ffffff"
+
+// GetGraph handles GET /tasks/graph
+// @Summary Export the task graph
+// @Description Render tasks and the relationships between them as a Graphviz DOT document, a Mermaid flowchart or a node/edge JSON structure
+// @Tags dependencies
+// @Produce json
+// @Produce plain
+// @Param format query string false "Output format: json (default), dot or mermaid"
+// @Param status query string false "Filter by status (pending, in_progress, completed)"
+// @Param assignee query string false "Filter by assignee"
+// @Success 200 {object} dto.GraphResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/graph [get]
+func (h *TaskHandler) GetGraph(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dot" && format != "mermaid" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid format", "format must be one of json, dot, mermaid"))
+		return
+	}
+	status := c.Query("status")
+	assignee := c.Query("assignee")
+
+	tasks, err := h.repo.GetFiltered(c.Request.Context(), status, assignee)
+	if err != nil {
+		logger.Error("Failed to fetch tasks for graph", "status", status, "assignee", assignee, "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to build task graph"))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	deps, err := h.repo.GetDependencies(c.Request.Context(), ids)
+	if err != nil {
+		logger.Error("Failed to fetch task dependencies", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to build task graph"))
+		return
+	}
+
+	graph := buildGraphResponse(tasks, deps)
+	logger.Info("Task graph exported", "format", format, "nodes", len(graph.Nodes), "edges", len(graph.Edges))
+
+	switch format {
+	case "dot":
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(renderDOT(graph)))
+	case "mermaid":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(renderMermaid(graph)))
+	default:
+		c.JSON(http.StatusOK, graph)
+	}
+}
+
+func buildGraphResponse(tasks []models.Task, deps []models.TaskDependency) dto.GraphResponse {
+	graph := dto.GraphResponse{
+		Nodes: make([]dto.GraphNode, len(tasks)),
+		Edges: make([]dto.GraphEdge, len(deps)),
+	}
+	for i, task := range tasks {
+		graph.Nodes[i] = dto.GraphNode{ID: task.ID, Title: task.Title, Status: task.Status, Assignee: task.Assignee}
+	}
+	for i, dep := range deps {
+		graph.Edges[i] = dto.GraphEdge{From: dep.BlockerID, To: dep.TaskID, Type: edgeBlocks}
+	}
+	return graph
+}
+
+// renderDOT renders the graph as a Graphviz digraph keyed by task ID
+func renderDOT(graph dto.GraphResponse) string {
+	var sb strings.Builder
+	sb.WriteString("digraph tasks {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=\"" + defaultStatusColor + "\"];\n")
+	for _, node := range graph.Nodes {
+		label := node.Title + "\n" + string(node.Status)
+		if node.Assignee != "" {
+			label += " · " + node.Assignee
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s", dotQuote(node.ID.String()), dotQuote(label))
+		if color, ok := statusColors[node.Status]; ok {
+			fmt.Fprintf(&sb, ", fillcolor=%s", dotQuote(color))
+		}
+		sb.WriteString("];\n")
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(edge.From.String()), dotQuote(edge.To.String()), dotQuote(edge.Type))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// renderMermaid renders the graph as a Mermaid flowchart. Mermaid node IDs cannot
+// contain dashes, so tasks are numbered in the order they were listed.
+func renderMermaid(graph dto.GraphResponse) string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	nodeIDs := make(map[uuid.UUID]string, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		nodeID := fmt.Sprintf("t%d", i+1)
+		nodeIDs[node.ID] = nodeID
+		label := mermaidEscape(node.Title) + "<br/>" + string(node.Status)
+		if node.Assignee != "" {
+			label += " · " + mermaidEscape(node.Assignee)
+		}
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", nodeID, label)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&sb, "  %s -->|%s| %s\n", nodeIDs[edge.From], edge.Type, nodeIDs[edge.To])
+	}
+
+	// Every status present gets a class, those of the built-in statuses first so the output stays stable, and
+	// statuses added by a workflow in the order they are first seen
+	statuses := []types.TaskStatus{types.StatusPending, types.StatusInProgress, types.StatusCompleted}
+	members := make(map[types.TaskStatus][]string)
+	for _, node := range graph.Nodes {
+		if _, ok := members[node.Status]; !ok && statusColors[node.Status] == "" {
+			statuses = append(statuses, node.Status)
+		}
+		members[node.Status] = append(members[node.Status], nodeIDs[node.ID])
+	}
+	for _, status := range statuses {
+		if len(members[status]) == 0 {
+			continue
+		}
+		color, ok := statusColors[status]
+		if !ok {
+			color = defaultStatusColor
+		}
+		class := mermaidClassName(status)
+		fmt.Fprintf(&sb, "  classDef %s fill:%s\n", class, color)
+		fmt.Fprintf(&sb, "  class %s %s\n", strings.Join(members[status], ","), class)
+	}
+	return sb.String()
+}
+
+// mermaidClassName turns status into a Mermaid class name, replacing the characters a class name cannot hold
+func mermaidClassName(status types.TaskStatus) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, string(status))
+}
+
+// mermaidEscape replaces the characters that would end or break a quoted Mermaid label
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+		"\n", " ",
+		"\r", "",
+	).Replace(s)
+}
@@ -0,0 +1,137 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func (suite *TaskHandlerTestSuite) graphFixture() (models.Task, models.Task) {
+	design := models.Task{ID: uuid.MustParse("11111111-1111-1111-1111-111111111111"), Title: `Design "v2"`, Status: types.StatusCompleted, Assignee: "alice"}
+	build := models.Task{ID: uuid.MustParse("22222222-2222-2222-2222-222222222222"), Title: "Build", Status: types.StatusPending}
+
+	suite.mockRepo.GetFilteredFunc = func(ctx context.Context, status, assignee string) ([]models.Task, error) {
+		return []models.Task{design, build}, nil
+	}
+	suite.mockRepo.GetDependenciesFunc = func(ctx context.Context, ids []uuid.UUID) ([]models.TaskDependency, error) {
+		assert.Equal(suite.T(), []uuid.UUID{design.ID, build.ID}, ids)
+		return []models.TaskDependency{{TaskID: build.ID, BlockerID: design.ID}}, nil
+	}
+	suite.router.GET("/tasks/graph", suite.handler.GetGraph)
+	return design, build
+}
+
+func (suite *TaskHandlerTestSuite) TestGetGraph_JSON() {
+	design, build := suite.graphFixture()
+
+	var gotStatus, gotAssignee string
+	getFiltered := suite.mockRepo.GetFilteredFunc
+	suite.mockRepo.GetFilteredFunc = func(ctx context.Context, status, assignee string) ([]models.Task, error) {
+		gotStatus, gotAssignee = status, assignee
+		return getFiltered(ctx, status, assignee)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/graph?status=pending&assignee=alice", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "pending", gotStatus)
+	assert.Equal(suite.T(), "alice", gotAssignee)
+
+	var response dto.GraphResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(suite.T(), response.Nodes, 2)
+	assert.Equal(suite.T(), "alice", response.Nodes[0].Assignee)
+	assert.Equal(suite.T(), []dto.GraphEdge{{From: design.ID, To: build.ID, Type: "blocks"}}, response.Edges)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetGraph_DOT() {
+	suite.graphFixture()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/graph?format=dot", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), "text/vnd.graphviz; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.True(suite.T(), strings.HasPrefix(body, "digraph tasks {\n"))
+	assert.Contains(suite.T(), body, `"11111111-1111-1111-1111-111111111111" [label="Design \"v2\"\ncompleted · alice", fillcolor="#d1e7dd"];`)
+	assert.Contains(suite.T(), body, `"11111111-1111-1111-1111-111111111111" -> "22222222-2222-2222-2222-222222222222" [label="blocks"];`)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetGraph_Mermaid() {
+	suite.graphFixture()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/graph?format=mermaid", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `flowchart LR
+  t1["Design #quot;v2#quot;<br/>completed · alice"]
+  t2["Build<br/>pending"]
+  t1 -->|blocks| t2
+  classDef pending fill:#fff4c2
+  class t2 pending
+  classDef completed fill:#d1e7dd
+  class t1 completed
+`, w.Body.String())
+}
+
+func (suite *TaskHandlerTestSuite) TestGetGraph_MermaidWorkflowStatuses() {
+	suite.graphFixture()
+	review := models.Task{ID: uuid.MustParse("33333333-3333-3333-3333-333333333333"), Title: "Review", Status: "in review"}
+	suite.mockRepo.GetFilteredFunc = func(ctx context.Context, status, assignee string) ([]models.Task, error) {
+		return []models.Task{review}, nil
+	}
+	suite.mockRepo.GetDependenciesFunc = func(ctx context.Context, ids []uuid.UUID) ([]models.TaskDependency, error) {
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/graph?format=mermaid", nil)
+	suite.router.ServeHTTP(w, req)
+
+	// Statuses without a colour of their own get the default one, under a class name Mermaid accepts
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Equal(suite.T(), `flowchart LR
+  t1["Review<br/>in review"]
+  classDef in_review fill:#ffffff
+  class t1 in_review
+`, w.Body.String())
+}
+
+func (suite *TaskHandlerTestSuite) TestGetGraph_InvalidFormat() {
+	suite.router.GET("/tasks/graph", suite.handler.GetGraph)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/graph?format=svg", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetGraph_RepositoryError() {
+	suite.mockRepo.GetFilteredFunc = func(ctx context.Context, status, assignee string) ([]models.Task, error) {
+		return nil, errors.New("database error")
+	}
+	suite.router.GET("/tasks/graph", suite.handler.GetGraph)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/graph", nil)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+}
@@ -1,28 +1,216 @@
 package task
 
 import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"taheri24.ir/graph1/internal/database"
 	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
 	"taheri24.ir/graph1/internal/models"
 	"taheri24.ir/graph1/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// BuildTask creates a new models.Task from a create request, applying defaults
+func BuildTask(req dto.CreateTaskRequest) models.Task {
+	task := models.Task{
+		ID:          uuid.New(),
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+		Assignee:    req.Assignee,
+		AssigneeID:  req.AssigneeID,
+		Estimate:    req.Estimate,
+		Priority:    req.Priority,
+		DueAt:       req.DueAt,
+		ParentID:    req.ParentID,
+	}
+	if task.ParentID != nil && *task.ParentID == uuid.Nil {
+		task.ParentID = nil
+	}
+	if task.Status == "" {
+		task.Status = types.StatusPending
+	}
+	if task.Priority == "" {
+		task.Priority = types.PriorityMedium
+	}
+	return task
+}
+
+// ApplyUpdate copies the fields provided in an update request onto task
+func ApplyUpdate(task *models.Task, req dto.UpdateTaskRequest) {
+	if req.Title != nil {
+		task.Title = *req.Title
+	}
+	if req.Description != nil {
+		task.Description = *req.Description
+	}
+	if req.Status != nil {
+		task.Status = *req.Status
+	}
+	if req.Assignee != nil {
+		task.Assignee = *req.Assignee
+	}
+	if req.AssigneeID != nil {
+		task.AssigneeID = req.AssigneeID
+	}
+	if req.Estimate != nil {
+		task.Estimate = *req.Estimate
+	}
+	if req.Priority != nil {
+		task.Priority = *req.Priority
+	}
+	if req.DueAt != nil {
+		task.DueAt = req.DueAt
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == uuid.Nil {
+			task.ParentID = nil
+		} else {
+			task.ParentID = req.ParentID
+		}
+	}
+}
+
+// ApplyReplace overwrites the editable fields of task with those of a replace request, applying
+// the defaults of BuildTask to the fields it leaves out
+func ApplyReplace(task *models.Task, req dto.ReplaceTaskRequest) {
+	task.Title = req.Title
+	task.Description = req.Description
+	task.Status = req.Status
+	task.Assignee = req.Assignee
+	task.AssigneeID = req.AssigneeID
+	task.Estimate = req.Estimate
+	task.Priority = req.Priority
+	if task.Priority == "" {
+		task.Priority = types.PriorityMedium
+	}
+	task.DueAt = req.DueAt
+	task.ParentID = req.ParentID
+	if task.ParentID != nil && *task.ParentID == uuid.Nil {
+		task.ParentID = nil
+	}
+}
+
+// taskToReplaceRequest returns the editable fields of task, as sent to replace it
+func taskToReplaceRequest(task models.Task) dto.ReplaceTaskRequest {
+	return dto.ReplaceTaskRequest{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Assignee:    task.Assignee,
+		AssigneeID:  task.AssigneeID,
+		Estimate:    task.Estimate,
+		Priority:    task.Priority,
+		DueAt:       task.DueAt,
+		ParentID:    task.ParentID,
+	}
+}
+
+// ApplyDueFilter restricts opts to unfinished tasks in a due window relative to now: "overdue"
+// (due before now), "today" (due on now's calendar day) or "week" (due in the seven days starting today).
+// It reports false for any other window.
+func ApplyDueFilter(opts *database.TaskListOptions, due string, now time.Time) bool {
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var from, before time.Time
+	switch due {
+	case "overdue":
+		opts.DueBefore = &now
+	case "today":
+		from, before = startOfDay, startOfDay.AddDate(0, 0, 1)
+		opts.DueFrom, opts.DueBefore = &from, &before
+	case "week":
+		from, before = startOfDay, startOfDay.AddDate(0, 0, 7)
+		opts.DueFrom, opts.DueBefore = &from, &before
+	default:
+		return false
+	}
+	opts.Unfinished = true
+	return true
+}
+
+// ApplyLabelFilter restricts opts to tasks carrying the comma-separated label names in labels,
+// requiring all of them when mode is "all" or at least one when it is "any". Blank names are
+// ignored. It reports false for any other mode.
+func ApplyLabelFilter(opts *database.TaskListOptions, labels, mode string) bool {
+	if mode != "all" && mode != "any" {
+		return false
+	}
+	for _, name := range strings.Split(labels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Labels = append(opts.Labels, name)
+		}
+	}
+	opts.LabelMode = mode
+	return true
+}
+
+// taskToResponse converts a models.Task to dto.TaskResponse
+func taskToResponse(task models.Task) dto.TaskResponse {
+	var dueAt *string
+	if task.DueAt != nil {
+		formatted := task.DueAt.Format("2006-01-02T15:04:05Z07:00")
+		dueAt = &formatted
+	}
+	var deletedAt *string
+	if task.DeletedAt.Valid {
+		formatted := task.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+		deletedAt = &formatted
+	}
+	return dto.TaskResponse{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Assignee:    task.Assignee,
+		AssigneeID:  task.AssigneeID,
+		Estimate:    task.Estimate,
+		Priority:    task.Priority,
+		DueAt:       dueAt,
+		ParentID:    task.ParentID,
+		Version:     task.Version,
+		CreatedBy:   task.CreatedBy,
+		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DeletedAt:   deletedAt,
+	}
+}
+
 // tasksToResponses converts models.Task to dto.TaskResponse
 func tasksToResponses(tasks []models.Task) []dto.TaskResponse {
 	responses := make([]dto.TaskResponse, len(tasks))
 	for i, task := range tasks {
-		responses[i] = dto.TaskResponse{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Status:      task.Status,
-			Assignee:    task.Assignee,
-			CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
+		responses[i] = taskToResponse(task)
 	}
 	return responses
 }
 
+// searchResultTasks returns the tasks of search results, in order
+func searchResultTasks(results []database.TaskSearchResult) []models.Task {
+	tasks := make([]models.Task, len(results))
+	for i, result := range results {
+		tasks[i] = result.Task
+	}
+	return tasks
+}
+
+// attachMatches sets the search match of each response from the search result it was built from
+func attachMatches(responses []dto.TaskResponse, results []database.TaskSearchResult) {
+	for i, result := range results {
+		responses[i].Match = &dto.TaskMatchResponse{
+			Rank:        result.Rank,
+			Title:       result.TitleSnippet,
+			Description: result.DescriptionSnippet,
+		}
+	}
+}
+
 // filterTasksByStatus filters tasks by status
 func filterTasksByStatus(tasks []models.Task, status types.TaskStatus) []models.Task {
 	var filtered []models.Task
@@ -44,3 +232,28 @@ func filterTasksByAssignee(tasks []models.Task, assignee string) []models.Task {
 	}
 	return filtered
 }
+
+// parseUUIDParam parses the named path parameter as a UUID, writing a 400 response when it is invalid
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	idStr := c.Param(name)
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Invalid task ID provided", "param", name, "idStr", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid task ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// writeAssigneeError writes the response for a task assigned to someone who is not a user and reports whether
+// err was one
+func writeAssigneeError(c *gin.Context, err error) bool {
+	if !errors.Is(err, database.ErrUnknownAssignee) {
+		return false
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	logger.Info("Rejected unknown assignee", "error", err)
+	c.JSON(http.StatusUnprocessableEntity, dto.NewErrorResponse("Unknown assignee", err.Error()))
+	return true
+}
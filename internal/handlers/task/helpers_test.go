@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"taheri24.ir/graph1/internal/database"
 	"taheri24.ir/graph1/internal/models"
 	"taheri24.ir/graph1/internal/types"
 )
@@ -205,3 +206,43 @@ func TestFilterTasksByAssignee_EmptyAssignee(t *testing.T) {
 	assert.Equal(t, "Task 2", emptyAssigneeTasks[0].Title)
 	assert.Equal(t, "Task 3", emptyAssigneeTasks[1].Title)
 }
+
+func TestApplyDueFilter(t *testing.T) {
+	now := time.Date(2030, 5, 15, 14, 30, 0, 0, time.UTC)
+	startOfDay := time.Date(2030, 5, 15, 0, 0, 0, 0, time.UTC)
+
+	var opts database.TaskListOptions
+	assert.True(t, ApplyDueFilter(&opts, "overdue", now))
+	assert.Nil(t, opts.DueFrom)
+	assert.Equal(t, now, *opts.DueBefore)
+	assert.True(t, opts.Unfinished)
+
+	opts = database.TaskListOptions{}
+	assert.True(t, ApplyDueFilter(&opts, "today", now))
+	assert.Equal(t, startOfDay, *opts.DueFrom)
+	assert.Equal(t, startOfDay.AddDate(0, 0, 1), *opts.DueBefore)
+
+	opts = database.TaskListOptions{}
+	assert.True(t, ApplyDueFilter(&opts, "week", now))
+	assert.Equal(t, startOfDay, *opts.DueFrom)
+	assert.Equal(t, startOfDay.AddDate(0, 0, 7), *opts.DueBefore)
+
+	opts = database.TaskListOptions{}
+	assert.False(t, ApplyDueFilter(&opts, "tomorrow", now))
+	assert.Equal(t, database.TaskListOptions{}, opts)
+}
+
+func TestApplyLabelFilter(t *testing.T) {
+	var opts database.TaskListOptions
+	assert.True(t, ApplyLabelFilter(&opts, "bug, backend,,", "all"))
+	assert.Equal(t, []string{"bug", "backend"}, opts.Labels)
+	assert.Equal(t, "all", opts.LabelMode)
+
+	opts = database.TaskListOptions{}
+	assert.True(t, ApplyLabelFilter(&opts, "", "any"))
+	assert.Empty(t, opts.Labels)
+
+	opts = database.TaskListOptions{}
+	assert.False(t, ApplyLabelFilter(&opts, "bug", "some"))
+	assert.Equal(t, database.TaskListOptions{}, opts)
+}
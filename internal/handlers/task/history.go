@@ -0,0 +1,68 @@
+package task
+
+import (
+	"net/http"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTaskHistory handles GET /tasks/{id}/history
+// @Summary Get the change history of a task
+// @Description Retrieve every recorded creation, update and deletion of a task, oldest first. Each entry names the actor and request that made the change and the before and after values of the changed fields. The history of a deleted task remains available.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Success 200 {object} dto.TaskHistoryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/history [get]
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	events, err := h.repo.GetHistory(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("Failed to fetch task history", "id", id.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch task history"))
+		return
+	}
+	if len(events) == 0 {
+		logger.Info("No history found for task", "id", id.String())
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		return
+	}
+
+	logger.Info("Task history retrieved successfully", "id", id.String(), "count", len(events))
+	c.JSON(http.StatusOK, historyToResponse(id, events))
+}
+
+// historyToResponse converts the recorded events of a task to the API representation
+func historyToResponse(taskID uuid.UUID, events []models.TaskEvent) dto.TaskHistoryResponse {
+	response := dto.TaskHistoryResponse{TaskID: taskID, Events: make([]dto.TaskEventResponse, len(events))}
+	for i, event := range events {
+		changes := make(map[string]dto.FieldChange, len(event.Changes))
+		for field, change := range event.Changes {
+			changes[field] = dto.FieldChange{Before: change.Before, After: change.After}
+		}
+		response.Events[i] = dto.TaskEventResponse{
+			ID:           event.ID,
+			Action:       event.Action,
+			Actor:        event.Actor,
+			ClaimedActor: event.ClaimedActor,
+			RequestID:    event.RequestID,
+			Changes:      changes,
+			CreatedAt:    event.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return response
+}
@@ -0,0 +1,76 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+)
+
+func (suite *TaskHandlerTestSuite) TestGetTaskHistory() {
+	taskID := uuid.New()
+	suite.mockRepo.GetHistoryFunc = func(ctx context.Context, id uuid.UUID) ([]models.TaskEvent, error) {
+		assert.Equal(suite.T(), taskID, id)
+		return []models.TaskEvent{
+			{ID: uuid.New(), TaskID: id, Action: models.TaskEventCreated, Actor: "alice", RequestID: "req-1",
+				Changes: models.TaskChanges{"title": {Before: "", After: "Write docs"}}, CreatedAt: time.Now()},
+			{ID: uuid.New(), TaskID: id, Action: models.TaskEventDeleted, Actor: "bob", RequestID: "req-2",
+				Changes: models.TaskChanges{}, CreatedAt: time.Now()},
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+taskID.String()+"/history", nil)
+	suite.router.GET("/tasks/:id/history", suite.handler.GetTaskHistory)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.TaskHistoryResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), taskID, response.TaskID)
+	require.Len(suite.T(), response.Events, 2)
+	assert.Equal(suite.T(), "created", response.Events[0].Action)
+	assert.Equal(suite.T(), "alice", response.Events[0].Actor)
+	assert.Equal(suite.T(), "req-1", response.Events[0].RequestID)
+	assert.Equal(suite.T(), dto.FieldChange{Before: "", After: "Write docs"}, response.Events[0].Changes["title"])
+	assert.Equal(suite.T(), "deleted", response.Events[1].Action)
+	assert.Empty(suite.T(), response.Events[1].Changes)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTaskHistory_Errors() {
+	testCases := []struct {
+		name     string
+		id       string
+		err      error
+		expected int
+	}{
+		{"invalid id", "invalid-uuid", nil, http.StatusBadRequest},
+		{"no history", uuid.New().String(), nil, http.StatusNotFound},
+		{"repository error", uuid.New().String(), errors.New("database error"), http.StatusInternalServerError},
+	}
+	suite.router.GET("/tasks/:id/history", suite.handler.GetTaskHistory)
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.mockRepo.GetHistoryFunc = func(ctx context.Context, id uuid.UUID) ([]models.TaskEvent, error) {
+				return nil, tc.err
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/tasks/"+tc.id+"/history", nil)
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), tc.expected, w.Code)
+		})
+	}
+}
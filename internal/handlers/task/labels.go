@@ -0,0 +1,193 @@
+package task
+
+import (
+	"context"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/handlers/label"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTaskLabels handles GET /tasks/{id}/labels
+// @Summary List the labels of a task
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Success 200 {object} dto.LabelListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/labels [get]
+func (h *TaskHandler) GetTaskLabels(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := h.repo.GetByID(c.Request.Context(), id); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task not found", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		} else {
+			logger.Error("Failed to get task from repository", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get task"))
+		}
+		return
+	}
+
+	h.writeTaskLabels(c, http.StatusOK, id)
+}
+
+// AddTaskLabel handles POST /tasks/{id}/labels
+// @Summary Attach a label to a task
+// @Description Attach an existing label to a task. Attaching a label the task already carries is a no-op.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param label body dto.AddTaskLabelRequest true "Label to attach"
+// @Success 201 {object} dto.LabelListResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/labels [post]
+func (h *TaskHandler) AddTaskLabel(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if !h.authorizeTask(c, authz.EditTask, id, false) {
+		return
+	}
+
+	var req dto.AddTaskLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for adding label", "id", id.String(), "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+
+	if err := h.repo.AddLabel(c.Request.Context(), id, req.LabelID); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task or label not found", "id", id.String(), "label_id", req.LabelID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task or label not found"))
+		} else {
+			logger.Error("Failed to add label", "id", id.String(), "label_id", req.LabelID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to add label"))
+		}
+		return
+	}
+
+	// The labels are part of the task, so its cached version is stale
+	if err := middleware.TenantCache(c.Request.Context(), h.cache).Invalidate(id.String()); err != nil {
+		// Log error but don't fail the request
+		logger.Error("Failed to invalidate task cache", "id", id.String(), "error", err)
+	}
+
+	logger.Info("Label added successfully", "id", id.String(), "label_id", req.LabelID.String())
+	h.writeTaskLabels(c, http.StatusCreated, id)
+}
+
+// RemoveTaskLabel handles DELETE /tasks/{id}/labels/{label_id}
+// @Summary Detach a label from a task
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param label_id path string true "Label ID (UUID)"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/labels/{label_id} [delete]
+func (h *TaskHandler) RemoveTaskLabel(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	labelIDStr := c.Param("label_id")
+	labelID, err := uuid.Parse(labelIDStr)
+	if err != nil {
+		logger.Error("Invalid label ID provided", "idStr", labelIDStr, "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid label ID"))
+		return
+	}
+	if !h.authorizeTask(c, authz.EditTask, id, false) {
+		return
+	}
+
+	if err := h.repo.RemoveLabel(c.Request.Context(), id, labelID); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task label not found for removal", "id", id.String(), "label_id", labelID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task label not found"))
+		} else {
+			logger.Error("Failed to remove label", "id", id.String(), "label_id", labelID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to remove label"))
+		}
+		return
+	}
+
+	if err := middleware.TenantCache(c.Request.Context(), h.cache).Invalidate(id.String()); err != nil {
+		// Log error but don't fail the request
+		logger.Error("Failed to invalidate task cache", "id", id.String(), "error", err)
+	}
+
+	logger.Info("Label removed successfully", "id", id.String(), "label_id", labelID.String())
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// writeTaskLabels writes the current labels of the task with the given status
+func (h *TaskHandler) writeTaskLabels(c *gin.Context, status int, id uuid.UUID) {
+	labels, err := h.repo.GetLabelsForTasks(c.Request.Context(), []uuid.UUID{id})
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Failed to fetch task labels", "id", id.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch task labels"))
+		return
+	}
+	c.JSON(status, dto.LabelListResponse{Labels: label.LabelsToResponses(labels[id])})
+}
+
+// attachLabels fills in the labels of each response. Failures are logged and leave the
+// responses without labels rather than failing the request.
+func (h *TaskHandler) attachLabels(ctx context.Context, responses []dto.TaskResponse) {
+	if len(responses) == 0 {
+		return
+	}
+	ids := make([]uuid.UUID, len(responses))
+	for i, response := range responses {
+		ids[i] = response.ID
+	}
+
+	labels, err := h.repo.GetLabelsForTasks(ctx, ids)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(ctx)
+		logger.Error("Failed to fetch task labels", "count", len(ids), "error", err)
+		return
+	}
+	for i := range responses {
+		if taskLabels := labels[responses[i].ID]; len(taskLabels) > 0 {
+			responses[i].Labels = label.LabelsToResponses(taskLabels)
+		}
+	}
+}
+
+// labelledTaskResponse converts task to a response carrying its labels
+func (h *TaskHandler) labelledTaskResponse(ctx context.Context, task models.Task) dto.TaskResponse {
+	responses := []dto.TaskResponse{taskToResponse(task)}
+	h.attachLabels(ctx, responses)
+	return responses[0]
+}
@@ -0,0 +1,183 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_LabelFilter() {
+	task := models.Task{ID: uuid.New(), Title: "Crash", Status: types.StatusPending}
+	bug := models.Label{ID: uuid.New(), Name: "bug"}
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), []string{"bug", "backend"}, opts.Labels)
+		assert.Equal(suite.T(), "all", opts.LabelMode)
+		return []models.Task{task}, 1, nil
+	}
+	suite.mockRepo.GetLabelsForTasksFunc = func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+		assert.Equal(suite.T(), []uuid.UUID{task.ID}, ids)
+		return map[uuid.UUID][]models.Label{task.ID: {bug}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?labels=bug,backend&label_mode=all", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.TaskListResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(suite.T(), response.Tasks, 1) {
+		assert.Equal(suite.T(), []dto.LabelResponse{{ID: bug.ID, Name: "bug"}}, response.Tasks[0].Labels)
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_InvalidLabelMode() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?labels=bug&label_mode=some", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTaskLabels() {
+	taskID := uuid.New()
+	bug := models.Label{ID: uuid.New(), Name: "bug", Color: "#ff0000"}
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return &models.Task{ID: id}, nil
+	}
+	suite.mockRepo.GetLabelsForTasksFunc = func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+		return map[uuid.UUID][]models.Label{taskID: {bug}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+taskID.String()+"/labels", nil)
+	suite.router.GET("/tasks/:id/labels", suite.handler.GetTaskLabels)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.LabelListResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), []dto.LabelResponse{{ID: bug.ID, Name: "bug", Color: "#ff0000"}}, response.Labels)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTaskLabels_TaskNotFound() {
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+uuid.New().String()+"/labels", nil)
+	suite.router.GET("/tasks/:id/labels", suite.handler.GetTaskLabels)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestAddTaskLabel_Success() {
+	taskID, labelID := uuid.New(), uuid.New()
+	var added bool
+	suite.mockRepo.AddLabelFunc = func(ctx context.Context, t, l uuid.UUID) error {
+		assert.Equal(suite.T(), taskID, t)
+		assert.Equal(suite.T(), labelID, l)
+		added = true
+		return nil
+	}
+	var invalidated string
+	suite.mockCache.InvalidateFunc = func(id string) error {
+		invalidated = id
+		return nil
+	}
+
+	body, _ := json.Marshal(dto.AddTaskLabelRequest{LabelID: labelID})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/"+taskID.String()+"/labels", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/labels", suite.handler.AddTaskLabel)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+	assert.True(suite.T(), added)
+	assert.Equal(suite.T(), taskID.String(), invalidated)
+}
+
+func (suite *TaskHandlerTestSuite) TestAddTaskLabel_NotFound() {
+	suite.mockRepo.AddLabelFunc = func(ctx context.Context, t, l uuid.UUID) error {
+		return sql.ErrNoRows
+	}
+
+	body, _ := json.Marshal(dto.AddTaskLabelRequest{LabelID: uuid.New()})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/"+uuid.New().String()+"/labels", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/labels", suite.handler.AddTaskLabel)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestAddTaskLabel_InvalidBody() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/"+uuid.New().String()+"/labels", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks/:id/labels", suite.handler.AddTaskLabel)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestRemoveTaskLabel() {
+	taskID := uuid.New()
+	suite.mockRepo.RemoveLabelFunc = func(ctx context.Context, t, l uuid.UUID) error {
+		return nil
+	}
+	var invalidated string
+	suite.mockCache.InvalidateFunc = func(id string) error {
+		invalidated = id
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+taskID.String()+"/labels/"+uuid.New().String(), nil)
+	suite.router.DELETE("/tasks/:id/labels/:label_id", suite.handler.RemoveTaskLabel)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), taskID.String(), invalidated)
+}
+
+func (suite *TaskHandlerTestSuite) TestRemoveTaskLabel_NotFound() {
+	suite.mockRepo.RemoveLabelFunc = func(ctx context.Context, t, l uuid.UUID) error {
+		return sql.ErrNoRows
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+uuid.New().String()+"/labels/"+uuid.New().String(), nil)
+	suite.router.DELETE("/tasks/:id/labels/:label_id", suite.handler.RemoveTaskLabel)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestRemoveTaskLabel_InvalidLabelID() {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+uuid.New().String()+"/labels/not-a-uuid", nil)
+	suite.router.DELETE("/tasks/:id/labels/:label_id", suite.handler.RemoveTaskLabel)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,148 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/pkg/jsonpatch"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Media types of the patch documents accepted by PatchTask
+const (
+	mergePatchMediaType = "application/merge-patch+json"
+	jsonPatchMediaType  = "application/json-patch+json"
+)
+
+// PatchTask handles PATCH /tasks/{id}
+// @Summary Patch a task
+// @Description Change some fields of a task with a JSON Merge Patch (RFC 7396, application/merge-patch+json) or a JSON Patch (RFC 6902, application/json-patch+json). Patches apply to the document accepted by PUT; a null member of a merge patch, or a remove operation, clears the field. A status change must be allowed by the task workflow (see GET /workflow).
+// @Tags tasks
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Param If-Match header string false "Only update the task if its ETag matches"
+// @Param patch body object true "Merge patch object or array of JSON Patch operations"
+// @Success 200 {object} dto.TaskResponse
+// @Header 200 {string} ETag "Entity tag of the updated task"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 415 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id} [patch]
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	contentType := c.ContentType()
+	if contentType != mergePatchMediaType && contentType != jsonPatchMediaType {
+		logger.Info("Unsupported patch media type", "content_type", contentType)
+		c.JSON(http.StatusUnsupportedMediaType, dto.NewErrorResponse("Unsupported patch format",
+			"Content-Type must be "+mergePatchMediaType+" or "+jsonPatchMediaType))
+		return
+	}
+
+	task, ok := h.findTaskForUpdate(c)
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		logger.Error("Failed to read patch", "id", task.ID.String(), "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid patch document", err.Error()))
+		return
+	}
+	apply, err := decodePatch(contentType, body)
+	if err != nil {
+		logger.Info("Invalid patch document", "id", task.ID.String(), "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid patch document", err.Error()))
+		return
+	}
+	if !checkIfMatch(c, *task) {
+		return
+	}
+
+	doc, err := taskDocument(taskToReplaceRequest(*task))
+	if err != nil {
+		logger.Error("Failed to encode task for patching", "id", task.ID.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to update task"))
+		return
+	}
+
+	patched, err := apply(doc)
+	if err != nil {
+		logger.Info("Patch cannot be applied", "id", task.ID.String(), "error", err)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("Patch test failed", err.Error()))
+		} else {
+			c.JSON(http.StatusUnprocessableEntity, dto.NewErrorResponse("Patch cannot be applied", err.Error()))
+		}
+		return
+	}
+
+	req, err := decodePatchedTask(patched)
+	if err != nil {
+		logger.Info("Patched task is invalid", "id", task.ID.String(), "error", err)
+		c.JSON(http.StatusUnprocessableEntity, dto.NewErrorResponse("Invalid patched task", err.Error()))
+		return
+	}
+
+	h.replaceTask(c, task, req)
+}
+
+// decodePatch parses a patch document of the given media type into a function applying it
+func decodePatch(contentType string, body []byte) (func(doc any) (any, error), error) {
+	if contentType == jsonPatchMediaType {
+		patch, err := jsonpatch.Decode(body)
+		if err != nil {
+			return nil, err
+		}
+		return patch.Apply, nil
+	}
+
+	var patch any
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, err
+	}
+	return func(doc any) (any, error) {
+		return jsonpatch.MergePatch(doc, patch), nil
+	}, nil
+}
+
+// taskDocument converts the editable fields of a task into the JSON document that patches apply to
+func taskDocument(req dto.ReplaceTaskRequest) (any, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	err = json.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// decodePatchedTask turns a patched task document back into a validated replace request
+func decodePatchedTask(doc any) (dto.ReplaceTaskRequest, error) {
+	var req dto.ReplaceTaskRequest
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return req, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		return req, err
+	}
+	return req, binding.Validator.ValidateStruct(&req)
+}
@@ -0,0 +1,200 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+// patchTestTask returns a task with every editable field set
+func patchTestTask() models.Task {
+	dueAt := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	parentID := uuid.New()
+	return models.Task{
+		ID:          uuid.New(),
+		Title:       "Write docs",
+		Description: "Document the API",
+		Status:      types.StatusInProgress,
+		Assignee:    "alice",
+		Estimate:    3,
+		Priority:    types.PriorityHigh,
+		DueAt:       &dueAt,
+		ParentID:    &parentID,
+		Version:     2,
+	}
+}
+
+// sendPatch sends a patch document to the registered PATCH route of a task served by the mock repository,
+// and returns the response and the task as it was saved, if it was
+func (suite *TaskHandlerTestSuite) sendPatch(task models.Task, contentType, patch string) (*httptest.ResponseRecorder, *models.Task) {
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		current := task
+		return &current, nil
+	}
+	var saved *models.Task
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, t *models.Task) error {
+		saved = t
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/"+task.ID.String(), bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", contentType)
+	suite.router.ServeHTTP(w, req)
+	return w, saved
+}
+
+func (suite *TaskHandlerTestSuite) TestPatchTask_MergePatch() {
+	suite.router.PATCH("/tasks/:id", suite.handler.PatchTask)
+	task := patchTestTask()
+
+	w, saved := suite.sendPatch(task, "application/merge-patch+json", `{"title":"Write more docs","assignee":null,"due_at":null}`)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NotNil(suite.T(), saved)
+	assert.Equal(suite.T(), "Write more docs", saved.Title)
+	assert.Empty(suite.T(), saved.Assignee)
+	assert.Nil(suite.T(), saved.DueAt)
+	// Fields the patch leaves out keep their values
+	assert.Equal(suite.T(), "Document the API", saved.Description)
+	assert.Equal(suite.T(), types.StatusInProgress, saved.Status)
+	assert.Equal(suite.T(), types.PriorityHigh, saved.Priority)
+	assert.Equal(suite.T(), 3.0, saved.Estimate)
+	assert.Equal(suite.T(), task.ParentID, saved.ParentID)
+
+	var response dto.TaskResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Write more docs", response.Title)
+	assert.Nil(suite.T(), response.DueAt)
+}
+
+func (suite *TaskHandlerTestSuite) TestPatchTask_JSONPatch() {
+	suite.router.PATCH("/tasks/:id", suite.handler.PatchTask)
+	task := patchTestTask()
+
+	w, saved := suite.sendPatch(task, "application/json-patch+json", `[
+		{"op": "test", "path": "/status", "value": "in_progress"},
+		{"op": "replace", "path": "/status", "value": "completed"},
+		{"op": "copy", "from": "/title", "path": "/description"},
+		{"op": "remove", "path": "/parent_id"}
+	]`)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NotNil(suite.T(), saved)
+	assert.Equal(suite.T(), types.StatusCompleted, saved.Status)
+	assert.Equal(suite.T(), "Write docs", saved.Description)
+	assert.Nil(suite.T(), saved.ParentID)
+	assert.Equal(suite.T(), "alice", saved.Assignee)
+}
+
+func (suite *TaskHandlerTestSuite) TestPatchTask_TestFailed() {
+	suite.router.PATCH("/tasks/:id", suite.handler.PatchTask)
+
+	w, saved := suite.sendPatch(patchTestTask(), "application/json-patch+json", `[
+		{"op": "test", "path": "/assignee", "value": "bob"},
+		{"op": "replace", "path": "/assignee", "value": "carol"}
+	]`)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	assert.Nil(suite.T(), saved)
+	var response dto.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Patch test failed", response.Error)
+}
+
+func (suite *TaskHandlerTestSuite) TestPatchTask_Errors() {
+	suite.router.PATCH("/tasks/:id", suite.handler.PatchTask)
+
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		status      int
+		error       string
+	}{
+		{"unsupported media type", "application/json", `{"title":"x"}`, http.StatusUnsupportedMediaType, "Unsupported patch format"},
+		{"malformed merge patch", "application/merge-patch+json", `{"title":`, http.StatusBadRequest, "Invalid patch document"},
+		{"malformed JSON patch", "application/json-patch+json", `[{"op":"jump","path":"/title"}]`, http.StatusBadRequest, "Invalid patch document"},
+		{"missing path", "application/json-patch+json", `[{"op":"replace","path":"/owner","value":"x"}]`, http.StatusUnprocessableEntity, "Patch cannot be applied"},
+		{"read-only field", "application/json-patch+json", `[{"op":"add","path":"/version","value":9}]`, http.StatusUnprocessableEntity, "Invalid patched task"},
+		{"required field removed", "application/merge-patch+json", `{"title":null}`, http.StatusUnprocessableEntity, "Invalid patched task"},
+		{"wrong type", "application/merge-patch+json", `{"estimate":"soon"}`, http.StatusUnprocessableEntity, "Invalid patched task"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			w, _ := suite.sendPatch(patchTestTask(), tt.contentType, tt.patch)
+			assert.Equal(suite.T(), tt.status, w.Code)
+			if tt.error != "" {
+				var response dto.ErrorResponse
+				require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(suite.T(), tt.error, response.Error)
+			}
+		})
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestPatchTask_IfMatch() {
+	suite.router.PATCH("/tasks/:id", suite.handler.PatchTask)
+	task := patchTestTask()
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		current := task
+		return &current, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/"+task.ID.String(), bytes.NewBufferString(`{"title":"Renamed"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusPreconditionFailed, w.Code)
+	assert.Equal(suite.T(), `"2"`, w.Header().Get("ETag"))
+}
+
+func (suite *TaskHandlerTestSuite) TestUpdateTask_ReplacesWholeTask() {
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+	task := patchTestTask()
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		current := task
+		return &current, nil
+	}
+	var saved *models.Task
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, t *models.Task) error {
+		saved = t
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/tasks/"+task.ID.String(), bytes.NewBufferString(`{"title":"Rewritten","status":"in_progress"}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	require.NotNil(suite.T(), saved)
+	assert.Equal(suite.T(), "Rewritten", saved.Title)
+	assert.Empty(suite.T(), saved.Description)
+	assert.Empty(suite.T(), saved.Assignee)
+	assert.Zero(suite.T(), saved.Estimate)
+	assert.Equal(suite.T(), types.PriorityMedium, saved.Priority)
+	assert.Nil(suite.T(), saved.DueAt)
+	assert.Nil(suite.T(), saved.ParentID)
+
+	// A replacement must name the status
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("PUT", "/tasks/"+task.ID.String(), bytes.NewBufferString(`{"title":"Rewritten"}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,84 @@
+package task
+
+import (
+	"net/http"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/graph"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetPlan handles GET /tasks/plan
+// @Summary Get the execution plan for unfinished tasks
+// @Description Order all non-completed tasks so every task comes after its blockers, grouped into waves that can run in parallel. When tasks carry an estimate the critical path is included.
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.PlanResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/plan [get]
+func (h *TaskHandler) GetPlan(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	tasks, err := h.repo.GetUnfinished(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to fetch unfinished tasks", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to build plan"))
+		return
+	}
+
+	ids := make([]uuid.UUID, len(tasks))
+	byID := make(map[uuid.UUID]models.Task, len(tasks))
+	g := graph.New[uuid.UUID]()
+	for i, task := range tasks {
+		ids[i] = task.ID
+		byID[task.ID] = task
+		g.AddNode(task.ID)
+	}
+
+	edges, err := h.repo.GetDependencies(c.Request.Context(), ids)
+	if err != nil {
+		logger.Error("Failed to fetch task dependencies", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to build plan"))
+		return
+	}
+	for _, edge := range edges {
+		g.AddEdge(edge.BlockerID, edge.TaskID)
+	}
+
+	waves, err := g.Waves()
+	if err != nil {
+		logger.Error("Task dependencies are not acyclic", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to build plan", err.Error()))
+		return
+	}
+
+	response := dto.PlanResponse{
+		Order: make([]uuid.UUID, 0, len(tasks)),
+		Waves: make([]dto.PlanWave, len(waves)),
+	}
+	hasEstimates := false
+	for i, wave := range waves {
+		waveTasks := make([]models.Task, len(wave))
+		for j, id := range wave {
+			waveTasks[j] = byID[id]
+			hasEstimates = hasEstimates || byID[id].Estimate > 0
+		}
+		response.Order = append(response.Order, wave...)
+		response.Waves[i] = dto.PlanWave{Wave: i + 1, Tasks: tasksToResponses(waveTasks)}
+	}
+
+	if hasEstimates {
+		path, total, err := g.CriticalPath(func(id uuid.UUID) float64 { return byID[id].Estimate })
+		if err == nil {
+			response.CriticalPath = &dto.CriticalPathResponse{TaskIDs: path, Estimate: total}
+		}
+	}
+
+	logger.Info("Plan built successfully", "tasks", len(tasks), "waves", len(waves))
+	c.JSON(http.StatusOK, response)
+}
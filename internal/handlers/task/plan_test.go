@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func (suite *TaskHandlerTestSuite) TestGetPlan_Success() {
+	design := models.Task{ID: uuid.New(), Title: "Design", Status: types.StatusInProgress, Estimate: 3}
+	build := models.Task{ID: uuid.New(), Title: "Build", Status: types.StatusPending, Estimate: 5}
+	docs := models.Task{ID: uuid.New(), Title: "Docs", Status: types.StatusPending, Estimate: 1}
+
+	suite.mockRepo.GetUnfinishedFunc = func(ctx context.Context) ([]models.Task, error) {
+		return []models.Task{design, build, docs}, nil
+	}
+	suite.mockRepo.GetDependenciesFunc = func(ctx context.Context, ids []uuid.UUID) ([]models.TaskDependency, error) {
+		assert.Len(suite.T(), ids, 3)
+		return []models.TaskDependency{{TaskID: build.ID, BlockerID: design.ID}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/plan", nil)
+	suite.router.GET("/tasks/plan", suite.handler.GetPlan)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.PlanResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), []uuid.UUID{design.ID, docs.ID, build.ID}, response.Order)
+	assert.Len(suite.T(), response.Waves, 2)
+	assert.Equal(suite.T(), 1, response.Waves[0].Wave)
+	assert.Len(suite.T(), response.Waves[0].Tasks, 2)
+	assert.Equal(suite.T(), build.ID, response.Waves[1].Tasks[0].ID)
+	if assert.NotNil(suite.T(), response.CriticalPath) {
+		assert.Equal(suite.T(), []uuid.UUID{design.ID, build.ID}, response.CriticalPath.TaskIDs)
+		assert.Equal(suite.T(), 8.0, response.CriticalPath.Estimate)
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestGetPlan_WithoutEstimates() {
+	suite.mockRepo.GetUnfinishedFunc = func(ctx context.Context) ([]models.Task, error) {
+		return []models.Task{{ID: uuid.New(), Title: "Only", Status: types.StatusPending}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/plan", nil)
+	suite.router.GET("/tasks/plan", suite.handler.GetPlan)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), "critical_path")
+}
+
+func (suite *TaskHandlerTestSuite) TestGetPlan_RepositoryError() {
+	suite.mockRepo.GetUnfinishedFunc = func(ctx context.Context) ([]models.Task, error) {
+		return nil, assert.AnError
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/plan", nil)
+	suite.router.GET("/tasks/plan", suite.handler.GetPlan)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
+}
@@ -1,15 +1,20 @@
 package task
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"taheri24.ir/graph1/internal/authz"
 	"taheri24.ir/graph1/internal/cache"
 	"taheri24.ir/graph1/internal/database"
 	"taheri24.ir/graph1/internal/dto"
 	"taheri24.ir/graph1/internal/middleware"
 	"taheri24.ir/graph1/internal/models"
-	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
 	"taheri24.ir/graph1/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -17,26 +22,36 @@ import (
 )
 
 type TaskHandler struct {
-	repo  database.TaskRepository
-	cache cache.CacheInterface[models.Task]
+	repo     database.TaskRepository
+	cache    cache.CacheInterface[models.Task]
+	workflow *workflow.Workflow
 }
 
-func NewTaskHandler(repo database.TaskRepository, cache cache.CacheInterface[models.Task]) *TaskHandler {
-	return &TaskHandler{repo: repo, cache: cache}
+func NewTaskHandler(repo database.TaskRepository, cache cache.CacheInterface[models.Task], wf *workflow.Workflow) *TaskHandler {
+	return &TaskHandler{repo: repo, cache: cache, workflow: wf}
 }
 
 // CreateTask handles POST /tasks
 // @Summary Create a new task
-// @Description Create a new task with the provided information
+// @Description Create a new task with the provided information. The assignee, named by username or by assignee_id, must be a user (see GET /users).
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param task body dto.CreateTaskRequest true "Task information"
+// @Param Idempotency-Key header string false "Replay the stored response when a request with this key is retried"
 // @Success 201 {object} dto.TaskResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/tasks [post]
 func (h *TaskHandler) CreateTask(c *gin.Context) {
+	if !authz.Check(c, authz.CreateTask, nil) {
+		return
+	}
+
 	var req dto.CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
@@ -44,53 +59,56 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.NewErr(err))
 		return
 	}
-
-	task := models.Task{
-		ID:          uuid.New(),
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
-		Assignee:    req.Assignee,
+	if req.Status != "" && !h.checkStatus(c, req.Status) {
+		return
 	}
 
-	if req.Status == "" {
-		task.Status = types.StatusPending
-	}
+	task := BuildTask(req)
 
 	if err := h.repo.Create(c.Request.Context(), &task); err != nil {
+		if writeParentError(c, err) || writeAssigneeError(c, err) {
+			return
+		}
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
 		logger.Error("Failed to create task in repository", "title", req.Title, "error", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to create task"))
 		return
 	}
 
-	response := dto.TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		Assignee:    task.Assignee,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := taskToResponse(task)
 
 	logger := middleware.GetLoggerFromContext(c.Request.Context())
 	logger.Info("Task created successfully", "id", task.ID.String(), "title", task.Title, "status", string(task.Status))
 
+	c.Header("ETag", taskETag(task))
 	c.JSON(http.StatusCreated, response)
 }
 
 // GetTasks handles GET /tasks
 // @Summary Get all tasks with pagination and filtering
-// @Description Retrieve a paginated list of tasks with optional filtering by status and assignee
+// @Description Retrieve a paginated list of tasks with optional filtering by status and assignee, sorted by creation time unless sort is given. With q only the tasks whose title or description contain its words are listed, most relevant first unless sort is given, each with the matching words highlighted in match.
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Param q query string false "Full-text search over title and description"
+// @Param filter query string false "Filter expression, e.g. status in (pending, in_progress) and assignee != \"bot\" and created_at > 2026-01-01"
 // @Param page query int false "Page number (default: 1)" minimum(1)
 // @Param limit query int false "Items per page (default: 10, max: 100)" minimum(1) maximum(100)
+// @Param cursor query string false "Resume after the last task of a previous page, from its next_cursor; page is then ignored"
+// @Param include_total query bool false "Count the matching tasks (default: true)"
 // @Param status query string false "Filter by status (pending, in_progress, completed)"
 // @Param assignee query string false "Filter by assignee"
+// @Param sort query string false "Sort field (created_at, updated_at, due_at, priority, title, status)"
+// @Param order query string false "Sort order (asc, desc; default: asc)"
+// @Param due query string false "Only unfinished tasks that are overdue, due today or due within the week (overdue, today, week)"
+// @Param labels query string false "Comma-separated label names to filter by"
+// @Param label_mode query string false "Whether tasks need all or any of the labels (all, any; default: any)"
+// @Param If-None-Match header string false "Answer 304 if the list still has this ETag"
 // @Success 200 {object} dto.TaskListResponse
+// @Header 200 {string} ETag "Entity tag of the list"
+// @Header 200 {string} Last-Modified "Latest update among the listed tasks"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} dto.FilterErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/tasks [get]
 func (h *TaskHandler) GetTasks(c *gin.Context) {
@@ -98,6 +116,13 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "10")
 	status := c.Query("status")
 	assignee := c.Query("assignee")
+	sort := c.DefaultQuery("sort", "created_at")
+	order := c.DefaultQuery("order", "asc")
+
+	if errs := ValidateListSort(sort, order); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid sort parameters", errs[0].Message))
+		return
+	}
 
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
@@ -109,42 +134,136 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		limit = 10
 	}
 
-	tasks, total, err := h.repo.GetAll(c.Request.Context(), page, limit, status, assignee)
+	opts := database.TaskListOptions{
+		Page:     page,
+		Limit:    limit,
+		Status:   status,
+		Assignee: assignee,
+		Sort:     sort,
+		Order:    order,
+	}
+	if due := c.Query("due"); due != "" && !ApplyDueFilter(&opts, due, time.Now()) {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid due filter", "due must be one of: overdue, today, week"))
+		return
+	}
+	if !ApplyLabelFilter(&opts, c.Query("labels"), c.DefaultQuery("label_mode", "any")) {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid label mode", "label_mode must be one of: all, any"))
+		return
+	}
+	if filter := c.Query("filter"); filter != "" {
+		expr, err := database.ParseTaskFilter(filter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, filterErrorResponse(err))
+			return
+		}
+		opts.Filter = expr
+	}
+	includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid include_total", "include_total must be true or false"))
+		return
+	}
+	opts.SkipTotal = !includeTotal
+
+	query := strings.TrimSpace(c.Query("q"))
+	if cursor := c.Query("cursor"); cursor != "" {
+		if err := applyCursor(&opts, cursor, query); err != nil {
+			c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid cursor", err.Error()))
+			return
+		}
+	}
+	// Without a count, one more task is fetched to tell whether there is a next page
+	peek := opts.After != nil || opts.SkipTotal
+	if peek {
+		opts.Limit = limit + 1
+	}
+
+	var tasks []models.Task
+	var matches []database.TaskSearchResult
+	var total int64
+	if query != "" {
+		// Search results are ranked by relevance unless a sort is asked for
+		if c.Query("sort") == "" {
+			opts.Sort = ""
+		}
+		matches, total, err = h.repo.Search(c.Request.Context(), query, opts)
+		tasks = searchResultTasks(matches)
+	} else {
+		tasks, total, err = h.repo.GetAll(c.Request.Context(), opts)
+	}
 	if err != nil {
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
-		logger.Error("Failed to fetch tasks from repository", "page", page, "limit", limit, "status", status, "assignee", assignee, "error", err)
+		logger.Error("Failed to fetch tasks from repository", "page", page, "limit", limit, "status", status, "assignee", assignee, "sort", sort, "order", order, "q", query, "error", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch tasks"))
 		return
 	}
 
-	taskResponses := make([]dto.TaskResponse, len(tasks))
-	for i, task := range tasks {
-		taskResponses[i] = dto.TaskResponse{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Status:      task.Status,
-			Assignee:    task.Assignee,
-			CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	hasNext := page < totalPages
+	if peek {
+		hasNext = len(tasks) > limit
+		if hasNext {
+			tasks = tasks[:limit]
+			if matches != nil {
+				matches = matches[:limit]
+			}
 		}
 	}
 
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	responses := tasksToResponses(tasks)
+	h.attachLabels(c.Request.Context(), responses)
+	attachMatches(responses, matches)
 
 	response := dto.TaskListResponse{
-		Tasks:       taskResponses,
-		Total:       total,
+		Tasks:       responses,
 		Page:        page,
 		Limit:       limit,
-		HasNext:     page < totalPages,
-		HasPrevious: page > 1,
+		HasNext:     hasNext,
+		HasPrevious: page > 1 || opts.After != nil,
+	}
+	if includeTotal {
+		response.Total = &total
+	}
+	// Cursors follow the created_at, id order, so they are only issued for listings in that order
+	if hasNext && query == "" && sort == "created_at" {
+		response.NextCursor = encodeCursor(tasks[len(tasks)-1], order)
 	}
 
 	logger := middleware.GetLoggerFromContext(c.Request.Context())
 	logger.Info("Tasks retrieved successfully", "page", page, "limit", limit, "total", total, "status", status, "assignee", assignee)
 
-	c.JSON(http.StatusOK, response)
+	writeListResponse(c, response, lastModified(tasks...))
+}
+
+// filterErrorResponse describes why a filter was rejected, pointing at the offending token
+func filterErrorResponse(err error) dto.FilterErrorResponse {
+	response := dto.FilterErrorResponse{Error: "Invalid filter", Message: err.Error()}
+	var filterErr *database.FilterError
+	if errors.As(err, &filterErr) {
+		response.Message = filterErr.Message
+		response.Position = filterErr.Position
+		response.Token = filterErr.Token
+	}
+	return response
+}
+
+// applyCursor sets opts to resume from cursor, failing when the cursor cannot be used for the listing
+func applyCursor(opts *database.TaskListOptions, cursor, query string) error {
+	if query != "" {
+		return errors.New("cursor cannot be combined with q; use page instead")
+	}
+	if opts.Sort != "created_at" {
+		return errors.New("cursor requires sort=created_at")
+	}
+	after, order, err := decodeCursor(cursor)
+	if err != nil {
+		return err
+	}
+	if order != opts.Order {
+		return fmt.Errorf("the cursor was issued for order=%s", order)
+	}
+	opts.After = after
+	return nil
 }
 
 // GetTask handles GET /tasks/{id}
@@ -154,9 +273,14 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID (UUID)"
+// @Param If-None-Match header string false "Answer 304 if the task still has this ETag"
+// @Param If-Modified-Since header string false "Answer 304 if the task has not changed since this time"
 // @Success 200 {object} dto.TaskResponse
+// @Header 200 {string} ETag "Entity tag of the task version"
+// @Header 200 {string} Last-Modified "Time of the last change to the task"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} dto.ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
 // @Router /api/v1/tasks/{id} [get]
 func (h *TaskHandler) GetTask(c *gin.Context) {
 	idStr := c.Param("id")
@@ -169,21 +293,20 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 	}
 
 	// Try to get from cache first
-	taskPtr, err := h.cache.Get(id.String())
+	taskPtr, err := middleware.TenantCache(c.Request.Context(), h.cache).Get(id.String())
 	if err == nil && taskPtr != nil {
 		// Cache hit
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
 		logger.Info("Task retrieved from cache", "id", id.String())
 		c.Header("X-Cache-Status", "HIT")
-		response := dto.TaskResponse{
-			ID:          taskPtr.ID,
-			Title:       taskPtr.Title,
-			Description: taskPtr.Description,
-			Status:      taskPtr.Status,
-			Assignee:    taskPtr.Assignee,
-			CreatedAt:   taskPtr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:   taskPtr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		// Revalidation is answered from the cached task alone
+		etag, modified := taskETag(*taskPtr), lastModified(*taskPtr)
+		setValidators(c, etag, modified)
+		if notModified(c, etag, modified) {
+			c.Status(http.StatusNotModified)
+			return
 		}
+		response := h.labelledTaskResponse(c.Request.Context(), *taskPtr)
 		c.JSON(http.StatusOK, response)
 		return
 	}
@@ -205,7 +328,7 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 	}
 
 	// Set in cache
-	if err := h.cache.Set(id.String(), *taskPtr); err != nil {
+	if err := middleware.TenantCache(c.Request.Context(), h.cache).Set(id.String(), *taskPtr); err != nil {
 		// Log error but don't fail the request
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
 		logger.Error("Failed to set task in cache", "id", id.String(), "error", err)
@@ -215,40 +338,66 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 	logger.Info("Task retrieved from database", "id", id.String())
 
 	c.Header("X-Cache-Status", "MISS")
-	response := dto.TaskResponse{
-		ID:          taskPtr.ID,
-		Title:       taskPtr.Title,
-		Description: taskPtr.Description,
-		Status:      taskPtr.Status,
-		Assignee:    taskPtr.Assignee,
-		CreatedAt:   taskPtr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   taskPtr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	etag, modified := taskETag(*taskPtr), lastModified(*taskPtr)
+	setValidators(c, etag, modified)
+	if notModified(c, etag, modified) {
+		c.Status(http.StatusNotModified)
+		return
 	}
+	response := h.labelledTaskResponse(c.Request.Context(), *taskPtr)
 
 	c.JSON(http.StatusOK, response)
 }
 
 // UpdateTask handles PUT /tasks/{id}
-// @Summary Update a task
-// @Description Update an existing task with the provided information. Only provided fields will be updated.
+// @Summary Replace a task
+// @Description Replace the editable fields of a task. Optional fields that are left out are cleared or reset to their defaults; use PATCH to change some fields only. A status change must be allowed by the task workflow (see GET /workflow). The task is reassigned by changing either assignee or assignee_id, to a user.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID (UUID)"
-// @Param task body dto.UpdateTaskRequest true "Task update information"
+// @Param If-Match header string false "Only update the task if its ETag matches"
+// @Param task body dto.ReplaceTaskRequest true "New task contents"
 // @Success 200 {object} dto.TaskResponse
+// @Header 200 {string} ETag "Entity tag of the updated task"
 // @Failure 400 {object} dto.ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.TransitionErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/tasks/{id} [put]
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	task, ok := h.findTaskForUpdate(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ReplaceTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Invalid request body for updating task", "id", c.Param("id"), "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+	if !checkIfMatch(c, *task) {
+		return
+	}
+
+	h.replaceTask(c, task, req)
+}
+
+// findTaskForUpdate loads the task named by the id path parameter and checks that the caller may edit it,
+// writing an error response when it cannot
+func (h *TaskHandler) findTaskForUpdate(c *gin.Context) (*models.Task, bool) {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
 		logger.Error("Invalid task ID provided", "idStr", idStr, "error", err)
 		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid task ID"))
-		return
+		return nil, false
 	}
 
 	task, err := h.repo.GetByID(c.Request.Context(), id)
@@ -261,71 +410,70 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 			logger.Error("Failed to get task for update", "id", id.String(), "error", err)
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get task"))
 		}
-		return
+		return nil, false
+	}
+	if !authz.Check(c, authz.EditTask, task) {
+		return nil, false
 	}
+	return task, true
+}
 
-	var req dto.UpdateTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger := middleware.GetLoggerFromContext(c.Request.Context())
-		logger.Error("Invalid request body for updating task", "id", id.String(), "error", err)
-		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+// replaceTask checks the status change, saves the new contents of task and writes the updated task
+func (h *TaskHandler) replaceTask(c *gin.Context, task *models.Task, req dto.ReplaceTaskRequest) {
+	if !h.checkTransition(c, task.Status, req.Status) {
 		return
 	}
 
-	// Update only provided fields
-	if req.Title != nil {
-		task.Title = *req.Title
-	}
-	if req.Description != nil {
-		task.Description = *req.Description
-	}
-	if req.Status != nil {
-		task.Status = *req.Status
-	}
-	if req.Assignee != nil {
-		task.Assignee = *req.Assignee
-	}
+	ApplyReplace(task, req)
 
 	if err := h.repo.Update(c.Request.Context(), task); err != nil {
+		if writeParentError(c, err) || writeAssigneeError(c, err) {
+			return
+		}
+		if errors.Is(err, database.ErrVersionConflict) {
+			logger := middleware.GetLoggerFromContext(c.Request.Context())
+			logger.Info("Task update lost a concurrent write", "id", task.ID.String())
+			writeVersionConflict(c)
+			return
+		}
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
-		logger.Error("Failed to update task in repository", "id", id.String(), "error", err)
+		logger.Error("Failed to update task in repository", "id", task.ID.String(), "error", err)
 		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to update task"))
 		return
 	}
 
 	// Invalidate cache
-	if err := h.cache.Invalidate(id.String()); err != nil {
+	if err := middleware.TenantCache(c.Request.Context(), h.cache).Invalidate(task.ID.String()); err != nil {
 		// Log error but don't fail the request
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
-		logger.Error("Failed to invalidate task cache", "id", id.String(), "error", err)
+		logger.Error("Failed to invalidate task cache", "id", task.ID.String(), "error", err)
 	}
 
-	response := dto.TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		Assignee:    task.Assignee,
-		CreatedAt:   task.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   task.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := taskToResponse(*task)
 
 	logger := middleware.GetLoggerFromContext(c.Request.Context())
 	logger.Info("Task updated successfully", "id", task.ID.String(), "title", task.Title, "status", string(task.Status))
 
+	c.Header("ETag", taskETag(*task))
 	c.JSON(http.StatusOK, response)
 }
 
 // DeleteTask handles DELETE /tasks/{id}
 // @Summary Delete a task
-// @Description Delete a task by its UUID
+// @Description Move a task to the trash, or delete it permanently when purge is true. Tasks with subtasks are only deleted, together with all their descendants, when cascade is true. Purging also removes subtasks that are already in the trash.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID (UUID)"
+// @Param cascade query bool false "Also delete all subtasks (default: false)"
+// @Param purge query bool false "Delete permanently instead of moving to the trash; works on trashed tasks too (default: false)"
+// @Param If-Match header string false "Only delete the task if its ETag matches, also for a task in the trash when purging"
 // @Success 204 "No Content"
 // @Failure 400 {object} dto.ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
@@ -337,11 +485,57 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid task ID"))
 		return
 	}
-	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
+	cascade, purge := c.Query("cascade") == "true", c.Query("purge") == "true"
+	if !h.authorizeDelete(c, id, cascade, purge) {
+		return
+	}
+
+	// With If-Match the task is only deleted at the version that matched, so that a change made in between
+	// is not lost; a purge may also match a task in the trash
+	version := database.AnyVersion
+	if c.GetHeader("If-Match") != "" {
+		getTask := h.repo.GetByID
+		if purge {
+			getTask = h.repo.GetByIDWithTrashed
+		}
+		current, err := getTask(c.Request.Context(), id)
+		if err != nil && !utils.ErrIsRecordNotFound(err) {
+			logger := middleware.GetLoggerFromContext(c.Request.Context())
+			logger.Error("Failed to get task for deletion", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get task"))
+			return
+		}
+		if err != nil {
+			// A task that does not exist cannot match any entity tag
+			c.JSON(http.StatusPreconditionFailed, dto.NewErrorResponse("Precondition failed", "the task does not exist"))
+			return
+		}
+		if !checkIfMatch(c, *current) {
+			return
+		}
+		version = current.Version
+	}
+
+	deleted := []uuid.UUID{id}
+	switch {
+	case purge:
+		deleted, err = h.repo.Purge(c.Request.Context(), id, cascade, version)
+	case cascade:
+		deleted, err = h.repo.DeleteTree(c.Request.Context(), id, version)
+	default:
+		err = h.repo.Delete(c.Request.Context(), id, version)
+	}
+	if err != nil {
 		logger := middleware.GetLoggerFromContext(c.Request.Context())
 		if utils.ErrIsRecordNotFound(err) {
 			logger.Info("Task not found for deletion", "id", id.String())
 			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		} else if errors.Is(err, database.ErrTaskHasChildren) {
+			logger.Info("Refused to delete task with subtasks", "id", id.String())
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("Task has subtasks", "delete the subtasks first or pass cascade=true"))
+		} else if errors.Is(err, database.ErrVersionConflict) {
+			logger.Info("Task modified before deletion", "id", id.String())
+			writeVersionConflict(c)
 		} else {
 			logger.Error("Failed to delete task from repository", "id", id.String(), "error", err)
 			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to delete task"))
@@ -350,14 +544,17 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	}
 
 	// Invalidate cache
-	if err := h.cache.Invalidate(id.String()); err != nil {
-		// Log error but don't fail the request
-		logger := middleware.GetLoggerFromContext(c.Request.Context())
-		logger.Error("Failed to invalidate task cache", "id", id.String(), "error", err)
+	taskCache := middleware.TenantCache(c.Request.Context(), h.cache)
+	for _, deletedID := range deleted {
+		if err := taskCache.Invalidate(deletedID.String()); err != nil {
+			// Log error but don't fail the request
+			logger := middleware.GetLoggerFromContext(c.Request.Context())
+			logger.Error("Failed to invalidate task cache", "id", deletedID.String(), "error", err)
+		}
 	}
 
 	logger := middleware.GetLoggerFromContext(c.Request.Context())
-	logger.Info("Task deleted successfully", "id", id.String())
+	logger.Info("Task deleted successfully", "id", id.String(), "count", len(deleted))
 
 	c.JSON(http.StatusNoContent, nil)
 }
@@ -2,6 +2,7 @@ package task
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"taheri24.ir/graph1/internal/dto"
 	"taheri24.ir/graph1/internal/models"
 	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
 	"taheri24.ir/graph1/pkg/config"
 
 	"github.com/gin-gonic/gin"
@@ -43,6 +45,15 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 
 	// Clean up any existing data
 	suite.db.DB.Exec("DELETE FROM tasks")
+	suite.db.DB.Exec("DELETE FROM users")
+
+	// Tasks can only be assigned to users
+	for _, username := range []string{"integration@example.com", "original@example.com", "user1@example.com", "user2@example.com"} {
+		if err := suite.db.CreateUser(context.TODO(), &models.User{Username: username}); err != nil {
+			suite.T().Skipf("Skipping integration tests: %v", err)
+			return
+		}
+	}
 
 	// Setup cache
 	redisAddr := fmt.Sprintf("%s:%s", testConfig.Redis.Host, testConfig.Redis.Port)
@@ -55,7 +66,8 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 
 	// Setup router
 	suite.router = gin.New()
-	taskHandler := NewTaskHandler(suite.db, taskCache)
+	suite.router.Use(asAdmin)
+	taskHandler := NewTaskHandler(suite.db, taskCache, workflow.Default())
 
 	api := suite.router.Group("/tasks")
 	{
@@ -70,6 +82,7 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 func (suite *IntegrationTestSuite) TearDownSuite() {
 	if suite.db != nil {
 		suite.db.DB.Exec("DELETE FROM tasks")
+		suite.db.DB.Exec("DELETE FROM users")
 		suite.db.Close()
 	}
 }
@@ -154,9 +167,9 @@ func (suite *IntegrationTestSuite) TestUpdateTask() {
 	taskID := createResp.ID.String()
 
 	// Update the task
-	updateReq := dto.UpdateTaskRequest{
-		Title:  stringPtr("Updated Title"),
-		Status: statusPtr(types.StatusCompleted),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusCompleted,
 	}
 
 	w2 := httptest.NewRecorder()
@@ -243,7 +256,7 @@ func (suite *IntegrationTestSuite) TestGetTasksWithPagination() {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), 2, len(resp.Tasks))
-	assert.Equal(suite.T(), int64(3), resp.Total)
+	assert.Equal(suite.T(), int64(3), *resp.Total)
 	assert.Equal(suite.T(), 1, resp.Page)
 	assert.Equal(suite.T(), 2, resp.Limit)
 }
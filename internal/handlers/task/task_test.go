@@ -4,29 +4,60 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
 	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
 	"taheri24.ir/graph1/internal/models"
 	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
 )
 
 // MockTaskRepository implements TaskRepository for testing
 type MockTaskRepository struct {
 	CreateFunc  func(ctx context.Context, task *models.Task) error
 	GetByIDFunc func(ctx context.Context, id uuid.UUID) (*models.Task, error)
-	GetAllFunc  func(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error)
+	GetAllFunc  func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error)
+	SearchFunc  func(ctx context.Context, query string, opts database.TaskListOptions) ([]database.TaskSearchResult, int64, error)
 	UpdateFunc  func(ctx context.Context, task *models.Task) error
-	DeleteFunc  func(ctx context.Context, id uuid.UUID) error
+	DeleteFunc  func(ctx context.Context, id uuid.UUID, version int64) error
+
+	AddDependencyFunc         func(ctx context.Context, taskID, blockerID uuid.UUID) error
+	RemoveDependencyFunc      func(ctx context.Context, taskID, blockerID uuid.UUID) error
+	GetBlockersFunc           func(ctx context.Context, taskID uuid.UUID) ([]models.Task, error)
+	GetDependentsFunc         func(ctx context.Context, taskID uuid.UUID) ([]models.Task, error)
+	GetDependenciesFunc       func(ctx context.Context, taskIDs []uuid.UUID) ([]models.TaskDependency, error)
+	GetBlockersForTasksFunc   func(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error)
+	GetDependentsForTasksFunc func(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error)
+	GetUnfinishedFunc         func(ctx context.Context) ([]models.Task, error)
+	GetFilteredFunc           func(ctx context.Context, status, assignee string) ([]models.Task, error)
+	ClaimDueTasksFunc         func(ctx context.Context, now time.Time) ([]models.Task, error)
+	AddLabelFunc              func(ctx context.Context, taskID, labelID uuid.UUID) error
+	RemoveLabelFunc           func(ctx context.Context, taskID, labelID uuid.UUID) error
+	GetLabelsForTasksFunc     func(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error)
+	GetSubtreeFunc            func(ctx context.Context, id uuid.UUID) ([]models.Task, error)
+	DeleteTreeFunc            func(ctx context.Context, id uuid.UUID, version int64) ([]uuid.UUID, error)
+	GetHistoryFunc            func(ctx context.Context, taskID uuid.UUID) ([]models.TaskEvent, error)
+	GetTrashedFunc            func(ctx context.Context, page, limit int) ([]models.Task, int64, error)
+	GetByIDWithTrashedFunc    func(ctx context.Context, id uuid.UUID) (*models.Task, error)
+	RestoreFunc               func(ctx context.Context, id uuid.UUID) ([]models.Task, error)
+	PurgeFunc                 func(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error)
+	TransactionFunc           func(ctx context.Context, fn func(repo database.TaskRepository) error) error
 }
 
 // MockCache implements CacheInterface for testing
@@ -57,6 +88,10 @@ func (m *MockCache) Invalidate(id string) error {
 	return nil
 }
 
+func (m *MockCache) Section(name string) cache.CacheInterface[models.Task] {
+	return m
+}
+
 func (m *MockCache) GetAll() ([]models.Task, error) {
 	return nil, nil
 }
@@ -83,9 +118,16 @@ func (m *MockTaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return nil, nil
 }
 
-func (m *MockTaskRepository) GetAll(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error) {
+func (m *MockTaskRepository) GetAll(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc(ctx, page, limit, status, assignee)
+		return m.GetAllFunc(ctx, opts)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockTaskRepository) Search(ctx context.Context, query string, opts database.TaskListOptions) ([]database.TaskSearchResult, int64, error) {
+	if m.SearchFunc != nil {
+		return m.SearchFunc(ctx, query, opts)
 	}
 	return nil, 0, nil
 }
@@ -97,13 +139,160 @@ func (m *MockTaskRepository) Update(ctx context.Context, task *models.Task) erro
 	return nil
 }
 
-func (m *MockTaskRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockTaskRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
 	if m.DeleteFunc != nil {
-		return m.DeleteFunc(ctx, id)
+		return m.DeleteFunc(ctx, id, version)
+	}
+	return nil
+}
+
+func (m *MockTaskRepository) AddDependency(ctx context.Context, taskID, blockerID uuid.UUID) error {
+	if m.AddDependencyFunc != nil {
+		return m.AddDependencyFunc(ctx, taskID, blockerID)
+	}
+	return nil
+}
+
+func (m *MockTaskRepository) RemoveDependency(ctx context.Context, taskID, blockerID uuid.UUID) error {
+	if m.RemoveDependencyFunc != nil {
+		return m.RemoveDependencyFunc(ctx, taskID, blockerID)
 	}
 	return nil
 }
 
+func (m *MockTaskRepository) GetBlockers(ctx context.Context, taskID uuid.UUID) ([]models.Task, error) {
+	if m.GetBlockersFunc != nil {
+		return m.GetBlockersFunc(ctx, taskID)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetDependents(ctx context.Context, taskID uuid.UUID) ([]models.Task, error) {
+	if m.GetDependentsFunc != nil {
+		return m.GetDependentsFunc(ctx, taskID)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetBlockersForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error) {
+	if m.GetBlockersForTasksFunc != nil {
+		return m.GetBlockersForTasksFunc(ctx, taskIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetDependentsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Task, error) {
+	if m.GetDependentsForTasksFunc != nil {
+		return m.GetDependentsForTasksFunc(ctx, taskIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetDependencies(ctx context.Context, taskIDs []uuid.UUID) ([]models.TaskDependency, error) {
+	if m.GetDependenciesFunc != nil {
+		return m.GetDependenciesFunc(ctx, taskIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetUnfinished(ctx context.Context) ([]models.Task, error) {
+	if m.GetUnfinishedFunc != nil {
+		return m.GetUnfinishedFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetFiltered(ctx context.Context, status, assignee string) ([]models.Task, error) {
+	if m.GetFilteredFunc != nil {
+		return m.GetFilteredFunc(ctx, status, assignee)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) ClaimDueTasks(ctx context.Context, now time.Time) ([]models.Task, error) {
+	if m.ClaimDueTasksFunc != nil {
+		return m.ClaimDueTasksFunc(ctx, now)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) AddLabel(ctx context.Context, taskID, labelID uuid.UUID) error {
+	if m.AddLabelFunc != nil {
+		return m.AddLabelFunc(ctx, taskID, labelID)
+	}
+	return nil
+}
+
+func (m *MockTaskRepository) RemoveLabel(ctx context.Context, taskID, labelID uuid.UUID) error {
+	if m.RemoveLabelFunc != nil {
+		return m.RemoveLabelFunc(ctx, taskID, labelID)
+	}
+	return nil
+}
+
+func (m *MockTaskRepository) GetLabelsForTasks(ctx context.Context, taskIDs []uuid.UUID) (map[uuid.UUID][]models.Label, error) {
+	if m.GetLabelsForTasksFunc != nil {
+		return m.GetLabelsForTasksFunc(ctx, taskIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetSubtree(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+	if m.GetSubtreeFunc != nil {
+		return m.GetSubtreeFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) DeleteTree(ctx context.Context, id uuid.UUID, version int64) ([]uuid.UUID, error) {
+	if m.DeleteTreeFunc != nil {
+		return m.DeleteTreeFunc(ctx, id, version)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskEvent, error) {
+	if m.GetHistoryFunc != nil {
+		return m.GetHistoryFunc(ctx, taskID)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) GetTrashed(ctx context.Context, page, limit int) ([]models.Task, int64, error) {
+	if m.GetTrashedFunc != nil {
+		return m.GetTrashedFunc(ctx, page, limit)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockTaskRepository) GetByIDWithTrashed(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	if m.GetByIDWithTrashedFunc != nil {
+		return m.GetByIDWithTrashedFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) Restore(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) Purge(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error) {
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(ctx, id, cascade, version)
+	}
+	return nil, nil
+}
+
+func (m *MockTaskRepository) Transaction(ctx context.Context, fn func(repo database.TaskRepository) error) error {
+	if m.TransactionFunc != nil {
+		return m.TransactionFunc(ctx, fn)
+	}
+	return fn(m)
+}
+
 type TaskHandlerTestSuite struct {
 	suite.Suite
 	mockRepo  *MockTaskRepository
@@ -116,9 +305,18 @@ func (suite *TaskHandlerTestSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
 	suite.mockRepo = &MockTaskRepository{}
 	suite.mockCache = &MockCache{}
-	suite.handler = NewTaskHandler(suite.mockRepo, suite.mockCache)
+	suite.handler = NewTaskHandler(suite.mockRepo, suite.mockCache, workflow.Default())
 	suite.router = gin.New()
+	suite.router.Use(asAdmin)
+}
 
+// asAdmin authenticates the requests not made by another caller as an admin, as AuthMiddleware would, since
+// callers that were not authenticated may only read
+func asAdmin(c *gin.Context) {
+	if _, ok := middleware.GetSubjectFromContext(c.Request.Context()); !ok {
+		ctx := middleware.ContextWithSubject(c.Request.Context(), "admin")
+		c.Request = c.Request.WithContext(middleware.ContextWithRole(ctx, middleware.RoleAdmin))
+	}
 }
 
 func TestTaskHandlerTestSuite(t *testing.T) {
@@ -285,6 +483,18 @@ func statusPtr(s types.TaskStatus) *types.TaskStatus {
 	return &s
 }
 
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func priorityPtr(p types.TaskPriority) *types.TaskPriority {
+	return &p
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 func (suite *TaskHandlerTestSuite) TestGetTasks_Success() {
 	// Setup
 	expectedTasks := []models.Task{
@@ -308,7 +518,7 @@ func (suite *TaskHandlerTestSuite) TestGetTasks_Success() {
 		},
 	}
 
-	suite.mockRepo.GetAllFunc = func(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error) {
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
 		return expectedTasks, 2, nil
 	}
 
@@ -325,7 +535,7 @@ func (suite *TaskHandlerTestSuite) TestGetTasks_Success() {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), 2, len(response.Tasks))
-	assert.Equal(suite.T(), int64(2), response.Total)
+	assert.Equal(suite.T(), int64(2), *response.Total)
 	assert.Equal(suite.T(), 1, response.Page)
 	assert.Equal(suite.T(), 10, response.Limit)
 }
@@ -343,9 +553,9 @@ func (suite *TaskHandlerTestSuite) TestUpdateTask_Success() {
 		UpdatedAt:   time.Now(),
 	}
 
-	updateReq := dto.UpdateTaskRequest{
-		Title:  stringPtr("Updated Title"),
-		Status: statusPtr(types.StatusCompleted),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusCompleted,
 	}
 
 	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
@@ -381,7 +591,7 @@ func (suite *TaskHandlerTestSuite) TestUpdateTask_Success() {
 func (suite *TaskHandlerTestSuite) TestDeleteTask_Success() {
 	// Setup
 	taskID := uuid.New()
-	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID) error {
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
 		return nil
 	}
 
@@ -398,7 +608,7 @@ func (suite *TaskHandlerTestSuite) TestDeleteTask_Success() {
 func (suite *TaskHandlerTestSuite) TestDeleteTask_NotFound() {
 	// Setup
 	taskID := uuid.New()
-	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID) error {
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
 		return sql.ErrNoRows
 	}
 
@@ -436,7 +646,7 @@ func (suite *TaskHandlerTestSuite) TestDeleteTask_InvalidID() {
 func (suite *TaskHandlerTestSuite) TestDeleteTask_DatabaseError() {
 	// Setup
 	taskID := uuid.New()
-	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID) error {
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
 		return assert.AnError
 	}
 
@@ -458,8 +668,9 @@ func (suite *TaskHandlerTestSuite) TestDeleteTask_DatabaseError() {
 func (suite *TaskHandlerTestSuite) TestUpdateTask_NotFound() {
 	// Setup
 	taskID := uuid.New()
-	updateReq := dto.UpdateTaskRequest{
-		Title: stringPtr("Updated Title"),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusPending,
 	}
 
 	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
@@ -485,8 +696,9 @@ func (suite *TaskHandlerTestSuite) TestUpdateTask_NotFound() {
 
 func (suite *TaskHandlerTestSuite) TestUpdateTask_InvalidID() {
 	// Setup
-	updateReq := dto.UpdateTaskRequest{
-		Title: stringPtr("Updated Title"),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusPending,
 	}
 
 	// Execute
@@ -536,8 +748,9 @@ func (suite *TaskHandlerTestSuite) TestUpdateTask_DatabaseError() {
 		UpdatedAt:   time.Now(),
 	}
 
-	updateReq := dto.UpdateTaskRequest{
-		Title: stringPtr("Updated Title"),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusPending,
 	}
 
 	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
@@ -610,6 +823,27 @@ func (suite *TaskHandlerTestSuite) TestCreateTask_DatabaseError() {
 	assert.Equal(suite.T(), http.StatusInternalServerError, w.Code)
 }
 
+func (suite *TaskHandlerTestSuite) TestCreateTask_UnknownAssignee() {
+	assigneeID := uuid.New()
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		assert.Equal(suite.T(), &assigneeID, task.AssigneeID)
+		return fmt.Errorf("%w: no user has the ID %s", database.ErrUnknownAssignee, assigneeID)
+	}
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(dto.CreateTaskRequest{Title: "Test Task", AssigneeID: &assigneeID})
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+	var response dto.ErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Unknown assignee", response.Error)
+	assert.Contains(suite.T(), response.Message, assigneeID.String())
+}
+
 func (suite *TaskHandlerTestSuite) TestGetTasks_WithFilters() {
 	// Setup
 	expectedTasks := []models.Task{
@@ -624,11 +858,11 @@ func (suite *TaskHandlerTestSuite) TestGetTasks_WithFilters() {
 		},
 	}
 
-	suite.mockRepo.GetAllFunc = func(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error) {
-		assert.Equal(suite.T(), 1, page)
-		assert.Equal(suite.T(), 5, limit)
-		assert.Equal(suite.T(), "pending", status)
-		assert.Equal(suite.T(), "user1@example.com", assignee)
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), 1, opts.Page)
+		assert.Equal(suite.T(), 5, opts.Limit)
+		assert.Equal(suite.T(), "pending", opts.Status)
+		assert.Equal(suite.T(), "user1@example.com", opts.Assignee)
 		return expectedTasks, 1, nil
 	}
 
@@ -645,12 +879,345 @@ func (suite *TaskHandlerTestSuite) TestGetTasks_WithFilters() {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), 1, len(response.Tasks))
-	assert.Equal(suite.T(), int64(1), response.Total)
+	assert.Equal(suite.T(), int64(1), *response.Total)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_Sorting() {
+	// Setup
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), "due_at", opts.Sort)
+		assert.Equal(suite.T(), "desc", opts.Order)
+		return nil, 0, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?sort=due_at&order=desc", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_DefaultSort() {
+	// Setup
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), "created_at", opts.Sort)
+		assert.Equal(suite.T(), "asc", opts.Order)
+		return nil, 0, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_Search() {
+	// Setup
+	task := models.Task{ID: uuid.New(), Title: "Fix login crash", Status: types.StatusPending}
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		suite.T().Error("GetAll should not be called when searching")
+		return nil, 0, nil
+	}
+	suite.mockRepo.SearchFunc = func(ctx context.Context, query string, opts database.TaskListOptions) ([]database.TaskSearchResult, int64, error) {
+		assert.Equal(suite.T(), "login crash", query)
+		assert.Empty(suite.T(), opts.Sort, "search results are ranked by relevance")
+		assert.Equal(suite.T(), "pending", opts.Status)
+		return []database.TaskSearchResult{{Task: task, Rank: 1.5, TitleSnippet: "Fix <mark>login</mark> <mark>crash</mark>"}}, 1, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?q=+login+crash+&status=pending", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.TaskListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(1), *response.Total)
+	require.Len(suite.T(), response.Tasks, 1)
+	assert.Equal(suite.T(), task.ID, response.Tasks[0].ID)
+	require.NotNil(suite.T(), response.Tasks[0].Match)
+	assert.Equal(suite.T(), 1.5, response.Tasks[0].Match.Rank)
+	assert.Equal(suite.T(), "Fix <mark>login</mark> <mark>crash</mark>", response.Tasks[0].Match.Title)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_SearchSorted() {
+	// Setup
+	suite.mockRepo.SearchFunc = func(ctx context.Context, query string, opts database.TaskListOptions) ([]database.TaskSearchResult, int64, error) {
+		assert.Equal(suite.T(), "title", opts.Sort)
+		return nil, 0, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?q=login&sort=title", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.NotContains(suite.T(), w.Body.String(), `"match"`)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_Cursor() {
+	// Setup
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.FixedZone("", 3*60*60))
+	tasks := make([]models.Task, 3)
+	for i := range tasks {
+		tasks[i] = models.Task{ID: uuid.New(), Title: fmt.Sprintf("Task %d", i), CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+	}
+	var calls []database.TaskListOptions
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		calls = append(calls, opts)
+		if opts.After == nil {
+			return tasks, 0, nil
+		}
+		return tasks[2:], 0, nil
+	}
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	list := func(query string) dto.TaskListResponse {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks"+query, nil)
+		suite.router.ServeHTTP(w, req)
+		require.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.NotContains(suite.T(), w.Body.String(), `"total"`)
+		var response dto.TaskListResponse
+		require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Execute
+	first := list("?limit=2&include_total=false")
+	second := list("?limit=2&include_total=false&cursor=" + first.NextCursor)
+
+	// Assert
+	require.Len(suite.T(), calls, 2)
+	// One more task than the limit is fetched to tell whether there is a next page, without counting
+	assert.Equal(suite.T(), 3, calls[0].Limit)
+	assert.True(suite.T(), calls[0].SkipTotal)
+	assert.Nil(suite.T(), first.Total)
+	require.Len(suite.T(), first.Tasks, 2)
+	assert.True(suite.T(), first.HasNext)
+	assert.False(suite.T(), first.HasPrevious)
+	assert.NotEmpty(suite.T(), first.NextCursor)
+
+	// The cursor resumes after the last task of the page
+	require.NotNil(suite.T(), calls[1].After)
+	assert.Equal(suite.T(), tasks[1].ID, calls[1].After.ID)
+	assert.True(suite.T(), tasks[1].CreatedAt.Equal(calls[1].After.CreatedAt))
+	_, offset := calls[1].After.CreatedAt.Zone()
+	assert.Equal(suite.T(), 3*60*60, offset, "the cursor keeps the time zone of the stored time")
+	require.Len(suite.T(), second.Tasks, 1)
+	assert.False(suite.T(), second.HasNext)
+	assert.True(suite.T(), second.HasPrevious)
+	assert.Empty(suite.T(), second.NextCursor)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_CursorWithTotal() {
+	// Setup
+	tasks := []models.Task{{ID: uuid.New(), Title: "First"}, {ID: uuid.New(), Title: "Second"}}
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), 1, opts.Limit)
+		assert.False(suite.T(), opts.SkipTotal)
+		return tasks[:1], 2, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?limit=1", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	var response dto.TaskListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(2), *response.Total)
+	assert.True(suite.T(), response.HasNext)
+	// Page-based listings also hand out a cursor to continue from
+	after, order, err := decodeCursor(response.NextCursor)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), tasks[0].ID, after.ID)
+	assert.Equal(suite.T(), "asc", order)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_InvalidCursor() {
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	cursor := encodeCursor(models.Task{ID: uuid.New(), CreatedAt: time.Now()}, "asc")
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"not a cursor", "?cursor=abc"},
+		{"not JSON", "?cursor=" + base64.RawURLEncoding.EncodeToString([]byte("abc"))},
+		{"different order", "?order=desc&cursor=" + cursor},
+		{"different sort", "?sort=title&cursor=" + cursor},
+		{"search", "?q=login&cursor=" + cursor},
+		{"invalid include_total", "?include_total=maybe"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/tasks"+tt.query, nil)
+			suite.router.ServeHTTP(w, req)
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_Filter() {
+	// Setup
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), &database.FilterComparison{Field: "assignee", Op: "!=", Values: []any{"bot"}}, opts.Filter)
+		return nil, 0, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?filter="+url.QueryEscape(`assignee != "bot"`), nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_InvalidFilter() {
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?filter="+url.QueryEscape(`status = pending and owner = "me"`), nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	var response dto.FilterErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Invalid filter", response.Error)
+	assert.Contains(suite.T(), response.Message, `unknown field "owner"`)
+	assert.Equal(suite.T(), 22, response.Position)
+	assert.Equal(suite.T(), "owner", response.Token)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_DueFilter() {
+	// Setup
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
+		assert.Nil(suite.T(), opts.DueFrom)
+		if assert.NotNil(suite.T(), opts.DueBefore) {
+			assert.WithinDuration(suite.T(), time.Now(), *opts.DueBefore, time.Minute)
+		}
+		assert.True(suite.T(), opts.Unfinished)
+		return nil, 0, nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?due=overdue", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_InvalidDueFilter() {
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?due=someday", nil)
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTasks_InvalidSort() {
+	suite.router.GET("/tasks", suite.handler.GetTasks)
+
+	for _, query := range []string{"sort=assignee", "order=sideways"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestCreateTask_PriorityAndDueDate() {
+	// Setup
+	dueAt := time.Date(2030, 3, 1, 9, 30, 0, 0, time.UTC)
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		assert.Equal(suite.T(), types.PriorityHigh, task.Priority)
+		assert.True(suite.T(), dueAt.Equal(*task.DueAt))
+		return nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title":"Release","priority":"high","due_at":"2030-03-01T09:30:00Z"}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response dto.TaskResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), types.PriorityHigh, response.Priority)
+	if assert.NotNil(suite.T(), response.DueAt) {
+		assert.Equal(suite.T(), "2030-03-01T09:30:00Z", *response.DueAt)
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestCreateTask_DefaultPriority() {
+	// Setup
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		return nil
+	}
+
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title":"Someday"}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response dto.TaskResponse
+	assert.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), types.PriorityMedium, response.Priority)
+	assert.Nil(suite.T(), response.DueAt)
+}
+
+func (suite *TaskHandlerTestSuite) TestCreateTask_InvalidPriority() {
+	// Execute
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBufferString(`{"title":"Release","priority":"critical"}`))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+	suite.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
 }
 
 func (suite *TaskHandlerTestSuite) TestGetTasks_DatabaseError() {
 	// Setup
-	suite.mockRepo.GetAllFunc = func(ctx context.Context, page, limit int, status, assignee string) ([]models.Task, int64, error) {
+	suite.mockRepo.GetAllFunc = func(ctx context.Context, opts database.TaskListOptions) ([]models.Task, int64, error) {
 		return nil, 0, assert.AnError
 	}
 
@@ -717,8 +1284,9 @@ func (suite *TaskHandlerTestSuite) TestUpdateTask_CacheInvalidate() {
 		UpdatedAt:   time.Now(),
 	}
 
-	updateReq := dto.UpdateTaskRequest{
-		Title: stringPtr("Updated Title"),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusPending,
 	}
 
 	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
@@ -769,8 +1337,9 @@ func (suite *TaskHandlerTestSuite) TestUpdateTask_CacheInvalidateError() {
 		UpdatedAt:   time.Now(),
 	}
 
-	updateReq := dto.UpdateTaskRequest{
-		Title: stringPtr("Updated Title"),
+	updateReq := dto.ReplaceTaskRequest{
+		Title:  "Updated Title",
+		Status: types.StatusPending,
 	}
 
 	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
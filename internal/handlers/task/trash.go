@@ -0,0 +1,111 @@
+package task
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash handles GET /tasks/trash
+// @Summary List deleted tasks
+// @Description Retrieve a paginated list of the tasks in the trash, most recently deleted first. Trashed tasks are purged for good once they are older than the configured retention.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number (default: 1)" minimum(1)
+// @Param limit query int false "Items per page (default: 10, max: 100)" minimum(1) maximum(100)
+// @Success 200 {object} dto.TaskListResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/trash [get]
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	tasks, total, err := h.repo.GetTrashed(c.Request.Context(), page, limit)
+	if err != nil {
+		logger.Error("Failed to fetch trashed tasks", "page", page, "limit", limit, "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch trashed tasks"))
+		return
+	}
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	response := dto.TaskListResponse{
+		Tasks:       tasksToResponses(tasks),
+		Total:       &total,
+		Page:        page,
+		Limit:       limit,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+
+	logger.Info("Trashed tasks retrieved successfully", "page", page, "limit", limit, "total", total)
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreTask handles POST /tasks/{id}/restore
+// @Summary Restore a deleted task
+// @Description Take a task out of the trash together with the subtasks and comments that were deleted with it. A task whose parent has been purged becomes a top-level task.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Success 200 {object} dto.TaskResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/restore [post]
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if !h.authorizeTask(c, authz.DeleteTask, id, true) {
+		return
+	}
+
+	restored, err := h.repo.Restore(c.Request.Context(), id)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task not found in trash", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found in trash"))
+		} else if errors.Is(err, database.ErrParentDeleted) {
+			logger.Info("Refused to restore task with deleted parent", "id", id.String())
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("Parent task is in the trash", "restore the parent task first"))
+		} else {
+			logger.Error("Failed to restore task", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to restore task"))
+		}
+		return
+	}
+
+	// Cache the restored tasks as GetTask would after reading them from the repository
+	taskCache := middleware.TenantCache(c.Request.Context(), h.cache)
+	for _, task := range restored {
+		if err := taskCache.Set(task.ID.String(), task); err != nil {
+			// Log error but don't fail the request
+			logger.Error("Failed to set task in cache", "id", task.ID.String(), "error", err)
+		}
+	}
+
+	logger.Info("Task restored successfully", "id", id.String(), "count", len(restored))
+	c.JSON(http.StatusOK, h.labelledTaskResponse(c.Request.Context(), restored[0]))
+}
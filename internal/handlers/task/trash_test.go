@@ -0,0 +1,140 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func (suite *TaskHandlerTestSuite) TestGetTrash() {
+	deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	suite.mockRepo.GetTrashedFunc = func(ctx context.Context, page, limit int) ([]models.Task, int64, error) {
+		assert.Equal(suite.T(), 2, page)
+		assert.Equal(suite.T(), 1, limit)
+		return []models.Task{{ID: uuid.New(), Title: "Old", Status: types.StatusPending,
+			DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}}, 3, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/trash?page=2&limit=1", nil)
+	suite.router.GET("/tasks/trash", suite.handler.GetTrash)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.TaskListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), int64(3), *response.Total)
+	assert.True(suite.T(), response.HasNext)
+	assert.True(suite.T(), response.HasPrevious)
+	require.Len(suite.T(), response.Tasks, 1)
+	require.NotNil(suite.T(), response.Tasks[0].DeletedAt)
+	assert.Equal(suite.T(), "2026-01-02T03:04:05Z", *response.Tasks[0].DeletedAt)
+}
+
+func (suite *TaskHandlerTestSuite) TestRestoreTask() {
+	epic := models.Task{ID: uuid.New(), Title: "Epic", Status: types.StatusPending}
+	story := models.Task{ID: uuid.New(), Title: "Story", Status: types.StatusPending, ParentID: &epic.ID}
+	suite.mockRepo.RestoreFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+		return []models.Task{epic, story}, nil
+	}
+	cached := map[string]models.Task{}
+	suite.mockCache.SetFunc = func(id string, item models.Task) error {
+		cached[id] = item
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/"+epic.ID.String()+"/restore", nil)
+	suite.router.POST("/tasks/:id/restore", suite.handler.RestoreTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var response dto.TaskResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), epic.ID, response.ID)
+	assert.Nil(suite.T(), response.DeletedAt)
+	assert.Equal(suite.T(), map[string]models.Task{epic.ID.String(): epic, story.ID.String(): story}, cached)
+}
+
+func (suite *TaskHandlerTestSuite) TestRestoreTask_Errors() {
+	testCases := []struct {
+		name     string
+		id       string
+		err      error
+		expected int
+	}{
+		{"invalid id", "invalid-uuid", nil, http.StatusBadRequest},
+		{"not in trash", uuid.New().String(), sql.ErrNoRows, http.StatusNotFound},
+		{"parent in trash", uuid.New().String(), database.ErrParentDeleted, http.StatusConflict},
+		{"repository error", uuid.New().String(), errors.New("database error"), http.StatusInternalServerError},
+	}
+	suite.router.POST("/tasks/:id/restore", suite.handler.RestoreTask)
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.mockRepo.RestoreFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+				return nil, tc.err
+			}
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/tasks/"+tc.id+"/restore", nil)
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), tc.expected, w.Code)
+		})
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestDeleteTask_Purge() {
+	taskID, subtaskID := uuid.New(), uuid.New()
+	suite.mockRepo.PurgeFunc = func(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error) {
+		assert.True(suite.T(), cascade)
+		return []uuid.UUID{id, subtaskID}, nil
+	}
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
+		suite.Fail("Delete must not be called when purging")
+		return nil
+	}
+	var invalidated []string
+	suite.mockCache.InvalidateFunc = func(id string) error {
+		invalidated = append(invalidated, id)
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+taskID.String()+"?purge=true&cascade=true", nil)
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), []string{taskID.String(), subtaskID.String()}, invalidated)
+}
+
+func (suite *TaskHandlerTestSuite) TestDeleteTask_PurgeWithSubtasks() {
+	suite.mockRepo.PurgeFunc = func(ctx context.Context, id uuid.UUID, cascade bool, version int64) ([]uuid.UUID, error) {
+		return nil, database.ErrTaskHasChildren
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+uuid.New().String()+"?purge=true", nil)
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
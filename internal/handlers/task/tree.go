@@ -0,0 +1,100 @@
+package task
+
+import (
+	"errors"
+	"math"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTaskTree handles GET /tasks/{id}/tree
+// @Summary Get the subtask tree of a task
+// @Description Retrieve a task with all of its subtasks nested below it. Every node carries the percentage of completed tasks in its subtree.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID (UUID)"
+// @Success 200 {object} dto.TaskTreeNode
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/tasks/{id}/tree [get]
+func (h *TaskHandler) GetTaskTree(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	tasks, err := h.repo.GetSubtree(c.Request.Context(), id)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("Task not found for tree", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("Task not found"))
+		} else {
+			logger.Error("Failed to fetch task tree", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch task tree"))
+		}
+		return
+	}
+
+	tree, _, _ := buildTaskTree(tasks[0], childrenByParent(tasks))
+	logger.Info("Task tree retrieved successfully", "id", id.String(), "count", len(tasks))
+	c.JSON(http.StatusOK, tree)
+}
+
+// childrenByParent groups tasks under the ID of their parent, keeping their order
+func childrenByParent(tasks []models.Task) map[uuid.UUID][]models.Task {
+	children := make(map[uuid.UUID][]models.Task)
+	for _, task := range tasks {
+		if task.ParentID != nil {
+			children[*task.ParentID] = append(children[*task.ParentID], task)
+		}
+	}
+	return children
+}
+
+// buildTaskTree nests the descendants of task below it and returns the node together with
+// the number of tasks and completed tasks in its subtree
+func buildTaskTree(task models.Task, children map[uuid.UUID][]models.Task) (dto.TaskTreeNode, int, int) {
+	node := dto.TaskTreeNode{TaskResponse: taskToResponse(task), Children: []dto.TaskTreeNode{}}
+	total, completed := 1, 0
+	if task.Status == types.StatusCompleted {
+		completed++
+	}
+
+	for _, child := range children[task.ID] {
+		childNode, childTotal, childCompleted := buildTaskTree(child, children)
+		node.Children = append(node.Children, childNode)
+		total += childTotal
+		completed += childCompleted
+	}
+
+	node.Completion = math.Round(float64(completed)/float64(total)*1000) / 10
+	return node, total, completed
+}
+
+// writeParentError writes the response for a rejected parent_id and reports whether err was one
+func writeParentError(c *gin.Context, err error) bool {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	switch {
+	case errors.Is(err, database.ErrParentNotFound):
+		logger.Info("Parent task not found", "error", err)
+		c.JSON(http.StatusNotFound, dto.NewErrorResponse("Parent task not found"))
+	case errors.Is(err, database.ErrParentCycle):
+		logger.Info("Rejected cyclic parent", "error", err)
+		c.JSON(http.StatusConflict, dto.NewErrorResponse("Task cannot be its own ancestor"))
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+)
+
+func (suite *TaskHandlerTestSuite) TestGetTaskTree() {
+	epic := models.Task{ID: uuid.New(), Title: "Epic", Status: types.StatusInProgress}
+	story := models.Task{ID: uuid.New(), Title: "Story", Status: types.StatusPending, ParentID: &epic.ID}
+	done := models.Task{ID: uuid.New(), Title: "Done", Status: types.StatusCompleted, ParentID: &epic.ID}
+	subtask := models.Task{ID: uuid.New(), Title: "Subtask", Status: types.StatusCompleted, ParentID: &story.ID}
+	suite.mockRepo.GetSubtreeFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+		return []models.Task{epic, story, done, subtask}, nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+epic.ID.String()+"/tree", nil)
+	suite.router.GET("/tasks/:id/tree", suite.handler.GetTaskTree)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var tree dto.TaskTreeNode
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &tree))
+	assert.Equal(suite.T(), epic.ID, tree.ID)
+	assert.Equal(suite.T(), 50.0, tree.Completion)
+	require.Len(suite.T(), tree.Children, 2)
+	assert.Equal(suite.T(), "Story", tree.Children[0].Title)
+	assert.Equal(suite.T(), &epic.ID, tree.Children[0].ParentID)
+	assert.Equal(suite.T(), 50.0, tree.Children[0].Completion)
+	assert.Equal(suite.T(), 100.0, tree.Children[1].Completion)
+	require.Len(suite.T(), tree.Children[0].Children, 1)
+	assert.Equal(suite.T(), "Subtask", tree.Children[0].Children[0].Title)
+	assert.Empty(suite.T(), tree.Children[0].Children[0].Children)
+}
+
+func (suite *TaskHandlerTestSuite) TestGetTaskTree_NotFound() {
+	suite.mockRepo.GetSubtreeFunc = func(ctx context.Context, id uuid.UUID) ([]models.Task, error) {
+		return nil, sql.ErrNoRows
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/"+uuid.New().String()+"/tree", nil)
+	suite.router.GET("/tasks/:id/tree", suite.handler.GetTaskTree)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestCreateTask_ParentNotFound() {
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		return database.ErrParentNotFound
+	}
+
+	parentID := uuid.New()
+	body, _ := json.Marshal(dto.CreateTaskRequest{Title: "Subtask", ParentID: &parentID})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestUpdateTask_ParentCycle() {
+	taskID := uuid.New()
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return &models.Task{ID: id, Title: "Epic", Status: types.StatusPending}, nil
+	}
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, task *models.Task) error {
+		assert.Equal(suite.T(), &taskID, task.ParentID)
+		return database.ErrParentCycle
+	}
+
+	body, _ := json.Marshal(dto.ReplaceTaskRequest{Title: "Epic", Status: types.StatusPending, ParentID: &taskID})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/tasks/"+taskID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestDeleteTask_HasSubtasks() {
+	suite.mockRepo.DeleteFunc = func(ctx context.Context, id uuid.UUID, version int64) error {
+		return database.ErrTaskHasChildren
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+uuid.New().String(), nil)
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *TaskHandlerTestSuite) TestDeleteTask_Cascade() {
+	taskID, childID := uuid.New(), uuid.New()
+	suite.mockRepo.DeleteTreeFunc = func(ctx context.Context, id uuid.UUID, version int64) ([]uuid.UUID, error) {
+		return []uuid.UUID{id, childID}, nil
+	}
+	var invalidated []string
+	suite.mockCache.InvalidateFunc = func(id string) error {
+		invalidated = append(invalidated, id)
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/"+taskID.String()+"?cascade=true", nil)
+	suite.router.DELETE("/tasks/:id", suite.handler.DeleteTask)
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.Equal(suite.T(), []string{taskID.String(), childID.String()}, invalidated)
+}
+
+func TestApplyUpdate_ParentID(t *testing.T) {
+	parentID := uuid.New()
+	task := models.Task{}
+
+	ApplyUpdate(&task, dto.UpdateTaskRequest{ParentID: &parentID})
+	assert.Equal(t, &parentID, task.ParentID)
+
+	ApplyUpdate(&task, dto.UpdateTaskRequest{})
+	assert.Equal(t, &parentID, task.ParentID)
+
+	nilID := uuid.Nil
+	ApplyUpdate(&task, dto.UpdateTaskRequest{ParentID: &nilID})
+	assert.Nil(t, task.ParentID)
+}
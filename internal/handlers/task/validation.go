@@ -5,6 +5,7 @@ import (
 
 	"taheri24.ir/graph1/internal/dto"
 	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
 )
 
 // ValidationError represents a validation error
@@ -13,8 +14,8 @@ type ValidationError struct {
 	Message string
 }
 
-// ValidateCreateTaskRequest validates a CreateTaskRequest
-func ValidateCreateTaskRequest(req dto.CreateTaskRequest) []ValidationError {
+// ValidateCreateTaskRequest validates a CreateTaskRequest against the statuses declared by wf
+func ValidateCreateTaskRequest(req dto.CreateTaskRequest, wf *workflow.Workflow) []ValidationError {
 	var errors []ValidationError
 
 	// Validate Title
@@ -32,8 +33,8 @@ func ValidateCreateTaskRequest(req dto.CreateTaskRequest) []ValidationError {
 	}
 
 	// Validate Status
-	if req.Status != "" && !isValidTaskStatus(req.Status) {
-		errors = append(errors, ValidationError{Field: "status", Message: "status must be one of: pending, in_progress, completed"})
+	if req.Status != "" && !wf.IsValid(req.Status) {
+		errors = append(errors, statusError(wf))
 	}
 
 	// Validate Assignee
@@ -41,11 +42,27 @@ func ValidateCreateTaskRequest(req dto.CreateTaskRequest) []ValidationError {
 		errors = append(errors, ValidationError{Field: "assignee", Message: "assignee must be at most 100 characters"})
 	}
 
+	// Validate Estimate
+	if !isValidEstimate(req.Estimate) {
+		errors = append(errors, ValidationError{Field: "estimate", Message: "estimate must be between 0 and 10000 hours"})
+	}
+
+	// Validate Priority
+	if req.Priority != "" && !isValidTaskPriority(req.Priority) {
+		errors = append(errors, ValidationError{Field: "priority", Message: "priority must be one of: low, medium, high, urgent"})
+	}
+
+	// Validate DueAt
+	if req.DueAt != nil && req.DueAt.IsZero() {
+		errors = append(errors, ValidationError{Field: "due_at", Message: "due_at must be a valid RFC 3339 timestamp"})
+	}
+
 	return errors
 }
 
-// ValidateUpdateTaskRequest validates an UpdateTaskRequest
-func ValidateUpdateTaskRequest(req dto.UpdateTaskRequest) []ValidationError {
+// ValidateUpdateTaskRequest validates an UpdateTaskRequest against the statuses declared by wf.
+// Whether the status change itself is allowed is checked with wf.CheckTransition.
+func ValidateUpdateTaskRequest(req dto.UpdateTaskRequest, wf *workflow.Workflow) []ValidationError {
 	var errors []ValidationError
 
 	// Validate Title
@@ -66,8 +83,8 @@ func ValidateUpdateTaskRequest(req dto.UpdateTaskRequest) []ValidationError {
 	}
 
 	// Validate Status
-	if req.Status != nil && !isValidTaskStatus(*req.Status) {
-		errors = append(errors, ValidationError{Field: "status", Message: "status must be one of: pending, in_progress, completed"})
+	if req.Status != nil && !wf.IsValid(*req.Status) {
+		errors = append(errors, statusError(wf))
 	}
 
 	// Validate Assignee
@@ -75,15 +92,64 @@ func ValidateUpdateTaskRequest(req dto.UpdateTaskRequest) []ValidationError {
 		errors = append(errors, ValidationError{Field: "assignee", Message: "assignee must be at most 100 characters"})
 	}
 
+	// Validate Estimate
+	if req.Estimate != nil && !isValidEstimate(*req.Estimate) {
+		errors = append(errors, ValidationError{Field: "estimate", Message: "estimate must be between 0 and 10000 hours"})
+	}
+
+	// Validate Priority
+	if req.Priority != nil && !isValidTaskPriority(*req.Priority) {
+		errors = append(errors, ValidationError{Field: "priority", Message: "priority must be one of: low, medium, high, urgent"})
+	}
+
+	// Validate DueAt
+	if req.DueAt != nil && req.DueAt.IsZero() {
+		errors = append(errors, ValidationError{Field: "due_at", Message: "due_at must be a valid RFC 3339 timestamp"})
+	}
+
+	return errors
+}
+
+// ValidateListSort validates the sort field and order of a task listing
+func ValidateListSort(sort, order string) []ValidationError {
+	var errors []ValidationError
+
+	if sort != "" && !isValidSortField(sort) {
+		errors = append(errors, ValidationError{Field: "sort", Message: "sort must be one of: created_at, updated_at, due_at, priority, title, status"})
+	}
+	if order != "" && order != "asc" && order != "desc" {
+		errors = append(errors, ValidationError{Field: "order", Message: "order must be one of: asc, desc"})
+	}
+
 	return errors
 }
 
-// isValidTaskStatus checks if the status is valid
-func isValidTaskStatus(status types.TaskStatus) bool {
-	switch status {
-	case types.StatusPending, types.StatusInProgress, types.StatusCompleted:
+// statusError reports a status that the workflow does not declare
+func statusError(wf *workflow.Workflow) ValidationError {
+	return ValidationError{Field: "status", Message: "status must be one of: " + wf.StatusList()}
+}
+
+// isValidTaskPriority checks if the priority is valid
+func isValidTaskPriority(priority types.TaskPriority) bool {
+	switch priority {
+	case types.PriorityLow, types.PriorityMedium, types.PriorityHigh, types.PriorityUrgent:
 		return true
 	default:
 		return false
 	}
 }
+
+// isValidSortField checks if tasks can be sorted by the field
+func isValidSortField(field string) bool {
+	switch field {
+	case "created_at", "updated_at", "due_at", "priority", "title", "status":
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidEstimate checks if the estimate (in hours) is within range
+func isValidEstimate(estimate float64) bool {
+	return estimate >= 0 && estimate <= 10000
+}
@@ -2,11 +2,13 @@ package task
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"taheri24.ir/graph1/internal/dto"
 	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
 )
 
 func TestValidateCreateTaskRequest(t *testing.T) {
@@ -96,6 +98,45 @@ func TestValidateCreateTaskRequest(t *testing.T) {
 				{Field: "assignee", Message: "assignee must be at most 100 characters"},
 			},
 		},
+		{
+			name: "negative estimate",
+			req: dto.CreateTaskRequest{
+				Title:    "Test Task",
+				Estimate: -1,
+			},
+			expected: []ValidationError{
+				{Field: "estimate", Message: "estimate must be between 0 and 10000 hours"},
+			},
+		},
+		{
+			name: "invalid priority",
+			req: dto.CreateTaskRequest{
+				Title:    "Test Task",
+				Priority: "critical",
+			},
+			expected: []ValidationError{
+				{Field: "priority", Message: "priority must be one of: low, medium, high, urgent"},
+			},
+		},
+		{
+			name: "zero due date",
+			req: dto.CreateTaskRequest{
+				Title: "Test Task",
+				DueAt: &time.Time{},
+			},
+			expected: []ValidationError{
+				{Field: "due_at", Message: "due_at must be a valid RFC 3339 timestamp"},
+			},
+		},
+		{
+			name: "priority and due date",
+			req: dto.CreateTaskRequest{
+				Title:    "Test Task",
+				Priority: types.PriorityUrgent,
+				DueAt:    timePtr(time.Date(2030, 1, 2, 15, 0, 0, 0, time.UTC)),
+			},
+			expected: nil,
+		},
 		{
 			name: "multiple errors",
 			req: dto.CreateTaskRequest{
@@ -185,7 +226,7 @@ func TestValidateCreateTaskRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := ValidateCreateTaskRequest(tt.req)
+			result := ValidateCreateTaskRequest(tt.req, workflow.Default())
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -254,6 +295,31 @@ func TestValidateUpdateTaskRequest(t *testing.T) {
 				{Field: "assignee", Message: "assignee must be at most 100 characters"},
 			},
 		},
+		{
+			name: "estimate too large",
+			req: dto.UpdateTaskRequest{
+				Estimate: floatPtr(10001),
+			},
+			expected: []ValidationError{
+				{Field: "estimate", Message: "estimate must be between 0 and 10000 hours"},
+			},
+		},
+		{
+			name: "invalid priority",
+			req: dto.UpdateTaskRequest{
+				Priority: priorityPtr("critical"),
+			},
+			expected: []ValidationError{
+				{Field: "priority", Message: "priority must be one of: low, medium, high, urgent"},
+			},
+		},
+		{
+			name: "valid priority",
+			req: dto.UpdateTaskRequest{
+				Priority: priorityPtr(types.PriorityLow),
+			},
+			expected: nil,
+		},
 		{
 			name: "multiple errors",
 			req: dto.UpdateTaskRequest{
@@ -314,8 +380,19 @@ func TestValidateUpdateTaskRequest(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := ValidateUpdateTaskRequest(tt.req)
+			result := ValidateUpdateTaskRequest(tt.req, workflow.Default())
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestValidateListSort(t *testing.T) {
+	assert.Empty(t, ValidateListSort("", ""))
+	assert.Empty(t, ValidateListSort("due_at", "desc"))
+	assert.Empty(t, ValidateListSort("priority", "asc"))
+
+	assert.Equal(t, []ValidationError{
+		{Field: "sort", Message: "sort must be one of: created_at, updated_at, due_at, priority, title, status"},
+		{Field: "order", Message: "order must be one of: asc, desc"},
+	}, ValidateListSort("id; DROP TABLE tasks", "up"))
+}
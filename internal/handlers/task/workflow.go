@@ -0,0 +1,47 @@
+package task
+
+import (
+	"errors"
+	"net/http"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkStatus writes a 400 response and returns false unless the workflow declares status
+func (h *TaskHandler) checkStatus(c *gin.Context, status types.TaskStatus) bool {
+	if h.workflow.IsValid(status) {
+		return true
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	logger.Info("Rejected unknown task status", "status", string(status))
+	c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid status", statusError(h.workflow).Message))
+	return false
+}
+
+// checkTransition writes an error response and returns false unless the workflow lets a task
+// move from one status to the other. Disallowed changes get a 422 listing the allowed next statuses.
+func (h *TaskHandler) checkTransition(c *gin.Context, from, to types.TaskStatus) bool {
+	if !h.checkStatus(c, to) {
+		return false
+	}
+
+	var transitionErr *workflow.TransitionError
+	if err := h.workflow.CheckTransition(from, to); errors.As(err, &transitionErr) {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Info("Rejected task status transition", "from", string(from), "to", string(to))
+		c.JSON(http.StatusUnprocessableEntity, dto.TransitionErrorResponse{
+			Error:   "Invalid status transition",
+			Message: transitionErr.Error(),
+			From:    transitionErr.From,
+			To:      transitionErr.To,
+			Allowed: transitionErr.Allowed,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
+)
+
+// updateStatus sends a status change for a task currently in the given status to the registered PUT route
+func (suite *TaskHandlerTestSuite) updateStatus(current, next types.TaskStatus) *httptest.ResponseRecorder {
+	taskID := uuid.New()
+	suite.mockRepo.GetByIDFunc = func(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+		return &models.Task{ID: id, Title: "Task", Status: current}, nil
+	}
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(dto.ReplaceTaskRequest{Title: "Task", Status: next})
+	req, _ := http.NewRequest("PUT", "/tasks/"+taskID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *TaskHandlerTestSuite) TestUpdateTask_InvalidTransition() {
+	suite.mockRepo.UpdateFunc = func(ctx context.Context, task *models.Task) error {
+		suite.Fail("Update must not be called for a rejected transition")
+		return nil
+	}
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+
+	w := suite.updateStatus(types.StatusCompleted, types.StatusPending)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	var response dto.TransitionErrorResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Invalid status transition", response.Error)
+	assert.Equal(suite.T(), types.StatusCompleted, response.From)
+	assert.Equal(suite.T(), types.StatusPending, response.To)
+	assert.Equal(suite.T(), []types.TaskStatus{types.StatusInProgress}, response.Allowed)
+}
+
+func (suite *TaskHandlerTestSuite) TestUpdateTask_UnknownStatus() {
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+	w := suite.updateStatus(types.StatusPending, "blocked")
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), "status must be one of: pending, in_progress, completed")
+}
+
+func (suite *TaskHandlerTestSuite) TestCustomWorkflow() {
+	wf, err := workflow.New(workflow.Definition{
+		Statuses: []types.TaskStatus{"pending", "in_progress", "blocked", "completed"},
+		Transitions: map[types.TaskStatus][]types.TaskStatus{
+			"pending":     {"in_progress"},
+			"in_progress": {"blocked", "completed"},
+			"blocked":     {"in_progress"},
+		},
+	})
+	require.NoError(suite.T(), err)
+	suite.handler = NewTaskHandler(suite.mockRepo, suite.mockCache, wf)
+	suite.router.PUT("/tasks/:id", suite.handler.UpdateTask)
+
+	w := suite.updateStatus(types.StatusInProgress, "blocked")
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+	w = suite.updateStatus("blocked", types.StatusCompleted)
+	assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+	suite.mockRepo.CreateFunc = func(ctx context.Context, task *models.Task) error {
+		return nil
+	}
+	w = httptest.NewRecorder()
+	body, _ := json.Marshal(dto.CreateTaskRequest{Title: "Waiting", Status: "blocked"})
+	req, _ := http.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	suite.router.POST("/tasks", suite.handler.CreateTask)
+	suite.router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+}
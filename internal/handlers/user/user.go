@@ -0,0 +1,317 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"taheri24.ir/graph1/internal/authz"
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserHandler handles user-related HTTP requests
+type UserHandler struct {
+	repo      database.UserRepository
+	taskCache cache.CacheInterface[models.Task]
+}
+
+// NewUserHandler creates a new UserHandler. taskCache is the task cache, whose entries go stale
+// when the assignee of a cached task is renamed or deleted.
+func NewUserHandler(repo database.UserRepository, taskCache cache.CacheInterface[models.Task]) *UserHandler {
+	return &UserHandler{repo: repo, taskCache: taskCache}
+}
+
+// ListUsers handles GET /users
+// @Summary List users
+// @Description Retrieve every user tasks can be assigned to, ordered by username
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.UserListResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	users, err := h.repo.ListUsers(c.Request.Context())
+	if err != nil {
+		logger.Error("Failed to fetch users", "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to fetch users"))
+		return
+	}
+
+	responses := make([]dto.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = userToResponse(user)
+	}
+	c.JSON(http.StatusOK, dto.UserListResponse{Users: responses})
+}
+
+// CreateUser handles POST /users
+// @Summary Create a user
+// @Description Create a new user tasks can be assigned to. Usernames are unique. Only admins may create users.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body dto.CreateUserRequest true "User information"
+// @Success 201 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/users [post]
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	if !authz.Check(c, authz.ManageUsers, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for creating user", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+	username, ok := validateUsername(c, req.Username)
+	if !ok {
+		return
+	}
+
+	user := models.User{ID: uuid.New(), Username: username, Name: req.Name, Email: req.Email}
+	if err := h.repo.CreateUser(c.Request.Context(), &user); err != nil {
+		if errors.Is(err, database.ErrUserExists) {
+			logger.Info("Username already in use", "username", username)
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("User already exists"))
+		} else {
+			logger.Error("Failed to create user", "username", username, "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to create user"))
+		}
+		return
+	}
+
+	logger.Info("User created successfully", "id", user.ID.String(), "username", user.Username)
+	c.JSON(http.StatusCreated, userToResponse(user))
+}
+
+// GetUser handles GET /users/{id}
+// @Summary Get a user by ID
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID (UUID)"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, userToResponse(*user))
+}
+
+// UpdateUser handles PUT /users/{id}
+// @Summary Update a user
+// @Description Rename a user or change its name or email. Renaming a user renames the assignee of every task assigned to it and records the change in the task history. Only admins may update users.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID (UUID)"
+// @Param user body dto.UpdateUserRequest true "User updates"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/users/{id} [put]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	if !authz.Check(c, authz.ManageUsers, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+
+	var req dto.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("Invalid request body for updating user", "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErr(err))
+		return
+	}
+
+	user, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	renamed := false
+	if req.Username != nil {
+		username, ok := validateUsername(c, *req.Username)
+		if !ok {
+			return
+		}
+		renamed = username != user.Username
+		user.Username = username
+	}
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
+
+	if err := h.repo.UpdateUser(c.Request.Context(), user); err != nil {
+		if errors.Is(err, database.ErrUserExists) {
+			logger.Info("Username already in use", "username", user.Username)
+			c.JSON(http.StatusConflict, dto.NewErrorResponse("User already exists"))
+		} else if utils.ErrIsRecordNotFound(err) {
+			logger.Info("User not found for update", "id", user.ID.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("User not found"))
+		} else {
+			logger.Error("Failed to update user", "id", user.ID.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to update user"))
+		}
+		return
+	}
+
+	if renamed {
+		h.invalidateTasks(c, user.ID)
+	}
+
+	logger.Info("User updated successfully", "id", user.ID.String(), "username", user.Username)
+	c.JSON(http.StatusOK, userToResponse(*user))
+}
+
+// DeleteUser handles DELETE /users/{id}
+// @Summary Delete a user
+// @Description Delete a user and unassign every task assigned to it. Only admins may delete users.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID (UUID)"
+// @Success 204 "No Content"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	if !authz.Check(c, authz.ManageUsers, nil) {
+		return
+	}
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	// The tasks have to be looked up before they are unassigned
+	taskIDs, err := h.repo.GetAssignedTaskIDs(c.Request.Context(), id)
+	if err != nil {
+		logger.Error("Failed to get assigned tasks", "id", id.String(), "error", err)
+		c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to delete user"))
+		return
+	}
+
+	if err := h.repo.DeleteUser(c.Request.Context(), id); err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("User not found for deletion", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("User not found"))
+		} else {
+			logger.Error("Failed to delete user", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to delete user"))
+		}
+		return
+	}
+
+	h.invalidateCachedTasks(c, taskIDs)
+
+	logger.Info("User deleted successfully", "id", id.String(), "unassigned", len(taskIDs))
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// invalidateTasks drops the tasks assigned to userID from the task cache
+func (h *UserHandler) invalidateTasks(c *gin.Context, userID uuid.UUID) {
+	taskIDs, err := h.repo.GetAssignedTaskIDs(c.Request.Context(), userID)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Failed to get assigned tasks", "id", userID.String(), "error", err)
+		return
+	}
+	h.invalidateCachedTasks(c, taskIDs)
+}
+
+// invalidateCachedTasks drops the given tasks from the task cache
+func (h *UserHandler) invalidateCachedTasks(c *gin.Context, taskIDs []uuid.UUID) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	taskCache := middleware.TenantCache(c.Request.Context(), h.taskCache)
+	for _, taskID := range taskIDs {
+		if err := taskCache.Invalidate(taskID.String()); err != nil {
+			// Log error but don't fail the request
+			logger.Error("Failed to invalidate task cache", "id", taskID.String(), "error", err)
+		}
+	}
+}
+
+// findUser loads the user named by the id path parameter, writing an error response when it cannot
+func (h *UserHandler) findUser(c *gin.Context) (*models.User, bool) {
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	id, ok := parseUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	user, err := h.repo.GetUser(c.Request.Context(), id)
+	if err != nil {
+		if utils.ErrIsRecordNotFound(err) {
+			logger.Info("User not found", "id", id.String())
+			c.JSON(http.StatusNotFound, dto.NewErrorResponse("User not found"))
+		} else {
+			logger.Error("Failed to get user", "id", id.String(), "error", err)
+			c.JSON(http.StatusInternalServerError, dto.NewErrorResponse("Failed to get user"))
+		}
+		return nil, false
+	}
+	return user, true
+}
+
+func parseUserID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger := middleware.GetLoggerFromContext(c.Request.Context())
+		logger.Error("Invalid user ID provided", "idStr", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid user ID"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// validateUsername trims a username and rejects blank usernames
+func validateUsername(c *gin.Context, username string) (string, bool) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		c.JSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid username", "username must be non-empty"))
+		return "", false
+	}
+	return username, true
+}
+
+func userToResponse(user models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
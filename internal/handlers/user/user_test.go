@@ -0,0 +1,209 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/database"
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserHandlerTestSuite struct {
+	suite.Suite
+	db        *database.Database
+	taskCache cache.CacheInterface[models.Task]
+	router    *gin.Engine
+}
+
+func (suite *UserHandlerTestSuite) SetupTest() {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.NewDatabase(config.NewTestConfig())
+	require.NoError(suite.T(), err)
+	suite.db = db
+
+	taskCache := cache.NewInMemoryCacheImpl[models.Task]()
+	// The requests of the suite act on the default tenant
+	suite.taskCache = taskCache.Section(middleware.DefaultTenant)
+	handler := NewUserHandler(suite.db, taskCache)
+	suite.router = gin.New()
+	suite.router.GET("/users", handler.ListUsers)
+	suite.router.POST("/users", handler.CreateUser)
+	suite.router.GET("/users/:id", handler.GetUser)
+	suite.router.PUT("/users/:id", handler.UpdateUser)
+	suite.router.DELETE("/users/:id", handler.DeleteUser)
+}
+
+func (suite *UserHandlerTestSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestUserHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(UserHandlerTestSuite))
+}
+
+// request makes a request as an admin
+func (suite *UserHandlerTestSuite) request(method, path string, body any) *httptest.ResponseRecorder {
+	admin := middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), "admin"), middleware.RoleAdmin)
+	return suite.requestWithContext(admin, method, path, body)
+}
+
+func (suite *UserHandlerTestSuite) requestWithContext(ctx context.Context, method, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, method, path, bytes.NewBuffer(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *UserHandlerTestSuite) createUser(username string) dto.UserResponse {
+	w := suite.request(http.MethodPost, "/users", dto.CreateUserRequest{Username: username})
+	require.Equal(suite.T(), http.StatusCreated, w.Code, w.Body.String())
+
+	var response dto.UserResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func (suite *UserHandlerTestSuite) TestCreateUser() {
+	w := suite.request(http.MethodPost, "/users", dto.CreateUserRequest{Username: "  alice ", Name: "Alice Liddell", Email: "alice@example.com"})
+	assert.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var response dto.UserResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotEqual(suite.T(), uuid.Nil, response.ID)
+	assert.Equal(suite.T(), "alice", response.Username)
+	assert.Equal(suite.T(), "Alice Liddell", response.Name)
+	assert.Equal(suite.T(), "alice@example.com", response.Email)
+
+	w = suite.request(http.MethodPost, "/users", dto.CreateUserRequest{Username: "alice"})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+}
+
+func (suite *UserHandlerTestSuite) TestCreateUserInvalid() {
+	testCases := []struct {
+		name string
+		body any
+	}{
+		{"missing username", map[string]any{"name": "Alice"}},
+		{"blank username", dto.CreateUserRequest{Username: "   "}},
+		{"invalid email", dto.CreateUserRequest{Username: "alice", Email: "alice"}},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			w := suite.request(http.MethodPost, "/users", tc.body)
+			assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (suite *UserHandlerTestSuite) TestListAndGetUsers() {
+	suite.createUser("bob")
+	alice := suite.createUser("alice")
+
+	w := suite.request(http.MethodGet, "/users", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var list dto.UserListResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(suite.T(), list.Users, 2)
+	assert.Equal(suite.T(), "alice", list.Users[0].Username)
+	assert.Equal(suite.T(), "bob", list.Users[1].Username)
+
+	w = suite.request(http.MethodGet, "/users/"+alice.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	w = suite.request(http.MethodGet, "/users/"+uuid.New().String(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	w = suite.request(http.MethodGet, "/users/invalid-uuid", nil)
+	assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+}
+
+func (suite *UserHandlerTestSuite) TestUpdateUser() {
+	alice := suite.createUser("alice")
+	suite.createUser("bob")
+
+	name := "Alice Liddell"
+	w := suite.request(http.MethodPut, "/users/"+alice.ID.String(), dto.UpdateUserRequest{Name: &name})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	var response dto.UserResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "alice", response.Username)
+	assert.Equal(suite.T(), name, response.Name)
+
+	taken := "bob"
+	w = suite.request(http.MethodPut, "/users/"+alice.ID.String(), dto.UpdateUserRequest{Username: &taken})
+	assert.Equal(suite.T(), http.StatusConflict, w.Code)
+
+	w = suite.request(http.MethodPut, "/users/"+uuid.New().String(), dto.UpdateUserRequest{Name: &name})
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *UserHandlerTestSuite) TestUserChangesUpdateTasks() {
+	alice := suite.createUser("alice")
+	assigned := models.Task{Title: "Crash", Status: types.StatusPending, Assignee: "alice"}
+	other := models.Task{Title: "Docs", Status: types.StatusPending}
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &assigned))
+	require.NoError(suite.T(), suite.db.Create(context.TODO(), &other))
+
+	cached := func(id uuid.UUID) bool {
+		task, err := suite.taskCache.Get(id.String())
+		return err == nil && task != nil
+	}
+
+	require.NoError(suite.T(), suite.taskCache.Set(assigned.ID.String(), assigned))
+	require.NoError(suite.T(), suite.taskCache.Set(other.ID.String(), other))
+	username := "alicia"
+	w := suite.request(http.MethodPut, "/users/"+alice.ID.String(), dto.UpdateUserRequest{Username: &username})
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.False(suite.T(), cached(assigned.ID))
+	assert.True(suite.T(), cached(other.ID))
+	found, err := suite.db.GetByID(context.TODO(), assigned.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "alicia", found.Assignee)
+
+	require.NoError(suite.T(), suite.taskCache.Set(assigned.ID.String(), assigned))
+	w = suite.request(http.MethodDelete, "/users/"+alice.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusNoContent, w.Code)
+	assert.False(suite.T(), cached(assigned.ID))
+	assert.True(suite.T(), cached(other.ID))
+	found, err = suite.db.GetByID(context.TODO(), assigned.ID)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), found.Assignee)
+	assert.Nil(suite.T(), found.AssigneeID)
+
+	w = suite.request(http.MethodDelete, "/users/"+alice.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+}
+
+func (suite *UserHandlerTestSuite) TestOnlyAdminsManageUsers() {
+	alice := suite.createUser("alice")
+	member := middleware.ContextWithRole(middleware.ContextWithSubject(context.TODO(), "alice"), middleware.RoleMember)
+
+	w := suite.requestWithContext(member, http.MethodPost, "/users", dto.CreateUserRequest{Username: "mallory"})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	name := "Mallory"
+	w = suite.requestWithContext(member, http.MethodPut, "/users/"+alice.ID.String(), dto.UpdateUserRequest{Name: &name})
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	w = suite.requestWithContext(member, http.MethodDelete, "/users/"+alice.ID.String(), nil)
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+
+	// Everyone may look users up
+	w = suite.requestWithContext(member, http.MethodGet, "/users", nil)
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+}
@@ -0,0 +1,36 @@
+package workflow
+
+import (
+	"net/http"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/middleware"
+	flow "taheri24.ir/graph1/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkflowHandler serves the task status workflow
+type WorkflowHandler struct {
+	workflow *flow.Workflow
+}
+
+// NewWorkflowHandler creates a new WorkflowHandler
+func NewWorkflowHandler(wf *flow.Workflow) *WorkflowHandler {
+	return &WorkflowHandler{workflow: wf}
+}
+
+// GetWorkflow handles GET /workflow
+// @Summary Get the task status workflow
+// @Description List the task statuses and, for each of them, the statuses a task may move to next
+// @Tags workflow
+// @Produce json
+// @Success 200 {object} dto.WorkflowResponse
+// @Router /api/v1/workflow [get]
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+	def := h.workflow.Definition()
+
+	logger := middleware.GetLoggerFromContext(c.Request.Context())
+	logger.Info("Workflow retrieved successfully", "statuses", len(def.Statuses))
+	c.JSON(http.StatusOK, dto.WorkflowResponse{Statuses: def.Statuses, Transitions: def.Transitions})
+}
@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/internal/types"
+	flow "taheri24.ir/graph1/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWorkflow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	wf, err := flow.New(flow.Definition{
+		Statuses: []types.TaskStatus{"pending", "in_progress", "in_review", "completed"},
+		Transitions: map[types.TaskStatus][]types.TaskStatus{
+			"pending":     {"in_progress"},
+			"in_progress": {"in_review"},
+			"in_review":   {"in_progress", "completed"},
+		},
+	})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/workflow", NewWorkflowHandler(wf).GetWorkflow)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/workflow", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response dto.WorkflowResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []types.TaskStatus{"pending", "in_progress", "in_review", "completed"}, response.Statuses)
+	assert.Equal(t, []types.TaskStatus{"in_progress", "completed"}, response.Transitions["in_review"])
+	assert.Equal(t, []types.TaskStatus{}, response.Transitions["completed"])
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActorKey is the context key for the actor making a request
+type ActorKey string
+
+const (
+	actorKey        ActorKey = "actor"
+	claimedActorKey ActorKey = "claimed_actor"
+)
+
+// AnonymousActor names the actor of requests that do not identify themselves
+const AnonymousActor = "anonymous"
+
+// maxActorLength bounds the actor names recorded in the task history
+const maxActorLength = 100
+
+// ActorMiddleware records who the caller says is making the request, as given by the X-Actor header. Nothing
+// vouches for the header, so it is kept as the claimed actor and never becomes the actor, which only
+// AuthMiddleware sets.
+func ActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := strings.TrimSpace(c.GetHeader("X-Actor")); actor != "" {
+			if len(actor) > maxActorLength {
+				actor = actor[:maxActorLength]
+			}
+			c.Request = c.Request.WithContext(ContextWithClaimedActor(c.Request.Context(), actor))
+		}
+		c.Next()
+	}
+}
+
+// ContextWithActor returns a copy of ctx naming actor as the one making changes
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// GetActorFromContext retrieves the actor from the context, or AnonymousActor when there is none
+func GetActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// ContextWithClaimedActor returns a copy of ctx carrying the actor the caller claims to act for
+func ContextWithClaimedActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, claimedActorKey, actor)
+}
+
+// GetClaimedActorFromContext retrieves the actor the caller claims to act for, or "" when it named none
+func GetClaimedActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(claimedActorKey).(string)
+	return actor
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"uses X-Actor header", "alice", "alice"},
+		{"trims whitespace", "  bob ", "bob"},
+		{"defaults to none", "", ""},
+		{"truncates long names", strings.Repeat("a", 150), strings.Repeat("a", maxActorLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ActorMiddleware())
+
+			var actor, claimed string
+			router.GET("/test", func(c *gin.Context) {
+				actor = GetActorFromContext(c.Request.Context())
+				claimed = GetClaimedActorFromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Actor", tt.header)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			// The header is only a claim; the actor stays anonymous until the caller authenticates
+			assert.Equal(t, tt.expected, claimed)
+			assert.Equal(t, AnonymousActor, actor)
+		})
+	}
+}
+
+func TestGetActorFromContext(t *testing.T) {
+	assert.Equal(t, AnonymousActor, GetActorFromContext(context.Background()))
+	assert.Equal(t, "alice", GetActorFromContext(ContextWithActor(context.Background(), "alice")))
+
+	assert.Empty(t, GetClaimedActorFromContext(context.Background()))
+	assert.Equal(t, "alice", GetClaimedActorFromContext(ContextWithClaimedActor(context.Background(), "alice")))
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"taheri24.ir/graph1/internal/dto"
+	"taheri24.ir/graph1/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubjectKey is the context key for the authenticated subject of a request
+type SubjectKey string
+
+const subjectKey SubjectKey = "subject"
+
+// APIKeyPrefix starts every API key, telling them apart from tokens
+const APIKeyPrefix = "tk_"
+
+// TokenVerifier checks a bearer token and returns its claims
+type TokenVerifier interface {
+	Verify(token string) (*jwt.Claims, error)
+}
+
+// Principal is the caller a credential identifies, the scopes it was granted, its role and the tenant it is
+// bound to, DefaultTenant when empty
+type Principal struct {
+	Subject string
+	Scopes  []string
+	Role    string
+	Tenant  string
+}
+
+// APIKeyVerifier looks up the caller of an API key
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// AuthMiddleware rejects requests without a valid bearer token or API key with 401. API keys are sent as
+// bearer tokens and recognized by APIKeyPrefix; keys may be nil to accept tokens only. For the other
+// requests the subject of the credential is put in the request context with its scopes, role and tenant,
+// added to the request-scoped logger and recorded as the actor; an X-Actor header only ever names the claimed actor.
+func AuthMiddleware(tokens TokenVerifier, keys APIKeyVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := GetLoggerFromContext(c.Request.Context())
+
+		scheme, credential, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		credential = strings.TrimSpace(credential)
+		if !strings.EqualFold(scheme, "Bearer") || credential == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.NewErrorResponse("Unauthorized",
+				"send a token from POST /api/v1/auth/token or an API key in an Authorization: Bearer header"))
+			return
+		}
+
+		principal, err := authenticate(c.Request.Context(), credential, tokens, keys)
+		if err != nil {
+			logger.Info("Rejected credential", "error", err)
+			c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.NewErrorResponse("Unauthorized", "invalid token"))
+			return
+		}
+
+		ctx := ContextWithSubject(c.Request.Context(), principal.Subject)
+		ctx = ContextWithScopes(ctx, principal.Scopes)
+		ctx = ContextWithRole(ctx, principal.Role)
+		// A credential without a tenant, such as a token minted before tenants existed, is bound to the
+		// default tenant rather than left free to pick any
+		tenant := principal.Tenant
+		if tenant == "" {
+			tenant = DefaultTenant
+		}
+		ctx = ContextWithTenant(ctx, tenant)
+		ctx = ContextWithLogger(ctx, logger.With("subject", principal.Subject))
+		ctx = ContextWithActor(ctx, principal.Subject)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// authenticate finds the caller of an API key or a token
+func authenticate(ctx context.Context, credential string, tokens TokenVerifier, keys APIKeyVerifier) (*Principal, error) {
+	if strings.HasPrefix(credential, APIKeyPrefix) && keys != nil {
+		return keys.VerifyAPIKey(ctx, credential)
+	}
+	claims, err := tokens.Verify(credential)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: claims.Subject, Scopes: strings.Fields(claims.Scope), Role: claims.Role, Tenant: claims.Tenant}, nil
+}
+
+// ContextWithSubject returns a copy of ctx naming subject as the authenticated caller
+func ContextWithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey, subject)
+}
+
+// GetSubjectFromContext retrieves the authenticated subject from the context, reporting whether the request
+// was authenticated
+func GetSubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey).(string)
+	return subject, ok && subject != ""
+}
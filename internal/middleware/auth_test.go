@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := jwt.NewHMACKey([]byte("0123456789abcdef0123456789abcdef"), "graph1")
+	require.NoError(t, err)
+	token, _, err := key.Issue(jwt.Claims{Subject: "ci-bot"}, time.Hour)
+	require.NoError(t, err)
+	expired, err := key.Sign(&jwt.Claims{Subject: "ci-bot", Issuer: "graph1", ExpiresAt: time.Now().Add(-time.Hour).Unix()})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		subject       string
+	}{
+		{"valid token", "Bearer " + token, http.StatusOK, "ci-bot"},
+		{"scheme is case-insensitive", "bearer " + token, http.StatusOK, "ci-bot"},
+		{"no header", "", http.StatusUnauthorized, ""},
+		{"other scheme", "Basic Y2k6c2VjcmV0", http.StatusUnauthorized, ""},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, ""},
+		{"garbage", "Bearer not-a-token", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ActorMiddleware(), AuthMiddleware(key, nil))
+
+			var subject, actor, claimed string
+			router.GET("/test", func(c *gin.Context) {
+				subject, _ = GetSubjectFromContext(c.Request.Context())
+				actor = GetActorFromContext(c.Request.Context())
+				claimed = GetClaimedActorFromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("X-Actor", "someone-else")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.subject, subject)
+			if tt.status == http.StatusOK {
+				// The authenticated subject is the actor; the self-declared one is only kept as a claim
+				assert.Equal(t, tt.subject, actor)
+				assert.Equal(t, "someone-else", claimed)
+			} else {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+				assert.Contains(t, w.Body.String(), "Unauthorized")
+				if strings.HasPrefix(tt.authorization, "Bearer ") {
+					// The reason a credential was refused is logged, not told to the caller
+					assert.JSONEq(t, `{"error":"Unauthorized","message":"invalid token"}`, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+type fakeAPIKeys map[string]*Principal
+
+func (keys fakeAPIKeys) VerifyAPIKey(_ context.Context, key string) (*Principal, error) {
+	if principal, ok := keys[key]; ok {
+		return principal, nil
+	}
+	return nil, errors.New("API key is invalid")
+}
+
+func TestAuthMiddlewareAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := jwt.NewHMACKey([]byte("0123456789abcdef0123456789abcdef"), "")
+	require.NoError(t, err)
+	token, _, err := key.Issue(jwt.Claims{Subject: "ci-bot", Scope: "tasks:read alerts:fire", Role: RoleAdmin}, time.Hour)
+	require.NoError(t, err)
+	keys := fakeAPIKeys{"tk_valid": {Subject: "apikey:tk_valid", Scopes: []string{ScopeTasksRead}, Role: RoleMember, Tenant: "acme"}}
+
+	tests := []struct {
+		name       string
+		credential string
+		status     int
+		subject    string
+		scopes     []string
+		role       string
+		tenant     string
+	}{
+		{"API key", "tk_valid", http.StatusOK, "apikey:tk_valid", []string{ScopeTasksRead}, RoleMember, "acme"},
+		{"unknown API key", "tk_unknown", http.StatusUnauthorized, "", nil, "", ""},
+		// Tokens without a tenant bind the request to the default one
+		{"token scopes", token, http.StatusOK, "ci-bot", []string{ScopeTasksRead, ScopeAlertsFire}, RoleAdmin, DefaultTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(AuthMiddleware(key, keys))
+
+			var subject string
+			var scopes []string
+			var role, tenant string
+			router.GET("/test", func(c *gin.Context) {
+				subject, _ = GetSubjectFromContext(c.Request.Context())
+				scopes, _ = c.Request.Context().Value(scopesKey).([]string)
+				role = GetRoleFromContext(c.Request.Context())
+				tenant, _ = c.Request.Context().Value(tenantKey).(string)
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.credential)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.subject, subject)
+			assert.Equal(t, tt.scopes, scopes)
+			assert.Equal(t, tt.role, role)
+			assert.Equal(t, tt.tenant, tenant)
+		})
+	}
+}
+
+func TestAuthMiddlewareLogsSubject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := jwt.NewHMACKey([]byte("0123456789abcdef0123456789abcdef"), "")
+	require.NoError(t, err)
+	token, _, err := key.Issue(jwt.Claims{Subject: "ci-bot"}, time.Hour)
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		logger := slog.New(slog.NewTextHandler(&logs, nil))
+		c.Request = c.Request.WithContext(ContextWithLogger(c.Request.Context(), logger))
+	}, AuthMiddleware(key, nil))
+	router.GET("/test", func(c *gin.Context) {
+		GetLoggerFromContext(c.Request.Context()).Info("handled")
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, logs.String(), "subject=ci-bot")
+}
+
+func TestGetSubjectFromContext(t *testing.T) {
+	_, ok := GetSubjectFromContext(context.Background())
+	assert.False(t, ok)
+
+	subject, ok := GetSubjectFromContext(ContextWithSubject(context.Background(), "ci-bot"))
+	assert.True(t, ok)
+	assert.Equal(t, "ci-bot", subject)
+}
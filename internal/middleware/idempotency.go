@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"taheri24.ir/graph1/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header naming a retryable request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed for a retried request
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// DefaultIdempotencyWindow is how long responses are kept when no window is configured
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// idempotencyLockTTL is how long a key stays reserved for a request still being handled, so that the key of a
+// request whose instance died is released long before the window has passed
+const idempotencyLockTTL = 2 * time.Minute
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// idempotencyKeyPrefix namespaces the keys in the store
+const idempotencyKeyPrefix = "idempotency:"
+
+// idempotencyNoStoreKey is the gin context key set by handlers whose responses must not be stored
+const idempotencyNoStoreKey = "idempotency_no_store"
+
+// replayedHeaders are the response headers stored and replayed along with the body
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "Last-Modified"}
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key header safe to retry. The first
+// response for a key is stored for window and replayed for later requests with the same key and body;
+// reusing a key with a different request is rejected with 422, and a retry that arrives while the first
+// request is still being handled, which holds the key for idempotencyLockTTL at most, is rejected with 409. Keys are kept apart for every tenant and
+// authenticated caller, so it must come after the authentication and tenant middleware. Server errors are
+// not stored, so they can be retried, and neither are 401 and 403 responses, which only say something
+// about the credential of the caller.
+func IdempotencyMiddleware(store IdempotencyStore, window time.Duration) gin.HandlerFunc {
+	if window <= 0 {
+		window = DefaultIdempotencyWindow
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		logger := GetLoggerFromContext(c.Request.Context())
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid Idempotency-Key",
+				"Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid request", err.Error()))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := idempotencyStoreKey(c.Request.Context(), key)
+		fingerprint := requestFingerprint(c.Request, body)
+		existing, err := store.Reserve(storeKey, IdempotencyRecord{Fingerprint: fingerprint}, idempotencyLockTTL)
+		if err != nil {
+			// Handle the request anyway rather than failing it because the store is unavailable
+			logger.Error("Failed to reserve idempotency key", "key", key, "error", err)
+			c.Next()
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				logger.Info("Idempotency key reused for a different request", "key", key)
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, dto.NewErrorResponse("Idempotency-Key reused",
+					"the key was already used for a different request"))
+			case !existing.Completed:
+				logger.Info("Idempotent request still in progress", "key", key)
+				c.AbortWithStatusJSON(http.StatusConflict, dto.NewErrorResponse("Request in progress",
+					"a request with this Idempotency-Key is still being processed"))
+			default:
+				logger.Info("Replaying idempotent response", "key", key, "status", existing.Status)
+				for name, value := range existing.Headers {
+					c.Header(name, value)
+				}
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Status(existing.Status)
+				c.Writer.Write(existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		saved := false
+		defer func() {
+			// Release the key when the response is not stored, including when the handler panics
+			if !saved {
+				if err := store.Delete(storeKey); err != nil {
+					logger.Error("Failed to release idempotency key", "key", key, "error", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusUnauthorized || status == http.StatusForbidden {
+			return
+		}
+		var record IdempotencyRecord
+		if c.GetBool(idempotencyNoStoreKey) {
+			logger.Debug("Idempotent response replaced by a marker at the request of the handler", "key", key)
+			record = processedMarker(fingerprint)
+		} else {
+			record = IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      status,
+				Headers:     make(map[string]string),
+				Body:        writer.body.Bytes(),
+			}
+			for _, name := range replayedHeaders {
+				if value := writer.Header().Get(name); value != "" {
+					record.Headers[name] = value
+				}
+			}
+		}
+		if err := store.Save(storeKey, record, window); err != nil {
+			logger.Error("Failed to store idempotent response", "key", key, "error", err)
+			return
+		}
+		saved = true
+	}
+}
+
+// SkipIdempotencyStore marks the response of the current request as one the idempotency middleware must not
+// store, for responses carrying secrets. The key is still held for the window, and a retry with it gets a 409
+// saying the request was already processed rather than the secret or a second one.
+func SkipIdempotencyStore(c *gin.Context) {
+	c.Set(idempotencyNoStoreKey, true)
+}
+
+// processedMarker is the record stored in place of a response that must not be stored
+func processedMarker(fingerprint string) IdempotencyRecord {
+	body, _ := json.Marshal(dto.NewErrorResponse("Request already processed",
+		"a request with this Idempotency-Key was already processed and its response cannot be replayed"))
+	return IdempotencyRecord{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		Status:      http.StatusConflict,
+		Headers:     map[string]string{"Content-Type": "application/json; charset=utf-8"},
+		Body:        body,
+	}
+}
+
+// idempotencyStoreKey namespaces key by the tenant and the authenticated subject of ctx, so callers never
+// share keys. The subject is length-prefixed since it may itself contain colons.
+func idempotencyStoreKey(ctx context.Context, key string) string {
+	subject, _ := GetSubjectFromContext(ctx)
+	return fmt.Sprintf("%s%s:%d:%s:%s", idempotencyKeyPrefix, GetTenantFromContext(ctx), len(subject), subject, key)
+}
+
+// requestFingerprint identifies a request by its method, URI, host, tenant and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	// The same request sent to another tenant is another request
+	io.WriteString(hash, r.Host+" "+r.Header.Get(TenantHeader)+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body written through it
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"taheri24.ir/graph1/internal/cache"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyRecord is what is stored for an Idempotency-Key
+type IdempotencyRecord struct {
+	// Fingerprint identifies the request that first used the key
+	Fingerprint string `json:"fingerprint"`
+	// Completed is false while the first request is being handled
+	Completed bool              `json:"completed"`
+	Status    int               `json:"status,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      []byte            `json:"body,omitempty"`
+}
+
+// IdempotencyStore keeps idempotency records for a limited time
+type IdempotencyStore interface {
+	// Reserve stores record under key unless the key is already taken, in which case the record already
+	// stored is returned instead
+	Reserve(key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Save stores record under key, replacing any record already stored
+	Save(key string, record IdempotencyRecord, ttl time.Duration) error
+	// Delete removes the record of key
+	Delete(key string) error
+}
+
+// redisIdempotencyStore keeps idempotency records in Redis, so they are shared by every instance
+type redisIdempotencyStore struct {
+	redisCache *cache.RedisCache
+}
+
+var _ IdempotencyStore = (*redisIdempotencyStore)(nil)
+
+// NewRedisIdempotencyStore creates an IdempotencyStore backed by Redis
+func NewRedisIdempotencyStore(redisCache *cache.RedisCache) IdempotencyStore {
+	return &redisIdempotencyStore{redisCache: redisCache}
+}
+
+func (s *redisIdempotencyStore) Reserve(key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// The record found may expire before it is read; try again to reserve the key when it does
+	for attempt := 0; attempt < 3; attempt++ {
+		stored, err := s.redisCache.SetNX(key, data, ttl)
+		if err != nil || stored {
+			return nil, err
+		}
+
+		var raw []byte
+		err = s.redisCache.Get(key, &raw)
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var existing IdempotencyRecord
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	return nil, errors.New("idempotency key is expiring repeatedly")
+}
+
+func (s *redisIdempotencyStore) Save(key string, record IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.redisCache.Set(key, data, ttl)
+}
+
+func (s *redisIdempotencyStore) Delete(key string) error {
+	return s.redisCache.Delete(key)
+}
+
+// memoryIdempotencyStore keeps idempotency records in the process, for single-instance deployments
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryIdempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+var _ IdempotencyStore = (*memoryIdempotencyStore)(nil)
+
+// sweepInterval is how often expired records are removed from a memory store
+const sweepInterval = time.Minute
+
+// NewMemoryIdempotencyStore creates an IdempotencyStore that keeps records in memory
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: make(map[string]memoryIdempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(key string, record IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, entry := range s.records {
+			if !now.Before(entry.expiresAt) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if entry, ok := s.records[key]; ok && now.Before(entry.expiresAt) {
+		existing := entry.record
+		return &existing, nil
+	}
+	s.records[key] = memoryIdempotencyEntry{record: record, expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryIdempotencyEntry{record: record, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
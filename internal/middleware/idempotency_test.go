@@ -0,0 +1,379 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotencyTestRouter serves POST /tasks through the idempotency middleware, answering with the given
+// status and counting the requests that reach the handler
+func idempotencyTestRouter(store IdempotencyStore, status *int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(store, time.Hour))
+	handler := func(c *gin.Context) {
+		*calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Header("Location", "/tasks/1")
+		c.Header("X-Call", "handled")
+		c.JSON(*status, gin.H{"call": *calls, "body": string(body)})
+	}
+	router.POST("/tasks", handler)
+	router.PUT("/tasks", handler)
+	return router
+}
+
+func sendIdempotent(router *gin.Engine, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func newTestRedisIdempotencyStore(t *testing.T) (IdempotencyStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { redisCache.Close() })
+	return NewRedisIdempotencyStore(redisCache), mr
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	stores := map[string]func(t *testing.T) IdempotencyStore{
+		"memory": func(t *testing.T) IdempotencyStore { return NewMemoryIdempotencyStore() },
+		"redis": func(t *testing.T) IdempotencyStore {
+			store, _ := newTestRedisIdempotencyStore(t)
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Run("replays the first response", func(t *testing.T) {
+				status, calls := http.StatusCreated, 0
+				router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+				first := sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+				retry := sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+
+				assert.Equal(t, 1, calls)
+				assert.Equal(t, http.StatusCreated, first.Code)
+				assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+				assert.Equal(t, http.StatusCreated, retry.Code)
+				assert.Equal(t, first.Body.String(), retry.Body.String())
+				assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+				assert.Equal(t, "/tasks/1", retry.Header().Get("Location"))
+				assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+				// Only the listed headers are replayed
+				assert.Empty(t, retry.Header().Get("X-Call"))
+			})
+
+			t.Run("rejects a key reused with a different body", func(t *testing.T) {
+				status, calls := http.StatusCreated, 0
+				router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+				sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+				w := sendIdempotent(router, "POST", "key-1", `{"title":"b"}`)
+
+				assert.Equal(t, 1, calls)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), "Idempotency-Key reused")
+			})
+
+			t.Run("never replays a response to another tenant", func(t *testing.T) {
+				status, calls := http.StatusCreated, 0
+				router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+				sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+				req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"a"}`))
+				req.Header.Set(IdempotencyKeyHeader, "key-1")
+				req.Header.Set(TenantHeader, "acme")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, 1, calls)
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+			})
+
+			t.Run("keys are independent", func(t *testing.T) {
+				status, calls := http.StatusCreated, 0
+				router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+				sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+				w := sendIdempotent(router, "POST", "key-2", `{"title":"a"}`)
+
+				assert.Equal(t, 2, calls)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+			})
+
+			t.Run("replays client errors", func(t *testing.T) {
+				status, calls := http.StatusBadRequest, 0
+				router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+				sendIdempotent(router, "POST", "key-1", `{}`)
+				w := sendIdempotent(router, "POST", "key-1", `{}`)
+
+				assert.Equal(t, 1, calls)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			})
+
+			t.Run("does not store server errors", func(t *testing.T) {
+				status, calls := http.StatusInternalServerError, 0
+				router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+				sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+				status = http.StatusCreated
+				w := sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+
+				assert.Equal(t, 2, calls)
+				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+			})
+
+			t.Run("does not store authentication failures", func(t *testing.T) {
+				for _, denied := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+					status, calls := denied, 0
+					router := idempotencyTestRouter(newStore(t), &status, &calls)
+
+					sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+					status = http.StatusCreated
+					w := sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+
+					assert.Equal(t, 2, calls)
+					assert.Equal(t, http.StatusCreated, w.Code)
+					assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+				}
+			})
+
+			t.Run("rejects a retry while the first request is in progress", func(t *testing.T) {
+				store := newStore(t)
+				status, calls := http.StatusCreated, 0
+				router := idempotencyTestRouter(store, &status, &calls)
+
+				req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"a"}`))
+				_, err := store.Reserve(idempotencyStoreKey(req.Context(), "key-1"),
+					IdempotencyRecord{Fingerprint: requestFingerprint(req, []byte(`{"title":"a"}`))}, time.Hour)
+				require.NoError(t, err)
+
+				w := sendIdempotent(router, "POST", "key-1", `{"title":"a"}`)
+
+				assert.Equal(t, 0, calls)
+				assert.Equal(t, http.StatusConflict, w.Code)
+			})
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareKeysPerCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	router := gin.New()
+	// Stands in for the authentication and tenant middleware
+	router.Use(func(c *gin.Context) {
+		ctx := ContextWithTenant(c.Request.Context(), c.GetHeader(TenantHeader))
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			ctx = ContextWithSubject(ctx, subject)
+		}
+		c.Request = c.Request.WithContext(ctx)
+	})
+	router.Use(IdempotencyMiddleware(NewMemoryIdempotencyStore(), time.Hour))
+	router.POST("/tasks", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	send := func(subject, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"a"}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req.Header.Set("X-Subject", subject)
+		req.Header.Set(TenantHeader, tenant)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("alice", "acme")
+	assert.Equal(t, "true", send("alice", "acme").Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	// Neither another caller nor the same caller in another tenant gets the stored response
+	for _, w := range []*httptest.ResponseRecorder{send("bob", "acme"), send("", "acme"), send("alice", "globex")} {
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	}
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotencyMiddlewareSkipStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryIdempotencyStore()
+	calls := 0
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(store, time.Hour))
+	router.POST("/api-keys", func(c *gin.Context) {
+		calls++
+		SkipIdempotencyStore(c)
+		c.JSON(http.StatusCreated, gin.H{"key": "secret"})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api-keys", strings.NewReader(`{"name":"ci"}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send()
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Contains(t, first.Body.String(), "secret")
+
+	// The key stays held, and a retry is told the request was processed without seeing the secret again
+	w := send()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.NotContains(t, w.Body.String(), "secret")
+	assert.Contains(t, w.Body.String(), "already processed")
+}
+
+func TestIdempotencyMiddlewareLockTTL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore().(*memoryIdempotencyStore)
+	store.now = func() time.Time { return now }
+
+	release := make(chan struct{})
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(store, time.Hour))
+	router.POST("/tasks", func(c *gin.Context) {
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"id": "1"})
+	})
+
+	// A request still being handled holds its key for the lock TTL only, not for the whole window
+	done := make(chan struct{})
+	go func() {
+		sendIdempotent(router, "POST", "key-1", `{}`)
+		close(done)
+	}()
+	key := idempotencyStoreKey(httptest.NewRequest("POST", "/tasks", nil).Context(), "key-1")
+	expiry := func() time.Time {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return store.records[key].expiresAt
+	}
+	require.Eventually(t, func() bool { return !expiry().IsZero() }, time.Second, time.Millisecond)
+	assert.Equal(t, now.Add(idempotencyLockTTL), expiry())
+
+	// The completed response is kept for the window
+	close(release)
+	<-done
+	assert.Equal(t, now.Add(time.Hour), expiry())
+}
+
+func TestIdempotencyMiddlewarePassThrough(t *testing.T) {
+	status, calls := http.StatusOK, 0
+	router := idempotencyTestRouter(NewMemoryIdempotencyStore(), &status, &calls)
+
+	// Requests without a key, and methods other than POST, are always handled
+	sendIdempotent(router, "POST", "", `{"title":"a"}`)
+	sendIdempotent(router, "POST", "", `{"title":"a"}`)
+	sendIdempotent(router, "PUT", "key-1", `{"title":"a"}`)
+	w := sendIdempotent(router, "PUT", "key-1", `{"title":"a"}`)
+
+	assert.Equal(t, 4, calls)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotencyMiddlewareKeyTooLong(t *testing.T) {
+	status, calls := http.StatusCreated, 0
+	router := idempotencyTestRouter(NewMemoryIdempotencyStore(), &status, &calls)
+
+	w := sendIdempotent(router, "POST", strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIdempotencyMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryIdempotencyStore()
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Use(IdempotencyMiddleware(store, time.Hour))
+	router.POST("/tasks", func(c *gin.Context) { panic("boom") })
+
+	sendIdempotent(router, "POST", "key-1", `{}`)
+
+	existing, err := store.Reserve(idempotencyKeyPrefix+"key-1", IdempotencyRecord{}, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore().(*memoryIdempotencyStore)
+	store.now = func() time.Time { return now }
+
+	existing, err := store.Reserve("key", IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	now = now.Add(59 * time.Minute)
+	existing, err = store.Reserve("key", IdempotencyRecord{Fingerprint: "b"}, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, "a", existing.Fingerprint)
+
+	// Once the window has passed the key can be used again, and expired records are swept
+	now = now.Add(time.Minute)
+	existing, err = store.Reserve("key", IdempotencyRecord{Fingerprint: "b"}, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	require.NoError(t, store.Save("other", IdempotencyRecord{Completed: true}, time.Minute))
+	now = now.Add(2 * time.Minute)
+	_, err = store.Reserve("key", IdempotencyRecord{}, time.Hour)
+	require.NoError(t, err)
+	assert.NotContains(t, store.records, "other")
+}
+
+func TestRedisIdempotencyStoreExpiry(t *testing.T) {
+	store, mr := newTestRedisIdempotencyStore(t)
+
+	existing, err := store.Reserve("key", IdempotencyRecord{Fingerprint: "a"}, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.Equal(t, time.Hour, mr.TTL("key"))
+
+	require.NoError(t, store.Save("key", IdempotencyRecord{Fingerprint: "a", Completed: true, Status: 201}, time.Hour))
+	existing, err = store.Reserve("key", IdempotencyRecord{Fingerprint: "b"}, time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, IdempotencyRecord{Fingerprint: "a", Completed: true, Status: 201}, *existing)
+
+	mr.FastForward(time.Hour)
+	existing, err = store.Reserve("key", IdempotencyRecord{Fingerprint: "b"}, time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
@@ -29,6 +29,12 @@ var (
 		Help: "Current number of tasks in the database",
 	})
 
+	// taskRemindersTotal counts reminders emitted for tasks reaching their due time
+	taskRemindersTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "task_reminders_total",
+		Help: "Total number of due-date reminders emitted for tasks",
+	})
+
 	// alertTrigger is a gauge that can be set to trigger alerts manually
 	alertTrigger = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "alert_trigger",
@@ -63,6 +69,11 @@ func UpdateTasksCount(count float64) {
 	tasksCount.Set(count)
 }
 
+// RecordTaskReminder increments the task reminders counter
+func RecordTaskReminder() {
+	taskRemindersTotal.Inc()
+}
+
 // TriggerAlert sets the alert trigger gauge to 1 for the given alert name
 func TriggerAlert(alertName string) {
 	alertTrigger.WithLabelValues(alertName).Set(1)
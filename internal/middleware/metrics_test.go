@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestMetricsMiddleware(t *testing.T) {
@@ -183,3 +184,14 @@ func TestUpdateTasksCount(t *testing.T) {
 		t.Errorf("Expected tasks_count to be 42.0, got %f", value)
 	}
 }
+
+func TestRecordTaskReminder(t *testing.T) {
+	before := testutil.ToFloat64(taskRemindersTotal)
+
+	RecordTaskReminder()
+	RecordTaskReminder()
+
+	if got := testutil.ToFloat64(taskRemindersTotal) - before; got != 2 {
+		t.Errorf("Expected task_reminders_total to increase by 2, got %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Roles decide which tasks an authenticated caller may change, on top of the scopes of its credential
+const (
+	// RoleViewer may read tasks but not change them
+	RoleViewer = "viewer"
+	// RoleMember may create tasks and change the tasks it created or is assigned to
+	RoleMember = "member"
+	// RoleAdmin may change and delete every task and fire and reset alerts
+	RoleAdmin = "admin"
+)
+
+// AllRoles lists every role, from the least to the most trusted
+var AllRoles = []string{RoleViewer, RoleMember, RoleAdmin}
+
+// RoleKey is the context key for the role of the caller of a request
+type RoleKey string
+
+const roleKey RoleKey = "role"
+
+// ParseRole checks that role is one of AllRoles, ignoring case
+func ParseRole(role string) (string, error) {
+	role = strings.ToLower(strings.TrimSpace(role))
+	if !slices.Contains(AllRoles, role) {
+		return "", fmt.Errorf("unknown role %q; roles are %s", role, strings.Join(AllRoles, ", "))
+	}
+	return role, nil
+}
+
+// RoleIncludes reports whether role is trusted with everything other is. Unknown roles include nothing.
+func RoleIncludes(role, other string) bool {
+	rank, otherRank := slices.Index(AllRoles, role), slices.Index(AllRoles, other)
+	return rank >= 0 && rank >= otherRank
+}
+
+// ContextWithRole returns a copy of ctx giving role to the caller
+func ContextWithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// GetRoleFromContext retrieves the role of the caller of the request behind ctx. Requests that were not
+// authenticated, which only reach the API when authentication is disabled, and authenticated callers
+// without a known role are viewers.
+func GetRoleFromContext(ctx context.Context) string {
+	if _, ok := GetSubjectFromContext(ctx); !ok {
+		return RoleViewer
+	}
+	role, _ := ctx.Value(roleKey).(string)
+	if !slices.Contains(AllRoles, role) {
+		return RoleViewer
+	}
+	return role
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole(" Admin ")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, role)
+
+	_, err = ParseRole("root")
+	assert.ErrorContains(t, err, `unknown role "root"`)
+}
+
+func TestRoleIncludes(t *testing.T) {
+	assert.True(t, RoleIncludes(RoleAdmin, RoleMember))
+	assert.True(t, RoleIncludes(RoleMember, RoleMember))
+	assert.False(t, RoleIncludes(RoleMember, RoleAdmin))
+	assert.False(t, RoleIncludes("root", RoleViewer))
+}
+
+func TestGetRoleFromContext(t *testing.T) {
+	// Requests that were not authenticated may only read
+	assert.Equal(t, RoleViewer, GetRoleFromContext(context.Background()))
+
+	ctx := ContextWithSubject(context.Background(), "ci-bot")
+	assert.Equal(t, RoleMember, GetRoleFromContext(ContextWithRole(ctx, RoleMember)))
+	// Authenticated callers without a known role are viewers
+	assert.Equal(t, RoleViewer, GetRoleFromContext(ctx))
+	assert.Equal(t, RoleViewer, GetRoleFromContext(ContextWithRole(ctx, "root")))
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"taheri24.ir/graph1/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scopes limit what an authenticated caller may do
+const (
+	// ScopeTasksRead allows reading tasks, labels, users, comments and the workflow, and GraphQL queries
+	ScopeTasksRead = "tasks:read"
+	// ScopeTasksWrite allows changing tasks, labels, users and comments, and GraphQL mutations
+	ScopeTasksWrite = "tasks:write"
+	// ScopeAlertsRead allows listing alerts
+	ScopeAlertsRead = "alerts:read"
+	// ScopeAlertsFire allows firing and resetting alerts
+	ScopeAlertsFire = "alerts:fire"
+	// ScopeKeysAdmin allows minting, listing and revoking API keys
+	ScopeKeysAdmin = "keys:admin"
+)
+
+// AllScopes lists every scope, in the order they are documented
+var AllScopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeAlertsRead, ScopeAlertsFire, ScopeKeysAdmin}
+
+// AnonymousScopes lists the scopes of the requests that were not authenticated, which only reach the API when
+// authentication is disabled: they may read, but not change anything
+var AnonymousScopes = []string{ScopeTasksRead, ScopeAlertsRead}
+
+// RoleScopes lists the scopes a credential with role may be granted. Administering API keys is for admins
+// only, so the keys:admin scope is left out for the other roles.
+func RoleScopes(role string) []string {
+	if role == RoleAdmin {
+		return AllScopes
+	}
+	return slices.DeleteFunc(slices.Clone(AllScopes), func(scope string) bool { return scope == ScopeKeysAdmin })
+}
+
+// ScopesKey is the context key for the scopes granted to the caller of a request
+type ScopesKey string
+
+const scopesKey ScopesKey = "scopes"
+
+// ParseScopes splits a space- or comma-separated list of scopes, rejecting unknown ones. Duplicates are
+// dropped and the scopes are returned in the order of AllScopes.
+func ParseScopes(list string) ([]string, error) {
+	requested := strings.FieldsFunc(list, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, scope := range requested {
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q; scopes are %s", scope, strings.Join(AllScopes, ", "))
+		}
+	}
+	scopes := []string{}
+	for _, scope := range AllScopes {
+		if slices.Contains(requested, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// ContextWithScopes returns a copy of ctx granting scopes to the caller
+func ContextWithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// HasScope reports whether the caller of the request behind ctx was granted scope. Requests that were not
+// authenticated have the AnonymousScopes.
+func HasScope(ctx context.Context, scope string) bool {
+	if _, ok := GetSubjectFromContext(ctx); !ok {
+		return slices.Contains(AnonymousScopes, scope)
+	}
+	scopes, _ := ctx.Value(scopesKey).([]string)
+	return slices.Contains(scopes, scope)
+}
+
+// RequireScope rejects requests whose caller lacks scope with 403
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !checkScope(c, scope) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireReadWriteScope requires read of GET and HEAD requests and write of every other request, rejecting
+// the requests whose caller lacks it with 403
+func RequireReadWriteScope(read, write string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = read
+		}
+		if !checkScope(c, scope) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkScope aborts the request with 403 unless its caller was granted scope
+func checkScope(c *gin.Context, scope string) bool {
+	if HasScope(c.Request.Context(), scope) {
+		return true
+	}
+	subject, _ := GetSubjectFromContext(c.Request.Context())
+	GetLoggerFromContext(c.Request.Context()).Info("Request lacks a scope", "scope", scope, "subject", subject)
+	c.AbortWithStatusJSON(http.StatusForbidden, dto.NewErrorResponse("Forbidden",
+		fmt.Sprintf("this credential was not granted the %s scope", scope)))
+	return false
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("alerts:fire, tasks:read tasks:read")
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeTasksRead, ScopeAlertsFire}, scopes)
+
+	scopes, err = ParseScopes("")
+	require.NoError(t, err)
+	assert.Empty(t, scopes)
+
+	_, err = ParseScopes("tasks:read tasks:delete")
+	assert.ErrorContains(t, err, `unknown scope "tasks:delete"`)
+}
+
+func TestRoleScopes(t *testing.T) {
+	assert.Equal(t, AllScopes, RoleScopes(RoleAdmin))
+	for _, role := range []string{RoleMember, RoleViewer, "root"} {
+		assert.NotContains(t, RoleScopes(role), ScopeKeysAdmin, role)
+		assert.Len(t, RoleScopes(role), len(AllScopes)-1, role)
+	}
+	// AllScopes is left alone
+	assert.Contains(t, AllScopes, ScopeKeysAdmin)
+}
+
+func TestHasScope(t *testing.T) {
+	// Requests that were not authenticated may only read
+	assert.True(t, HasScope(context.Background(), ScopeTasksRead))
+	assert.True(t, HasScope(context.Background(), ScopeAlertsRead))
+	assert.False(t, HasScope(context.Background(), ScopeTasksWrite))
+	assert.False(t, HasScope(context.Background(), ScopeKeysAdmin))
+
+	ctx := ContextWithScopes(ContextWithSubject(context.Background(), "ci-bot"), []string{ScopeTasksRead})
+	assert.True(t, HasScope(ctx, ScopeTasksRead))
+	assert.False(t, HasScope(ctx, ScopeTasksWrite))
+
+	// Authenticated callers without scopes may do nothing
+	assert.False(t, HasScope(ContextWithSubject(context.Background(), "ci-bot"), ScopeTasksRead))
+}
+
+func TestRequireReadWriteScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		scopes []string
+		status int
+	}{
+		{"read with read scope", "GET", []string{ScopeAlertsRead}, http.StatusOK},
+		{"write with read scope", "POST", []string{ScopeAlertsRead}, http.StatusForbidden},
+		{"write with write scope", "POST", []string{ScopeAlertsFire}, http.StatusOK},
+		{"read with write scope only", "GET", []string{ScopeAlertsFire}, http.StatusForbidden},
+		{"read not authenticated", "GET", nil, http.StatusOK},
+		{"write not authenticated", "POST", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.scopes != nil {
+					ctx := ContextWithScopes(ContextWithSubject(c.Request.Context(), "ci-bot"), tt.scopes)
+					c.Request = c.Request.WithContext(ctx)
+				}
+			}, RequireReadWriteScope(ScopeAlertsRead, ScopeAlertsFire))
+			router.Handle(tt.method, "/alerts", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/alerts", nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "Forbidden")
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ctx := ContextWithScopes(ContextWithSubject(c.Request.Context(), "ci-bot"), []string{ScopeTasksRead})
+		c.Request = c.Request.WithContext(ctx)
+	}, RequireScope(ScopeKeysAdmin))
+	router.GET("/api-keys", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api-keys", nil))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "the keys:admin scope")
+}
@@ -13,6 +13,9 @@ func SetupGlobalMiddleware(router *gin.Engine) {
 	// Request ID middleware for tracing
 	router.Use(RequestIDMiddleware())
 
+	// Actor middleware for the task history
+	router.Use(ActorMiddleware())
+
 	// Prometheus metrics middleware
 	router.Use(MetricsMiddleware())
 }
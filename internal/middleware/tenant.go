@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"taheri24.ir/graph1/internal/cache"
+	"taheri24.ir/graph1/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader names the header choosing the tenant, or workspace, of a request
+const TenantHeader = "X-Tenant-ID"
+
+// DefaultTenant is the tenant of the requests that do not choose one, and of the data created before tenants
+// existed
+const DefaultTenant = "default"
+
+// TenantKey is the context key for the tenant a request acts on
+type TenantKey string
+
+const (
+	tenantKey     TenantKey = "tenant"
+	allTenantsKey TenantKey = "all-tenants"
+)
+
+// tenantPattern accepts DNS labels, so that every tenant can also be reached through its subdomain
+var tenantPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ParseTenant checks that tenant is a valid tenant name, ignoring case
+func ParseTenant(tenant string) (string, error) {
+	tenant = strings.ToLower(strings.TrimSpace(tenant))
+	if !tenantPattern.MatchString(tenant) {
+		return "", fmt.Errorf("invalid tenant %q; tenants are 1 to 63 letters, digits and inner hyphens", tenant)
+	}
+	return tenant, nil
+}
+
+// TenantMiddleware resolves the tenant of the request and puts it in the request context. A credential bound
+// to a tenant decides it, and requests choosing another tenant are refused with 403. Otherwise the tenant is
+// taken from the X-Tenant-ID header or, when domain is set, from the subdomain of domain the request was sent
+// to, and is DefaultTenant when neither names one. It comes after AuthMiddleware.
+func TenantMiddleware(domain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger := GetLoggerFromContext(ctx)
+
+		requested := strings.TrimSpace(c.GetHeader(TenantHeader))
+		if requested == "" {
+			requested = subdomain(c.Request.Host, domain)
+		}
+		if requested != "" {
+			var err error
+			if requested, err = ParseTenant(requested); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, dto.NewErrorResponse("Invalid tenant", err.Error()))
+				return
+			}
+		}
+
+		tenant := requested
+		if bound, ok := ctx.Value(tenantKey).(string); ok && bound != "" {
+			if requested != "" && requested != bound {
+				logger.Info("Credential used for another tenant", "tenant", bound, "requested", requested)
+				c.AbortWithStatusJSON(http.StatusForbidden, dto.NewErrorResponse("Forbidden",
+					fmt.Sprintf("this credential belongs to tenant %q and cannot act on tenant %q", bound, requested)))
+				return
+			}
+			tenant = bound
+		}
+		if tenant == "" {
+			tenant = DefaultTenant
+		}
+
+		ctx = ContextWithTenant(ctx, tenant)
+		ctx = ContextWithLogger(ctx, logger.With("tenant", tenant))
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(TenantHeader, tenant)
+		c.Next()
+	}
+}
+
+// subdomain returns the label of host right below domain, or "" when host is not a subdomain of domain
+func subdomain(host, domain string) string {
+	if domain == "" {
+		return ""
+	}
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// ContextWithTenant returns a copy of ctx acting on the data of tenant. Before TenantMiddleware runs it binds
+// the credential of the request to tenant.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// GetTenantFromContext retrieves the tenant the request behind ctx acts on, or DefaultTenant when there is
+// none, so that code that forgot to resolve the tenant never sees the data of another one
+func GetTenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+// ContextWithAllTenants returns a copy of ctx acting on the data of every tenant, for background jobs that
+// serve all of them. Requests never get one.
+func ContextWithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey, true)
+}
+
+// SpansAllTenants reports whether ctx was made by ContextWithAllTenants
+func SpansAllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey).(bool)
+	return all
+}
+
+// TenantCache returns the section of c holding the items of the tenant of ctx, so that an item cached for one
+// tenant is never served to another
+func TenantCache[T any](ctx context.Context, c cache.CacheInterface[T]) cache.CacheInterface[T] {
+	return c.Section(GetTenantFromContext(ctx))
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"taheri24.ir/graph1/internal/cache"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTenant(t *testing.T) {
+	tenant, err := ParseTenant(" Acme-42 ")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-42", tenant)
+
+	for _, invalid := range []string{"", "-acme", "acme-", "ac.me", "ac me", strings.Repeat("a", 64)} {
+		_, err := ParseTenant(invalid)
+		assert.ErrorContains(t, err, "invalid tenant", invalid)
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		bound    string
+		header   string
+		host     string
+		status   int
+		expected string
+	}{
+		{"defaults to the default tenant", "", "", "api.example.com", http.StatusOK, DefaultTenant},
+		{"uses the header", "", "Acme", "api.example.com", http.StatusOK, "acme"},
+		{"uses the subdomain", "", "", "globex.tasks.example.com:8080", http.StatusOK, "globex"},
+		{"prefers the header to the subdomain", "", "acme", "globex.tasks.example.com", http.StatusOK, "acme"},
+		{"ignores deeper subdomains", "", "", "a.globex.tasks.example.com", http.StatusOK, DefaultTenant},
+		{"rejects invalid tenants", "", "ac_me", "api.example.com", http.StatusBadRequest, ""},
+		{"uses the tenant of the credential", "acme", "", "api.example.com", http.StatusOK, "acme"},
+		{"accepts the tenant of the credential", "acme", "acme", "acme.tasks.example.com", http.StatusOK, "acme"},
+		{"refuses other tenants to a bound credential", "acme", "globex", "api.example.com", http.StatusForbidden, ""},
+		{"refuses other subdomains to a bound credential", "acme", "", "globex.tasks.example.com", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.bound != "" {
+					c.Request = c.Request.WithContext(ContextWithTenant(c.Request.Context(), tt.bound))
+				}
+			})
+			router.Use(TenantMiddleware("tasks.example.com"))
+
+			var tenant string
+			router.GET("/test", func(c *gin.Context) {
+				tenant = GetTenantFromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+			assert.Equal(t, tt.expected, tenant)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.expected, w.Header().Get(TenantHeader))
+			}
+		})
+	}
+}
+
+func TestGetTenantFromContext(t *testing.T) {
+	assert.Equal(t, DefaultTenant, GetTenantFromContext(context.Background()))
+	assert.Equal(t, "acme", GetTenantFromContext(ContextWithTenant(context.Background(), "acme")))
+
+	assert.False(t, SpansAllTenants(context.Background()))
+	assert.True(t, SpansAllTenants(ContextWithAllTenants(context.Background())))
+}
+
+func TestTenantCache(t *testing.T) {
+	shared := cache.NewInMemoryCacheImpl[string]()
+	acme := ContextWithTenant(context.Background(), "acme")
+	globex := ContextWithTenant(context.Background(), "globex")
+
+	require.NoError(t, TenantCache(acme, shared).Set("1", "acme task"))
+	item, err := TenantCache(acme, shared).Get("1")
+	require.NoError(t, err)
+	require.NotNil(t, item)
+	assert.Equal(t, "acme task", *item)
+
+	// Another tenant never sees the items of the first
+	item, _ = TenantCache(globex, shared).Get("1")
+	assert.Nil(t, item)
+}
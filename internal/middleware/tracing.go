@@ -63,6 +63,11 @@ func GetRequestIDFromContext(ctx context.Context) string {
 	return ""
 }
 
+// ContextWithLogger returns a copy of ctx carrying logger, for work that runs outside a request
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
 // GetLoggerFromContext retrieves the logger from the context
 func GetLoggerFromContext(ctx context.Context) *slog.Logger {
 	if logger := ctx.Value(loggerKey); logger != nil {
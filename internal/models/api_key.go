@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential of a machine client. Only the SHA-256 hash of the key is stored; the key
+// itself is shown once, when it is minted.
+type APIKey struct {
+	ID   uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	Name string    `json:"name" gorm:"type:varchar(100);not null"`
+	// Prefix is the start of the key, enough to recognize it in listings and logs
+	Prefix string `json:"prefix" gorm:"type:varchar(16);not null"`
+	Hash   string `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	// Scopes lists the scopes granted to the key, separated by spaces
+	Scopes string `json:"scopes" gorm:"type:varchar(255);not null"`
+	// Role is the role of the callers using the key; keys minted before roles existed are members
+	Role string `json:"role" gorm:"type:varchar(20);not null;default:'member'"`
+	// TenantID is the tenant the callers using the key are bound to
+	TenantID   string     `json:"tenant_id" gorm:"type:varchar(63);not null;default:'default';index"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(100)"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// ScopeList returns the scopes granted to the key
+func (k APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// Active reports whether the key may be used at now: it is neither revoked nor expired
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
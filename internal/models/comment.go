@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Comment is a message in the discussion thread of a task
+type Comment struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	TaskID    uuid.UUID      `json:"task_id" gorm:"type:uuid;not null;index"`
+	Author    string         `json:"author" gorm:"type:varchar(100);not null"`
+	Body      string         `json:"body" gorm:"type:text;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Comment) TableName() string {
+	return "comments"
+}
+
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
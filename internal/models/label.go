@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Label is a named tag that can be attached to any number of tasks of its tenant. Names are unique within
+// a tenant.
+type Label struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	TenantID  string    `json:"-" gorm:"type:varchar(63);not null;default:'default';uniqueIndex:idx_labels_tenant_name"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null;uniqueIndex:idx_labels_tenant_name"`
+	Color     string    `json:"color" gorm:"type:varchar(7)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Label) TableName() string {
+	return "labels"
+}
+
+func (l *Label) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+// TaskLabel is the join row attaching LabelID to TaskID
+type TaskLabel struct {
+	TaskID    uuid.UUID `json:"task_id" gorm:"type:uuid;primaryKey"`
+	LabelID   uuid.UUID `json:"label_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TaskLabel) TableName() string {
+	return "task_labels"
+}
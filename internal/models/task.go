@@ -9,15 +9,29 @@ import (
 	"gorm.io/gorm"
 )
 
+// Task is a unit of work of a tenant. Assignee is the username of the user AssigneeID refers to, kept
+// with the task so that tasks are listed and filtered by assignee without a join.
 type Task struct {
-	ID          uuid.UUID        `json:"id" gorm:"type:uuid;primary_key"`
-	Title       string           `json:"title" gorm:"not null"`
-	Description string           `json:"description" gorm:"type:text"`
-	Status      types.TaskStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
-	Assignee    string           `json:"assignee" gorm:"type:varchar(100)"`
-	CreatedAt   time.Time        `json:"created_at"`
-	UpdatedAt   time.Time        `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt   `json:"-" gorm:"index"`
+	ID          uuid.UUID          `json:"id" gorm:"type:uuid;primary_key"`
+	TenantID    string             `json:"tenant_id" gorm:"type:varchar(63);not null;default:'default';index"`
+	Title       string             `json:"title" gorm:"not null"`
+	Description string             `json:"description" gorm:"type:text"`
+	Status      types.TaskStatus   `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	Assignee    string             `json:"assignee" gorm:"type:varchar(100)"`
+	AssigneeID  *uuid.UUID         `json:"assignee_id,omitempty" gorm:"type:uuid;index"`
+	Estimate    float64            `json:"estimate" gorm:"default:0"`
+	Priority    types.TaskPriority `json:"priority" gorm:"type:varchar(20);default:'medium';index"`
+	DueAt       *time.Time         `json:"due_at,omitempty" gorm:"index"`
+	// RemindedAt is when the reminder for DueAt was sent; it is cleared when DueAt changes
+	RemindedAt *time.Time     `json:"-" gorm:"index"`
+	ParentID   *uuid.UUID     `json:"parent_id,omitempty" gorm:"type:uuid;index"`
+	Version    int64          `json:"version" gorm:"not null;default:1"`
+	CreatedBy  string         `json:"created_by" gorm:"type:varchar(100);index"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+	Blockers   []Task         `json:"-" gorm:"many2many:task_dependencies;joinForeignKey:TaskID;joinReferences:BlockerID"`
+	Labels     []Label        `json:"-" gorm:"many2many:task_labels;joinForeignKey:TaskID;joinReferences:LabelID"`
 }
 
 func (Task) TableName() string {
@@ -28,5 +42,8 @@ func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == uuid.Nil {
 		t.ID = uuid.New()
 	}
+	if t.Version == 0 {
+		t.Version = 1
+	}
 	return nil
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskDependency is the join row stating that BlockerID must be finished before TaskID
+type TaskDependency struct {
+	TaskID    uuid.UUID `json:"task_id" gorm:"type:uuid;primaryKey"`
+	BlockerID uuid.UUID `json:"blocker_id" gorm:"type:uuid;primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TaskDependency) TableName() string {
+	return "task_dependencies"
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Task event actions
+const (
+	TaskEventCreated  = "created"
+	TaskEventUpdated  = "updated"
+	TaskEventDeleted  = "deleted"
+	TaskEventRestored = "restored"
+	TaskEventPurged   = "purged"
+)
+
+// FieldChange holds the value of a task field before and after a change
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// TaskChanges maps task field names, as they appear in the API, to how they changed.
+// It is stored as a JSON document.
+type TaskChanges map[string]FieldChange
+
+// Value implements driver.Valuer
+func (c TaskChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+// Scan implements sql.Scanner
+func (c *TaskChanges) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*c = TaskChanges{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return errors.New("unsupported type for TaskChanges")
+	}
+}
+
+// TaskEvent is an immutable record of a change made to a task
+type TaskEvent struct {
+	ID           uuid.UUID   `json:"id" gorm:"type:uuid;primary_key"`
+	TaskID       uuid.UUID   `json:"task_id" gorm:"type:uuid;not null;index"`
+	TenantID     string      `json:"-" gorm:"type:varchar(63);not null;default:'default';index"`
+	Action       string      `json:"action" gorm:"type:varchar(20);not null"`
+	Actor        string      `json:"actor" gorm:"type:varchar(100)"`
+	ClaimedActor string      `json:"claimed_actor,omitempty" gorm:"type:varchar(100)"`
+	RequestID    string      `json:"request_id" gorm:"type:varchar(100)"`
+	Changes      TaskChanges `json:"changes" gorm:"type:text"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"index"`
+}
+
+func (TaskEvent) TableName() string {
+	return "task_events"
+}
+
+func (e *TaskEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// DiffTasks returns the audited fields whose values differ between before and after
+func DiffTasks(before, after Task) TaskChanges {
+	beforeFields, afterFields := auditedFields(before), auditedFields(after)
+	changes := TaskChanges{}
+	for name, value := range afterFields {
+		if !reflect.DeepEqual(beforeFields[name], value) {
+			changes[name] = FieldChange{Before: beforeFields[name], After: value}
+		}
+	}
+	return changes
+}
+
+// auditedFields returns the user-editable fields of a task keyed by their JSON names
+func auditedFields(t Task) map[string]any {
+	var dueAt, parentID any
+	if t.DueAt != nil {
+		dueAt = t.DueAt.UTC().Format(time.RFC3339)
+	}
+	if t.ParentID != nil {
+		parentID = t.ParentID.String()
+	}
+	return map[string]any{
+		"title":       t.Title,
+		"description": t.Description,
+		"status":      string(t.Status),
+		"assignee":    t.Assignee,
+		"estimate":    t.Estimate,
+		"priority":    string(t.Priority),
+		"due_at":      dueAt,
+		"parent_id":   parentID,
+	}
+}
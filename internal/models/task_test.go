@@ -31,6 +31,13 @@ func TestTaskStatusConstants(t *testing.T) {
 	assert.Equal(t, types.TaskStatus("completed"), types.StatusCompleted)
 }
 
+func TestTaskPriorityConstants(t *testing.T) {
+	assert.Equal(t, types.TaskPriority("low"), types.PriorityLow)
+	assert.Equal(t, types.TaskPriority("medium"), types.PriorityMedium)
+	assert.Equal(t, types.TaskPriority("high"), types.PriorityHigh)
+	assert.Equal(t, types.TaskPriority("urgent"), types.PriorityUrgent)
+}
+
 func TestTaskModel(t *testing.T) {
 	id := uuid.New()
 	now := time.Now()
@@ -334,3 +341,31 @@ func TestTaskCacheTypeAssertion(t *testing.T) {
 	assert.NotNil(t, retrievedTask2)
 	assert.Equal(t, task2.Title, retrievedTask2.Title)
 }
+
+func TestDiffTasks(t *testing.T) {
+	due := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	parentID := uuid.New()
+	before := models.Task{Title: "Draft", Status: types.StatusPending, Estimate: 2}
+	after := before
+	after.Status = types.StatusCompleted
+	after.DueAt = &due
+	after.ParentID = &parentID
+
+	assert.Equal(t, models.TaskChanges{
+		"status":    {Before: "pending", After: "completed"},
+		"due_at":    {Before: nil, After: "2026-03-01T12:00:00Z"},
+		"parent_id": {Before: nil, After: parentID.String()},
+	}, models.DiffTasks(before, after))
+	assert.Empty(t, models.DiffTasks(after, after))
+}
+
+func TestTaskChangesRoundTrip(t *testing.T) {
+	changes := models.TaskChanges{"title": {Before: "Draft", After: "Final"}}
+	value, err := changes.Value()
+	require.NoError(t, err)
+
+	var scanned models.TaskChanges
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, changes, scanned)
+	assert.Error(t, scanned.Scan(42))
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// User is a person of a tenant that tasks can be assigned to. Usernames are unique within a tenant.
+type User struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	TenantID  string    `json:"-" gorm:"type:varchar(63);not null;default:'default';uniqueIndex:idx_users_tenant_username"`
+	Username  string    `json:"username" gorm:"type:varchar(100);not null;uniqueIndex:idx_users_tenant_username"`
+	Name      string    `json:"name" gorm:"type:varchar(200)"`
+	Email     string    `json:"email" gorm:"type:varchar(254)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
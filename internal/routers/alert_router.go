@@ -1,6 +1,8 @@
 package routers
 
 import (
+	"taheri24.ir/graph1/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -11,9 +13,10 @@ type AlertHandlerInterface interface {
 	ResetAlert(c *gin.Context)
 }
 
-// SetupAlertRouter configures the alert-related endpoints
+// SetupAlertRouter configures the alert-related endpoints. Listing alerts requires the alerts:read scope and
+// firing or resetting them the alerts:fire scope.
 func SetupAlertRouter(router gin.IRouter, alertHandler AlertHandlerInterface) {
-	api := router.Group("/alerts")
+	api := router.Group("/alerts", middleware.RequireReadWriteScope(middleware.ScopeAlertsRead, middleware.ScopeAlertsFire))
 	{
 		api.GET("", alertHandler.GetAlerts)
 		api.POST("/fire", alertHandler.FireAlert)
@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"taheri24.ir/graph1/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -80,6 +82,8 @@ func TestSetupAlertRouter_EndpointHandlers(t *testing.T) {
 
 	// Create gin router
 	router := gin.New()
+	// The routes that change data need an authenticated caller
+	withScopes(router, middleware.AllScopes...)
 
 	// Setup alert router
 	SetupAlertRouter(router, mockAlertHandler)
@@ -149,3 +153,35 @@ func TestSetupAlertRouter_GroupPath(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupAlertRouter_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockAlertHandler := new(MockAlertHandler)
+	mockAlertHandler.On("GetAlerts", mock.AnythingOfType("*gin.Context")).Once()
+
+	// A read-only caller may list alerts but not fire or reset them
+	router := gin.New()
+	withScopes(router, middleware.ScopeTasksRead, middleware.ScopeAlertsRead)
+	SetupAlertRouter(router, mockAlertHandler)
+
+	testCases := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/alerts", http.StatusOK},
+		{"POST", "/alerts/fire", http.StatusForbidden},
+		{"POST", "/alerts/reset", http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "%s %s", tc.method, tc.path)
+	}
+
+	mockAlertHandler.AssertExpectations(t)
+	mockAlertHandler.AssertNotCalled(t, "FireAlert", mock.Anything)
+	mockAlertHandler.AssertNotCalled(t, "ResetAlert", mock.Anything)
+}
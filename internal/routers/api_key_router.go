@@ -0,0 +1,25 @@
+package routers
+
+import (
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandlerInterface defines the API key handler methods needed by the router
+type APIKeyHandlerInterface interface {
+	ListAPIKeys(c *gin.Context)
+	CreateAPIKey(c *gin.Context)
+	RevokeAPIKey(c *gin.Context)
+}
+
+// SetupAPIKeyRouter configures the API key administration endpoints, which require the keys:admin scope and,
+// checked by the handlers, the admin role
+func SetupAPIKeyRouter(router gin.IRouter, apiKeyHandler APIKeyHandlerInterface) {
+	api := router.Group("/api-keys", middleware.RequireScope(middleware.ScopeKeysAdmin))
+	{
+		api.GET("", apiKeyHandler.ListAPIKeys)
+		api.POST("", apiKeyHandler.CreateAPIKey)
+		api.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+	}
+}
@@ -0,0 +1,81 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyHandler is a mock implementation of APIKeyHandlerInterface
+type MockAPIKeyHandler struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyHandler) ListAPIKeys(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockAPIKeyHandler) CreateAPIKey(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockAPIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	m.Called(c)
+}
+
+// withScopes authenticates every request of router as a caller granted scopes
+func withScopes(router *gin.Engine, scopes ...string) {
+	router.Use(func(c *gin.Context) {
+		ctx := middleware.ContextWithSubject(c.Request.Context(), "test-client")
+		c.Request = c.Request.WithContext(middleware.ContextWithScopes(ctx, scopes))
+	})
+}
+
+func TestSetupAPIKeyRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockHandler := new(MockAPIKeyHandler)
+	for _, method := range []string{"ListAPIKeys", "CreateAPIKey", "RevokeAPIKey"} {
+		mockHandler.On(method, mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+			args.Get(0).(*gin.Context).Status(http.StatusOK)
+		}).Once()
+	}
+
+	router := gin.New()
+	withScopes(router, middleware.ScopeKeysAdmin)
+	SetupAPIKeyRouter(router.Group("/api/v1"), mockHandler)
+
+	for _, route := range []struct{ method, path string }{
+		{"GET", "/api/v1/api-keys"},
+		{"POST", "/api/v1/api-keys"},
+		{"DELETE", "/api/v1/api-keys/123"},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(route.method, route.path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "%s %s", route.method, route.path)
+	}
+	mockHandler.AssertExpectations(t)
+}
+
+func TestSetupAPIKeyRouter_RequiresAdminScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockHandler := new(MockAPIKeyHandler)
+	router := gin.New()
+	withScopes(router, middleware.AllScopes[:4]...)
+	SetupAPIKeyRouter(router, mockHandler)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api-keys", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockHandler.AssertNotCalled(t, "ListAPIKeys", mock.Anything)
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandlerInterface defines the auth handler methods needed by the router
+type AuthHandlerInterface interface {
+	IssueToken(c *gin.Context)
+}
+
+// SetupAuthRouter configures the token endpoint, which must stay reachable without a token
+func SetupAuthRouter(router gin.IRouter, authHandler AuthHandlerInterface) {
+	api := router.Group("/auth")
+	{
+		api.POST("/token", authHandler.IssueToken)
+	}
+}
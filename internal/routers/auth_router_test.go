@@ -0,0 +1,39 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuthHandler is a mock implementation of AuthHandlerInterface
+type MockAuthHandler struct {
+	mock.Mock
+}
+
+func (m *MockAuthHandler) IssueToken(c *gin.Context) {
+	m.Called(c)
+}
+
+func TestSetupAuthRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockHandler := new(MockAuthHandler)
+	mockHandler.On("IssueToken", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+		args.Get(0).(*gin.Context).Status(http.StatusOK)
+	}).Once()
+
+	router := gin.New()
+	SetupAuthRouter(router.Group("/api/v1"), mockHandler)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/token", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockHandler.AssertExpectations(t)
+}
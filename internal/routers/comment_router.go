@@ -0,0 +1,29 @@
+package routers
+
+import (
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommentHandlerInterface defines the comment handler methods needed by the router
+type CommentHandlerInterface interface {
+	ListComments(c *gin.Context)
+	CreateComment(c *gin.Context)
+	GetComment(c *gin.Context)
+	UpdateComment(c *gin.Context)
+	DeleteComment(c *gin.Context)
+}
+
+// SetupCommentRouter configures the comment endpoints nested under each task, under the tasks:read and
+// tasks:write scopes
+func SetupCommentRouter(router gin.IRouter, commentHandler CommentHandlerInterface) {
+	api := router.Group("/tasks/:id/comments", middleware.RequireReadWriteScope(middleware.ScopeTasksRead, middleware.ScopeTasksWrite))
+	{
+		api.GET("", commentHandler.ListComments)
+		api.POST("", commentHandler.CreateComment)
+		api.GET("/:comment_id", commentHandler.GetComment)
+		api.PUT("/:comment_id", commentHandler.UpdateComment)
+		api.DELETE("/:comment_id", commentHandler.DeleteComment)
+	}
+}
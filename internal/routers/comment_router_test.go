@@ -0,0 +1,75 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCommentHandler is a mock implementation of CommentHandlerInterface
+type MockCommentHandler struct {
+	mock.Mock
+}
+
+func (m *MockCommentHandler) ListComments(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockCommentHandler) CreateComment(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockCommentHandler) GetComment(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockCommentHandler) UpdateComment(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockCommentHandler) DeleteComment(c *gin.Context) {
+	m.Called(c)
+}
+
+func TestSetupCommentRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		handler string
+		method  string
+		path    string
+	}{
+		{"ListComments", "GET", "/api/v1/tasks/1/comments"},
+		{"CreateComment", "POST", "/api/v1/tasks/1/comments"},
+		{"GetComment", "GET", "/api/v1/tasks/1/comments/2"},
+		{"UpdateComment", "PUT", "/api/v1/tasks/1/comments/2"},
+		{"DeleteComment", "DELETE", "/api/v1/tasks/1/comments/2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.handler, func(t *testing.T) {
+			mockHandler := new(MockCommentHandler)
+			mockHandler.On(tc.handler, mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).Status(http.StatusOK)
+			}).Once()
+
+			router := gin.New()
+			// The routes that change data need an authenticated caller
+			withScopes(router, middleware.AllScopes...)
+			SetupCommentRouter(router.Group("/api/v1"), mockHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockHandler.AssertExpectations(t)
+		})
+	}
+}
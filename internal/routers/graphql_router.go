@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLHandlerInterface defines the GraphQL handler methods needed by the router
+type GraphQLHandlerInterface interface {
+	Query(c *gin.Context)
+}
+
+// SetupGraphQLRouter configures the GraphQL endpoint. The handler requires the tasks:read scope of queries and
+// the tasks:write scope of mutations, since a POST may carry either.
+func SetupGraphQLRouter(router gin.IRouter, graphqlHandler GraphQLHandlerInterface) {
+	api := router.Group("/graphql")
+	{
+		api.GET("", graphqlHandler.Query)
+		api.POST("", graphqlHandler.Query)
+	}
+}
@@ -0,0 +1,43 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockGraphQLHandler is a mock implementation of GraphQLHandlerInterface
+type MockGraphQLHandler struct {
+	mock.Mock
+}
+
+func (m *MockGraphQLHandler) Query(c *gin.Context) {
+	m.Called(c)
+}
+
+func TestSetupGraphQLRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, method := range []string{"GET", "POST"} {
+		t.Run(method, func(t *testing.T) {
+			mockHandler := new(MockGraphQLHandler)
+			mockHandler.On("Query", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).Status(http.StatusOK)
+			}).Once()
+
+			router := gin.New()
+			SetupGraphQLRouter(router.Group("/api/v1"), mockHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(method, "/api/v1/graphql", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockHandler.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LabelHandlerInterface defines the label handler methods needed by the router
+type LabelHandlerInterface interface {
+	ListLabels(c *gin.Context)
+	CreateLabel(c *gin.Context)
+	GetLabel(c *gin.Context)
+	UpdateLabel(c *gin.Context)
+	DeleteLabel(c *gin.Context)
+}
+
+// SetupLabelRouter configures the label-related endpoints, under the tasks:read and tasks:write scopes
+func SetupLabelRouter(router gin.IRouter, labelHandler LabelHandlerInterface) {
+	api := router.Group("/labels", middleware.RequireReadWriteScope(middleware.ScopeTasksRead, middleware.ScopeTasksWrite))
+	{
+		api.GET("", labelHandler.ListLabels)
+		api.POST("", labelHandler.CreateLabel)
+		api.GET("/:id", labelHandler.GetLabel)
+		api.PUT("/:id", labelHandler.UpdateLabel)
+		api.DELETE("/:id", labelHandler.DeleteLabel)
+	}
+}
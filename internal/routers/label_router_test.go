@@ -0,0 +1,75 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLabelHandler is a mock implementation of LabelHandlerInterface
+type MockLabelHandler struct {
+	mock.Mock
+}
+
+func (m *MockLabelHandler) ListLabels(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockLabelHandler) CreateLabel(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockLabelHandler) GetLabel(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockLabelHandler) UpdateLabel(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockLabelHandler) DeleteLabel(c *gin.Context) {
+	m.Called(c)
+}
+
+func TestSetupLabelRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		handler string
+		method  string
+		path    string
+	}{
+		{"ListLabels", "GET", "/api/v1/labels"},
+		{"CreateLabel", "POST", "/api/v1/labels"},
+		{"GetLabel", "GET", "/api/v1/labels/1"},
+		{"UpdateLabel", "PUT", "/api/v1/labels/1"},
+		{"DeleteLabel", "DELETE", "/api/v1/labels/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.handler, func(t *testing.T) {
+			mockHandler := new(MockLabelHandler)
+			mockHandler.On(tc.handler, mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).Status(http.StatusOK)
+			}).Once()
+
+			router := gin.New()
+			// The routes that change data need an authenticated caller
+			withScopes(router, middleware.AllScopes...)
+			SetupLabelRouter(router.Group("/api/v1"), mockHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockHandler.AssertExpectations(t)
+		})
+	}
+}
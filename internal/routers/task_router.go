@@ -1,26 +1,59 @@
 package routers
 
 import (
+	"taheri24.ir/graph1/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
 // TaskHandlerInterface defines the task handler methods needed by the router
 type TaskHandlerInterface interface {
 	CreateTask(c *gin.Context)
+	BulkTasks(c *gin.Context)
 	GetTasks(c *gin.Context)
 	GetTask(c *gin.Context)
 	UpdateTask(c *gin.Context)
+	PatchTask(c *gin.Context)
 	DeleteTask(c *gin.Context)
+	GetBlockers(c *gin.Context)
+	AddBlocker(c *gin.Context)
+	RemoveBlocker(c *gin.Context)
+	GetDependents(c *gin.Context)
+	GetTaskLabels(c *gin.Context)
+	AddTaskLabel(c *gin.Context)
+	RemoveTaskLabel(c *gin.Context)
+	GetTaskTree(c *gin.Context)
+	GetTaskHistory(c *gin.Context)
+	GetTrash(c *gin.Context)
+	RestoreTask(c *gin.Context)
+	GetPlan(c *gin.Context)
+	GetGraph(c *gin.Context)
 }
 
-// SetupTaskRouter configures the task-related endpoints
+// SetupTaskRouter configures the task-related endpoints. Reading requires the tasks:read scope and every
+// change the tasks:write scope.
 func SetupTaskRouter(router gin.IRouter, taskHandler TaskHandlerInterface) {
-	api := router.Group("/tasks")
+	api := router.Group("/tasks", middleware.RequireReadWriteScope(middleware.ScopeTasksRead, middleware.ScopeTasksWrite))
 	{
 		api.POST("", taskHandler.CreateTask)
+		api.POST("/bulk", taskHandler.BulkTasks)
 		api.GET("", taskHandler.GetTasks)
+		api.GET("/plan", taskHandler.GetPlan)
+		api.GET("/graph", taskHandler.GetGraph)
+		api.GET("/trash", taskHandler.GetTrash)
 		api.GET("/:id", taskHandler.GetTask)
 		api.PUT("/:id", taskHandler.UpdateTask)
+		api.PATCH("/:id", taskHandler.PatchTask)
 		api.DELETE("/:id", taskHandler.DeleteTask)
+		api.GET("/:id/blockers", taskHandler.GetBlockers)
+		api.POST("/:id/blockers", taskHandler.AddBlocker)
+		api.DELETE("/:id/blockers/:blocker_id", taskHandler.RemoveBlocker)
+		api.GET("/:id/dependents", taskHandler.GetDependents)
+		api.GET("/:id/labels", taskHandler.GetTaskLabels)
+		api.POST("/:id/labels", taskHandler.AddTaskLabel)
+		api.DELETE("/:id/labels/:label_id", taskHandler.RemoveTaskLabel)
+		api.GET("/:id/tree", taskHandler.GetTaskTree)
+		api.GET("/:id/history", taskHandler.GetTaskHistory)
+		api.POST("/:id/restore", taskHandler.RestoreTask)
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"taheri24.ir/graph1/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -31,10 +33,70 @@ func (m *MockTaskHandler) UpdateTask(c *gin.Context) {
 	m.Called(c)
 }
 
+func (m *MockTaskHandler) PatchTask(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) BulkTasks(c *gin.Context) {
+	m.Called(c)
+}
+
 func (m *MockTaskHandler) DeleteTask(c *gin.Context) {
 	m.Called(c)
 }
 
+func (m *MockTaskHandler) GetBlockers(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) AddBlocker(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) RemoveBlocker(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetDependents(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetPlan(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetGraph(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetTaskLabels(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) AddTaskLabel(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) RemoveTaskLabel(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetTaskTree(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetTaskHistory(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) GetTrash(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockTaskHandler) RestoreTask(c *gin.Context) {
+	m.Called(c)
+}
+
 func TestSetupTaskRouter_RouteRegistration(t *testing.T) {
 	// Set gin to test mode
 	gin.SetMode(gin.TestMode)
@@ -57,10 +119,25 @@ func TestSetupTaskRouter_RouteRegistration(t *testing.T) {
 		method string
 	}{
 		{"/tasks", "POST"},
+		{"/tasks/bulk", "POST"},
 		{"/tasks", "GET"},
 		{"/tasks/:id", "GET"},
 		{"/tasks/:id", "PUT"},
+		{"/tasks/:id", "PATCH"},
 		{"/tasks/:id", "DELETE"},
+		{"/tasks/:id/blockers", "GET"},
+		{"/tasks/:id/blockers", "POST"},
+		{"/tasks/:id/blockers/:blocker_id", "DELETE"},
+		{"/tasks/:id/dependents", "GET"},
+		{"/tasks/plan", "GET"},
+		{"/tasks/graph", "GET"},
+		{"/tasks/:id/labels", "GET"},
+		{"/tasks/:id/labels", "POST"},
+		{"/tasks/:id/labels/:label_id", "DELETE"},
+		{"/tasks/:id/tree", "GET"},
+		{"/tasks/:id/history", "GET"},
+		{"/tasks/trash", "GET"},
+		{"/tasks/:id/restore", "POST"},
 	}
 
 	// Verify all expected routes are registered
@@ -88,10 +165,27 @@ func TestSetupTaskRouter_EndpointHandlers(t *testing.T) {
 	mockTaskHandler.On("GetTasks", mock.AnythingOfType("*gin.Context"))
 	mockTaskHandler.On("GetTask", mock.AnythingOfType("*gin.Context"))
 	mockTaskHandler.On("UpdateTask", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("PatchTask", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("BulkTasks", mock.AnythingOfType("*gin.Context"))
 	mockTaskHandler.On("DeleteTask", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetBlockers", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("AddBlocker", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("RemoveBlocker", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetDependents", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetPlan", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetGraph", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetTaskLabels", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("AddTaskLabel", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("RemoveTaskLabel", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetTaskTree", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetTaskHistory", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("GetTrash", mock.AnythingOfType("*gin.Context"))
+	mockTaskHandler.On("RestoreTask", mock.AnythingOfType("*gin.Context"))
 
 	// Create gin router
 	router := gin.New()
+	// The routes that change data need an authenticated caller
+	withScopes(router, middleware.AllScopes...)
 
 	// Setup task router
 	SetupTaskRouter(router, mockTaskHandler)
@@ -106,7 +200,22 @@ func TestSetupTaskRouter_EndpointHandlers(t *testing.T) {
 		{"Get Tasks", "GET", "/tasks"},
 		{"Get Task", "GET", "/tasks/1"},
 		{"Update Task", "PUT", "/tasks/1"},
+		{"Patch Task", "PATCH", "/tasks/1"},
+		{"Bulk Tasks", "POST", "/tasks/bulk"},
 		{"Delete Task", "DELETE", "/tasks/1"},
+		{"Get Blockers", "GET", "/tasks/1/blockers"},
+		{"Add Blocker", "POST", "/tasks/1/blockers"},
+		{"Remove Blocker", "DELETE", "/tasks/1/blockers/2"},
+		{"Get Dependents", "GET", "/tasks/1/dependents"},
+		{"Get Plan", "GET", "/tasks/plan"},
+		{"Get Graph", "GET", "/tasks/graph"},
+		{"Get Task Labels", "GET", "/tasks/1/labels"},
+		{"Add Task Label", "POST", "/tasks/1/labels"},
+		{"Remove Task Label", "DELETE", "/tasks/1/labels/2"},
+		{"Get Task Tree", "GET", "/tasks/1/tree"},
+		{"Get Task History", "GET", "/tasks/1/history"},
+		{"Get Trash", "GET", "/tasks/trash"},
+		{"Restore Task", "POST", "/tasks/1/restore"},
 	}
 
 	for _, tc := range testCases {
@@ -163,3 +272,31 @@ func TestSetupTaskRouter_GroupPath(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupTaskRouter_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockHandler := new(MockTaskHandler)
+	mockHandler.On("GetTasks", mock.AnythingOfType("*gin.Context")).Once()
+
+	router := gin.New()
+	withScopes(router, middleware.ScopeTasksRead)
+	SetupTaskRouter(router, mockHandler)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/tasks", http.StatusOK},
+		{"POST", "/tasks", http.StatusForbidden},
+		{"DELETE", "/tasks/123", http.StatusForbidden},
+	} {
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.status, w.Code, "%s %s", tc.method, tc.path)
+	}
+
+	mockHandler.AssertExpectations(t)
+}
@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandlerInterface defines the user handler methods needed by the router
+type UserHandlerInterface interface {
+	ListUsers(c *gin.Context)
+	CreateUser(c *gin.Context)
+	GetUser(c *gin.Context)
+	UpdateUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+}
+
+// SetupUserRouter configures the user-related endpoints, under the tasks:read and tasks:write scopes
+func SetupUserRouter(router gin.IRouter, userHandler UserHandlerInterface) {
+	api := router.Group("/users", middleware.RequireReadWriteScope(middleware.ScopeTasksRead, middleware.ScopeTasksWrite))
+	{
+		api.GET("", userHandler.ListUsers)
+		api.POST("", userHandler.CreateUser)
+		api.GET("/:id", userHandler.GetUser)
+		api.PUT("/:id", userHandler.UpdateUser)
+		api.DELETE("/:id", userHandler.DeleteUser)
+	}
+}
@@ -0,0 +1,75 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserHandler is a mock implementation of UserHandlerInterface
+type MockUserHandler struct {
+	mock.Mock
+}
+
+func (m *MockUserHandler) ListUsers(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockUserHandler) CreateUser(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockUserHandler) GetUser(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockUserHandler) UpdateUser(c *gin.Context) {
+	m.Called(c)
+}
+
+func (m *MockUserHandler) DeleteUser(c *gin.Context) {
+	m.Called(c)
+}
+
+func TestSetupUserRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		handler string
+		method  string
+		path    string
+	}{
+		{"ListUsers", "GET", "/api/v1/users"},
+		{"CreateUser", "POST", "/api/v1/users"},
+		{"GetUser", "GET", "/api/v1/users/1"},
+		{"UpdateUser", "PUT", "/api/v1/users/1"},
+		{"DeleteUser", "DELETE", "/api/v1/users/1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.handler, func(t *testing.T) {
+			mockHandler := new(MockUserHandler)
+			mockHandler.On(tc.handler, mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+				args.Get(0).(*gin.Context).Status(http.StatusOK)
+			}).Once()
+
+			router := gin.New()
+			// The routes that change data need an authenticated caller
+			withScopes(router, middleware.AllScopes...)
+			SetupUserRouter(router.Group("/api/v1"), mockHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockHandler.AssertExpectations(t)
+		})
+	}
+}
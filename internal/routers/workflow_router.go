@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkflowHandlerInterface defines the workflow handler methods needed by the router
+type WorkflowHandlerInterface interface {
+	GetWorkflow(c *gin.Context)
+}
+
+// SetupWorkflowRouter configures the workflow endpoint, which requires the tasks:read scope
+func SetupWorkflowRouter(router gin.IRouter, workflowHandler WorkflowHandlerInterface) {
+	router.GET("/workflow", middleware.RequireScope(middleware.ScopeTasksRead), workflowHandler.GetWorkflow)
+}
@@ -0,0 +1,39 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWorkflowHandler is a mock implementation of WorkflowHandlerInterface
+type MockWorkflowHandler struct {
+	mock.Mock
+}
+
+func (m *MockWorkflowHandler) GetWorkflow(c *gin.Context) {
+	m.Called(c)
+}
+
+func TestSetupWorkflowRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockHandler := new(MockWorkflowHandler)
+	mockHandler.On("GetWorkflow", mock.AnythingOfType("*gin.Context")).Run(func(args mock.Arguments) {
+		args.Get(0).(*gin.Context).Status(http.StatusOK)
+	}).Once()
+
+	router := gin.New()
+	SetupWorkflowRouter(router.Group("/api/v1"), mockHandler)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/workflow", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockHandler.AssertExpectations(t)
+}
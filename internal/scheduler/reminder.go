@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"taheri24.ir/graph1/internal/middleware"
+	"taheri24.ir/graph1/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// DueTaskSource is the part of the task repository the reminder scheduler needs
+type DueTaskSource interface {
+	ClaimDueTasks(ctx context.Context, now time.Time) ([]models.Task, error)
+}
+
+// ReminderScheduler periodically looks for unfinished tasks whose due time has passed and emits
+// one reminder for each of them.
+type ReminderScheduler struct {
+	repo     DueTaskSource
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewReminderScheduler creates a ReminderScheduler. Which tasks were reminded about is kept with
+// the tasks, so restarting the server, or running several instances, does not repeat reminders,
+// and tasks that became due while no scheduler was running are reminded about on the next scan.
+func NewReminderScheduler(repo DueTaskSource, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{
+		repo:     repo,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run scans for due tasks every interval until ctx is cancelled
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Scan(ctx)
+		}
+	}
+}
+
+// Scan emits a reminder for every due task no reminder was sent for yet and returns how many
+// were sent. When the lookup fails the tasks are left for the next scan.
+func (s *ReminderScheduler) Scan(ctx context.Context) (int, error) {
+	now := s.now()
+
+	logger := slog.With(slog.String("job", "reminders"), slog.String("runID", uuid.New().String()))
+	ctx = middleware.ContextWithLogger(ctx, logger)
+	// Reminders are sent for the tasks of every tenant
+	ctx = middleware.ContextWithAllTenants(ctx)
+
+	tasks, err := s.repo.ClaimDueTasks(ctx, now)
+	if err != nil {
+		logger.Error("Failed to claim due tasks", "now", now, "error", err)
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		logger.Info("Task is due",
+			"id", task.ID.String(),
+			"tenant", task.TenantID,
+			"title", task.Title,
+			"assignee", task.Assignee,
+			"status", string(task.Status),
+			"due_at", task.DueAt.Format(time.RFC3339),
+		)
+		middleware.RecordTaskReminder()
+	}
+
+	if len(tasks) > 0 {
+		logger.Info("Task reminders sent", "count", len(tasks))
+	}
+	return len(tasks), nil
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/models"
+	"taheri24.ir/graph1/internal/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDueTaskSource serves due tasks, remembering which were claimed, and records the times it was asked for
+type fakeDueTaskSource struct {
+	tasks   []models.Task
+	err     error
+	claimed map[uuid.UUID]bool
+	scans   []time.Time
+}
+
+func (f *fakeDueTaskSource) ClaimDueTasks(ctx context.Context, now time.Time) ([]models.Task, error) {
+	f.scans = append(f.scans, now)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.claimed == nil {
+		f.claimed = map[uuid.UUID]bool{}
+	}
+	var due []models.Task
+	for _, task := range f.tasks {
+		if !task.DueAt.After(now) && !f.claimed[task.ID] {
+			f.claimed[task.ID] = true
+			due = append(due, task)
+		}
+	}
+	return due, nil
+}
+
+func newTestScheduler(source DueTaskSource, start time.Time) (*ReminderScheduler, *time.Time) {
+	clock := start
+	s := NewReminderScheduler(source, time.Minute)
+	s.now = func() time.Time { return clock }
+	return s, &clock
+}
+
+func dueTask(title string, dueAt time.Time) models.Task {
+	return models.Task{ID: uuid.New(), Title: title, Status: types.StatusPending, DueAt: &dueAt}
+}
+
+func TestScanRemindsEachTaskOnce(t *testing.T) {
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	source := &fakeDueTaskSource{tasks: []models.Task{
+		dueTask("Already overdue", start.Add(-time.Hour)),
+		dueTask("Standup", start.Add(30*time.Second)),
+		dueTask("Review", start.Add(90*time.Second)),
+	}}
+	s, clock := newTestScheduler(source, start)
+
+	// Tasks that became due while no scheduler was running are reminded about on the first scan
+	*clock = start.Add(time.Minute)
+	sent, err := s.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	*clock = start.Add(2 * time.Minute)
+	sent, err = s.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	sent, err = s.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	assert.Equal(t, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(2 * time.Minute)}, source.scans)
+}
+
+func TestScanSharesRemindersAcrossSchedulers(t *testing.T) {
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	source := &fakeDueTaskSource{tasks: []models.Task{dueTask("Standup", start.Add(30*time.Second))}}
+
+	// A restarted scheduler, or a second instance, does not repeat what the first one sent
+	first, clock := newTestScheduler(source, start.Add(time.Minute))
+	sent, err := first.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	second, _ := newTestScheduler(source, *clock)
+	sent, err = second.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestScanRetriesAfterError(t *testing.T) {
+	start := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	source := &fakeDueTaskSource{
+		tasks: []models.Task{dueTask("Standup", start.Add(30*time.Second))},
+		err:   errors.New("database unavailable"),
+	}
+	s, clock := newTestScheduler(source, start)
+
+	*clock = start.Add(time.Minute)
+	_, err := s.Scan(context.Background())
+	assert.Error(t, err)
+
+	source.err = nil
+	*clock = start.Add(2 * time.Minute)
+	sent, err := s.Scan(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestRunStopsWhenContextIsCancelled(t *testing.T) {
+	s := NewReminderScheduler(&fakeDueTaskSource{}, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
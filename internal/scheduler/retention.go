@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// RetentionActor is recorded as the actor of purges made by the retention job
+const RetentionActor = "trash-retention"
+
+// TrashPurger is the part of the task repository the retention job needs
+type TrashPurger interface {
+	PurgeTrashedBefore(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error)
+}
+
+// RetentionJob periodically purges tasks that have been in the trash longer than the retention
+type RetentionJob struct {
+	repo      TrashPurger
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewRetentionJob creates a RetentionJob that purges tasks trashed more than retention ago
+func NewRetentionJob(repo TrashPurger, retention, interval time.Duration) *RetentionJob {
+	return &RetentionJob{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run purges expired tasks once at start and then every interval until ctx is cancelled
+func (j *RetentionJob) Run(ctx context.Context) {
+	j.Purge(ctx)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.Purge(ctx)
+		}
+	}
+}
+
+// Purge permanently deletes every task trashed before the retention cutoff and returns how many were purged
+func (j *RetentionJob) Purge(ctx context.Context) (int, error) {
+	cutoff := j.now().Add(-j.retention)
+	logger := slog.With(slog.String("job", "trash-retention"), slog.String("runID", uuid.New().String()))
+	ctx = middleware.ContextWithLogger(ctx, logger)
+	ctx = middleware.ContextWithActor(ctx, RetentionActor)
+	// The retention period is the same in every tenant
+	ctx = middleware.ContextWithAllTenants(ctx)
+
+	purged, err := j.repo.PurgeTrashedBefore(ctx, cutoff)
+	if err != nil {
+		logger.Error("Failed to purge trashed tasks", "cutoff", cutoff, "error", err)
+		return 0, err
+	}
+
+	if len(purged) > 0 {
+		logger.Info("Trashed tasks purged", "count", len(purged), "cutoff", cutoff)
+	}
+	return len(purged), nil
+}
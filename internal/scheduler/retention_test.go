@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"taheri24.ir/graph1/internal/middleware"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTrashPurger records the cutoffs and actors it was asked to purge with
+type fakeTrashPurger struct {
+	purged  []uuid.UUID
+	err     error
+	cutoffs []time.Time
+	actors  []string
+}
+
+func (f *fakeTrashPurger) PurgeTrashedBefore(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	f.cutoffs = append(f.cutoffs, cutoff)
+	f.actors = append(f.actors, middleware.GetActorFromContext(ctx))
+	return f.purged, f.err
+}
+
+func TestRetentionPurge(t *testing.T) {
+	now := time.Date(2030, 1, 31, 9, 0, 0, 0, time.UTC)
+	purger := &fakeTrashPurger{purged: []uuid.UUID{uuid.New(), uuid.New()}}
+	job := NewRetentionJob(purger, 30*24*time.Hour, time.Hour)
+	job.now = func() time.Time { return now }
+
+	purged, err := job.Purge(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, []time.Time{time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)}, purger.cutoffs)
+	assert.Equal(t, []string{RetentionActor}, purger.actors)
+
+	purger.err = errors.New("database unavailable")
+	purged, err = job.Purge(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, purged)
+}
+
+func TestRetentionRunStopsOnCancel(t *testing.T) {
+	purger := &fakeTrashPurger{}
+	job := NewRetentionJob(purger, time.Hour, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		job.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+	// Run purges once before waiting for the first tick
+	assert.Len(t, purger.cutoffs, 1)
+}
@@ -47,6 +47,12 @@ func Seed(db *database.Database) error {
 		}
 	}
 
+	// The seeded assignees become the users the tasks are assigned to
+	if err := database.MigrateAssignees(db.DB); err != nil {
+		log.Printf("Error seeding users: %v", err)
+		return err
+	}
+
 	log.Println("Database seeded successfully")
 	return nil
 }
@@ -10,10 +10,18 @@ import (
 	"taheri24.ir/graph1/internal/cache"
 	"taheri24.ir/graph1/internal/database"
 	"taheri24.ir/graph1/internal/handlers/alert"
+	"taheri24.ir/graph1/internal/handlers/apikey"
+	"taheri24.ir/graph1/internal/handlers/auth"
+	"taheri24.ir/graph1/internal/handlers/comment"
+	"taheri24.ir/graph1/internal/handlers/graphql"
+	"taheri24.ir/graph1/internal/handlers/label"
 	"taheri24.ir/graph1/internal/handlers/task"
+	"taheri24.ir/graph1/internal/handlers/user"
+	workflowhandler "taheri24.ir/graph1/internal/handlers/workflow"
 	"taheri24.ir/graph1/internal/middleware"
 	"taheri24.ir/graph1/internal/models"
 	"taheri24.ir/graph1/internal/routers"
+	"taheri24.ir/graph1/internal/workflow"
 	"taheri24.ir/graph1/pkg/config"
 )
 
@@ -37,6 +45,7 @@ import (
 func SetupAppServer(db *database.Database, cfg *config.Config) *gin.Engine {
 	// Initialize cache
 	var taskCache cache.CacheInterface[models.Task]
+	var idempotencyStore middleware.IdempotencyStore
 	if cfg.CacheEnabled {
 		redisAddr := fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port)
 		redisCache, err := cache.NewRedisCache(redisAddr, cfg.Redis.Password, cfg.Redis.DB)
@@ -45,25 +54,78 @@ func SetupAppServer(db *database.Database, cfg *config.Config) *gin.Engine {
 			return nil
 		}
 		taskCache = cache.NewRedisCacheImpl[models.Task]("tasks", redisCache)
+		idempotencyStore = middleware.NewRedisIdempotencyStore(redisCache)
 		slog.Info("Cache enabled")
 	} else {
 		taskCache = cache.NewNoOpCacheImpl[models.Task]()
+		idempotencyStore = middleware.NewMemoryIdempotencyStore()
 		slog.Info("Cache disabled")
 	}
 
+	// Load the task status workflow
+	taskWorkflow, err := workflow.Load(cfg.Workflow.File)
+	if err != nil {
+		slog.Error("Failed to load task workflow", "err", err)
+		return nil
+	}
+
 	// Initialize handlers
-	taskHandler := task.NewTaskHandler(db, taskCache)
+	taskHandler := task.NewTaskHandler(db, taskCache, taskWorkflow)
+	labelHandler := label.NewLabelHandler(db, taskCache)
+	userHandler := user.NewUserHandler(db, taskCache)
+	commentHandler := comment.NewCommentHandler(db)
+	workflowHandler := workflowhandler.NewWorkflowHandler(taskWorkflow)
 	alertHandler := alert.NewAlertHandler()
+	graphqlHandler := graphql.NewGraphQLHandler(db, taskCache, taskWorkflow)
+	apiKeyHandler := apikey.NewAPIKeyHandler(db)
 
 	rootRouter := gin.Default()
-	apiRouter := rootRouter.Group("/api/v1")
-	// Setup global middleware
+	// Setup global middleware; groups copy the middleware of the router when they are created, so this
+	// comes first, or the routes under /api/v1 would record history without a request ID or actor
 	middleware.SetupGlobalMiddleware(rootRouter)
+	apiRouter := rootRouter.Group("/api/v1")
 
-	// Setup routes
+	// Setup routes reachable without a token
 	routers.SetupHealthRouter(apiRouter, db)
-	routers.SetupTaskRouter(apiRouter, taskHandler)
-	routers.SetupAlertRouter(apiRouter, alertHandler)
+
+	// Every other route needs a bearer token or an API key when authentication is enabled
+	protectedRouter := apiRouter.Group("")
+	if cfg.Auth.Enabled {
+		authKey, err := auth.NewKey(cfg.Auth)
+		if err != nil {
+			slog.Error("Failed to load the token signing key; set AUTH_SECRET, or AUTH_ENABLED=false to serve read-only anonymous requests", "err", err)
+			return nil
+		}
+		if authKey.CanSign() {
+			authHandler, err := auth.NewAuthHandler(authKey, cfg.Auth)
+			if err != nil {
+				slog.Error("Invalid service account settings", "err", err)
+				return nil
+			}
+			routers.SetupAuthRouter(apiRouter, authHandler)
+		} else {
+			slog.Warn("No private key configured; tokens are verified but POST /auth/token is disabled")
+		}
+		protectedRouter.Use(middleware.AuthMiddleware(authKey, apiKeyHandler))
+		slog.Info("Authentication enabled", "algorithm", authKey.Algorithm())
+	} else {
+		slog.Warn("Authentication disabled by AUTH_ENABLED=false; anonymous requests may only read")
+	}
+	// Every protected route acts on the data of a single tenant, which may depend on the credential
+	protectedRouter.Use(middleware.TenantMiddleware(cfg.Tenants.Domain))
+	// Make retried POST requests with an Idempotency-Key safe; this comes after authentication so that a
+	// stored response is only ever replayed to a caller that was let in
+	protectedRouter.Use(middleware.IdempotencyMiddleware(idempotencyStore, cfg.Idempotency.Window))
+
+	// Setup routes
+	routers.SetupTaskRouter(protectedRouter, taskHandler)
+	routers.SetupLabelRouter(protectedRouter, labelHandler)
+	routers.SetupUserRouter(protectedRouter, userHandler)
+	routers.SetupCommentRouter(protectedRouter, commentHandler)
+	routers.SetupWorkflowRouter(protectedRouter, workflowHandler)
+	routers.SetupAlertRouter(protectedRouter, alertHandler)
+	routers.SetupGraphQLRouter(protectedRouter, graphqlHandler)
+	routers.SetupAPIKeyRouter(protectedRouter, apiKeyHandler)
 	routers.SetupSwaggerRouter(rootRouter)
 
 	// Setup metrics endpoint
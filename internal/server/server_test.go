@@ -1,18 +1,40 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"taheri24.ir/graph1/internal/database"
 	"taheri24.ir/graph1/pkg/config"
+	"taheri24.ir/graph1/pkg/jwt"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// issueToken returns a token of the service account clientID from the server of router
+func issueToken(t *testing.T, router *gin.Engine, clientID, secret string) string {
+	t.Helper()
+	req, err := http.NewRequest("POST", "/api/v1/auth/token",
+		strings.NewReader(`{"client_id":"`+clientID+`","client_secret":"`+secret+`"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+	return token.AccessToken
+}
+
 func TestSetupAppServerBasic(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -156,3 +178,683 @@ func TestSetupAppServerRecoveryMiddleware(t *testing.T) {
 	assert.NotEqual(t, http.StatusInternalServerError, w.Code,
 		"Recovery middleware should prevent 500 errors")
 }
+
+func TestSetupAppServerIdempotentTaskCreation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+
+	router := SetupAppServer(db, testCfg)
+	token := issueToken(t, router, "test-client", "test-client-secret")
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/v1/tasks", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "create-once")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := create(`{"title":"Retried task","status":"pending"}`)
+	retry := create(`{"title":"Retried task","status":"pending"}`)
+	reused := create(`{"title":"Another task","status":"pending"}`)
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+
+	var count int64
+	require.NoError(t, db.DB.Table("tasks").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSetupAppServerIdempotencyAfterAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+
+	serve := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "create-once")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("POST", "/api/v1/auth/token", "", `{"client_id":"test-client","client_secret":"test-client-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+
+	// A first attempt without a token is not replayed to the authenticated retry
+	body := `{"title":"Retried task","status":"pending"}`
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/api/v1/tasks", "", body).Code)
+	w = serve("POST", "/api/v1/tasks", token.AccessToken, body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+
+	// Nor is the stored response replayed to a caller without a token
+	w = serve("POST", "/api/v1/tasks", "", body)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.NotContains(t, w.Body.String(), "Retried task")
+
+	var count int64
+	require.NoError(t, db.DB.Table("tasks").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSetupAppServerRequestIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+
+	// The global middleware must run for the routes under /api/v1, so their history names the actor and
+	// the request
+	req, err := http.NewRequest("POST", "/api/v1/tasks", strings.NewReader(`{"title":"Traced task"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+issueToken(t, router, "test-client", "test-client-secret"))
+	req.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+
+	var event struct {
+		Actor     string
+		RequestID string
+	}
+	require.NoError(t, db.DB.Table("task_events").Select("actor, request_id").Limit(1).Scan(&event).Error)
+	assert.Equal(t, "test-client", event.Actor)
+	assert.Equal(t, "req-42", event.RequestID)
+}
+
+func TestSetupAppServerBulkTasks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+
+	router := SetupAppServer(db, testCfg)
+	token := issueToken(t, router, "test-client", "test-client-secret")
+
+	bulk := func(query, body string) int {
+		req, err := http.NewRequest("POST", "/api/v1/tasks/bulk"+query, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	countTasks := func() int64 {
+		var count int64
+		require.NoError(t, db.DB.Table("tasks").Where("deleted_at IS NULL").Count(&count).Error)
+		return count
+	}
+	operations := `[
+		{"op": "create", "task": {"title": "First"}},
+		{"op": "create", "task": {"title": "Second"}},
+		{"op": "delete", "id": "00000000-0000-0000-0000-000000000001"}
+	]`
+
+	// The missing task rolls back both creates
+	assert.Equal(t, http.StatusNotFound, bulk("?atomic=true", operations))
+	assert.Equal(t, int64(0), countTasks())
+
+	// Without atomic the creates are kept
+	assert.Equal(t, http.StatusOK, bulk("", operations))
+	assert.Equal(t, int64(2), countTasks())
+}
+
+func TestSetupAppServerAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+
+	serve := func(method, path, token string, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Health checks and the token endpoint need no token
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/health", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "/api/v1/tasks", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/api/v1/alerts/fire", "", `{"alert_name":"x"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized,
+		serve("POST", "/api/v1/auth/token", "", `{"client_id":"test-client","client_secret":"wrong"}`).Code)
+
+	w := serve("POST", "/api/v1/auth/token", "", `{"client_id":"test-client","client_secret":"test-client-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/tasks", token.AccessToken, "").Code)
+
+	// Changes are recorded as made by the authenticated service account
+	w = serve("POST", "/api/v1/tasks", token.AccessToken, `{"title":"Authenticated task","status":"pending"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var actor string
+	require.NoError(t, db.DB.Table("task_events").Select("actor").Limit(1).Scan(&actor).Error)
+	assert.Equal(t, "test-client", actor)
+}
+
+func TestSetupAppServerAuthenticationRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Authentication is on by default and there is no key to verify tokens with
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         config.AuthConfig{Enabled: true, Algorithm: "HS256"},
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	assert.Nil(t, SetupAppServer(db, testCfg))
+}
+
+func TestSetupAppServerAnonymousReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	require.False(t, testCfg.Auth.Enabled)
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Actor", "admin")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without authentication requests may read but not change anything
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/tasks", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/tasks", `{"title":"Anonymous task"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/alerts/fire", `{"alert_name":"x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/api-keys", `{"name":"x"}`).Code)
+
+	var count int64
+	require.NoError(t, db.DB.Table("tasks").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestSetupAppServerAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+	testCfg.Auth.ServiceAccounts = map[string]string{"test-client": "test-client-secret", "member-bot": "member-secret"}
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+
+	serve := func(method, path, credential, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if credential != "" {
+			req.Header.Set("Authorization", "Bearer "+credential)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("POST", "/api/v1/auth/token", "", `{"client_id":"test-client","client_secret":"test-client-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+
+	// The service account mints a read-only key for a CI bot
+	w = serve("POST", "/api/v1/api-keys", token.AccessToken, `{"name":"ci","scopes":["tasks:read","alerts:read"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/tasks", created.Key, "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/tasks", created.Key, `{"title":"Nope"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/alerts/fire", created.Key, `{"alert_name":"x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/alerts/reset", created.Key, `{"alert_name":"x"}`).Code)
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/v1/api-keys", created.Key, "").Code)
+	// GraphQL checks the scope of the operation, not of the method
+	assert.Equal(t, http.StatusOK, serve("POST", "/api/v1/graphql", created.Key, `{"query":"{ tasks { total } }"}`).Code)
+	assert.Equal(t, http.StatusForbidden,
+		serve("POST", "/api/v1/graphql", created.Key, `{"query":"mutation { createTask(input: {title: \"Nope\"}) { id } }"}`).Code)
+
+	// A member key can only delete the tasks it created and cannot touch alerts
+	w = serve("POST", "/api/v1/api-keys", token.AccessToken,
+		`{"name":"dev","scopes":["tasks:read","tasks:write","alerts:fire"],"role":"member"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var member struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &member))
+	var adminTask, memberTask struct {
+		ID        string `json:"id"`
+		CreatedBy string `json:"created_by"`
+	}
+	w = serve("POST", "/api/v1/tasks", token.AccessToken, `{"title":"Admin task"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &adminTask))
+	w = serve("POST", "/api/v1/tasks", member.Key, `{"title":"Member task"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &memberTask))
+	assert.Equal(t, "test-client", adminTask.CreatedBy)
+	assert.True(t, strings.HasPrefix(memberTask.CreatedBy, "apikey:tk_"))
+
+	assert.Equal(t, http.StatusForbidden, serve("DELETE", "/api/v1/tasks/"+adminTask.ID, member.Key, "").Code)
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/api/v1/tasks/"+memberTask.ID, member.Key, "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/api/v1/alerts/fire", member.Key, `{"alert_name":"x"}`).Code)
+
+	// Only admins administer keys: the token of a member account is not granted keys:admin, and one that
+	// somehow holds it is refused all the same
+	w = serve("POST", "/api/v1/auth/token", "", `{"client_id":"member-bot","client_secret":"member-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var memberToken struct {
+		AccessToken string `json:"access_token"`
+		Scope       string `json:"scope"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &memberToken))
+	assert.NotContains(t, memberToken.Scope, "keys:admin")
+	assert.Equal(t, http.StatusForbidden, serve("DELETE", "/api/v1/api-keys/"+created.ID, memberToken.AccessToken, "").Code)
+	signer, err := jwt.NewHMACKey([]byte(testCfg.Auth.Secret), testCfg.Auth.Issuer)
+	require.NoError(t, err)
+	forged, _, err := signer.Issue(jwt.Claims{Subject: "member-bot", Scope: "tasks:read keys:admin", Role: "member"}, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, serve("DELETE", "/api/v1/api-keys/"+created.ID, forged, "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/v1/api-keys", forged, "").Code)
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/tasks", created.Key, "").Code)
+
+	// Revoked keys are rejected
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/api/v1/api-keys/"+created.ID, token.AccessToken, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "/api/v1/tasks", created.Key, "").Code)
+
+	// Minted keys are never stored for replay; a retry is told the key was already minted instead
+	mint := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/v1/api-keys", strings.NewReader(`{"name":"retried","scopes":["tasks:read"]}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		req.Header.Set("Idempotency-Key", "mint-once")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	first, retry := mint(), mint()
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Equal(t, http.StatusConflict, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	var minted struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &minted))
+	require.NotEmpty(t, minted.Key)
+	assert.NotContains(t, retry.Body.String(), minted.Key)
+}
+
+func TestSetupAppServerTenantIsolation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The cache is on so that a task cached for one tenant is shown to be out of reach of the others
+	mr := miniredis.RunT(t)
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        config.RedisConfig{Host: mr.Host(), Port: mr.Port()},
+		Auth:         cfg.Auth,
+		CacheEnabled: true,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+	testCfg.Auth.ServiceAccounts = map[string]string{
+		"test-client": "test-client-secret", "acme-bot": "acme-secret", "globex-bot": "globex-secret"}
+	testCfg.Auth.ServiceAccountRoles = map[string]string{"test-client": "admin", "acme-bot": "admin", "globex-bot": "admin"}
+	testCfg.Auth.ServiceAccountTenants = map[string]string{"acme-bot": "acme", "globex-bot": "globex"}
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+	// Each tenant is reached with a token bound to it; the unbound test-client acts on the default tenant
+	tokens := map[string]string{
+		"acme":   issueToken(t, router, "acme-bot", "acme-secret"),
+		"globex": issueToken(t, router, "globex-bot", "globex-secret"),
+		"":       issueToken(t, router, "test-client", "test-client-secret"),
+	}
+
+	serve := func(method, path, tenant, contentType, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Tenant-ID", tenant)
+		token, ok := tokens[tenant]
+		if !ok {
+			token = tokens[""]
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("POST", "/api/v1/tasks", "acme", "application/json", `{"title":"Acme secret plan"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "acme", w.Header().Get("X-Tenant-ID"))
+	var task struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	path := "/api/v1/tasks/" + task.ID
+
+	// Reading the task caches it for its tenant
+	require.Equal(t, http.StatusOK, serve("GET", path, "acme", "application/json", "").Code)
+	assert.True(t, mr.Exists("tasks:acme:"+task.ID))
+
+	assert.Equal(t, http.StatusNotFound, serve("GET", path, "globex", "application/json", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("GET", path, "", "application/json", "").Code)
+	assert.Equal(t, http.StatusNotFound,
+		serve("PUT", path, "globex", "application/json", `{"title":"Hijacked","status":"pending"}`).Code)
+	assert.Equal(t, http.StatusNotFound,
+		serve("PATCH", path, "globex", "application/merge-patch+json", `{"title":"Hijacked"}`).Code)
+	assert.Equal(t, http.StatusNotFound, serve("GET", path+"/history", "globex", "application/json", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", path, "globex", "application/json", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", path+"?cascade=true", "globex", "application/json", "").Code)
+
+	w = serve("GET", "/api/v1/tasks", "globex", "application/json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), task.ID)
+
+	// The task is untouched for its own tenant
+	w = serve("GET", path, "acme", "application/json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Acme secret plan")
+	var count int64
+	require.NoError(t, db.DB.Table("tasks").Where("deleted_at IS NULL").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	assert.Equal(t, http.StatusBadRequest, serve("GET", "/api/v1/tasks", "not a tenant", "application/json", "").Code)
+}
+
+func TestSetupAppServerTenantBoundCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+	testCfg.Auth.ServiceAccounts = map[string]string{"test-client": "test-client-secret", "acme-bot": "acme-secret"}
+	testCfg.Auth.ServiceAccountTenants = map[string]string{"acme-bot": "acme"}
+	testCfg.Auth.ServiceAccountRoles = map[string]string{"test-client": "admin", "acme-bot": "admin"}
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+
+	serve := func(method, path, token, tenant, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("POST", "/api/v1/auth/token", "", "", `{"client_id":"acme-bot","client_secret":"acme-secret"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Tenant      string `json:"tenant"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+	assert.Equal(t, "acme", token.Tenant)
+
+	// The token acts on its own tenant without naming it, and cannot be pointed at another one
+	w = serve("POST", "/api/v1/tasks", token.AccessToken, "", `{"title":"Acme task"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "acme", w.Header().Get("X-Tenant-ID"))
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/v1/tasks", token.AccessToken, "acme", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/v1/tasks", token.AccessToken, "globex", "").Code)
+
+	// API keys minted by the token belong to its tenant as well
+	w = serve("POST", "/api/v1/api-keys", token.AccessToken, "", `{"name":"ci","scopes":["tasks:read"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var key struct {
+		Key    string `json:"key"`
+		Tenant string `json:"tenant"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+	assert.Equal(t, "acme", key.Tenant)
+	w = serve("GET", "/api/v1/tasks", key.Key, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Acme task")
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/v1/tasks", key.Key, "globex", "").Code)
+
+	// Accounts not bound to a tenant are held to the default one instead of picking any
+	unbound := issueToken(t, router, "test-client", "test-client-secret")
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/v1/tasks", unbound, "b", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("GET", "/api/v1/tasks", unbound, "acme", "").Code)
+	w = serve("GET", "/api/v1/tasks", unbound, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "default", w.Header().Get("X-Tenant-ID"))
+	assert.NotContains(t, w.Body.String(), "Acme task")
+}
+
+func TestSetupAppServerUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.NewTestConfig()
+	db, err := database.NewDatabase(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	testCfg := &config.Config{
+		Database:     cfg.Database,
+		Redis:        cfg.Redis,
+		Auth:         cfg.Auth,
+		CacheEnabled: false,
+		Server:       cfg.Server,
+	}
+	testCfg.Auth.Enabled = true
+
+	router := SetupAppServer(db, testCfg)
+	require.NotNil(t, router)
+	token := issueToken(t, router, "test-client", "test-client-secret")
+
+	serve := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	var user struct {
+		ID string `json:"id"`
+	}
+	var task struct {
+		ID         string `json:"id"`
+		Assignee   string `json:"assignee"`
+		AssigneeID string `json:"assignee_id"`
+	}
+
+	w := serve("POST", "/api/v1/users", "application/json", `{"username":"alice","email":"alice@example.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	alice := user.ID
+	w = serve("POST", "/api/v1/users", "application/json", `{"username":"bob"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	bob := user.ID
+
+	// Tasks are still assigned by username, and refer to the user by ID
+	w = serve("POST", "/api/v1/tasks", "application/json", `{"title":"Write docs","assignee":"alice"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, alice, task.AssigneeID)
+	path := "/api/v1/tasks/" + task.ID
+
+	w = serve("POST", "/api/v1/tasks", "application/json", `{"title":"Stray","assignee":"mallory"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+
+	// A patch of either field reassigns the task
+	w = serve("PATCH", path, "application/merge-patch+json", `{"assignee_id":"`+bob+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, "bob", task.Assignee)
+	w = serve("PATCH", path, "application/merge-patch+json", `{"assignee":"alice"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, alice, task.AssigneeID)
+
+	// Renaming the user renames the assignee of its tasks
+	w = serve("PUT", "/api/v1/users/"+alice, "application/json", `{"username":"alicia"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serve("GET", path, "application/json", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, "alicia", task.Assignee)
+}
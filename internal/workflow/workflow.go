@@ -0,0 +1,170 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"taheri24.ir/graph1/internal/types"
+)
+
+// builtinStatuses must be declared by every workflow: new tasks start out pending, and
+// completion drives reminders, planning and subtree progress
+var builtinStatuses = []types.TaskStatus{types.StatusPending, types.StatusInProgress, types.StatusCompleted}
+
+// statusPattern matches status names that fit the status column of the tasks table
+var statusPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// Definition is the serialized form of a workflow. Transitions maps each status to the
+// statuses a task may move to from it; a status without an entry is final.
+type Definition struct {
+	Statuses    []types.TaskStatus                      `json:"statuses"`
+	Transitions map[types.TaskStatus][]types.TaskStatus `json:"transitions"`
+}
+
+// DefaultDefinition is used when no workflow file is configured. Completed tasks can be
+// reopened, but only by putting them back in progress.
+var DefaultDefinition = Definition{
+	Statuses: builtinStatuses,
+	Transitions: map[types.TaskStatus][]types.TaskStatus{
+		types.StatusPending:    {types.StatusInProgress, types.StatusCompleted},
+		types.StatusInProgress: {types.StatusPending, types.StatusCompleted},
+		types.StatusCompleted:  {types.StatusInProgress},
+	},
+}
+
+// Workflow declares the statuses a task can have and which status changes are allowed
+type Workflow struct {
+	statuses    []types.TaskStatus
+	transitions map[types.TaskStatus][]types.TaskStatus
+}
+
+// TransitionError is returned when the workflow does not allow a status change
+type TransitionError struct {
+	From    types.TaskStatus
+	To      types.TaskStatus
+	Allowed []types.TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("cannot change status from %s to %s; %s is final", e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("cannot change status from %s to %s; allowed: %s", e.From, e.To, joinStatuses(e.Allowed))
+}
+
+// New validates a definition and builds a workflow from it
+func New(def Definition) (*Workflow, error) {
+	w := &Workflow{transitions: make(map[types.TaskStatus][]types.TaskStatus)}
+	for _, status := range def.Statuses {
+		if !statusPattern.MatchString(string(status)) {
+			return nil, fmt.Errorf("invalid status name %q: use up to 20 lowercase letters, digits and underscores", status)
+		}
+		if slices.Contains(w.statuses, status) {
+			return nil, fmt.Errorf("status %q is declared twice", status)
+		}
+		w.statuses = append(w.statuses, status)
+	}
+	for _, status := range builtinStatuses {
+		if !slices.Contains(w.statuses, status) {
+			return nil, fmt.Errorf("status %q must be declared", status)
+		}
+	}
+
+	for from, targets := range def.Transitions {
+		if !w.IsValid(from) {
+			return nil, fmt.Errorf("transition from undeclared status %q", from)
+		}
+		for _, to := range targets {
+			if !w.IsValid(to) {
+				return nil, fmt.Errorf("transition from %q to undeclared status %q", from, to)
+			}
+			if to != from && !slices.Contains(w.transitions[from], to) {
+				w.transitions[from] = append(w.transitions[from], to)
+			}
+		}
+	}
+	return w, nil
+}
+
+// Default returns the workflow described by DefaultDefinition
+func Default() *Workflow {
+	w, err := New(DefaultDefinition)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// Load reads a JSON workflow definition from path, or returns the default workflow when path is empty
+func Load(path string) (*Workflow, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflow: %w", err)
+	}
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	w, err := New(def)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow %s: %w", path, err)
+	}
+	return w, nil
+}
+
+// Statuses returns the declared statuses in declaration order
+func (w *Workflow) Statuses() []types.TaskStatus {
+	return slices.Clone(w.statuses)
+}
+
+// IsValid reports whether status is declared by the workflow
+func (w *Workflow) IsValid(status types.TaskStatus) bool {
+	return slices.Contains(w.statuses, status)
+}
+
+// Allowed returns the statuses a task may move to from the given status
+func (w *Workflow) Allowed(from types.TaskStatus) []types.TaskStatus {
+	allowed := slices.Clone(w.transitions[from])
+	if allowed == nil {
+		allowed = []types.TaskStatus{}
+	}
+	return allowed
+}
+
+// CheckTransition returns a *TransitionError unless a task may move from one status to the
+// other. Keeping the current status is always allowed.
+func (w *Workflow) CheckTransition(from, to types.TaskStatus) error {
+	if from == to || slices.Contains(w.transitions[from], to) {
+		return nil
+	}
+	return &TransitionError{From: from, To: to, Allowed: w.Allowed(from)}
+}
+
+// Definition returns the serialized form of the workflow, listing every declared status in Transitions
+func (w *Workflow) Definition() Definition {
+	def := Definition{Statuses: w.Statuses(), Transitions: make(map[types.TaskStatus][]types.TaskStatus, len(w.statuses))}
+	for _, status := range w.statuses {
+		def.Transitions[status] = w.Allowed(status)
+	}
+	return def
+}
+
+// StatusList formats the declared statuses for error messages, e.g. "pending, in_progress, completed"
+func (w *Workflow) StatusList() string {
+	return joinStatuses(w.statuses)
+}
+
+func joinStatuses(statuses []types.TaskStatus) string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return strings.Join(names, ", ")
+}
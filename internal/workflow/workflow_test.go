@@ -0,0 +1,109 @@
+package workflow_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"taheri24.ir/graph1/internal/types"
+	"taheri24.ir/graph1/internal/workflow"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultWorkflow(t *testing.T) {
+	wf := workflow.Default()
+
+	assert.Equal(t, []types.TaskStatus{types.StatusPending, types.StatusInProgress, types.StatusCompleted}, wf.Statuses())
+	assert.Equal(t, "pending, in_progress, completed", wf.StatusList())
+	assert.NoError(t, wf.CheckTransition(types.StatusPending, types.StatusInProgress))
+	assert.NoError(t, wf.CheckTransition(types.StatusCompleted, types.StatusInProgress))
+	assert.NoError(t, wf.CheckTransition(types.StatusCompleted, types.StatusCompleted))
+
+	err := wf.CheckTransition(types.StatusCompleted, types.StatusPending)
+	var transitionErr *workflow.TransitionError
+	require.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, []types.TaskStatus{types.StatusInProgress}, transitionErr.Allowed)
+	assert.Equal(t, "cannot change status from completed to pending; allowed: in_progress", err.Error())
+}
+
+func TestNewWorkflow(t *testing.T) {
+	wf, err := workflow.New(workflow.Definition{
+		Statuses: []types.TaskStatus{"pending", "blocked", "in_progress", "completed"},
+		Transitions: map[types.TaskStatus][]types.TaskStatus{
+			"pending":     {"in_progress", "blocked", "pending"},
+			"blocked":     {"pending"},
+			"in_progress": {"completed", "blocked"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, wf.IsValid("blocked"))
+	assert.False(t, wf.IsValid("in_review"))
+	// Self-transitions are implied rather than listed
+	assert.Equal(t, []types.TaskStatus{"in_progress", "blocked"}, wf.Allowed("pending"))
+	assert.Empty(t, wf.Allowed("completed"))
+	assert.EqualError(t, wf.CheckTransition("completed", "pending"), "cannot change status from completed to pending; completed is final")
+
+	def := wf.Definition()
+	assert.Len(t, def.Transitions, 4)
+	assert.Equal(t, []types.TaskStatus{}, def.Transitions["completed"])
+}
+
+func TestNewWorkflowInvalid(t *testing.T) {
+	builtin := []types.TaskStatus{"pending", "in_progress", "completed"}
+	testCases := []struct {
+		name string
+		def  workflow.Definition
+		err  string
+	}{
+		{"missing builtin status", workflow.Definition{Statuses: []types.TaskStatus{"pending", "completed"}}, `status "in_progress" must be declared`},
+		{"duplicate status", workflow.Definition{Statuses: append(builtin, "pending")}, `status "pending" is declared twice`},
+		{"invalid status name", workflow.Definition{Statuses: append(builtin, "In Review")}, `invalid status name "In Review"`},
+		{"undeclared source", workflow.Definition{
+			Statuses:    builtin,
+			Transitions: map[types.TaskStatus][]types.TaskStatus{"blocked": {"pending"}},
+		}, `transition from undeclared status "blocked"`},
+		{"undeclared target", workflow.Definition{
+			Statuses:    builtin,
+			Transitions: map[types.TaskStatus][]types.TaskStatus{"pending": {"blocked"}},
+		}, `transition from "pending" to undeclared status "blocked"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := workflow.New(tc.def)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	wf, err := workflow.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.Default(), wf)
+
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"statuses": ["pending", "in_progress", "in_review", "completed"],
+		"transitions": {"pending": ["in_progress"], "in_progress": ["in_review"], "in_review": ["completed", "in_progress"]}
+	}`), 0o600))
+	wf, err = workflow.Load(path)
+	require.NoError(t, err)
+	assert.NoError(t, wf.CheckTransition("in_progress", "in_review"))
+	assert.Error(t, wf.CheckTransition("in_progress", "completed"))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"statuses": ["pending"]}`), 0o600))
+	_, err = workflow.Load(path)
+	assert.ErrorContains(t, err, "invalid workflow")
+
+	require.NoError(t, os.WriteFile(path, []byte(`not json`), 0o600))
+	_, err = workflow.Load(path)
+	assert.ErrorContains(t, err, "failed to parse workflow")
+
+	_, err = workflow.Load(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read workflow")
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type DatabaseConfig struct {
@@ -24,9 +26,55 @@ type RedisConfig struct {
 	DB       int
 }
 
+type ReminderConfig struct {
+	Enabled  bool
+	Interval time.Duration // How often to scan for tasks that have become due
+}
+
+type TrashConfig struct {
+	PurgeEnabled  bool
+	Retention     time.Duration // How long deleted tasks stay in the trash before they are purged
+	PurgeInterval time.Duration // How often to purge tasks that have outlived the retention
+}
+
+type WorkflowConfig struct {
+	File string // Path of a JSON workflow definition; empty uses the built-in workflow
+}
+
+type IdempotencyConfig struct {
+	Window time.Duration // How long the response to a request with an Idempotency-Key is kept for replay
+}
+
+type TenantConfig struct {
+	Domain string // Base domain whose subdomains name tenants, as in acme.<Domain>; empty ignores the host
+}
+
+type AuthConfig struct {
+	Enabled        bool
+	Algorithm      string        // Token signing algorithm: "HS256" or "RS256"
+	Secret         string        // HMAC secret for HS256, at least 32 bytes
+	PrivateKeyFile string        // PEM file of the RSA key signing tokens with RS256
+	PublicKeyFile  string        // PEM file of the RSA key verifying tokens with RS256; taken from the private key when empty
+	Issuer         string        // Issuer put in the tokens issued and required of the tokens accepted
+	TokenTTL       time.Duration // How long the tokens issued by POST /auth/token are valid
+	// ServiceAccounts maps the client IDs that may request tokens to their secrets
+	ServiceAccounts map[string]string
+	// ServiceAccountRoles maps client IDs to the role put in their tokens; accounts not listed are members
+	ServiceAccountRoles map[string]string
+	// ServiceAccountTenants maps client IDs to the tenant their tokens are bound to; accounts not listed
+	// are bound to the default tenant
+	ServiceAccountTenants map[string]string
+}
+
 type Config struct {
 	Database     DatabaseConfig
 	Redis        RedisConfig
+	Reminders    ReminderConfig
+	Trash        TrashConfig
+	Workflow     WorkflowConfig
+	Idempotency  IdempotencyConfig
+	Tenants      TenantConfig
+	Auth         AuthConfig
 	CacheEnabled bool
 	Server       struct {
 		Port string
@@ -51,6 +99,37 @@ func Load() *Config {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
+		Reminders: ReminderConfig{
+			Enabled:  getEnvAsBool("REMINDERS_ENABLED", true),
+			Interval: getEnvAsDuration("REMINDER_INTERVAL", time.Minute),
+		},
+		Trash: TrashConfig{
+			// Purging deletes data for good, so it is only done when asked for
+			PurgeEnabled:  getEnvAsBool("TRASH_PURGE_ENABLED", false),
+			Retention:     getEnvAsDuration("TRASH_RETENTION", 30*24*time.Hour),
+			PurgeInterval: getEnvAsDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		},
+		Workflow: WorkflowConfig{
+			File: getEnv("WORKFLOW_FILE", ""),
+		},
+		Idempotency: IdempotencyConfig{
+			Window: getEnvAsDuration("IDEMPOTENCY_WINDOW", 24*time.Hour),
+		},
+		Tenants: TenantConfig{
+			Domain: getEnv("TENANT_DOMAIN", ""),
+		},
+		Auth: AuthConfig{
+			Enabled:               getEnvAsBool("AUTH_ENABLED", true),
+			Algorithm:             getEnv("AUTH_ALGORITHM", "HS256"),
+			Secret:                getEnv("AUTH_SECRET", ""),
+			PrivateKeyFile:        getEnv("AUTH_PRIVATE_KEY_FILE", ""),
+			PublicKeyFile:         getEnv("AUTH_PUBLIC_KEY_FILE", ""),
+			Issuer:                getEnv("AUTH_ISSUER", "graph1"),
+			TokenTTL:              getEnvAsDuration("AUTH_TOKEN_TTL", time.Hour),
+			ServiceAccounts:       getEnvAsMap("AUTH_SERVICE_ACCOUNTS"),
+			ServiceAccountRoles:   getEnvAsMap("AUTH_SERVICE_ACCOUNT_ROLES"),
+			ServiceAccountTenants: getEnvAsMap("AUTH_SERVICE_ACCOUNT_TENANTS"),
+		},
 		CacheEnabled: getEnvAsBool("CACHE_ENABLED", true),
 		Server: struct {
 			Port string
@@ -115,3 +194,24 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsMap reads a comma-separated list of key:value pairs, skipping pairs without a key or a value
+func getEnvAsMap(key string) map[string]string {
+	result := map[string]string{}
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && name != "" && value != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"taheri24.ir/graph1/pkg/config"
 
@@ -159,3 +160,100 @@ func TestGetEnvAsInt(t *testing.T) {
 	cfg = config.Load()
 	assert.Equal(t, 0, cfg.Redis.DB) // default value
 }
+
+func TestGetEnvAsDuration(t *testing.T) {
+	// Since getEnvAsDuration is not exported, we test it indirectly through Load
+	origValue := os.Getenv("REMINDER_INTERVAL")
+	defer os.Setenv("REMINDER_INTERVAL", origValue)
+
+	os.Setenv("REMINDER_INTERVAL", "30s")
+	cfg := config.Load()
+	assert.Equal(t, 30*time.Second, cfg.Reminders.Interval)
+
+	// Test getting non-existing environment variable (should return default)
+	os.Unsetenv("REMINDER_INTERVAL")
+	cfg = config.Load()
+	assert.Equal(t, time.Minute, cfg.Reminders.Interval) // default value
+
+	// Test invalid and non-positive durations (should return default)
+	for _, value := range []string{"soon", "0s", "-1m"} {
+		os.Setenv("REMINDER_INTERVAL", value)
+		cfg = config.Load()
+		assert.Equal(t, time.Minute, cfg.Reminders.Interval, value)
+	}
+}
+
+func TestIdempotencyWindow(t *testing.T) {
+	origValue := os.Getenv("IDEMPOTENCY_WINDOW")
+	defer os.Setenv("IDEMPOTENCY_WINDOW", origValue)
+
+	os.Unsetenv("IDEMPOTENCY_WINDOW")
+	assert.Equal(t, 24*time.Hour, config.Load().Idempotency.Window)
+
+	os.Setenv("IDEMPOTENCY_WINDOW", "2h")
+	assert.Equal(t, 2*time.Hour, config.Load().Idempotency.Window)
+}
+
+func TestTrashConfig(t *testing.T) {
+	for _, key := range []string{"TRASH_PURGE_ENABLED", "TRASH_RETENTION"} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+
+	// Trashed tasks are only purged when purging is turned on
+	cfg := config.Load()
+	assert.False(t, cfg.Trash.PurgeEnabled)
+	assert.Equal(t, 30*24*time.Hour, cfg.Trash.Retention)
+
+	os.Setenv("TRASH_PURGE_ENABLED", "true")
+	os.Setenv("TRASH_RETENTION", "168h")
+	cfg = config.Load()
+	assert.True(t, cfg.Trash.PurgeEnabled)
+	assert.Equal(t, 7*24*time.Hour, cfg.Trash.Retention)
+}
+
+func TestAuthConfig(t *testing.T) {
+	for _, key := range []string{"AUTH_ENABLED", "AUTH_SERVICE_ACCOUNTS", "AUTH_SERVICE_ACCOUNT_ROLES",
+		"AUTH_SERVICE_ACCOUNT_TENANTS", "AUTH_TOKEN_TTL"} {
+		defer os.Setenv(key, os.Getenv(key))
+	}
+
+	os.Unsetenv("AUTH_ENABLED")
+	os.Unsetenv("AUTH_SERVICE_ACCOUNTS")
+	os.Unsetenv("AUTH_SERVICE_ACCOUNT_ROLES")
+	os.Unsetenv("AUTH_SERVICE_ACCOUNT_TENANTS")
+	os.Unsetenv("AUTH_TOKEN_TTL")
+	cfg := config.Load()
+	assert.True(t, cfg.Auth.Enabled, "authentication is on unless turned off")
+	assert.Equal(t, "HS256", cfg.Auth.Algorithm)
+	assert.Equal(t, time.Hour, cfg.Auth.TokenTTL)
+	assert.Empty(t, cfg.Auth.ServiceAccounts)
+	assert.Empty(t, cfg.Auth.ServiceAccountRoles)
+	assert.Empty(t, cfg.Auth.ServiceAccountTenants)
+
+	os.Setenv("AUTH_ENABLED", "true")
+	os.Setenv("AUTH_SERVICE_ACCOUNTS", "ci:s3cret, monitor:pass:word ,broken,:nokey")
+	os.Setenv("AUTH_SERVICE_ACCOUNT_ROLES", "monitor:viewer")
+	os.Setenv("AUTH_SERVICE_ACCOUNT_TENANTS", "ci:acme")
+	os.Setenv("AUTH_TOKEN_TTL", "15m")
+	cfg = config.Load()
+	assert.True(t, cfg.Auth.Enabled)
+	assert.Equal(t, 15*time.Minute, cfg.Auth.TokenTTL)
+	assert.Equal(t, map[string]string{"ci": "s3cret", "monitor": "pass:word"}, cfg.Auth.ServiceAccounts)
+	assert.Equal(t, map[string]string{"monitor": "viewer"}, cfg.Auth.ServiceAccountRoles)
+	assert.Equal(t, map[string]string{"ci": "acme"}, cfg.Auth.ServiceAccountTenants)
+
+	os.Setenv("AUTH_ENABLED", "false")
+	cfg = config.Load()
+	assert.False(t, cfg.Auth.Enabled)
+}
+
+func TestTenantConfig(t *testing.T) {
+	defer os.Setenv("TENANT_DOMAIN", os.Getenv("TENANT_DOMAIN"))
+
+	os.Unsetenv("TENANT_DOMAIN")
+	assert.Empty(t, config.Load().Tenants.Domain)
+
+	os.Setenv("TENANT_DOMAIN", "tasks.example.com")
+	assert.Equal(t, "tasks.example.com", config.Load().Tenants.Domain)
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 func NewTestConfig() *Config {
 	return &Config{
 		Database: DatabaseConfig{
@@ -11,6 +13,27 @@ func NewTestConfig() *Config {
 			Port: "6379",
 			DB:   0,
 		},
+		Reminders: ReminderConfig{
+			Enabled:  false,
+			Interval: time.Minute,
+		},
+		Trash: TrashConfig{
+			PurgeEnabled:  false,
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Idempotency: IdempotencyConfig{
+			Window: 24 * time.Hour,
+		},
+		Auth: AuthConfig{
+			Enabled:             false,
+			Algorithm:           "HS256",
+			Secret:              "test-secret-that-is-at-least-32-bytes",
+			Issuer:              "graph1",
+			TokenTTL:            time.Hour,
+			ServiceAccounts:     map[string]string{"test-client": "test-client-secret"},
+			ServiceAccountRoles: map[string]string{"test-client": "admin"},
+		},
 		CacheEnabled: true,
 		Server: struct {
 			Port string
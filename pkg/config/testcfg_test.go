@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"taheri24.ir/graph1/pkg/config"
 
@@ -22,6 +23,19 @@ func TestNewTestConfig(t *testing.T) {
 	assert.Equal(t, "6379", cfg.Redis.Port)
 	assert.Equal(t, 0, cfg.Redis.DB)
 
+	// Check Reminders config
+	assert.False(t, cfg.Reminders.Enabled)
+
+	// Check Trash config
+	assert.False(t, cfg.Trash.PurgeEnabled)
+
+	// Check Idempotency config
+	assert.Equal(t, 24*time.Hour, cfg.Idempotency.Window)
+
+	// Check Auth config
+	assert.False(t, cfg.Auth.Enabled)
+	assert.NotEmpty(t, cfg.Auth.ServiceAccounts)
+
 	// Check Server config
 	assert.Equal(t, "8080", cfg.Server.Port)
 }
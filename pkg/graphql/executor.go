@@ -0,0 +1,378 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Request is the standard GraphQL-over-HTTP request body
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the standard GraphQL response body
+type Response struct {
+	Data   any      `json:"data"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// executor holds the state of a single operation execution
+type executor struct {
+	ctx       context.Context
+	doc       *Document
+	variables map[string]any
+	errors    []*Error
+}
+
+// Execute parses and runs a request against the schema. Parse and request errors, and operations over the
+// limits of the schema, produce a response without data; resolver errors null the failing field only.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+
+	op, err := doc.Operation(req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+
+	var root *Object
+	switch op.Type {
+	case "query":
+		root = s.Query
+	case "mutation":
+		root = s.Mutation
+	}
+	if root == nil {
+		return &Response{Errors: []*Error{newError(op.Loc, "Schema is not configured for %ss.", op.Type)}}
+	}
+	e := &executor{ctx: ctx, doc: doc}
+	if e.variables, err = coerceVariables(op, req.Variables); err != nil {
+		return &Response{Errors: []*Error{asError(err)}}
+	}
+	// The limits are checked with the variables, which may set the sizes of lists
+	if err := s.checkLimits(doc, op, root, e.variables); err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+
+	data := e.executeSelectionSet(root, nil, op.SelectionSet, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// Operation picks the operation to run: the one called name, or the only one when name is empty
+func (doc *Document) Operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations."}
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q.", name)}
+}
+
+func coerceVariables(op *Operation, provided map[string]any) (map[string]any, error) {
+	variables := map[string]any{}
+	for _, def := range op.Variables {
+		value, ok := provided[def.Name]
+		switch {
+		case ok:
+			variables[def.Name] = value
+		case def.HasValue:
+			resolved, err := resolveValue(def.Default, nil)
+			if err != nil {
+				return nil, err
+			}
+			variables[def.Name] = resolved
+		}
+		if def.NonNull && variables[def.Name] == nil {
+			return nil, newError(def.Loc, "Variable \"$%s\" of required type \"%s!\" was not provided.", def.Name, def.Type)
+		}
+	}
+	return variables, nil
+}
+
+// resolveValue converts an AST value into plain Go values, substituting variables
+func resolveValue(value any, variables map[string]any) (any, error) {
+	switch v := value.(type) {
+	case Variable:
+		return variables[string(v)], nil
+	case EnumValue:
+		return string(v), nil
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := resolveValue(item, variables)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = resolved
+		}
+		return list, nil
+	case []ObjectField:
+		object := make(map[string]any, len(v))
+		for _, field := range v {
+			resolved, err := resolveValue(field.Value, variables)
+			if err != nil {
+				return nil, err
+			}
+			object[field.Name] = resolved
+		}
+		return object, nil
+	default:
+		return v, nil
+	}
+}
+
+func (e *executor) fieldError(err error, field *Field, path []any) {
+	gqlErr := &Error{Message: err.Error(), Locations: []Location{field.Loc}, Path: append([]any(nil), path...)}
+	e.errors = append(e.errors, gqlErr)
+}
+
+func (e *executor) executeSelectionSet(obj *Object, source any, selections []Selection, path []any) *orderedMap {
+	keys, grouped := e.collectFields(obj, selections, map[string]bool{})
+	result := newOrderedMap()
+	for _, key := range keys {
+		fields := grouped[key]
+		fieldPath := append(append([]any(nil), path...), key)
+		result.set(key, e.executeField(obj, source, fields, fieldPath))
+	}
+	return result
+}
+
+func (e *executor) executeField(obj *Object, source any, fields []*Field, path []any) any {
+	field := fields[0]
+	if field.Name == "__typename" {
+		return obj.Name
+	}
+
+	def, ok := obj.Fields[field.Name]
+	if !ok {
+		e.fieldError(fmt.Errorf("Cannot query field %q on type %q.", field.Name, obj.Name), field, path)
+		return nil
+	}
+
+	args, err := e.coerceArguments(obj, def, field)
+	if err != nil {
+		e.fieldError(err, field, path)
+		return nil
+	}
+
+	var value any
+	if def.Resolve != nil {
+		value, err = def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+	} else if m, ok := source.(map[string]any); ok {
+		value = m[field.Name]
+	} else {
+		err = fmt.Errorf("no resolver for field %q on type %q", field.Name, obj.Name)
+	}
+	if err != nil {
+		e.fieldError(err, field, path)
+		return nil
+	}
+
+	var selections []Selection
+	for _, f := range fields {
+		selections = append(selections, f.SelectionSet...)
+	}
+	return e.completeValue(def.Type, field, selections, value, path)
+}
+
+func (e *executor) completeValue(typ *Object, field *Field, selections []Selection, value any, path []any) any {
+	if isNil(value) {
+		return nil
+	}
+
+	if typ == nil {
+		if len(selections) > 0 {
+			e.fieldError(fmt.Errorf("Field %q must not have a selection since it is a scalar.", field.Name), field, path)
+			return nil
+		}
+		return value
+	}
+	if len(selections) == 0 {
+		e.fieldError(fmt.Errorf("Field %q of type %q must have a selection of subfields.", field.Name, typ.Name), field, path)
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		list := make([]any, rv.Len())
+		for i := range list {
+			itemPath := append(append([]any(nil), path...), i)
+			list[i] = e.completeValue(typ, field, selections, rv.Index(i).Interface(), itemPath)
+		}
+		return list
+	}
+	return e.executeSelectionSet(typ, value, selections, path)
+}
+
+func (e *executor) coerceArguments(obj *Object, def *FieldConfig, field *Field) (Args, error) {
+	args := Args{}
+	for _, arg := range field.Arguments {
+		if _, ok := def.Args[arg.Name]; !ok {
+			return nil, fmt.Errorf("Unknown argument %q on field \"%s.%s\".", arg.Name, obj.Name, field.Name)
+		}
+		value, err := resolveValue(arg.Value, e.variables)
+		if err != nil {
+			return nil, err
+		}
+		if variable, isVariable := arg.Value.(Variable); isVariable {
+			if _, provided := e.variables[string(variable)]; !provided {
+				continue
+			}
+		}
+		args[arg.Name] = value
+	}
+	for name, config := range def.Args {
+		if _, ok := args[name]; !ok && config.Default != nil {
+			args[name] = config.Default
+		}
+		if config.NonNull && args[name] == nil {
+			return nil, fmt.Errorf("Argument %q of required type on field \"%s.%s\" was not provided.", name, obj.Name, field.Name)
+		}
+	}
+	return args, nil
+}
+
+// collectFields flattens fragments and groups fields by response key, keeping query order
+func (e *executor) collectFields(obj *Object, selections []Selection, visited map[string]bool) ([]string, map[string][]*Field) {
+	var keys []string
+	grouped := map[string][]*Field{}
+	add := func(subKeys []string, subGrouped map[string][]*Field) {
+		for _, key := range subKeys {
+			if _, exists := grouped[key]; !exists {
+				keys = append(keys, key)
+			}
+			grouped[key] = append(grouped[key], subGrouped[key]...)
+		}
+	}
+
+	for _, selection := range selections {
+		if !e.shouldInclude(selection.directives()) {
+			continue
+		}
+		switch s := selection.(type) {
+		case *Field:
+			key := s.ResponseKey()
+			add([]string{key}, map[string][]*Field{key: {s}})
+		case *InlineFragment:
+			if s.TypeCondition != "" && s.TypeCondition != obj.Name {
+				continue
+			}
+			add(e.collectFields(obj, s.SelectionSet, visited))
+		case *FragmentSpread:
+			if visited[s.Name] {
+				continue
+			}
+			visited[s.Name] = true
+			fragment, ok := e.doc.Fragments[s.Name]
+			if !ok {
+				e.errors = append(e.errors, newError(s.Loc, "Unknown fragment %q.", s.Name))
+				continue
+			}
+			if fragment.TypeCondition != obj.Name {
+				continue
+			}
+			add(e.collectFields(obj, fragment.SelectionSet, visited))
+		}
+	}
+	return keys, grouped
+}
+
+// shouldInclude evaluates the @skip and @include directives
+func (e *executor) shouldInclude(directives []*Directive) bool {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			continue
+		}
+		var condition bool
+		for _, arg := range directive.Arguments {
+			if arg.Name == "if" {
+				value, _ := resolveValue(arg.Value, e.variables)
+				condition, _ = value.(bool)
+			}
+		}
+		if directive.Name == "skip" && condition {
+			return false
+		}
+		if directive.Name == "include" && !condition {
+			return false
+		}
+	}
+	return true
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func asError(err error) *Error {
+	if gqlErr, ok := err.(*Error); ok {
+		return gqlErr
+	}
+	return &Error{Message: err.Error()}
+}
+
+// orderedMap is a JSON object that keeps the order its keys were selected in
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: map[string]any{}}
+}
+
+func (m *orderedMap) set(key string, value any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value stored under key
+func (m *orderedMap) Get(key string) any {
+	return m.values[key]
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		encodedValue, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
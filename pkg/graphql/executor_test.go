@@ -0,0 +1,245 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name  string
+	Count int
+	Child *testItem
+}
+
+func testSchema() *Schema {
+	itemType := &Object{Name: "Item"}
+	itemType.Fields = Fields{
+		"name":  {Resolve: func(p ResolveParams) (any, error) { return p.Source.(testItem).Name, nil }},
+		"count": {Resolve: func(p ResolveParams) (any, error) { return p.Source.(testItem).Count, nil }},
+		"child": {
+			Type: itemType,
+			Resolve: func(p ResolveParams) (any, error) {
+				if child := p.Source.(testItem).Child; child != nil {
+					return *child, nil
+				}
+				return nil, nil
+			},
+		},
+		"broken": {Resolve: func(p ResolveParams) (any, error) { return nil, errors.New("boom") }},
+	}
+
+	items := []testItem{
+		{Name: "a", Count: 1, Child: &testItem{Name: "a1"}},
+		{Name: "b", Count: 2},
+	}
+
+	return &Schema{
+		Query: &Object{Name: "Query", Fields: Fields{
+			"items": {
+				Type: itemType,
+				Args: map[string]*ArgumentConfig{"limit": {Default: int64(10)}},
+				Resolve: func(p ResolveParams) (any, error) {
+					limit, err := p.Args.Int("limit")
+					if err != nil {
+						return nil, err
+					}
+					if limit < len(items) {
+						return items[:limit], nil
+					}
+					return items, nil
+				},
+			},
+			"item": {
+				Type: itemType,
+				Args: map[string]*ArgumentConfig{"name": {NonNull: true}},
+				Resolve: func(p ResolveParams) (any, error) {
+					name, _ := p.Args.String("name")
+					for _, item := range items {
+						if item.Name == name {
+							return item, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			"summary": {Resolve: func(p ResolveParams) (any, error) {
+				return map[string]any{"total": len(items)}, nil
+			}},
+		}},
+		Mutation: &Object{Name: "Mutation", Fields: Fields{
+			"rename": {
+				Type: itemType,
+				Args: map[string]*ArgumentConfig{"input": {NonNull: true}},
+				Resolve: func(p ResolveParams) (any, error) {
+					input, err := p.Args.Object("input")
+					if err != nil {
+						return nil, err
+					}
+					return testItem{Name: input["name"].(string)}, nil
+				},
+			},
+		}},
+	}
+}
+
+func execute(t *testing.T, req Request) string {
+	t.Helper()
+	data, err := json.Marshal(testSchema().Execute(context.Background(), req))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestExecuteSelectsFieldsInOrder(t *testing.T) {
+	result := execute(t, Request{Query: `{ items { count name child { name } } }`})
+	assert.Equal(t, `{"data":{"items":[{"count":1,"name":"a","child":{"name":"a1"}},{"count":2,"name":"b","child":null}]}}`, result)
+}
+
+func TestExecuteAliasesVariablesAndDefaults(t *testing.T) {
+	result := execute(t, Request{
+		Query:     `query ($n: Int) { first: items(limit: $n) { name } all: items { name } }`,
+		Variables: map[string]any{"n": float64(1)},
+	})
+	assert.Equal(t, `{"data":{"first":[{"name":"a"}],"all":[{"name":"a"},{"name":"b"}]}}`, result)
+}
+
+func TestExecuteFragmentsAndDirectives(t *testing.T) {
+	result := execute(t, Request{
+		Query: `
+			query ($withCount: Boolean!) {
+				item(name: "a") { ...Fields count @include(if: $withCount) __typename }
+			}
+			fragment Fields on Item { name ... on Item { child { name } } }
+		`,
+		Variables: map[string]any{"withCount": false},
+	})
+	assert.Equal(t, `{"data":{"item":{"name":"a","child":{"name":"a1"},"__typename":"Item"}}}`, result)
+}
+
+func TestExecuteFieldErrorsAreLocal(t *testing.T) {
+	result := execute(t, Request{Query: `{ item(name: "b") { name broken } }`})
+	assert.JSONEq(t, `{
+		"data": {"item": {"name": "b", "broken": null}},
+		"errors": [{"message": "boom", "locations": [{"line": 1, "column": 26}], "path": ["item", "broken"]}]
+	}`, result)
+}
+
+func TestExecuteValidationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "unknown field", query: `{ nope }`, expected: `Cannot query field \"nope\" on type \"Query\".`},
+		{name: "unknown argument", query: `{ items(foo: 1) { name } }`, expected: `Unknown argument \"foo\"`},
+		{name: "missing required argument", query: `{ item { name } }`, expected: `Argument \"name\" of required type`},
+		{name: "missing selection", query: `{ items }`, expected: `must have a selection of subfields`},
+		{name: "selection on scalar", query: `{ items { name { x } } }`, expected: `must not have a selection`},
+		{name: "wrong argument type", query: `{ items(limit: "x") { name } }`, expected: `argument \"limit\" must be an integer`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Contains(t, execute(t, Request{Query: tt.query}), tt.expected)
+		})
+	}
+}
+
+func TestExecuteRequestErrors(t *testing.T) {
+	assert.JSONEq(t, `{"data":null,"errors":[{"message":"Syntax Error: Unexpected <EOF>.","locations":[{"line":1,"column":2}]}]}`,
+		execute(t, Request{Query: `{`}))
+	assert.Contains(t, execute(t, Request{Query: `query ($n: Int!) { items(limit: $n) { name } }`}), `Variable \"$n\" of required type \"Int!\" was not provided.`)
+	assert.Contains(t, execute(t, Request{Query: `subscription { items { name } }`}), "not configured for subscriptions")
+}
+
+func TestExecuteMutationAndMapSource(t *testing.T) {
+	result := execute(t, Request{Query: `mutation { rename(input: {name: "z"}) { name } }`})
+	assert.Equal(t, `{"data":{"rename":{"name":"z"}}}`, result)
+
+	summary := &Object{Name: "Summary", Fields: Fields{"total": {}}}
+	schema := testSchema()
+	schema.Query.Fields["summary"].Type = summary
+	response := schema.Execute(context.Background(), Request{Query: `{ summary { total } }`})
+	data, _ := json.Marshal(response)
+	assert.Equal(t, `{"data":{"summary":{"total":2}}}`, string(data))
+}
+
+func TestArgsAccessors(t *testing.T) {
+	args := Args{"s": "x", "i": int64(3), "f": 2.0, "b": true, "o": map[string]any{"k": 1}, "n": nil}
+
+	s, err := args.String("s")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", s)
+	i, err := args.Int("f")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+	f, err := args.Float("i")
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, f)
+	b, err := args.Bool("b")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	o, err := args.Object("o")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, o["k"])
+
+	assert.True(t, args.Has("s"))
+	assert.False(t, args.Has("n"))
+	_, err = args.Int("s")
+	assert.Error(t, err)
+	_, err = args.Bool("s")
+	assert.Error(t, err)
+	_, err = args.String("i")
+	assert.Error(t, err)
+	_, err = args.Object("s")
+	assert.Error(t, err)
+}
+
+func TestExecuteLimits(t *testing.T) {
+	schema := testSchema()
+	schema.MaxDepth = 4
+	schema.MaxComplexity = 6
+	run := func(query string) string {
+		data, err := json.Marshal(schema.Execute(context.Background(), Request{Query: query}))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, `{"data":{"items":[{"child":{"child":null}},{"child":null}]}}`,
+		run(`{ items { child { child { name } } } }`))
+	assert.JSONEq(t, `{"data":null,"errors":[{"message":"Operation nests fields 5 deep, more than the maximum of 4.","locations":[{"line":1,"column":1}]}]}`,
+		run(`{ items { child { child { child { name } } } } }`))
+	assert.Contains(t, run(`{ items { ...deep } } fragment deep on Item { child { child { child { name } } } }`),
+		"more than the maximum of 4")
+
+	// Aliases and fragments count every time they are selected
+	assert.Contains(t, run(`{ a: items { name } b: items { name } c: items { name } d: items { name } }`),
+		"Operation selects more than 6 fields.")
+	assert.Contains(t, run(`{ items { ...f ...f ...f ...f } } fragment f on Item { name count }`),
+		"Operation selects more than 6 fields.")
+
+	// Fragments spreading themselves end the walk
+	assert.Contains(t, run(`{ items { ...loop } } fragment loop on Item { name ...loop }`), `"name":"a"`)
+
+	// Fields below a list count once per item it may return, as its size argument or variable says
+	schema.Query.Fields["items"].SizeArg = "limit"
+	assert.Contains(t, run(`{ items { name } }`), "Operation selects more than 6 fields.")
+	assert.Contains(t, run(`{ items(limit: 5) { name } }`), `"name":"a"`)
+	assert.Contains(t, run(`{ items(limit: 2) { name child { name } } }`), "Operation selects more than 6 fields.")
+	query := `query ($limit: Int) { items(limit: $limit) { name count } }`
+	response := schema.Execute(context.Background(), Request{Query: query, Variables: map[string]any{"limit": 2}})
+	assert.Empty(t, response.Errors)
+	response = schema.Execute(context.Background(), Request{Query: query, Variables: map[string]any{"limit": 3}})
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, "Operation selects more than 6 fields.", response.Errors[0].Message)
+
+	// Lists without a size argument count as ListSize items
+	schema.Query.Fields["items"].SizeArg = ""
+	schema.Query.Fields["items"].ListSize = 3
+	assert.Contains(t, run(`{ items { name } }`), `"name":"a"`)
+	assert.Contains(t, run(`{ items { name count } }`), "Operation selects more than 6 fields.")
+}
@@ -0,0 +1,214 @@
+package graphql
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+// lexer splits a GraphQL document into tokens, skipping whitespace, commas and comments
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\uFEFF"):
+			l.pos += len("\uFEFF")
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := Location{Line: l.line, Column: l.col}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunct, value: "...", loc: loc}, nil
+	case strings.ContainsRune("!$&():=@[]{}|", rune(c)):
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), loc: loc}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.blockString(loc)
+		}
+		return l.string(loc)
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, newError(loc, "Syntax Error: Unexpected character %q.", r)
+}
+
+func (l *lexer) number(loc Location) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	if !l.digits() {
+		return token{}, newError(loc, "Syntax Error: Invalid number.")
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if !l.digits() {
+			return token{}, newError(loc, "Syntax Error: Invalid number.")
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if !l.digits() {
+			return token{}, newError(loc, "Syntax Error: Invalid number.")
+		}
+	}
+	return token{kind: kind, value: l.src[start:l.pos], loc: loc}, nil
+}
+
+func (l *lexer) digits() bool {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance(1)
+	}
+	return l.pos > start
+}
+
+func (l *lexer) string(loc Location) (token, error) {
+	l.advance(1)
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return token{kind: tokenString, value: sb.String(), loc: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, newError(loc, "Syntax Error: Unterminated string.")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, newError(loc, "Syntax Error: Unterminated string.")
+			}
+			escape := l.src[l.pos+1]
+			switch escape {
+			case '"', '\\', '/':
+				sb.WriteByte(escape)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+6 > len(l.src) {
+					return token{}, newError(loc, "Syntax Error: Invalid unicode escape sequence.")
+				}
+				code, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 32)
+				if err != nil {
+					return token{}, newError(loc, "Syntax Error: Invalid unicode escape sequence.")
+				}
+				sb.WriteRune(rune(code))
+				l.advance(4)
+			default:
+				return token{}, newError(loc, "Syntax Error: Invalid character escape sequence \\%c.", escape)
+			}
+			l.advance(2)
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			sb.WriteRune(r)
+			l.advance(size)
+		}
+	}
+	return token{}, newError(loc, "Syntax Error: Unterminated string.")
+}
+
+func (l *lexer) blockString(loc Location) (token, error) {
+	l.advance(3)
+	start := l.pos
+	for l.pos < len(l.src) {
+		if strings.HasPrefix(l.src[l.pos:], `\"""`) {
+			l.advance(4)
+			continue
+		}
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			raw := strings.ReplaceAll(l.src[start:l.pos], `\"""`, `"""`)
+			l.advance(3)
+			return token{kind: tokenString, value: strings.TrimSpace(raw), loc: loc}, nil
+		}
+		l.advance(1)
+	}
+	return token{}, newError(loc, "Syntax Error: Unterminated string.")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,99 @@
+package graphql
+
+// checkLimits rejects an operation nesting fields deeper than s.MaxDepth or costing more than
+// s.MaxComplexity. Every selected field costs one for each item of the lists it is selected in, so a field
+// below a list of up to 100 items costs 100; fragments count every time they are spread. Zero limits are not
+// enforced.
+func (s *Schema) checkLimits(doc *Document, op *Operation, root *Object, variables map[string]any) *Error {
+	if s.MaxDepth <= 0 && s.MaxComplexity <= 0 {
+		return nil
+	}
+
+	m := &measurer{doc: doc, variables: variables, maxCost: s.MaxComplexity, spreads: map[string]bool{}}
+	depth := m.depth(root, op.SelectionSet, 1)
+	if s.MaxComplexity > 0 && m.cost > s.MaxComplexity {
+		return newError(op.Loc, "Operation selects more than %d fields.", s.MaxComplexity)
+	}
+	if s.MaxDepth > 0 && depth > s.MaxDepth {
+		return newError(op.Loc, "Operation nests fields %d deep, more than the maximum of %d.", depth, s.MaxDepth)
+	}
+	return nil
+}
+
+// measurer walks the selections of an operation, adding up what its fields cost
+type measurer struct {
+	doc       *Document
+	variables map[string]any
+	maxCost   int
+	cost      int
+	// spreads holds the fragments being expanded, so that fragments spreading themselves end
+	spreads map[string]bool
+}
+
+// depth returns how deeply selections on obj nest fields, adding weight to the cost for every field. obj is
+// nil below fields the schema does not know, which are reported when the operation runs. The walk stops
+// early once the cost passes maxCost, so that fragments spread many times over cannot make it expensive.
+func (m *measurer) depth(obj *Object, selections []Selection, weight int) int {
+	depth := 0
+	for _, selection := range selections {
+		if m.maxCost > 0 && m.cost > m.maxCost {
+			return depth
+		}
+		switch s := selection.(type) {
+		case *Field:
+			m.cost += weight
+			var def *FieldConfig
+			if obj != nil {
+				def = obj.Fields[s.Name]
+			}
+			var typ *Object
+			itemWeight := weight
+			if def != nil {
+				typ = def.Type
+				itemWeight = m.saturate(weight, m.listSize(def, s))
+			}
+			depth = max(depth, m.depth(typ, s.SelectionSet, itemWeight)+1)
+		case *InlineFragment:
+			depth = max(depth, m.depth(obj, s.SelectionSet, weight))
+		case *FragmentSpread:
+			fragment, ok := m.doc.Fragments[s.Name]
+			if !ok || m.spreads[s.Name] {
+				continue
+			}
+			m.spreads[s.Name] = true
+			depth = max(depth, m.depth(obj, fragment.SelectionSet, weight))
+			delete(m.spreads, s.Name)
+		}
+	}
+	return depth
+}
+
+// listSize returns how many items the field may return: the value of its SizeArg argument, given or
+// defaulted, or else its ListSize. Fields that are not lists return one.
+func (m *measurer) listSize(def *FieldConfig, field *Field) int {
+	if def.SizeArg != "" {
+		var value any
+		if config := def.Args[def.SizeArg]; config != nil {
+			value = config.Default
+		}
+		for _, arg := range field.Arguments {
+			if arg.Name == def.SizeArg {
+				if resolved, err := resolveValue(arg.Value, m.variables); err == nil && resolved != nil {
+					value = resolved
+				}
+			}
+		}
+		if size, err := (Args{def.SizeArg: value}).Int(def.SizeArg); err == nil && size > 0 {
+			return size
+		}
+	}
+	return max(def.ListSize, 1)
+}
+
+// saturate multiplies weight by size, stopping right above maxCost so that nested lists cannot overflow
+func (m *measurer) saturate(weight, size int) int {
+	if m.maxCost > 0 && weight > m.maxCost/size {
+		return m.maxCost + 1
+	}
+	return weight * size
+}
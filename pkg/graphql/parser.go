@@ -0,0 +1,537 @@
+package graphql
+
+import (
+	"strconv"
+)
+
+// Document is a parsed GraphQL request document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query or mutation definition
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []*VariableDefinition
+	SelectionSet []Selection
+	Loc          Location
+}
+
+// VariableDefinition declares an operation variable
+type VariableDefinition struct {
+	Name     string
+	Type     string
+	NonNull  bool
+	Default  any
+	HasValue bool
+	Loc      Location
+}
+
+// Selection is a Field, FragmentSpread or InlineFragment
+type Selection interface {
+	directives() []*Directive
+}
+
+// Field selects a field, optionally aliased, with arguments and sub-selections
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Loc          Location
+}
+
+// FragmentSpread includes a named fragment
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Loc        Location
+}
+
+// InlineFragment includes a selection set, optionally restricted to a type
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Loc           Location
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+	Loc           Location
+}
+
+// Argument is a named argument of a field or directive
+type Argument struct {
+	Name  string
+	Value any
+	Loc   Location
+}
+
+// Directive is a directive such as @include(if: $flag)
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+	Loc       Location
+}
+
+// Variable references an operation variable inside a value
+type Variable string
+
+// EnumValue is an unquoted enum literal
+type EnumValue string
+
+// ObjectField is a single key of an input object literal
+type ObjectField struct {
+	Name  string
+	Value any
+}
+
+func (f *Field) directives() []*Directive          { return f.Directives }
+func (f *FragmentSpread) directives() []*Directive { return f.Directives }
+func (f *InlineFragment) directives() []*Directive { return f.Directives }
+
+// ResponseKey returns the key the field is reported under in the response
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+// Parse parses a GraphQL executable document
+func Parse(src string) (*Document, error) {
+	p := &parser{lexer: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: map[string]*Fragment{}}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peekPunct("{"):
+			op := &Operation{Type: "query", Loc: p.tok.loc}
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			op.SelectionSet = selections
+			doc.Operations = append(doc.Operations, op)
+		case p.peekName("query") || p.peekName("mutation") || p.peekName("subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peekName("fragment"):
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.Fragments[fragment.Name]; exists {
+				return nil, newError(fragment.Loc, "There can be only one fragment named %q.", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		return nil, newError(p.tok.loc, "Syntax Error: Document does not contain an operation.")
+	}
+	return doc, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) peekPunct(value string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == value
+}
+
+func (p *parser) peekName(value string) bool {
+	return p.tok.kind == tokenName && p.tok.value == value
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return newError(p.tok.loc, "Syntax Error: Unexpected <EOF>.")
+	}
+	return newError(p.tok.loc, "Syntax Error: Unexpected %q.", p.tok.value)
+}
+
+func (p *parser) expectPunct(value string) error {
+	if !p.peekPunct(value) {
+		if p.tok.kind == tokenEOF {
+			return newError(p.tok.loc, "Syntax Error: Expected %q, found <EOF>.", value)
+		}
+		return newError(p.tok.loc, "Syntax Error: Expected %q, found %q.", value, p.tok.value)
+	}
+	return p.advance()
+}
+
+func (p *parser) skipPunct(value string) (bool, error) {
+	if !p.peekPunct(value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	op := &Operation{Type: p.tok.value, Loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.Name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skipPunct("("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peekPunct(")") {
+			variable, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = append(op.Variables, variable)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.SelectionSet = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinition() (*VariableDefinition, error) {
+	def := &VariableDefinition{Loc: p.tok.loc}
+	if err := p.expectPunct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	def.Name = name
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	if def.Type, def.NonNull, err = p.parseType(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skipPunct("="); err != nil {
+		return nil, err
+	} else if ok {
+		value, err := p.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+		def.Default = value
+		def.HasValue = true
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// parseType parses a type reference, returning its textual form and whether it is non-null
+func (p *parser) parseType() (string, bool, error) {
+	var typ string
+	if ok, err := p.skipPunct("["); err != nil {
+		return "", false, err
+	} else if ok {
+		inner, innerNonNull, err := p.parseType()
+		if err != nil {
+			return "", false, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return "", false, err
+		}
+		if innerNonNull {
+			inner += "!"
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", false, err
+		}
+		typ = name
+	}
+
+	nonNull, err := p.skipPunct("!")
+	return typ, nonNull, err
+}
+
+func (p *parser) parseFragment() (*Fragment, error) {
+	fragment := &Fragment{Loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, newError(fragment.Loc, "Syntax Error: Unexpected Name \"on\".")
+	}
+	fragment.Name = name
+	if !p.peekName("on") {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.expectName(); err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var selections []Selection
+	for !p.peekPunct("}") {
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, p.unexpected()
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) parseSelection() (Selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skipPunct("..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			spread := &FragmentSpread{Name: p.tok.value, Loc: loc}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			spread.Directives, err = p.parseDirectives()
+			return spread, err
+		}
+
+		inline := &InlineFragment{Loc: loc}
+		if p.peekName("on") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if inline.TypeCondition, err = p.expectName(); err != nil {
+				return nil, err
+			}
+		}
+		if inline.Directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		inline.SelectionSet, err = p.parseSelectionSet()
+		return inline, err
+	}
+
+	field := &Field{Loc: loc}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skipPunct(":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = name
+		if name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+
+	if field.Arguments, err = p.parseArguments(false); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("{") {
+		if field.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) parseArguments(constant bool) ([]*Argument, error) {
+	ok, err := p.skipPunct("(")
+	if err != nil || !ok {
+		return nil, err
+	}
+	var args []*Argument
+	for !p.peekPunct(")") {
+		arg := &Argument{Loc: p.tok.loc}
+		if arg.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if arg.Value, err = p.parseValue(constant); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 {
+		return nil, p.unexpected()
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseDirectives() ([]*Directive, error) {
+	var directives []*Directive
+	for p.peekPunct("@") {
+		directive := &Directive{Loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		directive.Name = name
+		if directive.Arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+// parseValue parses a value literal; constant values may not reference variables
+func (p *parser) parseValue(constant bool) (any, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			return Variable(name), err
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			list := []any{}
+			for !p.peekPunct("]") {
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			fields := []ObjectField{}
+			for !p.peekPunct("}") {
+				name, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				value, err := p.parseValue(constant)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, ObjectField{Name: name, Value: value})
+			}
+			return fields, p.advance()
+		}
+	case tokenInt:
+		value, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, newError(tok.loc, "Syntax Error: Invalid number %s.", tok.value)
+		}
+		return value, p.advance()
+	case tokenFloat:
+		value, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, newError(tok.loc, "Syntax Error: Invalid number %s.", tok.value)
+		}
+		return value, p.advance()
+	case tokenString:
+		return tok.value, p.advance()
+	case tokenName:
+		var value any
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = EnumValue(tok.value)
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected()
+}
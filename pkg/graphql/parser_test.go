@@ -0,0 +1,117 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShorthandQuery(t *testing.T) {
+	doc, err := Parse(`{ tasks { id title } }`)
+	require.NoError(t, err)
+	require.Len(t, doc.Operations, 1)
+
+	op := doc.Operations[0]
+	assert.Equal(t, "query", op.Type)
+	require.Len(t, op.SelectionSet, 1)
+
+	field := op.SelectionSet[0].(*Field)
+	assert.Equal(t, "tasks", field.Name)
+	assert.Len(t, field.SelectionSet, 2)
+}
+
+func TestParseOperationWithVariablesAndArguments(t *testing.T) {
+	doc, err := Parse(`
+		# fetch one page
+		query Page($status: String = "pending", $limit: Int!, $ids: [ID!]) {
+			first: tasks(status: $status, limit: $limit, page: 2, ratio: -1.5e2, flag: true, mode: ALL, none: null,
+			             input: {title: "a\"bA", tags: ["x", "y"]}) @include(if: true) {
+				id
+			}
+		}
+	`)
+	require.NoError(t, err)
+	op := doc.Operations[0]
+	assert.Equal(t, "Page", op.Name)
+	require.Len(t, op.Variables, 3)
+	assert.Equal(t, "String", op.Variables[0].Type)
+	assert.Equal(t, "pending", op.Variables[0].Default)
+	assert.True(t, op.Variables[1].NonNull)
+	assert.Equal(t, "[ID!]", op.Variables[2].Type)
+
+	field := op.SelectionSet[0].(*Field)
+	assert.Equal(t, "first", field.ResponseKey())
+	assert.Equal(t, Location{Line: 4, Column: 4}, field.Loc)
+
+	values := map[string]any{}
+	for _, arg := range field.Arguments {
+		values[arg.Name] = arg.Value
+	}
+	assert.Equal(t, Variable("status"), values["status"])
+	assert.Equal(t, int64(2), values["page"])
+	assert.Equal(t, -150.0, values["ratio"])
+	assert.Equal(t, true, values["flag"])
+	assert.Equal(t, EnumValue("ALL"), values["mode"])
+	assert.Nil(t, values["none"])
+	assert.Equal(t, []ObjectField{
+		{Name: "title", Value: `a"bA`},
+		{Name: "tags", Value: []any{"x", "y"}},
+	}, values["input"])
+	require.Len(t, field.Directives, 1)
+	assert.Equal(t, "include", field.Directives[0].Name)
+}
+
+func TestParseFragments(t *testing.T) {
+	doc, err := Parse(`
+		query { task(id: "1") { ...TaskFields ... on Task { status } } }
+		fragment TaskFields on Task { id title }
+	`)
+	require.NoError(t, err)
+	require.Contains(t, doc.Fragments, "TaskFields")
+	assert.Equal(t, "Task", doc.Fragments["TaskFields"].TypeCondition)
+
+	task := doc.Operations[0].SelectionSet[0].(*Field)
+	assert.IsType(t, &FragmentSpread{}, task.SelectionSet[0])
+	assert.Equal(t, "Task", task.SelectionSet[1].(*InlineFragment).TypeCondition)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "empty document", query: "", expected: "Document does not contain an operation"},
+		{name: "unclosed selection", query: "{ tasks { id }", expected: "Unexpected <EOF>"},
+		{name: "empty selection", query: "{ }", expected: `Unexpected "}"`},
+		{name: "bad character", query: "{ tasks ? }", expected: "Unexpected character '?'"},
+		{name: "unterminated string", query: `{ task(id: "1) { id } }`, expected: "Unterminated string"},
+		{name: "variable in default", query: `query ($a: Int = $b) { x }`, expected: `Unexpected "$"`},
+		{name: "duplicate fragment", query: `{ x } fragment A on T { x } fragment A on T { y }`, expected: "only one fragment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+			assert.NotEmpty(t, err.(*Error).Locations)
+		})
+	}
+}
+
+func TestDocumentOperation(t *testing.T) {
+	doc, err := Parse(`query A { x } mutation B { y }`)
+	require.NoError(t, err)
+
+	_, err = doc.Operation("")
+	assert.Error(t, err)
+
+	op, err := doc.Operation("B")
+	require.NoError(t, err)
+	assert.Equal(t, "mutation", op.Type)
+
+	_, err = doc.Operation("C")
+	assert.Error(t, err)
+}
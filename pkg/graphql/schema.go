@@ -0,0 +1,136 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// ResolveFunc produces the value of a field
+type ResolveFunc func(p ResolveParams) (any, error)
+
+// ResolveParams is passed to every ResolveFunc
+type ResolveParams struct {
+	Context context.Context
+	// Source is the value resolved for the parent object, nil for root fields
+	Source any
+	Args   Args
+}
+
+// Schema holds the root operation types
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+	// MaxDepth bounds how deeply an operation may nest fields, and MaxComplexity how many fields it may
+	// select, each counted once per item of the lists it is selected in; operations over either are rejected
+	// before they run. Zero means no limit.
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Object is an output object type
+type Object struct {
+	Name   string
+	Fields Fields
+}
+
+// Fields maps field names to their definitions
+type Fields map[string]*FieldConfig
+
+// FieldConfig defines an output field. A nil Type marks a scalar (leaf) field; when Resolve
+// is nil the field is read from a map[string]any source by name.
+type FieldConfig struct {
+	Type    *Object
+	Args    map[string]*ArgumentConfig
+	Resolve ResolveFunc
+	// SizeArg names the integer argument bounding how many items a list field returns, such as "limit", and
+	// ListSize is the number of items assumed for lists without one. The fields selected below a list count
+	// once per item towards MaxComplexity.
+	SizeArg  string
+	ListSize int
+}
+
+// ArgumentConfig declares an accepted field argument
+type ArgumentConfig struct {
+	NonNull bool
+	Default any
+}
+
+// Args holds coerced argument values keyed by name. Numbers are int64 or float64,
+// enums and strings are strings, lists are []any and input objects are map[string]any.
+type Args map[string]any
+
+// Has reports whether the argument was provided with a non-null value
+func (a Args) Has(name string) bool {
+	value, ok := a[name]
+	return ok && value != nil
+}
+
+// String returns a string or enum argument
+func (a Args) String(name string) (string, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	default:
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+}
+
+// Int returns an integer argument
+func (a Args) Int(name string) (int, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return int(value), nil
+	case int:
+		return value, nil
+	case float64:
+		if value == math.Trunc(value) {
+			return int(value), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// Float returns a numeric argument
+func (a Args) Float(name string) (float64, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return float64(value), nil
+	case int:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	default:
+		return 0, fmt.Errorf("argument %q must be a number", name)
+	}
+}
+
+// Bool returns a boolean argument
+func (a Args) Bool(name string) (bool, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	default:
+		return false, fmt.Errorf("argument %q must be a boolean", name)
+	}
+}
+
+// Object returns an input object argument
+func (a Args) Object(name string) (map[string]any, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("argument %q must be an input object", name)
+	}
+}
@@ -0,0 +1,388 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents to
+// JSON values decoded by encoding/json into any.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned for patch documents that are not well-formed
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPathNotFound is returned when an operation refers to a location that does not exist
+	ErrPathNotFound = errors.New("path not found")
+	// ErrTestFailed is returned when a test operation does not hold
+	ErrTestFailed = errors.New("test failed")
+)
+
+// Error describes the operation of a patch that could not be decoded or applied
+type Error struct {
+	// Index is the position of the operation in the patch
+	Index int
+	Op    string
+	Path  string
+	// Err is one of ErrInvalidPatch, ErrPathNotFound or ErrTestFailed
+	Err    error
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %s", e.Index, e.Op, e.Path, e.Detail)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// MergePatch applies an RFC 7396 merge patch to doc and returns the result. Members of the patch
+// that are null remove the member from doc; a patch that is not an object replaces doc entirely.
+// Neither argument is modified.
+func MergePatch(doc, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	docObject, _ := doc.(map[string]any)
+	result := make(map[string]any, len(docObject))
+	for key, value := range docObject {
+		result[key] = value
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = MergePatch(result[key], value)
+		}
+	}
+	return result
+}
+
+// Operation is a single RFC 6902 operation
+type Operation struct {
+	Op   string
+	Path string
+	From string
+	// Value is the decoded value of add, replace and test operations
+	Value any
+}
+
+// Patch is an RFC 6902 JSON Patch document
+type Patch []Operation
+
+// Decode parses and checks a JSON Patch document
+func Decode(data []byte) (Patch, error) {
+	var raw []struct {
+		Op    *string         `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	patch := make(Patch, len(raw))
+	for i, r := range raw {
+		invalid := func(detail string) error {
+			op := &patch[i]
+			return &Error{Index: i, Op: op.Op, Path: op.Path, Err: ErrInvalidPatch, Detail: detail}
+		}
+		if r.Op == nil {
+			return nil, invalid(`missing "op"`)
+		}
+		patch[i].Op = *r.Op
+		if r.Path == nil {
+			return nil, invalid(`missing "path"`)
+		}
+		patch[i].Path = *r.Path
+
+		switch *r.Op {
+		case "add", "replace", "test":
+			// A null value is decoded to json.RawMessage("null"), a missing one to nil
+			if r.Value == nil {
+				return nil, invalid(`missing "value"`)
+			}
+			if err := json.Unmarshal(r.Value, &patch[i].Value); err != nil {
+				return nil, invalid(err.Error())
+			}
+		case "move", "copy":
+			if r.From == nil {
+				return nil, invalid(`missing "from"`)
+			}
+			patch[i].From = *r.From
+		case "remove":
+		default:
+			return nil, invalid(fmt.Sprintf("unknown operation %q", *r.Op))
+		}
+	}
+	return patch, nil
+}
+
+// Apply applies the operations of the patch to doc in order and returns the result. The patch is
+// applied as a whole: when an operation fails an *Error is returned and doc is left unchanged.
+func (p Patch) Apply(doc any) (any, error) {
+	doc = deepCopy(doc)
+	for i, op := range p {
+		var err error
+		if doc, err = op.apply(doc); err != nil {
+			var patchErr *Error
+			if errors.As(err, &patchErr) {
+				patchErr.Index, patchErr.Op = i, op.Op
+				if patchErr.Path == "" {
+					patchErr.Path = op.Path
+				}
+				return nil, patchErr
+			}
+			return nil, &Error{Index: i, Op: op.Op, Path: op.Path, Err: ErrInvalidPatch, Detail: err.Error()}
+		}
+	}
+	return doc, nil
+}
+
+// apply applies a single operation to doc
+func (op Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, deepCopy(op.Value))
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return deepCopy(op.Value), nil
+		}
+		if doc, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(op.Value))
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.From == op.Path {
+			_, err := get(doc, from)
+			return doc, err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, &Error{Path: op.Path, Err: ErrInvalidPatch, Detail: "cannot move a value into one of its children"}
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	case "test":
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.Value) {
+			return nil, &Error{Path: op.Path, Err: ErrTestFailed, Detail: "value does not match"}
+		}
+		return doc, nil
+	default:
+		return nil, &Error{Path: op.Path, Err: ErrInvalidPatch, Detail: fmt.Sprintf("unknown operation %q", op.Op)}
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, &Error{Path: pointer, Err: ErrInvalidPatch, Detail: `JSON pointer must start with "/"`}
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		for j := 0; j < len(token); j++ {
+			if token[j] == '~' && (j+1 == len(token) || (token[j+1] != '0' && token[j+1] != '1')) {
+				return nil, &Error{Path: pointer, Err: ErrInvalidPatch, Detail: `"~" must be followed by "0" or "1"`}
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// get returns the value at path
+func get(doc any, path []string) (any, error) {
+	for i, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, notFound(path[:i+1])
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, notFound(path[:i+1])
+			}
+			doc = node[index]
+		default:
+			return nil, notFound(path[:i+1])
+		}
+	}
+	return doc, nil
+}
+
+// add inserts value at path, creating an object member or shifting array elements to make room
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return modifyParent(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, notFound(path)
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		default:
+			return nil, notFound(path)
+		}
+	})
+}
+
+// remove deletes the value at path
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, &Error{Err: ErrInvalidPatch, Detail: "cannot remove the whole document"}
+	}
+	return modifyParent(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			if _, ok := node[token]; !ok {
+				return nil, notFound(path)
+			}
+			delete(node, token)
+			return node, nil
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, notFound(path)
+			}
+			return append(node[:index], node[index+1:]...), nil
+		default:
+			return nil, notFound(path)
+		}
+	})
+}
+
+// modifyParent replaces the container holding the last token of path with the result of modify.
+// path must not be empty.
+func modifyParent(doc any, path []string, modify func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return modify(doc, path[0])
+	}
+
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, notFound(path[:1])
+		}
+		child, err := modifyParent(child, path[1:], modify)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, notFound(path[:1])
+		}
+		child, err := modifyParent(node[index], path[1:], modify)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+		return node, nil
+	default:
+		return nil, notFound(path[:1])
+	}
+}
+
+// arrayIndex parses an array index token, which must be a decimal number without leading zeros
+// no greater than max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return index, nil
+}
+
+// notFound reports that the location named by the reference tokens does not exist
+func notFound(tokens []string) error {
+	escaped := make([]string, len(tokens))
+	for i, token := range tokens {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+	return &Error{Err: ErrPathNotFound, Detail: fmt.Sprintf("%q does not exist", "/"+strings.Join(escaped, "/"))}
+}
+
+// deepCopy copies the objects and arrays of a decoded JSON value
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = deepCopy(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = deepCopy(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
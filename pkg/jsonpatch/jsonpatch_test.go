@@ -0,0 +1,141 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJSON(t *testing.T, data string) any {
+	t.Helper()
+	var value any
+	require.NoError(t, json.Unmarshal([]byte(data), &value))
+	return value
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, Appendix A
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.doc+" "+tt.patch, func(t *testing.T) {
+			doc := decodeJSON(t, tt.doc)
+			got := MergePatch(doc, decodeJSON(t, tt.patch))
+			assert.Equal(t, decodeJSON(t, tt.want), got)
+			assert.Equal(t, decodeJSON(t, tt.doc), doc, "the document must not be modified")
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	// Examples from RFC 6902, Appendix A
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"test value", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"add to nonexistent target ignores extra members", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+		{"escape ordering", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"add array value", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, `{"foo":{"bar":1},"baz":{"bar":2}}`},
+		{"replace whole document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"null value", `{"foo":"bar"}`, `[{"op":"replace","path":"/foo","value":null},{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Decode([]byte(tt.patch))
+			require.NoError(t, err)
+			doc := decodeJSON(t, tt.doc)
+			got, err := patch.Apply(doc)
+			require.NoError(t, err)
+			assert.Equal(t, decodeJSON(t, tt.want), got)
+			assert.Equal(t, decodeJSON(t, tt.doc), doc, "the document must not be modified")
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name, doc, patch string
+		want             error
+		index            int
+	}{
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed, 0},
+		{"test compares types", `{"foo":"2"}`, `[{"op":"test","path":"/foo","value":2}]`, ErrTestFailed, 0},
+		{"add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrPathNotFound, 0},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"test","path":"/foo","value":"bar"},{"op":"remove","path":"/baz"}]`, ErrPathNotFound, 1},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, ErrPathNotFound, 0},
+		{"array index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":2}]`, ErrPathNotFound, 0},
+		{"leading zero index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/01"}]`, ErrPathNotFound, 0},
+		{"move into own child", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, ErrInvalidPatch, 0},
+		{"invalid pointer", `{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, ErrInvalidPatch, 0},
+		{"invalid escape", `{"foo":"bar"}`, `[{"op":"remove","path":"/fo~2o"}]`, ErrInvalidPatch, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Decode([]byte(tt.patch))
+			require.NoError(t, err)
+			doc := decodeJSON(t, tt.doc)
+			_, err = patch.Apply(doc)
+			assert.ErrorIs(t, err, tt.want)
+			var patchErr *Error
+			require.ErrorAs(t, err, &patchErr)
+			assert.Equal(t, tt.index, patchErr.Index)
+			assert.Equal(t, decodeJSON(t, tt.doc), doc, "the document must not be modified")
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name, patch string
+	}{
+		{"not an array", `{"op":"add"}`},
+		{"malformed", `[{"op":`},
+		{"missing op", `[{"path":"/a"}]`},
+		{"unknown op", `[{"op":"merge","path":"/a"}]`},
+		{"missing path", `[{"op":"remove"}]`},
+		{"missing value", `[{"op":"add","path":"/a"}]`},
+		{"missing from", `[{"op":"copy","path":"/a"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.patch))
+			assert.ErrorIs(t, err, ErrInvalidPatch)
+		})
+	}
+}
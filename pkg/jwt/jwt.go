@@ -0,0 +1,276 @@
+// Package jwt signs and verifies JSON Web Tokens (RFC 7519) in compact JWS form with HMAC (HS256) or RSA
+// (RS256) keys. Only the registered claims the API relies on are supported.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// clockSkew is the leeway allowed when checking the times of a token against the clock
+const clockSkew = time.Minute
+
+// minHMACSecretLength is the length of the shortest HMAC secret accepted, as RFC 7518 requires for HS256
+const minHMACSecretLength = 32
+
+var (
+	// ErrMalformed is returned for tokens that are not three base64url segments holding JSON
+	ErrMalformed = errors.New("token is malformed")
+	// ErrAlgorithm is returned for tokens signed with another algorithm than the key's
+	ErrAlgorithm = errors.New("token is signed with an unexpected algorithm")
+	// ErrSignature is returned for tokens whose signature does not match their content
+	ErrSignature = errors.New("token signature is invalid")
+	// ErrExpired is returned for tokens used after their exp claim
+	ErrExpired = errors.New("token has expired")
+	// ErrNotYetValid is returned for tokens used before their nbf claim
+	ErrNotYetValid = errors.New("token is not valid yet")
+	// ErrIssuer is returned for tokens issued by someone else than the key expects
+	ErrIssuer = errors.New("token issuer is not accepted")
+	// ErrNoSigningKey is returned when signing with a key that can only verify
+	ErrNoSigningKey = errors.New("key cannot sign tokens")
+)
+
+// Claims are the claims of a token. Times are seconds since the Unix epoch; zero times are left out.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	// Scope lists what the bearer may do, separated by spaces as in OAuth 2.0
+	Scope string `json:"scope,omitempty"`
+	// Role names how far the bearer is trusted with the data of others
+	Role string `json:"role,omitempty"`
+	// Tenant binds the bearer to the data of one tenant; tokens without it may choose theirs
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Key signs and verifies tokens with a single algorithm. Tokens naming any other algorithm in their header,
+// including "none", are rejected.
+type Key struct {
+	algorithm string
+	secret    []byte
+	private   *rsa.PrivateKey
+	public    *rsa.PublicKey
+	// issuer, when set, is put in the tokens signed and required of the tokens verified
+	issuer string
+	now    func() time.Time
+}
+
+// NewHMACKey creates a key signing and verifying HS256 tokens with secret, which must be at least 32 bytes
+func NewHMACKey(secret []byte, issuer string) (*Key, error) {
+	if len(secret) < minHMACSecretLength {
+		return nil, fmt.Errorf("HMAC secret must be at least %d bytes", minHMACSecretLength)
+	}
+	return &Key{algorithm: HS256, secret: secret, issuer: issuer, now: time.Now}, nil
+}
+
+// NewRSAKey creates a key verifying RS256 tokens with public and, when private is not nil, signing them.
+// A nil public key is taken from private.
+func NewRSAKey(private *rsa.PrivateKey, public *rsa.PublicKey, issuer string) (*Key, error) {
+	if public == nil {
+		if private == nil {
+			return nil, errors.New("RSA key needs a private or a public key")
+		}
+		public = &private.PublicKey
+	}
+	if public.N.BitLen() < 2048 {
+		return nil, errors.New("RSA key must be at least 2048 bits")
+	}
+	return &Key{algorithm: RS256, private: private, public: public, issuer: issuer, now: time.Now}, nil
+}
+
+// Algorithm returns the algorithm of the tokens the key signs and verifies
+func (k *Key) Algorithm() string {
+	return k.algorithm
+}
+
+// CanSign reports whether the key holds what is needed to sign tokens
+func (k *Key) CanSign() bool {
+	return k.algorithm == HS256 || k.private != nil
+}
+
+// Issue signs a token carrying claims that expires after ttl, filling in its issuer, times and ID
+func (k *Key) Issue(claims Claims, ttl time.Duration) (string, *Claims, error) {
+	now := k.now()
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, err
+	}
+	claims.Issuer = k.issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	claims.ID = base64.RawURLEncoding.EncodeToString(id)
+	token, err := k.Sign(&claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
+}
+
+// Sign encodes claims into a signed token
+func (k *Key) Sign(claims *Claims) (string, error) {
+	if !k.CanSign() {
+		return "", ErrNoSigningKey
+	}
+	encodedHeader, err := encodeSegment(header{Algorithm: k.algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encodedClaims
+
+	signature, err := k.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks the signature, issuer and validity period of token and returns its claims. Tokens without a
+// subject or an expiry are rejected as malformed.
+func (k *Key) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if h.Algorithm != k.algorithm {
+		return nil, ErrAlgorithm
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if !k.verify([]byte(parts[0]+"."+parts[1]), signature) {
+		return nil, ErrSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" || claims.ExpiresAt == 0 {
+		return nil, ErrMalformed
+	}
+	now := k.now()
+	if now.Add(-clockSkew).Unix() >= claims.ExpiresAt {
+		return nil, ErrExpired
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Unix() < claims.NotBefore {
+		return nil, ErrNotYetValid
+	}
+	if k.issuer != "" && claims.Issuer != k.issuer {
+		return nil, ErrIssuer
+	}
+	return &claims, nil
+}
+
+func (k *Key) sign(input []byte) ([]byte, error) {
+	if k.algorithm == HS256 {
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}
+	digest := sha256.Sum256(input)
+	return rsa.SignPKCS1v15(rand.Reader, k.private, crypto.SHA256, digest[:])
+}
+
+func (k *Key) verify(input, signature []byte) bool {
+	if k.algorithm == HS256 {
+		expected, _ := k.sign(input)
+		return hmac.Equal(signature, expected)
+	}
+	digest := sha256.Sum256(input)
+	return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, digest[:], signature) == nil
+}
+
+func encodeSegment(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// ParseRSAPrivateKeyPEM reads an RSA private key from a PEM block in PKCS #1 or PKCS #8 form
+func ParseRSAPrivateKeyPEM(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// ParseRSAPublicKeyPEM reads an RSA public key from a PEM block in PKIX or PKCS #1 form
+func ParseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,157 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return private
+}
+
+func TestHMACKey(t *testing.T) {
+	key, err := NewHMACKey(testSecret, "graph1")
+	require.NoError(t, err)
+
+	token, issued, err := key.Issue(Claims{Subject: "ci-bot", Scope: "tasks:read", Role: "member", Tenant: "acme"}, time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(token, "."), 3)
+
+	claims, err := key.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, issued, claims)
+	assert.Equal(t, "ci-bot", claims.Subject)
+	assert.Equal(t, "tasks:read", claims.Scope)
+	assert.Equal(t, "member", claims.Role)
+	assert.Equal(t, "acme", claims.Tenant)
+	assert.Equal(t, "graph1", claims.Issuer)
+	assert.Equal(t, claims.IssuedAt+3600, claims.ExpiresAt)
+
+	// Another secret does not verify the token
+	other, err := NewHMACKey([]byte(strings.Repeat("x", 32)), "graph1")
+	require.NoError(t, err)
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrSignature)
+
+	_, err = NewHMACKey([]byte("short"), "")
+	assert.Error(t, err)
+}
+
+func TestRSAKey(t *testing.T) {
+	private := newTestRSAKey(t)
+	signer, err := NewRSAKey(private, nil, "")
+	require.NoError(t, err)
+	token, _, err := signer.Issue(Claims{Subject: "ci-bot", Scope: "tasks:read"}, time.Hour)
+	require.NoError(t, err)
+
+	// A key holding only the public half verifies but cannot sign
+	verifier, err := NewRSAKey(nil, &private.PublicKey, "")
+	require.NoError(t, err)
+	claims, err := verifier.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "ci-bot", claims.Subject)
+	assert.False(t, verifier.CanSign())
+	_, _, err = verifier.Issue(Claims{Subject: "ci-bot", Scope: "tasks:read"}, time.Hour)
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	other, err := NewRSAKey(newTestRSAKey(t), nil, "")
+	require.NoError(t, err)
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrSignature)
+}
+
+func TestVerifyRejects(t *testing.T) {
+	key, err := NewHMACKey(testSecret, "graph1")
+	require.NoError(t, err)
+	now := time.Unix(1_800_000_000, 0)
+	key.now = func() time.Time { return now }
+
+	sign := func(claims Claims) string {
+		token, err := key.Sign(&claims)
+		require.NoError(t, err)
+		return token
+	}
+	valid := sign(Claims{Subject: "ci-bot", Issuer: "graph1", ExpiresAt: now.Unix() + 60})
+	parts := strings.Split(valid, ".")
+	// A token claiming to be unsigned, which must never be accepted
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	rsaSigner, err := NewRSAKey(newTestRSAKey(t), nil, "graph1")
+	require.NoError(t, err)
+	rsaToken, _, err := rsaSigner.Issue(Claims{Subject: "ci-bot", Scope: "tasks:read"}, time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"not a token", "abc", ErrMalformed},
+		{"bad base64", "!!." + parts[1] + "." + parts[2], ErrMalformed},
+		{"unsigned", unsigned, ErrAlgorithm},
+		{"other algorithm", rsaToken, ErrAlgorithm},
+		{"tampered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","exp":9999999999}`)) + "." + parts[2], ErrSignature},
+		{"expired", sign(Claims{Subject: "ci-bot", Issuer: "graph1", ExpiresAt: now.Unix() - 120}), ErrExpired},
+		{"not yet valid", sign(Claims{Subject: "ci-bot", Issuer: "graph1", ExpiresAt: now.Unix() + 600, NotBefore: now.Unix() + 300}), ErrNotYetValid},
+		{"other issuer", sign(Claims{Subject: "ci-bot", Issuer: "elsewhere", ExpiresAt: now.Unix() + 60}), ErrIssuer},
+		{"no subject", sign(Claims{Issuer: "graph1", ExpiresAt: now.Unix() + 60}), ErrMalformed},
+		{"no expiry", sign(Claims{Subject: "ci-bot", Issuer: "graph1"}), ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := key.Verify(tt.token)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	// Small clock differences between machines are tolerated
+	_, err = key.Verify(sign(Claims{Subject: "ci-bot", Issuer: "graph1", ExpiresAt: now.Unix() - 30}))
+	assert.NoError(t, err)
+	_, err = key.Verify(valid)
+	assert.NoError(t, err)
+}
+
+func TestParseRSAKeysPEM(t *testing.T) {
+	private := newTestRSAKey(t)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	for _, block := range []*pem.Block{
+		{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)},
+		{Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsed, err := ParseRSAPrivateKeyPEM(pem.EncodeToMemory(block))
+		require.NoError(t, err)
+		assert.True(t, private.Equal(parsed))
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	require.NoError(t, err)
+	for _, block := range []*pem.Block{
+		{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&private.PublicKey)},
+		{Type: "PUBLIC KEY", Bytes: pkix},
+	} {
+		parsed, err := ParseRSAPublicKeyPEM(pem.EncodeToMemory(block))
+		require.NoError(t, err)
+		assert.True(t, private.PublicKey.Equal(parsed))
+	}
+
+	_, err = ParseRSAPrivateKeyPEM([]byte("not a key"))
+	assert.Error(t, err)
+	_, err = ParseRSAPublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("junk")}))
+	assert.Error(t, err)
+}
@@ -130,12 +130,12 @@ for i in $(seq 1 $TOTAL_REQUESTS); do
                 -H "Content-Type: application/json" \
                 -d "{\"title\":\"Worker $WORKER_ID Task $i\",\"status\":\"pending\"}" >/dev/null
             ;;
-        3) # PUT update task
+        3) # PATCH update task
             if [ ${#TASK_IDS[@]} -gt 0 ]; then
                 random_index=$((RANDOM % ${#TASK_IDS[@]}))
                 task_id=${TASK_IDS[$random_index]}
-                curl -s -X PUT "$API_URL/tasks/$task_id" \
-                    -H "Content-Type: application/json" \
+                curl -s -X PATCH "$API_URL/tasks/$task_id" \
+                    -H "Content-Type: application/merge-patch+json" \
                     -d "{\"status\":\"in_progress\"}" >/dev/null
             fi
             ;;